@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/cmd/fetch/status"
 	"github.com/cockroachdb/molt/cmd/fetch/tokens"
 	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
 	"github.com/cockroachdb/molt/compression"
@@ -14,7 +16,9 @@ import (
 	"github.com/cockroachdb/molt/fetch"
 	"github.com/cockroachdb/molt/fetch/datablobstorage"
 	"github.com/cockroachdb/molt/fetch/fetchmetrics"
+	"github.com/cockroachdb/molt/fileformat"
 	"github.com/cockroachdb/molt/moltlogger"
+	"github.com/cockroachdb/molt/shardmode"
 	"github.com/cockroachdb/molt/testutils"
 	"github.com/cockroachdb/molt/utils"
 	"github.com/spf13/cobra"
@@ -65,13 +69,65 @@ func Command() *cobra.Command {
 		directCRDBCopy          bool
 		tableHandlingMode       TableHandlingOption
 		cfg                     fetch.Config
+		fileConfig              *cmdutil.Config
 	)
+	// tableOverrides returns the settings of the tables in the config file,
+	// which take precedence over the flags.
+	tableOverrides := func(fileConfig *cmdutil.Config, isCopyMode bool) ([]fetch.TableOverride, error) {
+		var ret []fetch.TableOverride
+		for _, t := range fileConfig.Tables {
+			o := fetch.TableOverride{
+				Table:        t.Table,
+				Shards:       t.Shards,
+				RowBatchSize: t.RowBatchSize,
+				FlushSize:    t.FlushSize,
+				FlushRows:    t.FlushRows,
+			}
+			if t.TableHandling != nil {
+				var mode TableHandlingOption
+				if err := enumflag.New(&mode, "string", TableHandlingOptionStringRepresentations, enumflag.EnumCaseInsensitive).Set(*t.TableHandling); err != nil {
+					return nil, errors.Wrapf(err, "invalid table-handling for tables %s", t.Table)
+				}
+				truncate, recreate := mode == TruncateIfExists, mode == DropOnTargetAndRecreate
+				o.Truncate, o.DropAndRecreateNewSchema = &truncate, &recreate
+			}
+			if t.Compression != nil {
+				var c compression.Flag
+				if err := enumflag.New(&c, "compression", compression.CompressionStringRepresentations, enumflag.EnumCaseInsensitive).Set(*t.Compression); err != nil {
+					return nil, errors.Wrapf(err, "invalid compression for tables %s", t.Table)
+				}
+				switch {
+				case isCopyMode && c == compression.GZIP:
+					return nil, errors.Newf("cannot run copy mode with compression for tables %s", t.Table)
+				case c == compression.Default && isCopyMode:
+					c = compression.None
+				case c == compression.Default:
+					c = compression.GZIP
+				}
+				o.Compression = &c
+			}
+			ret = append(ret, o)
+		}
+		return ret, nil
+	}
+
 	cmd := &cobra.Command{
 		Use:   "fetch",
 		Short: "Moves data from source to target.",
 		Long:  `Imports data from source directly into target tables.`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			commandsToremoveDBConnsFlag := map[string]any{"molt fetch tokens": nil}
+			// The config file is applied before the flags are validated.
+			var err error
+			if fileConfig, err = cmdutil.ApplyConfig(cmd); err != nil {
+				return err
+			}
+
+			commandsToremoveDBConnsFlag := map[string]any{
+				"molt fetch tokens list":   nil,
+				"molt fetch tokens show":   nil,
+				"molt fetch tokens delete": nil,
+				"molt fetch status":        nil,
+			}
 			if _, ok := commandsToremoveDBConnsFlag[cmd.CommandPath()]; ok {
 				// This marks these flags as not required.
 				// In the case that we want to list molt fetch tokens,
@@ -101,6 +157,12 @@ func Command() *cobra.Command {
 			if strings.TrimSpace(cfg.ContinuationFileName) != "" && !utils.MatchesFileConvention(cfg.ContinuationFileName) {
 				return errors.Newf(`continuation file name "%s" doesn't match the file convention "%s"`, cfg.ContinuationFileName, utils.FileConventionRegex.String())
 			}
+			if err := cfg.RetrySettings.Verify(); err != nil {
+				return errors.Wrap(err, "invalid retry settings")
+			}
+			if cfg.VerifyMaxMismatches < 0 {
+				return errors.Newf("verify-after-load-max-mismatches must be >= 0, got %d", cfg.VerifyMaxMismatches)
+			}
 
 			return nil
 		},
@@ -120,6 +182,15 @@ func Command() *cobra.Command {
 				cfg.DropAndRecreateNewSchema = true
 			}
 
+			if cfg.Format == fileformat.Parquet {
+				if directCRDBCopy {
+					return errors.New("cannot use parquet format with direct copy mode as no intermediate files are written")
+				}
+				if !cfg.UseCopy {
+					return errors.New("parquet format is only supported with --use-copy as IMPORT INTO cannot read parquet files")
+				}
+			}
+
 			isCopyMode := cfg.UseCopy || directCRDBCopy
 			if isCopyMode {
 				if cfg.Compression == compression.GZIP {
@@ -135,6 +206,26 @@ func Command() *cobra.Command {
 				logger.Info().Msgf("user set compression to %s", cfg.Compression.String())
 			}
 
+			if cfg.TableOverrides, err = tableOverrides(fileConfig, isCopyMode); err != nil {
+				return err
+			}
+			if cfg.TypeOverrides, err = cmdutil.TypeOverrides(); err != nil {
+				return err
+			}
+			if cfg.NameMapping, err = cmdutil.NameMapping(); err != nil {
+				return err
+			}
+			if cfg.Transforms, err = cmdutil.Transforms(); err != nil {
+				return err
+			}
+			if cfg.Predicates, err = cmdutil.TablePredicates(); err != nil {
+				return err
+			}
+			tableFilter, err := cmdutil.TableFilter()
+			if err != nil {
+				return err
+			}
+
 			conns, err := cmdutil.LoadDBConns(ctx)
 			if err != nil {
 				return err
@@ -149,6 +240,7 @@ func Command() *cobra.Command {
 			case directCRDBCopy:
 				datastorePayload = &datablobstorage.DirectCopyPayload{
 					TargetConnForCopy: conns[1].(*dbconn.PGConn).Conn,
+					RetrySettings:     cfg.RetrySettings,
 				}
 			case bucketPath != "":
 				u, err := url.Parse(bucketPath)
@@ -169,6 +261,11 @@ func Command() *cobra.Command {
 						GCPBucket:  u.Host,
 						BucketPath: path,
 					}
+				case "azure", "AZURE":
+					datastorePayload = &datablobstorage.AzurePayload{
+						AzureContainer: u.Host,
+						BucketPath:     path,
+					}
 				default:
 					return errors.Newf("unsupported datasource scheme: %s", u.Scheme)
 				}
@@ -193,7 +290,7 @@ func Command() *cobra.Command {
 				logger,
 				conns,
 				src,
-				cmdutil.TableFilter(),
+				tableFilter,
 				testutils.FetchTestingKnobs{},
 			)
 
@@ -206,6 +303,7 @@ func Command() *cobra.Command {
 	}
 
 	cmd.AddCommand(tokens.Command())
+	cmd.AddCommand(status.Command())
 
 	cmd.PersistentFlags().StringVar(
 		&logFile,
@@ -231,6 +329,12 @@ func Command() *cobra.Command {
 		false,
 		"Use `COPY FROM` instead of `IMPORT INTO` during the data movement phase. This keeps your table online during the process.",
 	)
+	cmd.PersistentFlags().BoolVar(
+		&cfg.AtomicCopy,
+		"atomic-copy",
+		false,
+		"Copy each file in a single transaction with --use-copy, so that files which fail with transient errors can be retried. Files which are not copied atomically are not retried.",
+	)
 	cmd.PersistentFlags().IntVar(
 		&cfg.FlushSize,
 		"flush-size",
@@ -256,11 +360,71 @@ func Command() *cobra.Command {
 		4,
 		"Number of threads to use for data export.",
 	)
+	cmd.PersistentFlags().IntVar(
+		&cfg.CopyConcurrency,
+		"copy-concurrency",
+		1,
+		"Number of files of a table to copy at a time, each on its own connection. Only used with --use-copy.",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&cfg.WorkStealing,
+		"shard-work-stealing",
+		false,
+		"If set, export threads which have finished their shards split the remaining rows of slow shards of the same table. Tables with string primary keys are not split.",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&cfg.PipelineImport,
+		"pipeline-import",
+		false,
+		"If set, files are imported into the target as they are written to the intermediate store, instead of once the whole table has been exported. Ignored if in direct-copy mode.",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&cfg.VerifyAfterLoad,
+		"verify-after-load",
+		false,
+		"If set, the rows of each table are verified against the source once the table has been loaded. Inconsistent rows are logged and recorded in the fetch status.",
+	)
+	cmd.PersistentFlags().IntVar(
+		&cfg.VerifyMaxMismatches,
+		"verify-after-load-max-mismatches",
+		0,
+		"Maximum number of inconsistent rows found by --verify-after-load across all tables before the fetch fails.",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&cfg.VerifySourceQuiesced,
+		"verify-after-load-source-quiesced",
+		false,
+		"Confirms that the source is not written to during the fetch, which --verify-after-load requires on MySQL sources as their rows cannot be read at the snapshot of the fetch, and on fetches continued with --fetch-id, as the source must not have been written to since the original fetch.",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&cfg.RetrySettings.InitialBackoff,
+		"retry-initial-backoff",
+		time.Second,
+		"Amount of time to wait for before retrying an import or copy which failed with a transient error, such as a serialization failure or a lost connection.",
+	)
+	cmd.PersistentFlags().IntVar(
+		&cfg.RetrySettings.Multiplier,
+		"retry-multiplier",
+		2,
+		"Multiplier to apply to the backoff duration after each failed attempt of an import or copy.",
+	)
+	cmd.PersistentFlags().DurationVar(
+		&cfg.RetrySettings.MaxBackoff,
+		"retry-max-backoff",
+		30*time.Second,
+		"Maximum amount of time to wait for before retrying an import or copy.",
+	)
+	cmd.PersistentFlags().IntVar(
+		&cfg.RetrySettings.MaxRetries,
+		"retry-max-attempts",
+		5,
+		"Maximum number of attempts of an import or copy which fails with transient errors, after which the table fails. 0 retries indefinitely.",
+	)
 	cmd.PersistentFlags().StringVar(
 		&bucketPath,
 		"bucket-path",
 		"",
-		"Path of the s3/gcp bucket where intermediate files are written (e.g., s3://bucket/path, or gs://bucket/path).",
+		"Path of the s3/gcp bucket or azure container where intermediate files are written (e.g., s3://bucket/path, gs://bucket/path, or azure://container/path).",
 	)
 	cmd.PersistentFlags().StringVar(
 		&localPath,
@@ -325,11 +489,31 @@ func Command() *cobra.Command {
 		"compression",
 		"Compression type for IMPORT INTO mode (gzip/none). (default gzip)",
 	)
+	cmd.PersistentFlags().Var(
+		enumflag.New(
+			&cfg.Format,
+			"format",
+			fileformat.FileFormatStringRepresentations,
+			enumflag.EnumCaseInsensitive,
+		),
+		"format",
+		"File format of the intermediate files (csv/parquet). The parquet format requires --use-copy. (default csv)",
+	)
+	cmd.PersistentFlags().Var(
+		enumflag.New(
+			&cfg.ShardMode,
+			"shard-mode",
+			shardmode.ShardModeStringRepresentations,
+			enumflag.EnumCaseInsensitive,
+		),
+		"shard-mode",
+		"How to choose the boundaries between the shards of each table (linear/stats). The stats mode uses table statistics or a sample of the table to give each shard a similar number of rows. (default linear)",
+	)
 	cmd.PersistentFlags().StringVar(
 		&cfg.FetchID,
 		fetchID,
 		"",
-		"If set, restarts the fetch process for all failed tables of the given ID",
+		"If set, restarts the fetch process for all failed tables of the given ID, resuming unfinished exports from their last checkpoint",
 	)
 	cmd.PersistentFlags().StringVar(
 		&cfg.ContinuationToken,
@@ -355,6 +539,11 @@ or as an automated job. If not set, prompts user for confirmation before perform
 	moltlogger.RegisterLoggerFlags(cmd)
 	cmdutil.RegisterDBConnFlags(cmd)
 	cmdutil.RegisterNameFilterFlags(cmd)
+	cmdutil.RegisterTypeOverridesFlags(cmd)
+	cmdutil.RegisterNameMappingFlags(cmd)
+	cmdutil.RegisterTransformsFlags(cmd)
+	cmdutil.RegisterTablePredicateFlags(cmd)
+	cmdutil.RegisterConfigFlags(cmd)
 	cmdutil.RegisterMetricsFlags(cmd)
 	cmdutil.RegisterPprofFlags(cmd)
 
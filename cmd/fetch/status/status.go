@@ -0,0 +1,77 @@
+package status
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/fetch"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	var connString string
+	var fetchID string
+	var format cmdutil.OutputFormat
+	var testOnly bool
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the progress of each table of a fetch.",
+		Long: `Show the progress of each table of a fetch, including its state, the number
+of rows and files exported and imported, and the durations of the export and
+import.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			conn, err := dbconn.Connect(ctx, "target", connString)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = conn.Close(ctx) }()
+
+			targetPgConn, valid := conn.(*dbconn.PGConn)
+			if !valid {
+				return errors.New("failed to assert conn as a pgconn")
+			}
+			targetPgxConn := targetPgConn.Conn
+
+			statusStr, err := fetch.ShowFetchStatus(ctx, testOnly, targetPgxConn, fetchID, format == cmdutil.OutputFormatJSON)
+			if err != nil {
+				return err
+			}
+
+			_, err = cmd.OutOrStdout().Write([]byte(statusStr))
+			return err
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(
+		&connString,
+		"conn-string",
+		"",
+		"Connection string of the database which has the _molt_fetch metadata.",
+	)
+	cmd.PersistentFlags().StringVar(
+		&fetchID,
+		"fetch-id",
+		"",
+		"ID of the fetch to show the progress of.",
+	)
+	cmdutil.RegisterOutputFormatFlag(cmd, &format)
+	cmd.PersistentFlags().BoolVarP(
+		&testOnly,
+		"test-only",
+		"t",
+		false,
+		"If set, runs in test mode with deterministic data.",
+	)
+
+	for _, flag := range []string{"conn-string", "fetch-id"} {
+		if err := cmd.MarkPersistentFlagRequired(flag); err != nil {
+			panic(err)
+		}
+	}
+
+	return cmd
+}
@@ -0,0 +1,46 @@
+package tokens
+
+import (
+	"context"
+
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
+	"github.com/cockroachdb/molt/fetch"
+	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/spf13/cobra"
+)
+
+func deleteCommand() *cobra.Command {
+	var filter status.ExceptionLogFilter
+
+	cmd := &cobra.Command{
+		Use:   "delete [<id>...]",
+		Short: "Delete continuation tokens.",
+		Long: `Delete the continuation tokens with the given IDs, or those selected by fetch
+ID, stage or table. Continuing a fetch no longer imports the tables of the
+deleted tokens.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			conn, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = conn.Close(ctx) }()
+
+			filter.IDs = args
+			if filter.Tables, err = cmdutil.TableFilter(); err != nil {
+				return err
+			}
+			tableStr, err := fetch.DeleteContinuationTokens(ctx, testOnly, conn, filter, format == cmdutil.OutputFormatJSON)
+			if err != nil {
+				return err
+			}
+
+			_, err = cmd.OutOrStdout().Write([]byte(tableStr))
+			return err
+		},
+	}
+
+	registerFilterFlags(cmd, &filter)
+
+	return cmd
+}
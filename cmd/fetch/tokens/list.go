@@ -2,36 +2,33 @@ package tokens
 
 import (
 	"context"
-	"errors"
 
-	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
 	"github.com/cockroachdb/molt/fetch"
+	"github.com/cockroachdb/molt/fetch/status"
 	"github.com/spf13/cobra"
 )
 
-func Command() *cobra.Command {
-	var connString string
+func listCommand() *cobra.Command {
 	var numResults int
-	var testOnly bool
+	var filter status.ExceptionLogFilter
 
 	cmd := &cobra.Command{
-		Use:   "tokens list",
+		Use:   "list",
 		Short: "List details about each continuation token.",
 		Long:  `List details about each continuation token.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
-			conn, err := dbconn.Connect(ctx, "target", connString)
+			conn, err := connect(ctx)
 			if err != nil {
 				return err
 			}
+			defer func() { _ = conn.Close(ctx) }()
 
-			targetPgConn, valid := conn.(*dbconn.PGConn)
-			if !valid {
-				return errors.New("failed to assert conn as a pgconn")
+			if filter.Tables, err = cmdutil.TableFilter(); err != nil {
+				return err
 			}
-			targetPgxConn := targetPgConn.Conn
-
-			tableStr, err := fetch.ListContinuationTokens(ctx, testOnly, targetPgxConn, numResults)
+			tableStr, err := fetch.ListContinuationTokens(ctx, testOnly, conn, numResults, filter, format == cmdutil.OutputFormatJSON)
 			if err != nil {
 				return err
 			}
@@ -41,30 +38,14 @@ func Command() *cobra.Command {
 		},
 	}
 
-	cmd.PersistentFlags().StringVar(
-		&connString,
-		"conn-string",
-		"",
-		"Connection string of the database which has the _molt_fetch metadata.",
-	)
-	cmd.PersistentFlags().IntVarP(
+	cmd.Flags().IntVarP(
 		&numResults,
 		"num-results",
 		"n",
 		0,
 		"Number of results to return",
 	)
-	cmd.PersistentFlags().BoolVarP(
-		&testOnly,
-		"test-only",
-		"t",
-		false,
-		"If set, runs in test mode with deterministic data.",
-	)
-
-	if err := cmd.MarkPersistentFlagRequired("conn-string"); err != nil {
-		panic(err)
-	}
+	registerFilterFlags(cmd, &filter)
 
 	return cmd
 }
@@ -0,0 +1,37 @@
+package tokens
+
+import (
+	"context"
+
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
+	"github.com/cockroachdb/molt/fetch"
+	"github.com/spf13/cobra"
+)
+
+func showCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show every detail of a continuation token.",
+		Long: `Show every detail of a continuation token, including the error which created
+it, its SQL state and the stage of the fetch which failed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			conn, err := connect(ctx)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = conn.Close(ctx) }()
+
+			tokenStr, err := fetch.ShowContinuationToken(ctx, testOnly, conn, args[0], format == cmdutil.OutputFormatJSON)
+			if err != nil {
+				return err
+			}
+
+			_, err = cmd.OutOrStdout().Write([]byte(tokenStr))
+			return err
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,79 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+)
+
+var (
+	connString string
+	format     cmdutil.OutputFormat
+	testOnly   bool
+)
+
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Manage the continuation tokens of fetches.",
+		Long:  `List, show and delete the continuation tokens of fetches.`,
+	}
+	cmd.AddCommand(listCommand(), showCommand(), deleteCommand())
+
+	cmd.PersistentFlags().StringVar(
+		&connString,
+		"conn-string",
+		"",
+		"Connection string of the database which has the _molt_fetch metadata.",
+	)
+	cmdutil.RegisterOutputFormatFlag(cmd, &format)
+	cmd.PersistentFlags().BoolVarP(
+		&testOnly,
+		"test-only",
+		"t",
+		false,
+		"If set, runs in test mode with deterministic data.",
+	)
+
+	if err := cmd.MarkPersistentFlagRequired("conn-string"); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// connect connects to the database which has the _molt_fetch metadata.
+func connect(ctx context.Context) (*pgx.Conn, error) {
+	conn, err := dbconn.Connect(ctx, "target", connString)
+	if err != nil {
+		return nil, err
+	}
+
+	targetPgConn, valid := conn.(*dbconn.PGConn)
+	if !valid {
+		return nil, errors.New("failed to assert conn as a pgconn")
+	}
+	return targetPgConn.Conn, nil
+}
+
+// registerFilterFlags registers the flags selecting tokens by fetch ID and
+// stage. Tokens are selected by table with the table filter flags of fetch.
+func registerFilterFlags(cmd *cobra.Command, filter *status.ExceptionLogFilter) {
+	cmd.Flags().StringVar(
+		&filter.FetchID,
+		"fetch-id",
+		"",
+		"If set, only selects the tokens of the given fetch.",
+	)
+	cmd.Flags().StringVar(
+		&filter.Stage,
+		"stage",
+		"",
+		"If set, only selects the tokens of the given stage (schema_creation/data_load).",
+	)
+}
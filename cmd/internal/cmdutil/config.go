@@ -0,0 +1,202 @@
+package cmdutil
+
+import (
+	"bytes"
+	"os"
+	"sort"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+const tablesConfigKey = "tables"
+
+// commandConfigKeys are the sections of a config file holding the flags of a
+// single command.
+var commandConfigKeys = map[string]struct{}{
+	"fetch":  {},
+	"verify": {},
+}
+
+var configFile string
+
+func RegisterConfigFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(
+		&configFile,
+		"config",
+		"",
+		"Path of a YAML file setting the flags of the command and overriding settings for some tables. Flags set on the command line take precedence over the flags of the file, and the settings of tables take precedence over the flags.",
+	)
+}
+
+// TableOverride overrides settings for the tables matching Table, which is a
+// glob formatted as schema.table or table. Settings which are not set keep
+// the value given by the flags.
+type TableOverride struct {
+	Table         string  `yaml:"table"`
+	Shards        *int    `yaml:"shards"`
+	RowBatchSize  *int    `yaml:"row-batch-size"`
+	TableHandling *string `yaml:"table-handling"`
+	FlushSize     *int    `yaml:"flush-size"`
+	FlushRows     *int    `yaml:"flush-rows"`
+	Compression   *string `yaml:"compression"`
+}
+
+// Config is a config file which has been applied to a command.
+type Config struct {
+	Tables []TableOverride
+	// setOnCommandLine are the flags which were set on the command line,
+	// before the config file was applied.
+	setOnCommandLine map[string]struct{}
+}
+
+// SetOnCommandLine returns whether the flag was set on the command line, in
+// which case it takes precedence over the options of the config file. The
+// table overrides apply to fewer tables than the flags, so they take
+// precedence over the flags however they are set.
+func (c *Config) SetOnCommandLine(flag string) bool {
+	_, ok := c.setOnCommandLine[flag]
+	return ok
+}
+
+// ApplyConfig sets the flags of the command which were not set on the
+// command line to their value in the config file given by --config, if any.
+// Flags are set by the top level keys of the file, and by the keys of the
+// section named after the command, which take precedence. As the file may be
+// shared by the commands, top level keys which are only flags of another
+// command are ignored. The settings of the tables are returned so that the
+// command can apply them.
+func ApplyConfig(cmd *cobra.Command) (*Config, error) {
+	ret := &Config{setOnCommandLine: map[string]struct{}{}}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		ret.setOnCommandLine[f.Name] = struct{}{}
+	})
+	if configFile == "" {
+		return ret, nil
+	}
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read config file %s", configFile)
+	}
+	if err := ret.apply(cmd, data); err != nil {
+		return nil, errors.Wrapf(err, "invalid config file %s", configFile)
+	}
+	return ret, nil
+}
+
+func (c *Config) apply(cmd *cobra.Command, data []byte) error {
+	var top map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &top); err != nil {
+		return err
+	}
+	var section map[string]yaml.Node
+	for key, node := range top {
+		switch _, isCommand := commandConfigKeys[key]; {
+		case key == tablesConfigKey:
+			if err := decodeKnownFields(&node, &c.Tables); err != nil {
+				return errors.Wrapf(err, "invalid %s", tablesConfigKey)
+			}
+		case isCommand:
+			// Sections of other commands are ignored.
+			if key != cmd.Name() {
+				break
+			}
+			if err := node.Decode(&section); err != nil {
+				return errors.Wrapf(err, "invalid %s section", key)
+			}
+		default:
+			continue
+		}
+		delete(top, key)
+	}
+	for i, t := range c.Tables {
+		if t.Table == "" {
+			return errors.Newf("table override %d: table is required", i+1)
+		}
+		if _, err := utils.MatchesTablePattern(t.Table, dbtable.Name{}); err != nil {
+			return errors.Wrapf(err, "table override %d", i+1)
+		}
+	}
+	// Options of the section of the command take precedence over the top
+	// level options.
+	for i, opts := range []map[string]yaml.Node{top, section} {
+		isTop := i == 0
+		keys := make([]string, 0, len(opts))
+		for key := range opts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if isTop && cmd.Flags().Lookup(key) == nil && isOtherCommandFlag(cmd, key) {
+				continue
+			}
+			if err := c.setFlag(cmd, key, opts[key]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isOtherCommandFlag returns whether name is a flag of another command which
+// has a section in the config file.
+func isOtherCommandFlag(cmd *cobra.Command, name string) bool {
+	for _, other := range cmd.Root().Commands() {
+		if _, ok := commandConfigKeys[other.Name()]; !ok || other.Name() == cmd.Name() {
+			continue
+		}
+		if other.Flags().Lookup(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) setFlag(cmd *cobra.Command, name string, node yaml.Node) error {
+	f := cmd.Flags().Lookup(name)
+	if f == nil || name == "config" {
+		return errors.Newf("unknown option %q for command %s", name, cmd.Name())
+	}
+	if c.SetOnCommandLine(name) {
+		return nil
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if err := cmd.Flags().Set(name, node.Value); err != nil {
+			return errors.Wrapf(err, "invalid value for option %q", name)
+		}
+		return nil
+	case yaml.SequenceNode:
+		var vals []string
+		if err := node.Decode(&vals); err != nil {
+			return errors.Wrapf(err, "invalid value for option %q", name)
+		}
+		sv, ok := f.Value.(pflag.SliceValue)
+		if !ok {
+			return errors.Newf("invalid value for option %q, expected a single value", name)
+		}
+		if err := sv.Replace(vals); err != nil {
+			return errors.Wrapf(err, "invalid value for option %q", name)
+		}
+		f.Changed = true
+		return nil
+	default:
+		return errors.Newf("invalid value for option %q, expected a scalar or a list", name)
+	}
+}
+
+// decodeKnownFields decodes a node, failing on keys which are not fields of
+// the output.
+func decodeKnownFields(node *yaml.Node, out any) error {
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	return dec.Decode(out)
+}
@@ -0,0 +1,252 @@
+package cmdutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyConfig(t *testing.T) {
+	type result struct {
+		source     string
+		shards     int
+		include    []string
+		testOnly   bool
+		tables     []TableOverride
+		commandSet map[string]bool
+	}
+	intPtr := func(i int) *int { return &i }
+	strPtr := func(s string) *string { return &s }
+
+	for _, tc := range []struct {
+		desc        string
+		config      string
+		args        []string
+		expected    result
+		expectedErr string
+	}{
+		{
+			desc: "no config file",
+			args: []string{"--source", "src"},
+			expected: result{
+				source:     "src",
+				shards:     4,
+				commandSet: map[string]bool{"source": true, "shards": false},
+			},
+		},
+		{
+			desc: "top level options",
+			config: `
+source: src
+shards: 8
+table-include: [public.a*, public.b*]
+test-only: true
+`,
+			expected: result{
+				source:     "src",
+				shards:     8,
+				include:    []string{"public.a*", "public.b*"},
+				testOnly:   true,
+				commandSet: map[string]bool{"source": false, "shards": false},
+			},
+		},
+		{
+			desc: "command line takes precedence",
+			config: `
+source: src
+shards: 8
+table-include: [public.a*]
+`,
+			args: []string{"--shards", "2", "--table-include", "public.c*"},
+			expected: result{
+				source:     "src",
+				shards:     2,
+				include:    []string{"public.c*"},
+				commandSet: map[string]bool{"shards": true, "table-include": true},
+			},
+		},
+		{
+			desc: "command section takes precedence",
+			config: `
+shards: 8
+fetch:
+  shards: 16
+verify:
+  not-a-fetch-option: 1
+`,
+			expected: result{
+				shards: 16,
+			},
+		},
+		{
+			desc: "table overrides",
+			config: `
+tables:
+  - table: public.big*
+    shards: 16
+    row-batch-size: 1000
+  - table: small
+    table-handling: truncate-if-exists
+    compression: none
+`,
+			expected: result{
+				shards: 4,
+				tables: []TableOverride{
+					{Table: "public.big*", Shards: intPtr(16), RowBatchSize: intPtr(1000)},
+					{Table: "small", TableHandling: strPtr("truncate-if-exists"), Compression: strPtr("none")},
+				},
+			},
+		},
+		{
+			desc:        "unknown option",
+			config:      "shard: 8\n",
+			expectedErr: `unknown option "shard" for command fetch`,
+		},
+		{
+			desc:        "invalid value",
+			config:      "shards: many\n",
+			expectedErr: `invalid value for option "shards"`,
+		},
+		{
+			desc:        "list for a single value",
+			config:      "shards: [1, 2]\n",
+			expectedErr: `invalid value for option "shards", expected a single value`,
+		},
+		{
+			desc:        "unknown table setting",
+			config:      "tables:\n  - table: a\n    shard: 8\n",
+			expectedErr: `field shard not found`,
+		},
+		{
+			desc:        "table required",
+			config:      "tables:\n  - shards: 8\n",
+			expectedErr: `table override 1: table is required`,
+		},
+		{
+			desc:        "invalid table pattern",
+			config:      "tables:\n  - table: '[a'\n",
+			expectedErr: `table override 1`,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var (
+				res     result
+				cfg     *Config
+				include []string
+			)
+			cmd := &cobra.Command{
+				Use: "fetch",
+				PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+					cfg, err = ApplyConfig(cmd)
+					return err
+				},
+				RunE: func(cmd *cobra.Command, args []string) error { return nil },
+			}
+			cmd.SilenceUsage = true
+			cmd.SilenceErrors = true
+			cmd.PersistentFlags().StringVar(&res.source, "source", "", "")
+			cmd.Flags().IntVar(&res.shards, "shards", 4, "")
+			cmd.Flags().StringSliceVar(&include, "table-include", nil, "")
+			cmd.Flags().BoolVar(&res.testOnly, "test-only", false, "")
+			RegisterConfigFlags(cmd)
+			configFile = ""
+
+			args := tc.args
+			if tc.config != "" {
+				path := filepath.Join(t.TempDir(), "config.yaml")
+				require.NoError(t, os.WriteFile(path, []byte(tc.config), 0644))
+				args = append(args, "--config", path)
+			}
+			cmd.SetArgs(args)
+			err := cmd.Execute()
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			res.include = include
+			res.tables = cfg.Tables
+			for flag, set := range tc.expected.commandSet {
+				require.Equal(t, set, cfg.SetOnCommandLine(flag), flag)
+			}
+			res.commandSet = tc.expected.commandSet
+			require.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func TestApplyConfigSharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+source: src
+bucket-path: s3://bucket
+concurrency: 8
+`), 0644))
+
+	for _, tc := range []struct {
+		desc        string
+		config      string
+		cmd         string
+		expectedErr string
+	}{
+		{desc: "fetch ignores verify options", cmd: "fetch"},
+		{desc: "verify ignores fetch options", cmd: "verify"},
+		{desc: "unknown option", config: "unknown: 1\n", cmd: "fetch", expectedErr: `unknown option "unknown" for command fetch`},
+		{desc: "option of another command in a section", config: "fetch:\n  concurrency: 8\n", cmd: "fetch", expectedErr: `unknown option "concurrency" for command fetch`},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			configPath := path
+			if tc.config != "" {
+				configPath = filepath.Join(t.TempDir(), "config.yaml")
+				require.NoError(t, os.WriteFile(configPath, []byte(tc.config), 0644))
+			}
+			var source, bucketPath string
+			var concurrency int
+			root := &cobra.Command{Use: "molt"}
+			root.PersistentFlags().StringVar(&source, "source", "", "")
+			newCommand := func(name string, register func(cmd *cobra.Command)) *cobra.Command {
+				cmd := &cobra.Command{
+					Use: name,
+					PreRunE: func(cmd *cobra.Command, args []string) error {
+						_, err := ApplyConfig(cmd)
+						return err
+					},
+					RunE: func(cmd *cobra.Command, args []string) error { return nil },
+				}
+				register(cmd)
+				RegisterConfigFlags(cmd)
+				return cmd
+			}
+			root.AddCommand(
+				newCommand("fetch", func(cmd *cobra.Command) {
+					cmd.Flags().StringVar(&bucketPath, "bucket-path", "", "")
+				}),
+				newCommand("verify", func(cmd *cobra.Command) {
+					cmd.Flags().IntVar(&concurrency, "concurrency", 1, "")
+				}),
+			)
+			root.SilenceUsage = true
+			root.SilenceErrors = true
+			configFile = ""
+			root.SetArgs([]string{tc.cmd, "--config", configPath})
+			err := root.Execute()
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "src", source)
+			if tc.cmd == "fetch" {
+				require.Equal(t, "s3://bucket", bucketPath)
+				require.Equal(t, 1, concurrency)
+			} else {
+				require.Empty(t, bucketPath)
+				require.Equal(t, 8, concurrency)
+			}
+		})
+	}
+}
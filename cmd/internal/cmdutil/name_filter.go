@@ -5,7 +5,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var tableFilter = utils.DefaultFilterConfig()
+var (
+	tableFilter   = utils.DefaultFilterConfig()
+	tableListFile string
+)
 
 func RegisterNameFilterFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVar(
@@ -20,8 +23,37 @@ func RegisterNameFilterFlags(cmd *cobra.Command) {
 		tableFilter.SchemaFilter,
 		"POSIX regexp filter for schemas to action on.",
 	)
+	cmd.PersistentFlags().StringArrayVar(
+		&tableFilter.Include,
+		"table-include",
+		nil,
+		"Glob of the tables to action on, formatted as schema.table or table (e.g. public.orders_*). Can be repeated.",
+	)
+	cmd.PersistentFlags().StringArrayVar(
+		&tableFilter.Exclude,
+		"table-exclude",
+		nil,
+		"Glob of the tables not to action on, formatted as schema.table or table (e.g. *.tmp_*). Can be repeated.",
+	)
+	cmd.PersistentFlags().StringVar(
+		&tableListFile,
+		"table-list-file",
+		"",
+		"Path of a file listing the tables to action on, one schema.table name per line.",
+	)
 }
 
-func TableFilter() utils.FilterConfig {
-	return tableFilter
+// TableFilter returns the filter of the tables to action on given by the
+// flags. The tables of --table-list-file are included alongside the
+// --table-include globs.
+func TableFilter() (utils.FilterConfig, error) {
+	ret := tableFilter
+	if tableListFile != "" {
+		patterns, err := utils.LoadTableList(tableListFile)
+		if err != nil {
+			return utils.FilterConfig{}, err
+		}
+		ret.Include = append(append([]string(nil), ret.Include...), patterns...)
+	}
+	return ret, nil
 }
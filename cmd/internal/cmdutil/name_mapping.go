@@ -0,0 +1,60 @@
+package cmdutil
+
+import (
+	"github.com/cockroachdb/molt/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	nameMapping   utils.NameMapping
+	tableMappings []string
+)
+
+func RegisterNameMappingFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringArrayVar(
+		&tableMappings,
+		"table-map",
+		nil,
+		"Maps a source table to a target table, formatted as source=target (e.g. legacy.Orders=sales.orders). Can be repeated.",
+	)
+	cmd.PersistentFlags().StringVar(
+		&nameMapping.StripTablePrefix,
+		"strip-table-prefix",
+		"",
+		"Prefix removed from the names of the source tables on the target.",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&nameMapping.Lowercase,
+		"lowercase-names",
+		false,
+		"Lowercases the schema and table names of the source tables on the target.",
+	)
+	cmd.PersistentFlags().StringVar(
+		&nameMapping.Schema,
+		"target-schema",
+		"",
+		"Schema on the target of every source table.",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&nameMapping.DatabaseAsSchema,
+		"database-as-schema",
+		false,
+		"Places the tables of a MySQL source in the schema named after the source database on the target.",
+	)
+}
+
+// NameMapping returns the mapping of source table names to target table names
+// given by the flags. Explicit --table-map pairs take precedence over the
+// rename rules.
+func NameMapping() (utils.NameMapping, error) {
+	ret := nameMapping
+	ret.Pairs = nil
+	for _, m := range tableMappings {
+		p, err := utils.ParseNamePair(m)
+		if err != nil {
+			return utils.NameMapping{}, err
+		}
+		ret.Pairs = append(ret.Pairs, p)
+	}
+	return ret, nil
+}
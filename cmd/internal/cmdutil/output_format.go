@@ -0,0 +1,33 @@
+package cmdutil
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
+)
+
+// OutputFormat is the format in which commands showing the metadata of
+// fetches output it.
+type OutputFormat enumflag.Flag
+
+const (
+	OutputFormatTable OutputFormat = iota
+	OutputFormatJSON
+)
+
+var outputFormatStringRepresentations = map[OutputFormat][]string{
+	OutputFormatTable: {"table"},
+	OutputFormatJSON:  {"json"},
+}
+
+func RegisterOutputFormatFlag(cmd *cobra.Command, format *OutputFormat) {
+	cmd.PersistentFlags().Var(
+		enumflag.New(
+			format,
+			"format",
+			outputFormatStringRepresentations,
+			enumflag.EnumCaseInsensitive,
+		),
+		"format",
+		"Format of the output (table/json).",
+	)
+}
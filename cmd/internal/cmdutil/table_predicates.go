@@ -0,0 +1,31 @@
+package cmdutil
+
+import (
+	"github.com/cockroachdb/molt/utils"
+	"github.com/spf13/cobra"
+)
+
+var tablePredicates []string
+
+func RegisterTablePredicateFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringArrayVar(
+		&tablePredicates,
+		"table-predicate",
+		nil,
+		"Restricts the rows of a source table to those matching a SQL boolean expression, formatted as table:predicate (e.g. public.orders:tenant_id = 42). Can be repeated.",
+	)
+}
+
+// TablePredicates returns the predicates of the source tables given by
+// --table-predicate.
+func TablePredicates() (utils.TablePredicates, error) {
+	var ret utils.TablePredicates
+	for _, s := range tablePredicates {
+		p, err := utils.ParseTablePredicate(s)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
@@ -0,0 +1,26 @@
+package cmdutil
+
+import (
+	"github.com/cockroachdb/molt/utils/transform"
+	"github.com/spf13/cobra"
+)
+
+var transformsFile string
+
+func RegisterTransformsFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&transformsFile,
+		"transforms",
+		"",
+		"Path of a YAML file of rules transforming the values of source columns, e.g. to mask PII.",
+	)
+}
+
+// Transforms loads the transforms file given by --transforms. No columns are
+// transformed if the flag is not set.
+func Transforms() (*transform.Transforms, error) {
+	if transformsFile == "" {
+		return nil, nil
+	}
+	return transform.Load(transformsFile)
+}
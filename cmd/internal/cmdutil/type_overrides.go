@@ -0,0 +1,26 @@
+package cmdutil
+
+import (
+	"github.com/cockroachdb/molt/utils/typeconv"
+	"github.com/spf13/cobra"
+)
+
+var typeOverridesFile string
+
+func RegisterTypeOverridesFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(
+		&typeOverridesFile,
+		"type-overrides",
+		"",
+		"Path of a YAML file of rules overriding the default mapping of source types to CockroachDB types.",
+	)
+}
+
+// TypeOverrides loads the type mapping file given by --type-overrides. No
+// types are overridden if the flag is not set.
+func TypeOverrides() (*typeconv.TypeOverrides, error) {
+	if typeOverridesFile == "" {
+		return nil, nil
+	}
+	return typeconv.LoadTypeOverrides(typeOverridesFile)
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/cockroachdb/molt/cmd/fetch"
+	"github.com/cockroachdb/molt/cmd/schema"
 	"github.com/cockroachdb/molt/cmd/verify"
 	"github.com/spf13/cobra"
 )
@@ -35,6 +36,7 @@ func init() {
 	rootCmd.AddCommand(EscapePasswordCommand())
 	rootCmd.AddCommand(verify.Command())
 	rootCmd.AddCommand(fetch.Command())
+	rootCmd.AddCommand(schema.Command())
 	rootCmd.Version = fmt.Sprintf("v%s", moltVersion)
 	// Hide completion options, because irrelevant.
 	rootCmd.CompletionOptions.HiddenDefaultCmd = true
@@ -0,0 +1,164 @@
+package schema
+
+import (
+	"context"
+	"os"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/fetch"
+	"github.com/cockroachdb/molt/moltlogger"
+	"github.com/spf13/cobra"
+)
+
+func Command() *cobra.Command {
+	var logFile string
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Converts the schema of the source and applies it to the target.",
+		Long: `Converts the schema of the source tables into a file of CockroachDB DDL which can be reviewed
+and edited before it is applied to the target.`,
+	}
+	cmd.AddCommand(convertCommand(&logFile), applyCommand(&logFile))
+
+	cmd.PersistentFlags().StringVar(
+		&logFile,
+		"log-file",
+		"",
+		"If set, writes to the log file specified. Otherwise, only writes to stdout.",
+	)
+	moltlogger.RegisterLoggerFlags(cmd)
+	return cmd
+}
+
+func convertCommand(logFile *string) *cobra.Command {
+	var (
+		connString string
+		outputFile string
+	)
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Writes the DDL creating the source tables on the target to a file.",
+		Long: `Writes the DDL creating the source tables, their enums, sequences, indexes and constraints on the target
+to a .sql file, with the warnings raised by the conversion as comments.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			logger, err := moltlogger.Logger(*logFile)
+			if err != nil {
+				return err
+			}
+			overrides, err := cmdutil.TypeOverrides()
+			if err != nil {
+				return err
+			}
+			mapping, err := cmdutil.NameMapping()
+			if err != nil {
+				return err
+			}
+			tableFilter, err := cmdutil.TableFilter()
+			if err != nil {
+				return err
+			}
+			conn, err := dbconn.Connect(ctx, "source", connString)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = conn.Close(ctx) }()
+
+			script, convertErr := fetch.ConvertSchema(ctx, logger, conn, tableFilter, mapping, overrides)
+			if script == "" {
+				return convertErr
+			}
+			if err := os.WriteFile(outputFile, []byte(script), 0644); err != nil {
+				return errors.Wrapf(err, "failed to write the schema to %s", outputFile)
+			}
+			logger.Info().Msgf("wrote the schema to %s", outputFile)
+			return convertErr
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(
+		&connString,
+		"source",
+		"",
+		"Connection string of the source database.",
+	)
+	cmd.PersistentFlags().StringVarP(
+		&outputFile,
+		"output",
+		"o",
+		"",
+		"Path of the .sql file to write the DDL to.",
+	)
+	cmdutil.RegisterNameFilterFlags(cmd)
+	cmdutil.RegisterTypeOverridesFlags(cmd)
+	cmdutil.RegisterNameMappingFlags(cmd)
+	for _, required := range []string{"source", "output"} {
+		if err := cmd.MarkPersistentFlagRequired(required); err != nil {
+			panic(err)
+		}
+	}
+	return cmd
+}
+
+func applyCommand(logFile *string) *cobra.Command {
+	var (
+		connString string
+		inputFile  string
+		postLoad   bool
+	)
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Executes the DDL of a file on the target.",
+		Long: `Executes the statements of a .sql file, such as one written by molt schema convert, on the target
+one at a time. The statements before the post-load marker of the file are executed, or with --post-load
+those after it, which are executed once the data is loaded. The statements are parsed before any is executed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+			logger, err := moltlogger.Logger(*logFile)
+			if err != nil {
+				return err
+			}
+			script, err := os.ReadFile(inputFile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read the schema from %s", inputFile)
+			}
+			conn, err := dbconn.Connect(ctx, "target", connString)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = conn.Close(ctx) }()
+			if !conn.IsCockroach() {
+				return errors.AssertionFailedf("target must be cockroach")
+			}
+			return fetch.ApplySchema(ctx, logger, conn.(*dbconn.PGConn), string(script), postLoad)
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(
+		&connString,
+		"target",
+		"",
+		"Connection string of the target database.",
+	)
+	cmd.PersistentFlags().StringVarP(
+		&inputFile,
+		"file",
+		"f",
+		"",
+		"Path of the .sql file to execute.",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&postLoad,
+		"post-load",
+		false,
+		"If set, executes the statements after the post-load marker of the file, such as indexes, constraints and sequence advancements, once the data is loaded.",
+	)
+	for _, required := range []string{"target", "file"} {
+		if err := cmd.MarkPersistentFlagRequired(required); err != nil {
+			panic(err)
+		}
+	}
+	return cmd
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/cockroachdb/molt/cmd/internal/cmdutil"
 	"github.com/cockroachdb/molt/moltlogger"
 	"github.com/cockroachdb/molt/retry"
+	"github.com/cockroachdb/molt/shardmode"
 	"github.com/cockroachdb/molt/utils"
 	"github.com/cockroachdb/molt/verify"
 	"github.com/cockroachdb/molt/verify/inconsistency"
@@ -15,6 +16,7 @@ import (
 	"github.com/cockroachdb/molt/verify/verifymetrics"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag/v2"
 )
 
 func Command() *cobra.Command {
@@ -25,6 +27,8 @@ func Command() *cobra.Command {
 	var (
 		verifyConcurrency              int
 		verifyTableSplits              int
+		verifyShardMode                shardmode.Flag
+		verifyWorkStealing             bool
 		verifyRowBatchSize             int
 		verifyFixup                    bool
 		verifyContinuousPause          time.Duration
@@ -45,6 +49,7 @@ func Command() *cobra.Command {
 		verifyRows               bool
 		verifyTestOnly           bool
 		logFile                  string
+		fileConfig               *cmdutil.Config
 	)
 
 	cmd := &cobra.Command{
@@ -52,6 +57,12 @@ func Command() *cobra.Command {
 		Short: "Verify table schemas and row data align.",
 		Long:  `Verify ensure table schemas and row data between the two databases are aligned.`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// The config file is applied before the flags are validated.
+			var err error
+			if fileConfig, err = cmdutil.ApplyConfig(cmd); err != nil {
+				return err
+			}
+
 			// Flag dependencies need to be checked here because in this hook is when
 			// we can determine if the flag has been changed by the user (i.e. user set a value).
 			if err := cmdutil.CheckFlagDependency(cmd, continuous, []string{"continuous-pause-between-runs"}); err != nil {
@@ -79,6 +90,37 @@ func Command() *cobra.Command {
 			reporter.Reporters = append(reporter.Reporters, &inconsistency.LogReporter{Logger: logger})
 			defer reporter.Close()
 
+			typeOverrides, err := cmdutil.TypeOverrides()
+			if err != nil {
+				return err
+			}
+			nameMapping, err := cmdutil.NameMapping()
+			if err != nil {
+				return err
+			}
+			transforms, err := cmdutil.Transforms()
+			if err != nil {
+				return err
+			}
+			predicates, err := cmdutil.TablePredicates()
+			if err != nil {
+				return err
+			}
+			tableFilter, err := cmdutil.TableFilter()
+			if err != nil {
+				return err
+			}
+			// The settings of the tables in the config file take
+			// precedence over the flags.
+			var tableOverrides []verify.TableOverride
+			for _, t := range fileConfig.Tables {
+				tableOverrides = append(tableOverrides, verify.TableOverride{
+					Table:        t.Table,
+					TableSplits:  t.Shards,
+					RowBatchSize: t.RowBatchSize,
+				})
+			}
+
 			ctx := context.Background()
 			conns, err := cmdutil.LoadDBConns(ctx)
 			if err != nil {
@@ -104,10 +146,17 @@ func Command() *cobra.Command {
 				reporter,
 				verify.WithConcurrency(verifyConcurrency),
 				verify.WithTableSplits(verifyTableSplits),
+				verify.WithShardMode(verifyShardMode),
+				verify.WithWorkStealing(verifyWorkStealing),
 				verify.WithRowBatchSize(verifyRowBatchSize),
 				verify.WithContinuous(verifyContinuous, verifyContinuousPause),
 				verify.WithLive(verifyLive, verifyLiveVerificationSettings),
-				verify.WithDBFilter(cmdutil.TableFilter()),
+				verify.WithDBFilter(tableFilter),
+				verify.WithTypeOverrides(typeOverrides),
+				verify.WithNameMapping(nameMapping),
+				verify.WithTransforms(transforms),
+				verify.WithPredicates(predicates),
+				verify.WithTableOverrides(tableOverrides),
 				verify.WithRowsPerSecond(verifyLimitRowsPerSecond),
 				verify.WithRows(verifyRows),
 				verify.WithTestOnly(verifyTestOnly),
@@ -140,6 +189,22 @@ func Command() *cobra.Command {
 		1,
 		"Number of shards to break down each table into while doing row-based verification.",
 	)
+	cmd.PersistentFlags().Var(
+		enumflag.New(
+			&verifyShardMode,
+			"shard-mode",
+			shardmode.ShardModeStringRepresentations,
+			enumflag.EnumCaseInsensitive,
+		),
+		"shard-mode",
+		"How to choose the boundaries between the shards of each table (linear/stats). The stats mode uses table statistics or a sample of the table to give each shard a similar number of rows. (default linear)",
+	)
+	cmd.PersistentFlags().BoolVar(
+		&verifyWorkStealing,
+		"shard-work-stealing",
+		false,
+		"If set, idle verification threads split the remaining rows of slow shards. Tables with string primary keys are not split. Not used with --continuous.",
+	)
 	cmd.PersistentFlags().IntVar(
 		&verifyRowBatchSize,
 		"row-batch-size",
@@ -249,6 +314,11 @@ func Command() *cobra.Command {
 	moltlogger.RegisterLoggerFlags(cmd)
 	cmdutil.RegisterDBConnFlags(cmd)
 	cmdutil.RegisterNameFilterFlags(cmd)
+	cmdutil.RegisterTypeOverridesFlags(cmd)
+	cmdutil.RegisterNameMappingFlags(cmd)
+	cmdutil.RegisterTransformsFlags(cmd)
+	cmdutil.RegisterTablePredicateFlags(cmd)
+	cmdutil.RegisterConfigFlags(cmd)
 	cmdutil.RegisterMetricsFlags(cmd)
 	return cmd
 }
@@ -9,8 +9,9 @@ import (
 )
 
 type MySQLConn struct {
-	id  ID
-	url string
+	id       ID
+	url      string
+	database string
 	*sql.DB
 	typeMap *pgtype.Map
 }
@@ -26,13 +27,18 @@ func ConnectMySQL(ctx context.Context, id ID, connStr string) (*MySQLConn, error
 		return nil, err
 	}
 	m := pgtype.NewMap()
-	return &MySQLConn{id: id, url: u, DB: db, typeMap: m}, nil
+	return &MySQLConn{id: id, url: u, database: cfg.DBName, DB: db, typeMap: m}, nil
 }
 
 func (c *MySQLConn) ID() ID {
 	return c.id
 }
 
+// Database returns the name of the database the connection uses.
+func (c *MySQLConn) Database() string {
+	return c.database
+}
+
 func (c *MySQLConn) Close(ctx context.Context) error {
 	return c.DB.Close()
 }
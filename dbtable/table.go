@@ -31,6 +31,15 @@ type DBTable struct {
 	OID oid.Oid
 }
 
+// Matches returns whether the table is named by name, given as either table
+// or schema.table. Names are compared case insensitively.
+func (n Name) Matches(name string) bool {
+	if schema, tbl, ok := strings.Cut(name, "."); ok {
+		return strings.EqualFold(schema, string(n.Schema)) && strings.EqualFold(tbl, string(n.Table))
+	}
+	return strings.EqualFold(name, string(n.Table))
+}
+
 func (n Name) SafeString() string {
 	return fmt.Sprintf("%s.%s", n.Schema, n.Table)
 }
@@ -50,10 +59,67 @@ func (tm DBTable) String() string {
 	return fmt.Sprintf("%s.%s", tm.Schema, tm.Table)
 }
 
+// KeyType is the kind of key used to order and shard the rows of a table.
+type KeyType int
+
+const (
+	// KeyTypePrimaryKey orders rows by the primary key.
+	KeyTypePrimaryKey KeyType = iota
+	// KeyTypeUniqueIndex orders rows by a unique index whose columns are all
+	// NOT NULL, as the table has no primary key.
+	KeyTypeUniqueIndex
+	// KeyTypeRowID orders rows by the physical row identifier of the source,
+	// i.e. ROWID on Oracle. It is only used to export tables without a
+	// unique key.
+	KeyTypeRowID
+	// KeyTypeNone means the table has no unique key. Rows are ordered by
+	// every column and read in a single scan, as they cannot be paged through.
+	KeyTypeNone
+)
+
 // VerifiedTable represents a table which has been verified across implementations.
 type VerifiedTable struct {
 	Name
+	// TargetName is the name of the table on the target, if it differs from
+	// the name of the table on the source.
+	TargetName Name
+	// PrimaryKeyColumns are the columns rows are ordered by. This is the
+	// primary key unless KeyType says otherwise.
 	PrimaryKeyColumns []tree.Name
+	KeyType           KeyType
 	Columns           []tree.Name
 	ColumnOIDs        [2][]oid.Oid
+	// TypeOverridden is set for the columns whose source values are
+	// converted to the type of the target column, as their type is
+	// overridden by a type mapping file.
+	TypeOverridden []bool
+	// Transforms are the transforms of the values of the columns read from
+	// the source, indexed like Columns, or nil if no column is transformed.
+	Transforms []ColumnTransform
+	// Predicate, if set, is a SQL boolean expression which restricts the rows
+	// of the table which are fetched and verified. It is run on both the
+	// source and the target.
+	Predicate string
+}
+
+// ColumnTransform transforms the values of a column read from the source
+// before they are written to, or compared with, the target.
+type ColumnTransform interface {
+	// Transform returns the value on the target of the column at idx, given
+	// the values of the row on the source.
+	Transform(row tree.Datums, idx int) (tree.Datum, error)
+}
+
+// Target returns the name of the table on the target.
+func (t VerifiedTable) Target() Name {
+	if t.TargetName == (Name{}) {
+		return t.Name
+	}
+	return t.TargetName
+}
+
+// Names returns the names of the table on the source and on the target, in
+// the order of the connections they are read from.
+func (t VerifiedTable) Names() [2]Name {
+	return [2]Name{t.Name, t.Target()}
 }
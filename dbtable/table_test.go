@@ -25,3 +25,11 @@ func TestDBTableCompare(t *testing.T) {
 	}
 
 }
+
+func TestNameMatches(t *testing.T) {
+	n := Name{Schema: "public", Table: "Users"}
+	require.True(t, n.Matches("users"))
+	require.True(t, n.Matches("PUBLIC.users"))
+	require.False(t, n.Matches("other.users"))
+	require.False(t, n.Matches("orders"))
+}
@@ -0,0 +1,398 @@
+package fetch
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/datablobstorage"
+	"github.com/cockroachdb/molt/fetch/dataexport"
+	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/jackc/pgx/v5"
+	"github.com/lib/pq/oid"
+	"github.com/rs/zerolog"
+)
+
+// exportCheckpointer persists the progress of the export of each shard of a
+// table, so that a fetch continued with --fetch-id resumes exporting each
+// shard after the last row written to the data store instead of from
+// scratch.
+//
+// A nil exportCheckpointer records nothing.
+type exportCheckpointer struct {
+	logger  zerolog.Logger
+	table   dbtable.VerifiedTable
+	fetchID uuid.UUID
+	// direct is whether rows are copied directly to the target, in which case
+	// every file is imported as soon as it is written.
+	direct bool
+	// resumable is whether the export of a shard can be resumed after a row.
+	// This requires rows to be ordered by a unique key which can be parsed
+	// back from the exported text. Otherwise, unfinished shards are exported
+	// again from the start.
+	resumable bool
+	// committedFiles is the number of files of each shard written by a
+	// previous attempt which can be imported, and committedRows the number of
+	// rows in them.
+	committedFiles map[int]int
+	committedRows  int
+	// importedFiles is the number of the files of each shard which were
+	// already imported by a previous attempt.
+	importedFiles map[int]int
+
+	mu struct {
+		sync.Mutex
+		conn        *pgx.Conn
+		checkpoints map[int]*status.ExportCheckpoint
+		// disabled is set if the checkpoints may no longer cover the shards
+		// being exported, in which case no further progress is recorded.
+		disabled bool
+	}
+}
+
+func newExportCheckpointer(
+	logger zerolog.Logger,
+	conn *pgx.Conn,
+	fetchID uuid.UUID,
+	table dbtable.VerifiedTable,
+	previous []*status.ExportCheckpoint,
+	direct bool,
+) *exportCheckpointer {
+	c := &exportCheckpointer{
+		logger:         logger,
+		table:          table,
+		fetchID:        fetchID,
+		direct:         direct,
+		resumable:      resumableKey(table),
+		committedFiles: make(map[int]int),
+		importedFiles:  make(map[int]int),
+	}
+	c.mu.conn = conn
+	c.mu.checkpoints = make(map[int]*status.ExportCheckpoint)
+	for _, cp := range previous {
+		c.mu.checkpoints[cp.ShardNum] = cp
+		c.importedFiles[cp.ShardNum] = cp.ImportedFileNum
+		if cp.Done || (c.resumable && len(cp.LastPK) > 0) {
+			c.committedFiles[cp.ShardNum] = cp.FileNum
+			c.committedRows += cp.NumRows
+		}
+	}
+	return c
+}
+
+// resumableKey returns whether a shard of the table can be resumed from the
+// last key written to the data store. The key is recorded as exported, after
+// type overrides and transforms are applied, so keys with overridden or
+// transformed columns cannot be parsed back into the values of the source.
+func resumableKey(table dbtable.VerifiedTable) bool {
+	if table.KeyType != dbtable.KeyTypePrimaryKey && table.KeyType != dbtable.KeyTypeUniqueIndex {
+		return false
+	}
+	for i, typOID := range table.ColumnOIDs[0][:len(table.PrimaryKeyColumns)] {
+		if _, ok := types.OidToType[typOID]; !ok {
+			return false
+		}
+		if i < len(table.TypeOverridden) && table.TypeOverridden[i] {
+			return false
+		}
+		if i < len(table.Transforms) && table.Transforms[i] != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// hasUnfinishedShards returns whether a previous attempt left shards which
+// have not been completely exported.
+func hasUnfinishedShards(checkpoints []*status.ExportCheckpoint) bool {
+	for _, cp := range checkpoints {
+		if !cp.Done {
+			return true
+		}
+	}
+	return false
+}
+
+// resumeShards returns the shards which were not completely exported by a
+// previous attempt, starting after the last row written to the data store.
+func (c *exportCheckpointer) resumeShards() ([]rowverify.TableShard, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	typOIDs := c.table.ColumnOIDs[0][:len(c.table.PrimaryKeyColumns)]
+	totalShards := 0
+	for shardNum := range c.mu.checkpoints {
+		totalShards = max(totalShards, shardNum)
+	}
+	var shards []rowverify.TableShard
+	for _, cp := range c.mu.checkpoints {
+		if cp.Done {
+			continue
+		}
+		shard := rowverify.TableShard{
+			VerifiedTable: c.table,
+			ShardNum:      cp.ShardNum,
+			TotalShards:   totalShards,
+		}
+		var err error
+		if shard.StartPKVals, err = parseCheckpointKey(cp.StartPK, typOIDs); err != nil {
+			return nil, err
+		}
+		if shard.EndPKVals, err = parseCheckpointKey(cp.EndPK, typOIDs); err != nil {
+			return nil, err
+		}
+		if cp.ImportedFileNum > 0 && (!c.resumable || cp.ImportedFileNum > cp.FileNum) {
+			return nil, errors.Newf(
+				"unable to resume export of shard %d of table %s as it was partially imported, please start a new fetch",
+				cp.ShardNum, c.table.SafeString())
+		}
+		if c.resumable && len(cp.LastPK) > 0 {
+			if shard.CursorPKVals, err = parseCheckpointKey(cp.LastPK, typOIDs); err != nil {
+				return nil, err
+			}
+		} else {
+			// Files written by the previous attempt are overwritten.
+			cp.LastPK = nil
+			cp.FileNum = 0
+			cp.NumRows = 0
+		}
+		shards = append(shards, shard)
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i].ShardNum < shards[j].ShardNum
+	})
+	return shards, nil
+}
+
+// committedResources filters the resources of the table in the data store
+// to those written by a previous attempt, in the order they are to be
+// imported.
+func (c *exportCheckpointer) committedResources(
+	resources []datablobstorage.Resource,
+) ([]queuedResource, error) {
+	var ret []queuedResource
+	for _, r := range resources {
+		key, err := r.Key()
+		if err != nil {
+			return nil, err
+		}
+		if path.Base(path.Dir(key)) != c.table.SafeString() {
+			continue
+		}
+		qr, err := newQueuedResource(r)
+		if err != nil {
+			return nil, err
+		}
+		if qr.fileNum == 0 {
+			continue
+		}
+		if qr.fileNum <= c.committedFiles[qr.shardNum] {
+			ret = append(ret, qr)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].shardNum != ret[j].shardNum {
+			return ret[i].shardNum < ret[j].shardNum
+		}
+		return ret[i].fileNum < ret[j].fileNum
+	})
+	return ret, nil
+}
+
+// wasImported returns whether the file was imported by a previous attempt.
+func (c *exportCheckpointer) wasImported(r queuedResource) bool {
+	if c == nil || r.fileNum == 0 {
+		return false
+	}
+	return r.fileNum <= c.importedFiles[r.shardNum]
+}
+
+// fileNum returns the number of the last file written for the given shard.
+func (c *exportCheckpointer) fileNum(shardNum int) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cp, ok := c.mu.checkpoints[shardNum]; ok {
+		return cp.FileNum
+	}
+	return 0
+}
+
+// startShard records that the export of a shard is starting.
+func (c *exportCheckpointer) startShard(ctx context.Context, shard rowverify.TableShard) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.disabled {
+		return nil
+	}
+	if _, ok := c.mu.checkpoints[shard.ShardNum]; ok {
+		return nil
+	}
+	cp := c.newCheckpointLocked(shard)
+	if err := cp.CreateEntry(ctx, c.mu.conn); err != nil {
+		return errors.Wrapf(err, "error creating export checkpoint for shard %d", shard.ShardNum)
+	}
+	c.mu.checkpoints[shard.ShardNum] = cp
+	return nil
+}
+
+// split records that the remaining rows of a shard have been split off into
+// a new shard.
+func (c *exportCheckpointer) split(
+	ctx context.Context, shard rowverify.TableShard, split rowverify.TableShard,
+) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.disabled {
+		return
+	}
+	cp := c.newCheckpointLocked(split)
+	if err := cp.CreateSplitEntry(ctx, c.mu.conn, shard.ShardNum); err != nil {
+		// The checkpoint of the original shard still covers the rows which were
+		// split off, but it may not be marked done until they are exported.
+		c.logger.Warn().Err(err).
+			Int("shard", shard.ShardNum).
+			Msgf("unable to record split shard, no longer recording export progress")
+		c.mu.disabled = true
+		return
+	}
+	c.mu.checkpoints[split.ShardNum] = cp
+}
+
+// flushed records that a file of the shard has been written to the data
+// store.
+func (c *exportCheckpointer) flushed(ctx context.Context, shardNum int, fileNum int, f flushedFile) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cp, ok := c.mu.checkpoints[shardNum]
+	if !ok || c.mu.disabled {
+		return
+	}
+	cp.FileNum = fileNum
+	if c.direct {
+		cp.ImportedFileNum = fileNum
+	}
+	cp.NumRows += f.numRows
+	if c.resumable {
+		cp.LastPK = f.lastKey
+	}
+	c.updateLocked(ctx, cp)
+}
+
+// done records that every row of the shard has been written to the data
+// store.
+func (c *exportCheckpointer) done(ctx context.Context, shardNum int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cp, ok := c.mu.checkpoints[shardNum]
+	if !ok || c.mu.disabled {
+		return
+	}
+	cp.Done = true
+	c.updateLocked(ctx, cp)
+}
+
+// imported records that the files of the shard up to the given file number
+// have been imported into the target.
+func (c *exportCheckpointer) imported(ctx context.Context, shardNum int, fileNum int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cp, ok := c.mu.checkpoints[shardNum]
+	if !ok || fileNum <= cp.ImportedFileNum {
+		return
+	}
+	cp.ImportedFileNum = fileNum
+	c.updateLocked(ctx, cp)
+}
+
+// updateLocked persists the checkpoint. Failing to do so only means more rows
+// are exported again when continuing, so the export carries on.
+func (c *exportCheckpointer) updateLocked(ctx context.Context, cp *status.ExportCheckpoint) {
+	if err := cp.UpdateEntry(ctx, c.mu.conn); err != nil {
+		c.logger.Warn().Err(err).
+			Int("shard", cp.ShardNum).
+			Msgf("unable to record export progress")
+	}
+}
+
+func (c *exportCheckpointer) newCheckpointLocked(shard rowverify.TableShard) *status.ExportCheckpoint {
+	return &status.ExportCheckpoint{
+		FetchID:  c.fetchID,
+		Table:    c.table.Table.String(),
+		Schema:   c.table.Schema.String(),
+		ShardNum: shard.ShardNum,
+		StartPK:  formatCheckpointKey(shard.StartPKVals),
+		EndPK:    formatCheckpointKey(shard.EndPKVals),
+		Time:     time.Now().UTC(),
+	}
+}
+
+// formatCheckpointKey formats a primary key as it is written to exported
+// files.
+func formatCheckpointKey(key tree.Datums) []string {
+	if len(key) == 0 {
+		return nil
+	}
+	ret := make([]string, len(key))
+	for i, d := range key {
+		ret[i] = dataexport.FormatDatum(d)
+	}
+	return ret
+}
+
+// parseCheckpointKey parses a primary key, or a prefix of it, formatted by
+// formatCheckpointKey.
+func parseCheckpointKey(key []string, typOIDs []oid.Oid) (tree.Datums, error) {
+	if len(key) == 0 {
+		return nil, nil
+	}
+	if len(key) > len(typOIDs) {
+		return nil, errors.AssertionFailedf("checkpoint key %v has more values than the primary key", key)
+	}
+	parseCtx := tree.NewParseContext(time.Now())
+	ret := make(tree.Datums, len(key))
+	for i, s := range key {
+		typ, ok := types.OidToType[typOIDs[i]]
+		if !ok {
+			return nil, errors.Newf("unable to parse checkpoint key of type oid %d", typOIDs[i])
+		}
+		switch typ.Family() {
+		case types.IntFamily, types.DecimalFamily, types.FloatFamily:
+			// Negative numbers are exported in brackets.
+			if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+				s = s[1 : len(s)-1]
+			}
+		}
+		d, _, err := tree.ParseAndRequireString(typ, s, parseCtx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing checkpoint key %q", s)
+		}
+		ret[i] = d
+	}
+	return ret, nil
+}
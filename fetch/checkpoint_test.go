@@ -0,0 +1,222 @@
+package fetch
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+	"github.com/cockroachdb/molt/comparectx"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/datablobstorage"
+	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/lib/pq/oid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointKey(t *testing.T) {
+	ts, err := tree.MakeDTimestamp(time.Date(2024, 3, 21, 1, 2, 3, 123456000, time.UTC), time.Microsecond)
+	require.NoError(t, err)
+	dec, err := tree.ParseDDecimal("-123.456")
+	require.NoError(t, err)
+	key := tree.Datums{
+		tree.NewDInt(-42),
+		tree.NewDString("a,\"b\"\nc"),
+		tree.NewDUuid(tree.DUuid{UUID: uuid.FromStringOrNil("35750bee-6d1f-43f0-a36c-d660474bfd2d")}),
+		ts,
+		dec,
+		tree.NewDFloat(-1.5),
+		tree.NewDBytes("\x00\xff"),
+	}
+	typOIDs := []oid.Oid{oid.T_int8, oid.T_text, oid.T_uuid, oid.T_timestamp, oid.T_numeric, oid.T_float8, oid.T_bytea}
+
+	formatted := formatCheckpointKey(key)
+	parsed, err := parseCheckpointKey(formatted, typOIDs)
+	require.NoError(t, err)
+	require.Len(t, parsed, len(key))
+	for i := range key {
+		require.Zerof(t, key[i].Compare(comparectx.CompareContext, parsed[i]), "%s != %s", key[i], parsed[i])
+	}
+
+	// Prefixes of the primary key are parsed using the leading types.
+	parsed, err = parseCheckpointKey(formatted[:1], typOIDs)
+	require.NoError(t, err)
+	require.Equal(t, tree.Datums{tree.NewDInt(-42)}, parsed)
+
+	parsed, err = parseCheckpointKey(nil, typOIDs)
+	require.NoError(t, err)
+	require.Nil(t, parsed)
+
+	_, err = parseCheckpointKey([]string{"abc"}, typOIDs)
+	require.Error(t, err)
+}
+
+type identityTransform struct{}
+
+func (identityTransform) Transform(row tree.Datums, idx int) (tree.Datum, error) {
+	return row[idx], nil
+}
+
+func TestResumableKey(t *testing.T) {
+	table := dbtable.VerifiedTable{
+		PrimaryKeyColumns: []tree.Name{"id"},
+		KeyType:           dbtable.KeyTypePrimaryKey,
+		Columns:           []tree.Name{"id", "v"},
+		ColumnOIDs:        [2][]oid.Oid{{oid.T_int8, oid.T_text}, {oid.T_int8, oid.T_text}},
+	}
+	require.True(t, resumableKey(table))
+
+	// Overrides and transforms of other columns do not change the key.
+	other := table
+	other.TypeOverridden = []bool{false, true}
+	other.Transforms = []dbtable.ColumnTransform{nil, identityTransform{}}
+	require.True(t, resumableKey(other))
+
+	// Keys are recorded as exported, so they cannot be resumed from if they
+	// are converted.
+	overridden := table
+	overridden.TypeOverridden = []bool{true, false}
+	require.False(t, resumableKey(overridden))
+
+	transformed := table
+	transformed.Transforms = []dbtable.ColumnTransform{identityTransform{}, nil}
+	require.False(t, resumableKey(transformed))
+
+	noKey := table
+	noKey.KeyType = dbtable.KeyTypeNone
+	require.False(t, resumableKey(noKey))
+}
+
+type testResource struct {
+	datablobstorage.Resource
+	key string
+}
+
+func (r testResource) Key() (string, error) {
+	return r.key, nil
+}
+
+func TestCommittedResources(t *testing.T) {
+	table := dbtable.VerifiedTable{
+		Name:              dbtable.Name{Schema: "public", Table: "t"},
+		PrimaryKeyColumns: []tree.Name{"id"},
+		KeyType:           dbtable.KeyTypePrimaryKey,
+		Columns:           []tree.Name{"id", "v"},
+		ColumnOIDs:        [2][]oid.Oid{{oid.T_int8, oid.T_text}, {oid.T_int8, oid.T_text}},
+	}
+	c := newExportCheckpointer(zerolog.Nop(), nil, uuid.Nil, table, []*status.ExportCheckpoint{
+		// Finished shard.
+		{ShardNum: 1, FileNum: 2, NumRows: 20, Done: true, EndPK: []string{"100"}},
+		// Resumed from a row, after its first file was imported.
+		{ShardNum: 2, FileNum: 1, ImportedFileNum: 1, NumRows: 5, StartPK: []string{"100"}, EndPK: []string{"200"}, LastPK: []string{"150"}},
+		// Never flushed, so exported again from the start.
+		{ShardNum: 3, StartPK: []string{"200"}},
+	}, false /* direct */)
+
+	var resources []datablobstorage.Resource
+	for _, key := range []string{
+		"bucket/public.t/shard_01_part_00000001.csv",
+		"bucket/public.t/shard_01_part_00000002.csv",
+		"bucket/public.t/shard_02_part_00000001.csv",
+		// Written after the last checkpoint of the shard.
+		"bucket/public.t/shard_02_part_00000002.csv",
+		"bucket/public.t/shard_03_part_00000001.csv",
+		// Belongs to another table.
+		"bucket/public.t2/shard_01_part_00000001.csv",
+	} {
+		resources = append(resources, testResource{key: key})
+	}
+	committed, err := c.committedResources(resources)
+	require.NoError(t, err)
+	var keys []string
+	for _, r := range committed {
+		key, err := r.Key()
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	require.Equal(t, []string{
+		"bucket/public.t/shard_01_part_00000001.csv",
+		"bucket/public.t/shard_01_part_00000002.csv",
+		"bucket/public.t/shard_02_part_00000001.csv",
+	}, keys)
+	require.Equal(t, 25, c.committedRows)
+	require.False(t, c.wasImported(committed[1]))
+	require.True(t, c.wasImported(committed[2]))
+
+	shards, err := c.resumeShards()
+	require.NoError(t, err)
+	require.Len(t, shards, 2)
+	require.Equal(t, 2, shards[0].ShardNum)
+	require.Equal(t, 3, shards[0].TotalShards)
+	require.Equal(t, tree.Datums{tree.NewDInt(100)}, tree.Datums(shards[0].StartPKVals))
+	require.Equal(t, tree.Datums{tree.NewDInt(200)}, tree.Datums(shards[0].EndPKVals))
+	require.Equal(t, tree.Datums{tree.NewDInt(150)}, tree.Datums(shards[0].CursorPKVals))
+	require.Equal(t, 1, c.fileNum(2))
+	require.Equal(t, 3, shards[1].ShardNum)
+	require.Empty(t, shards[1].CursorPKVals)
+	require.Equal(t, 0, c.fileNum(3))
+}
+
+func TestCSVPipeFlushedFiles(t *testing.T) {
+	var bufs []testStringBuf
+	var flushed []flushedFile
+	flushedCh := make(chan flushedFile, 1)
+	pipe := newCSVPipe(
+		strings.NewReader("1,a,x\n1,b,y\n2,a,z\n"),
+		zerolog.Nop(),
+		1024,
+		2,
+		1,
+		func(numRows chan int) (io.WriteCloser, error) {
+			bufs = append(bufs, testStringBuf{})
+			return &bufs[len(bufs)-1], nil
+		},
+	)
+	pipe.flushedCh = flushedCh
+	pipe.numKeyCols = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for {
+			select {
+			case <-pipe.numRowsCh:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for len(flushed) < 2 {
+			flushed = append(flushed, <-flushedCh)
+		}
+	}()
+	require.NoError(t, pipe.Pipe(dbtable.Name{Schema: "test", Table: "test"}))
+	<-doneCh
+	require.Equal(t, []flushedFile{
+		{lastKey: []string{"1", "b"}, numRows: 2},
+		{lastKey: []string{"2", "a"}, numRows: 1},
+	}, flushed)
+}
+
+func TestResumePartiallyImportedShard(t *testing.T) {
+	table := dbtable.VerifiedTable{
+		Name:              dbtable.Name{Schema: "public", Table: "t"},
+		PrimaryKeyColumns: []tree.Name{"rowid"},
+		KeyType:           dbtable.KeyTypeNone,
+		Columns:           []tree.Name{"v"},
+		ColumnOIDs:        [2][]oid.Oid{{oid.T_text}, {oid.T_text}},
+	}
+	// Shards which cannot be resumed from a row are exported again from the
+	// start, which cannot be done once some of their files were imported.
+	c := newExportCheckpointer(zerolog.Nop(), nil, uuid.Nil, table, []*status.ExportCheckpoint{
+		{ShardNum: 1, FileNum: 2, ImportedFileNum: 1},
+	}, false /* direct */)
+	_, err := c.resumeShards()
+	require.ErrorContains(t, err, "partially imported")
+}
@@ -2,7 +2,10 @@ package fetch
 
 import (
 	"context"
+	"io"
 	"path"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cockroachdb/molt/dbconn"
@@ -11,22 +14,45 @@ import (
 	"github.com/cockroachdb/molt/fetch/fetchmetrics"
 	"github.com/cockroachdb/molt/fetch/internal/dataquery"
 	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/cockroachdb/molt/fileformat"
 	"github.com/cockroachdb/molt/moltlogger"
+	"github.com/cockroachdb/molt/retry"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
 )
 
 type CopyResult struct {
 	StartTime time.Time
 	EndTime   time.Time
+	// NumRows and NumFiles are the number of rows and files copied by every
+	// worker.
+	NumRows  int
+	NumFiles int
 }
 
+// Copy copies the files handed out by the queue into the table using COPY
+// FROM. Up to numWorkers files are copied concurrently, each worker using
+// its own connection.
+//
+// If atomic is set, each file is copied in a single transaction, and files
+// which fail to copy with transient errors, or which were interrupted such as
+// by a lost connection, are copied again with the retry settings. Otherwise, a file which failed part way may have left rows
+// behind, so it is not copied again.
+//
+// If a file fails to copy, no further files are taken but the files being
+// copied by other workers are left to finish. The continuation token then
+// records the first file which was not copied.
 func Copy(
 	ctx context.Context,
 	baseConn dbconn.Conn,
 	logger zerolog.Logger,
 	table dbtable.VerifiedTable,
-	resources []datablobstorage.Resource,
-	isLocal bool,
+	numWorkers int,
+	atomic bool,
+	retrySettings retry.Settings,
+	queue *resourceQueue,
+	checkpointer *exportCheckpointer,
 	isClearContinuationTokenMode bool,
 	exceptionLog *status.ExceptionLog,
 ) (CopyResult, error) {
@@ -41,57 +67,178 @@ func Copy(
 	}()
 
 	dataLogger := moltlogger.GetDataLogger(logger)
-	var ret CopyResult
+	ret := CopyResult{
+		StartTime: time.Now(),
+	}
+	var mu struct {
+		sync.Mutex
+		// failed is the files which failed to copy.
+		failed []queuedResource
+	}
+	// takeCtx is cancelled once a file fails to copy. Copies which are running
+	// are not cancelled, as COPY may not be atomic and leave part of the file
+	// copied.
+	takeCtx, stopTaking := context.WithCancel(ctx)
+	defer stopTaking()
 
-	rowsSoFar := 0
-	conn := baseConn.(*dbconn.PGConn).Conn
-	// Set the session variables required for COPY
-	if err := datablobstorage.SetCopyEnvVars(ctx, conn); err != nil {
-		return ret, err
+	if !atomic {
+		retrySettings = retry.Settings{}
+	}
+	setCopyEnvVars := func(ctx context.Context, conn *pgx.Conn) error {
+		return datablobstorage.SetCopyEnvVars(ctx, conn, atomic)
 	}
-	ret.StartTime = time.Now()
-	for i, resource := range resources {
-		key, err := resource.Key()
-		if err != nil {
-			return ret, err
-		}
 
-		dataLogger.Debug().
-			Int("idx", i+1).
-			Msgf("reading resource")
-		if err := func() error {
-			r, err := resource.Reader(ctx)
+	wg, _ := errgroup.WithContext(ctx)
+	for w := 0; w < max(numWorkers, 1); w++ {
+		wg.Go(func() error {
+			workerConn, err := baseConn.Clone(ctx)
 			if err != nil {
+				stopTaking()
+				return err
+			}
+			defer func() {
+				if err := workerConn.Close(ctx); err != nil {
+					logger.Err(err).Msg("failed to close connection for copy")
+				}
+			}()
+			// Set the session variables required for COPY
+			if err := setCopyEnvVars(ctx, workerConn.(*dbconn.PGConn).Conn); err != nil {
+				stopTaking()
 				return err
 			}
-			dataLogger.Debug().
-				Int("idx", i+1).
-				Msgf("running copy from resource")
-			if copyRet, err := conn.PgConn().CopyFrom(
-				ctx,
-				r,
-				dataquery.CopyFrom(table, isLocal /*skips header if local */),
-			); err != nil {
-				fileName := path.Base(key)
-				return status.MaybeReportException(ctx, logger, exceptionConn.(*dbconn.PGConn).Conn, table.Name, err, fileName, status.StageDataLoad, isClearContinuationTokenMode, exceptionLog)
-			} else {
-				rowsSoFar += int(copyRet.RowsAffected())
+			conn := newRetryingConn(logger, retrySettings, atomic, workerConn.(*dbconn.PGConn).Conn, setCopyEnvVars)
+			defer func() {
+				if err := conn.Close(ctx); err != nil {
+					logger.Err(err).Msg("failed to close connection for copy")
+				}
+			}()
+			for {
+				resource, ok, err := queue.take(takeCtx)
+				if err != nil {
+					if ctx.Err() == nil {
+						// Another file failed to copy.
+						return nil
+					}
+					return err
+				}
+				if !ok {
+					return nil
+				}
+				mu.Lock()
+				ret.NumFiles++
+				idx := ret.NumFiles
+				mu.Unlock()
+
+				var numRows int
+				err = conn.do(ctx, "copy of "+fileKey(resource), func(conn *pgx.Conn) error {
+					var err error
+					numRows, err = copyResource(ctx, dataLogger, conn, table, resource, idx)
+					return err
+				})
+				queue.release(resource)
+				if err != nil {
+					mu.Lock()
+					mu.failed = append(mu.failed, resource)
+					mu.Unlock()
+					stopTaking()
+					return err
+				}
+
+				mu.Lock()
+				ret.NumRows += numRows
 				dataLogger.Info().
-					Int("num_rows", rowsSoFar).
+					Int("num_rows", ret.NumRows).
 					Str("table", table.SafeString()).
-					Str("file", key).
+					Str("file", fileKey(resource)).
 					Msg("row copy status")
-				fetchmetrics.ImportedRows.WithLabelValues(table.SafeString()).Add(float64(copyRet.RowsAffected()))
+				mu.Unlock()
+				fetchmetrics.ImportedRows.WithLabelValues(table.SafeString()).Add(float64(numRows))
+				recordImported(ctx, checkpointer, []queuedResource{resource})
+			}
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		// Files are continued from in order, so the continuation token
+		// records the first file which failed or was never taken.
+		var fileName string
+		notCopied := mu.failed
+		if r, ok := queue.firstPending(); ok {
+			notCopied = append(notCopied, r)
+		}
+		for _, r := range notCopied {
+			if name := path.Base(fileKey(r)); fileName == "" || name < fileName {
+				fileName = name
 			}
-			return nil
-		}(); err != nil {
-			return ret, err
 		}
+		return ret, status.MaybeReportException(ctx, logger, exceptionConn.(*dbconn.PGConn).Conn, table.Name, err, fileName, status.StageDataLoad, isClearContinuationTokenMode, exceptionLog)
 	}
 
 	ret.EndTime = time.Now()
 	dataLogger.Info().
 		Dur("duration", ret.EndTime.Sub(ret.StartTime)).
+		Int("num_files", ret.NumFiles).
 		Msgf("table COPY complete")
 	return ret, nil
 }
+
+// copyResource copies a single file into the table, returning the number of
+// rows copied.
+func copyResource(
+	ctx context.Context,
+	dataLogger zerolog.Logger,
+	conn *pgx.Conn,
+	table dbtable.VerifiedTable,
+	resource queuedResource,
+	idx int,
+) (int, error) {
+	key, err := resource.Key()
+	if err != nil {
+		return 0, err
+	}
+
+	dataLogger.Debug().
+		Int("idx", idx).
+		Msgf("reading resource")
+	var r io.ReadCloser
+	skipHeader := resource.IsLocal()
+	if strings.HasSuffix(key, "."+fileformat.ParquetFileExt) {
+		// Parquet files cannot be read by COPY directly, so we convert them
+		// back to CSV. They are read at any offset rather than in order, so
+		// that they need not be held in memory. Parquet files never have a
+		// row count header.
+		ra, size, err := resource.ReaderAt(ctx)
+		if err != nil {
+			return 0, err
+		}
+		defer ra.Close()
+		if r, err = fileformat.NewParquetCSVReader(ra, size, table.Columns); err != nil {
+			return 0, err
+		}
+		skipHeader = false
+	} else if r, err = resource.Reader(ctx); err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	dataLogger.Debug().
+		Int("idx", idx).
+		Msgf("running copy from resource")
+	copyRet, err := conn.PgConn().CopyFrom(
+		ctx,
+		r,
+		dataquery.CopyFrom(table, skipHeader),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(copyRet.RowsAffected()), nil
+}
+
+// fileKey returns the key of the resource, or an empty string if it cannot
+// be determined.
+func fileKey(r datablobstorage.Resource) string {
+	key, err := r.Key()
+	if err != nil {
+		return ""
+	}
+	return key
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"io"
 
+	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/fetch/fetchmetrics"
 	"github.com/cockroachdb/molt/moltlogger"
@@ -11,10 +12,30 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// recordWriter writes records read from the export to an output file.
+type recordWriter interface {
+	Write(record []string) error
+	// Close flushes any buffered data. It does not close the underlying writer.
+	Close() error
+}
+
+type csvRecordWriter struct {
+	*csv.Writer
+}
+
+func (w csvRecordWriter) Close() error {
+	w.Flush()
+	return w.Error()
+}
+
+func newCSVRecordWriter(out io.Writer) (recordWriter, error) {
+	return csvRecordWriter{Writer: csv.NewWriter(out)}, nil
+}
+
 type csvPipe struct {
 	in io.Reader
 
-	csvWriter *csv.Writer
+	csvWriter recordWriter
 	out       io.WriteCloser
 	logger    zerolog.Logger
 
@@ -26,10 +47,25 @@ type csvPipe struct {
 	shardNum  int
 	numRowsCh chan int
 	newWriter func(numRowsCh chan int) (io.WriteCloser, error)
+	// newRecordWriter creates the writer used to format records into the
+	// output file. Defaults to writing CSV.
+	newRecordWriter func(out io.Writer) (recordWriter, error)
+	// flushedCh, if set, receives the primary key of the last record and the
+	// number of records in each output file when it is flushed. The primary
+	// key is made up of the first numKeyCols values of each record.
+	flushedCh  chan flushedFile
+	numKeyCols int
+	lastKey    []string
 
 	testingKnobs testutils.FetchTestingKnobs
 }
 
+// flushedFile describes an output file which has been flushed.
+type flushedFile struct {
+	lastKey []string
+	numRows int
+}
+
 func newCSVPipe(
 	in io.Reader,
 	logger zerolog.Logger,
@@ -46,6 +82,8 @@ func newCSVPipe(
 		shardNum:  shardNum,
 		numRowsCh: make(chan int, 1),
 		newWriter: newWriter,
+
+		newRecordWriter: newCSVRecordWriter,
 	}
 }
 
@@ -85,6 +123,9 @@ func (p *csvPipe) Pipe(tn dbtable.Name) error {
 		if err := p.csvWriter.Write(record); err != nil {
 			return err
 		}
+		if p.flushedCh != nil {
+			p.lastKey = append(p.lastKey[:0], record[:p.numKeyCols]...)
+		}
 
 		if p.testingKnobs.TriggerCorruptCSVFile {
 			if err := p.csvWriter.Write([]string{"this", "should", "lead", "to", "an", "error"}); err != nil {
@@ -108,7 +149,15 @@ func (p *csvPipe) Pipe(tn dbtable.Name) error {
 func (p *csvPipe) flush() error {
 	if p.csvWriter != nil {
 		p.numRowsCh <- p.currRows
-		p.csvWriter.Flush()
+		if p.flushedCh != nil {
+			p.flushedCh <- flushedFile{
+				lastKey: append([]string(nil), p.lastKey...),
+				numRows: p.currRows,
+			}
+		}
+		if err := p.csvWriter.Close(); err != nil {
+			return errors.CombineErrors(err, p.out.Close())
+		}
 		if err := p.out.Close(); err != nil {
 			return err
 		}
@@ -127,7 +176,10 @@ func (p *csvPipe) maybeInitWriter() error {
 			return err
 		}
 		p.out = out
-		p.csvWriter = csv.NewWriter(p.out)
+		p.csvWriter, err = p.newRecordWriter(p.out)
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -0,0 +1,330 @@
+package datablobstorage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/testutils"
+	"github.com/cockroachdb/molt/utils"
+	"github.com/rs/zerolog"
+)
+
+// numRowsKeyAzure is lower case as Azure metadata names are case-insensitive
+// and may be returned in lower case.
+const numRowsKeyAzure = "numrows"
+
+const (
+	// AzuriteAccountName and AzuriteAccountKey are the well known credentials
+	// of the Azurite emulator, used for local testing.
+	AzuriteAccountName = "devstoreaccount1"
+	AzuriteAccountKey  = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+	azuriteServiceURL  = "http://127.0.0.1:10000/" + AzuriteAccountName
+)
+
+// importSASExpiry is how long the SAS tokens generated for IMPORT INTO
+// against the Azurite emulator are valid for.
+const importSASExpiry = 24 * time.Hour
+
+// AzureCredentials are the credentials used to access an Azure storage
+// account. Either AccountKey or SASToken must be set.
+type AzureCredentials struct {
+	AccountName string
+	AccountKey  string
+	// SASToken is a shared access signature, without the leading "?".
+	SASToken string
+}
+
+// azureServiceURL returns the blob service URL for the given credentials.
+func azureServiceURL(creds AzureCredentials, useLocalInfra bool) string {
+	if useLocalInfra {
+		return azuriteServiceURL
+	}
+	return fmt.Sprintf("https://%s.blob.core.windows.net/", creds.AccountName)
+}
+
+// NewAzureClient creates an Azure blob client using the given credentials,
+// preferring the account key over the SAS token if both are set.
+func NewAzureClient(creds AzureCredentials, useLocalInfra bool) (*azblob.Client, error) {
+	serviceURL := azureServiceURL(creds, useLocalInfra)
+	if creds.AccountKey != "" {
+		cred, err := azblob.NewSharedKeyCredential(creds.AccountName, creds.AccountKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create azure shared key credential")
+		}
+		return azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
+	}
+	if creds.SASToken != "" {
+		return azblob.NewClientWithNoCredential(serviceURL+"?"+creds.SASToken, nil)
+	}
+	return nil, errors.Newf("either an azure account key or SAS token must be set")
+}
+
+type azureStore struct {
+	logger        zerolog.Logger
+	container     string
+	bucketPath    string
+	client        *azblob.Client
+	creds         AzureCredentials
+	useLocalInfra bool
+}
+
+func NewAzureStore(
+	logger zerolog.Logger,
+	client *azblob.Client,
+	creds AzureCredentials,
+	container string,
+	bucketPath string,
+	useLocalInfra bool,
+) *azureStore {
+	utils.RedactedQueryParams = map[string]struct{}{utils.AzureAccountKey: {}, utils.AzureSASSignature: {}}
+	return &azureStore{
+		logger:        logger,
+		container:     container,
+		bucketPath:    bucketPath,
+		client:        client,
+		creds:         creds,
+		useLocalInfra: useLocalInfra,
+	}
+}
+
+func (s *azureStore) CreateFromReader(
+	ctx context.Context,
+	r io.Reader,
+	table dbtable.VerifiedTable,
+	iteration int,
+	fileExt string,
+	numRows chan int,
+	testingKnobs testutils.FetchTestingKnobs,
+	shardNum int,
+) (Resource, error) {
+	key := fmt.Sprintf("%s/shard_%02d_part_%08d.%s", table.SafeString(), shardNum, iteration, fileExt)
+	if s.bucketPath != "" {
+		key = fmt.Sprintf("%s/%s/shard_%02d_part_%08d.%s", s.bucketPath, table.SafeString(), shardNum, iteration, fileExt)
+	}
+
+	s.logger.Debug().Str("file", key).Msgf("creating new file")
+
+	if testingKnobs.FailedWriteToBucket.FailedAfterReadFromPipe {
+		return nil, errors.New(AzureUploadMockErrMsg)
+	}
+
+	rows := <-numRows
+	numRowStr := fmt.Sprintf("%d", rows)
+
+	// The metadata is set as part of the upload, as the number of rows
+	// is sent before the file contents are flushed.
+	if _, err := s.client.UploadStream(ctx, s.container, key, r, &azblob.UploadStreamOptions{
+		Metadata: map[string]*string{numRowsKeyAzure: &numRowStr},
+	}); err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug().Str("file", key).Int("rows", rows).Msgf("azure file creation complete")
+	return &azureResource{
+		store: s,
+		key:   key,
+		rows:  rows,
+	}, nil
+}
+
+func (s *azureStore) ListFromContinuationPoint(
+	ctx context.Context, table dbtable.VerifiedTable, fileName string,
+) ([]Resource, error) {
+	key, prefix := getKeyAndPrefix(fileName, s.bucketPath, table)
+	return listFromContinuationPointAzure(ctx, s.client, key, prefix, s)
+}
+
+func listFromContinuationPointAzure(
+	ctx context.Context, client *azblob.Client, key, prefix string, azureStore *azureStore,
+) ([]Resource, error) {
+	// Azure has no parameter to start listing from a given key, so we list
+	// everything under the prefix and filter.
+	pager := client.NewListBlobsFlatPager(azureStore.container, &azblob.ListBlobsFlatOptions{
+		Prefix:  &prefix,
+		Include: azblob.ListBlobsInclude{Metadata: true},
+	})
+
+	resources := []Resource{}
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil || !isAtOrAfterFile(*item.Name, key) || !utils.MatchesFileConvention(*item.Name) {
+				continue
+			}
+			numRows := 0
+			mdNumRows, ok := lookupAzureMetadata(item.Metadata, numRowsKeyAzure)
+			if !ok {
+				azureStore.logger.Error().Msgf("failed to find metadata for key %s", numRowsKeyAzure)
+			} else {
+				numRows, err = strconv.Atoi(mdNumRows)
+				if err != nil {
+					azureStore.logger.Err(err).Msgf("failed to convert %s to integer", mdNumRows)
+				}
+			}
+
+			// Continue even if the integer conversion or metadata get fails because
+			// file is likely still fine, but metadata was not updated properly.
+			// Log to let user know.
+			resources = append(resources, &azureResource{
+				store: azureStore,
+				key:   *item.Name,
+				rows:  numRows,
+			})
+		}
+	}
+	return resources, nil
+}
+
+func lookupAzureMetadata(md map[string]*string, key string) (string, bool) {
+	for k, v := range md {
+		if strings.EqualFold(k, key) && v != nil {
+			return *v, true
+		}
+	}
+	return "", false
+}
+
+func (s *azureStore) CanBeTarget() bool {
+	return true
+}
+
+func (s *azureStore) DefaultFlushBatchSize() int {
+	return 256 * 1024 * 1024
+}
+
+func (s *azureStore) Cleanup(ctx context.Context) error {
+	// Virtual directories are removed when the final blob is deleted.
+	return nil
+}
+
+func (s *azureStore) TelemetryName() string {
+	return "azure"
+}
+
+type azureResource struct {
+	store *azureStore
+	key   string
+	rows  int
+}
+
+func (r *azureResource) ImportURL() (string, error) {
+	creds := r.store.creds
+	switch {
+	case r.store.useLocalInfra:
+		// CockroachDB cannot point azure-blob:// URLs at the emulator, so
+		// instead IMPORT from a SAS URL served over http.
+		host := "127.0.0.1"
+		if runtime.GOOS == "darwin" {
+			host = "host.docker.internal"
+		}
+		cred, err := azblob.NewSharedKeyCredential(creds.AccountName, creds.AccountKey)
+		if err != nil {
+			return "", err
+		}
+		qp, err := sas.BlobSignatureValues{
+			Protocol:      sas.ProtocolHTTPSandHTTP,
+			ExpiryTime:    time.Now().UTC().Add(importSASExpiry),
+			Permissions:   (&sas.BlobPermissions{Read: true}).String(),
+			ContainerName: r.store.container,
+			BlobName:      r.key,
+		}.SignWithSharedKey(cred)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to sign SAS token")
+		}
+		return fmt.Sprintf(
+			"http://%s:10000/%s/%s/%s?%s",
+			host,
+			creds.AccountName,
+			r.store.container,
+			r.key,
+			qp.Encode(),
+		), nil
+	case creds.AccountKey != "":
+		return fmt.Sprintf(
+			"azure-blob://%s/%s?AZURE_ACCOUNT_NAME=%s&%s=%s",
+			r.store.container,
+			r.key,
+			url.QueryEscape(creds.AccountName),
+			utils.AzureAccountKey,
+			url.QueryEscape(creds.AccountKey),
+		), nil
+	case creds.SASToken != "":
+		// IMPORT INTO has no parameter for SAS tokens, but can read the
+		// blob over https with the SAS token as the query string.
+		return fmt.Sprintf(
+			"https://%s.blob.core.windows.net/%s/%s?%s",
+			creds.AccountName,
+			r.store.container,
+			r.key,
+			creds.SASToken,
+		), nil
+	}
+	return "", errors.AssertionFailedf("either an azure account key or SAS token must be set")
+}
+
+func (r *azureResource) Key() (string, error) {
+	return r.key, nil
+}
+
+func (r *azureResource) Rows() int {
+	return r.rows
+}
+
+func (r *azureResource) Reader(ctx context.Context) (io.ReadCloser, error) {
+	resp, err := r.store.client.DownloadStream(ctx, r.store.container, r.key, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (r *azureResource) ReaderAt(ctx context.Context) (ReadAtCloser, int64, error) {
+	props, err := r.store.client.ServiceClient().
+		NewContainerClient(r.store.container).
+		NewBlobClient(r.key).
+		GetProperties(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if props.ContentLength == nil {
+		return nil, 0, errors.Newf("size of blob %s is unknown", r.key)
+	}
+	size := *props.ContentLength
+	return &rangeReader{
+		ctx:  ctx,
+		size: size,
+		readRange: func(ctx context.Context, off, n int64) (io.ReadCloser, error) {
+			resp, err := r.store.client.DownloadStream(ctx, r.store.container, r.key, &azblob.DownloadStreamOptions{
+				Range: azblob.HTTPRange{Offset: off, Count: n},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return resp.Body, nil
+		},
+	}, size, nil
+}
+
+func (r *azureResource) MarkForCleanup(ctx context.Context) error {
+	_, err := r.store.client.DeleteBlob(ctx, r.store.container, r.key, nil)
+	return err
+}
+
+func (r *azureResource) IsLocal() bool {
+	return false
+}
+
+const AzureUploadMockErrMsg = "mocked error for uploading file for azure"
@@ -0,0 +1,88 @@
+package datablobstorage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/testutils"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListFromContinuationPointAzure(t *testing.T) {
+	ctx := context.Background()
+	var sb strings.Builder
+	creds := AzureCredentials{AccountName: AzuriteAccountName, AccountKey: AzuriteAccountKey}
+	azureClient, err := NewAzureClient(creds, true /* useLocalInfra */)
+	require.NoError(t, err)
+
+	azureStore := azureStore{
+		container: "fetch-test-shards",
+		logger:    zerolog.New(&sb),
+		client:    azureClient,
+		creds:     creds,
+	}
+
+	// Create the test container.
+	_, err = azureClient.CreateContainer(ctx, azureStore.container, nil)
+	require.NoError(t, err)
+	defer func() {
+		_, err := azureClient.DeleteContainer(ctx, azureStore.container, nil)
+		require.NoError(t, err)
+	}()
+
+	// Seed the initial data with 8 files for each of the 4 shards.
+	for i := 1; i <= 4; i++ {
+		for j := 1; j <= 8; j++ {
+			numRows := make(chan int, 1)
+			numRows <- j
+			_, err := azureStore.CreateFromReader(
+				ctx,
+				bytes.NewReader([]byte("abcde")),
+				dbtable.VerifiedTable{Name: dbtable.Name{Schema: "public", Table: "inventory"}},
+				j,
+				"tar.gz",
+				numRows,
+				testutils.FetchTestingKnobs{},
+				i,
+			)
+			require.NoError(t, err)
+		}
+	}
+
+	// List from shard 2 file 4 which should result in files from shard 2 4-8, shard 3 1-8, and shard 4 1-8
+	// 5,8,8 files respetively from each shard
+	resources, err := listFromContinuationPointAzure(ctx, azureClient, "public.inventory/shard_02_part_00000004.tar.gz", "public.inventory", &azureStore)
+	require.NoError(t, err)
+	require.Equal(t, 21, len(resources))
+	require.Equal(t, 4, resources[0].Rows())
+
+	// Check the ordering of the returned slice is correct.
+	// Lexicographically each successive file should be greater
+	// that the previous one
+	require.True(t, sort.SliceIsSorted(resources, func(i, j int) bool {
+		l, _ := resources[i].Key()
+		r, _ := resources[j].Key()
+		return l < r
+	}))
+
+	// Ensure the files can be read back and cleaned up.
+	for _, resource := range resources {
+		r, err := resource.Reader(ctx)
+		require.NoError(t, err)
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, "abcde", string(b))
+		require.NoError(t, resource.MarkForCleanup(ctx))
+	}
+	resources, err = listFromContinuationPointAzure(ctx, azureClient, fmt.Sprintf("public.inventory/shard_%02d_part_%08d.tar.gz", 1, 1), "public.inventory", &azureStore)
+	require.NoError(t, err)
+	require.Equal(t, 11, len(resources))
+}
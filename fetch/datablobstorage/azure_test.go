@@ -0,0 +1,71 @@
+package datablobstorage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureResource_ImportURL(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		r        *azureResource
+		expected string
+	}{
+		{
+			desc: "account key",
+			r: &azureResource{
+				key: "asdf/ghjk.csv",
+				store: &azureStore{
+					container: "nangs",
+					creds: AzureCredentials{
+						AccountName: "acct",
+						AccountKey:  "a+b/c==",
+					},
+				},
+			},
+			expected: "azure-blob://nangs/asdf/ghjk.csv?AZURE_ACCOUNT_NAME=acct&AZURE_ACCOUNT_KEY=a%2Bb%2Fc%3D%3D",
+		},
+		{
+			desc: "sas token",
+			r: &azureResource{
+				key: "asdf/ghjk.csv",
+				store: &azureStore{
+					container: "nangs",
+					creds: AzureCredentials{
+						AccountName: "acct",
+						SASToken:    "sv=2022-11-02&sp=r&sig=abc%3D",
+					},
+				},
+			},
+			expected: "https://acct.blob.core.windows.net/nangs/asdf/ghjk.csv?sv=2022-11-02&sp=r&sig=abc%3D",
+		},
+		{
+			desc: "account key preferred over sas token",
+			r: &azureResource{
+				key: "asdf/ghjk.csv",
+				store: &azureStore{
+					container: "nangs",
+					creds: AzureCredentials{
+						AccountName: "acct",
+						AccountKey:  "key",
+						SASToken:    "sv=2022-11-02&sp=r&sig=abc%3D",
+					},
+				},
+			},
+			expected: "azure-blob://nangs/asdf/ghjk.csv?AZURE_ACCOUNT_NAME=acct&AZURE_ACCOUNT_KEY=key",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			u, err := tc.r.ImportURL()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, u)
+		})
+	}
+
+	t.Run("no credentials", func(t *testing.T) {
+		r := &azureResource{key: "asdf/ghjk.csv", store: &azureStore{container: "nangs"}}
+		_, err := r.ImportURL()
+		require.Error(t, err)
+	})
+}
@@ -1,12 +1,14 @@
 package datablobstorage
 
 import (
+	"bytes"
 	"context"
 	"io"
 
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/fetch/internal/dataquery"
+	"github.com/cockroachdb/molt/retry"
 	"github.com/cockroachdb/molt/testutils"
 	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
@@ -18,6 +20,9 @@ import (
 type copyCRDBDirect struct {
 	logger zerolog.Logger
 	target *pgx.Conn
+	// retrySettings are used to retry the batches which fail to copy with
+	// transient errors. The zero settings disable retries.
+	retrySettings retry.Settings
 }
 
 const DirectCopyWriterMockErrMsg = "forced error for direct copy"
@@ -37,29 +42,69 @@ func (c *copyCRDBDirect) CreateFromReader(
 		<-numRows
 	}()
 
-	conn, err := pgx.ConnectConfig(ctx, c.target.Config())
-	if err != nil {
-		return nil, err
-	}
-
-	// Set the session variables required for COPY
-	if err := SetCopyEnvVars(ctx, conn); err != nil {
-		return nil, err
-	}
-
 	if testingKnobs.FailedWriteToBucket.FailedBeforeReadFromPipe {
 		return nil, errors.New(DirectCopyWriterMockErrMsg)
 	}
 
 	c.logger.Debug().Int("batch", iteration).Msgf("csv batch starting")
-	if _, err := conn.PgConn().CopyFrom(ctx, r, dataquery.CopyFrom(table, false /*skipHeader*/)); err != nil {
-		return nil, errors.CombineErrors(err, conn.Close(ctx))
+	if err := c.copy(ctx, table, iteration, r); err != nil {
+		return nil, err
 	}
 	if testingKnobs.FailedWriteToBucket.FailedAfterReadFromPipe {
 		return nil, errors.New(DirectCopyWriterMockErrMsg)
 	}
 	c.logger.Debug().Int("batch", iteration).Msgf("csv batch complete")
-	return nil, conn.Close(ctx)
+	return nil, nil
+}
+
+// copyBatch copies the batch into the table on a new connection.
+func (c *copyCRDBDirect) copyBatch(
+	ctx context.Context, table dbtable.VerifiedTable, r io.Reader,
+) error {
+	conn, err := pgx.ConnectConfig(ctx, c.target.Config())
+	if err != nil {
+		return err
+	}
+	// Each batch is copied atomically, as the export checkpoints of a shard
+	// assume that a batch which failed to copy left no rows behind. This
+	// also allows the batch to be copied again.
+	if err := SetCopyEnvVars(ctx, conn, true /* atomic */); err != nil {
+		return errors.CombineErrors(err, conn.Close(ctx))
+	}
+	if _, err := conn.PgConn().CopyFrom(ctx, r, dataquery.CopyFrom(table, false /*skipHeader*/)); err != nil {
+		return errors.CombineErrors(err, conn.Close(ctx))
+	}
+	return conn.Close(ctx)
+}
+
+// copy copies the batch read from r into the table, retrying it while it
+// fails with transient errors if retries are enabled. As batches are copied
+// atomically, batches which were interrupted, such as by a node shutting
+// down, are retried too.
+func (c *copyCRDBDirect) copy(
+	ctx context.Context, table dbtable.VerifiedTable, iteration int, r io.Reader,
+) error {
+	if c.retrySettings == (retry.Settings{}) {
+		return c.copyBatch(ctx, table, r)
+	}
+	// The batch is read from the pipe once, so it is buffered to be copied
+	// again if the copy is retried. Batches are bounded by the flush size.
+	var batch bytes.Buffer
+	if _, err := io.Copy(&batch, r); err != nil {
+		return err
+	}
+	rt, err := retry.NewRetry(c.retrySettings)
+	if err != nil {
+		return err
+	}
+	return rt.DoAtomic(ctx, func() error {
+		return c.copyBatch(ctx, table, bytes.NewReader(batch.Bytes()))
+	}, func(err error) {
+		c.logger.Warn().Err(err).
+			Int("batch", iteration).
+			Int("attempt", rt.Iteration).
+			Msgf("retrying csv batch after transient error")
+	})
 }
 
 func (c *copyCRDBDirect) ListFromContinuationPoint(
@@ -84,9 +129,12 @@ func (c *copyCRDBDirect) TelemetryName() string {
 	return "copy_direct"
 }
 
-func NewCopyCRDBDirect(logger zerolog.Logger, target *pgx.Conn) *copyCRDBDirect {
+func NewCopyCRDBDirect(
+	logger zerolog.Logger, target *pgx.Conn, retrySettings retry.Settings,
+) *copyCRDBDirect {
 	return &copyCRDBDirect{
-		logger: logger,
-		target: target,
+		logger:        logger,
+		target:        target,
+		retrySettings: retrySettings,
 	}
 }
@@ -4,13 +4,20 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/retry"
 	"github.com/cockroachdb/molt/testutils"
 	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
@@ -43,6 +50,9 @@ type Resource interface {
 	ImportURL() (string, error)
 	MarkForCleanup(ctx context.Context) error
 	Reader(ctx context.Context) (io.ReadCloser, error)
+	// ReaderAt returns a reader of the resource at any offset, along with
+	// the size of the resource.
+	ReaderAt(ctx context.Context) (ReadAtCloser, int64, error)
 	IsLocal() bool
 }
 
@@ -61,8 +71,47 @@ func getKeyAndPrefix(fileName, bucketPath string, table dbtable.VerifiedTable) (
 	return key, prefix
 }
 
+var shardFileRegex = regexp.MustCompile(`^shard_(\d+)_part_(\d+)\.`)
+
+// ParseShardFileName returns the shard and file number of a file written by
+// the export of a shard, given its name or key. It returns false if the file
+// was not named by an export.
+func ParseShardFileName(key string) (shardNum int, fileNum int, ok bool) {
+	m := shardFileRegex.FindStringSubmatch(path.Base(key))
+	if m == nil {
+		return 0, 0, false
+	}
+	var err error
+	if shardNum, err = strconv.Atoi(m[1]); err != nil {
+		return 0, 0, false
+	}
+	if fileNum, err = strconv.Atoi(m[2]); err != nil {
+		return 0, 0, false
+	}
+	return shardNum, fileNum, true
+}
+
+// isAtOrAfterFile returns whether the file with the given key is to be
+// imported when continuing from the file with continuationKey. Shard numbers
+// are only padded to two digits, so files named by an export are compared by
+// their shard and file numbers rather than by name.
+func isAtOrAfterFile(key, continuationKey string) bool {
+	shardNum, fileNum, ok := ParseShardFileName(key)
+	contShardNum, contFileNum, contOK := ParseShardFileName(continuationKey)
+	if !ok || !contOK {
+		return key >= continuationKey
+	}
+	if shardNum != contShardNum {
+		return shardNum > contShardNum
+	}
+	return fileNum >= contFileNum
+}
+
 type DirectCopyPayload struct {
 	TargetConnForCopy *pgx.Conn
+	// RetrySettings are used to retry the batches which fail to copy with
+	// transient errors.
+	RetrySettings retry.Settings
 }
 
 type LocalPathPayload struct {
@@ -82,10 +131,23 @@ type S3Payload struct {
 	Region     string
 }
 
+type AzurePayload struct {
+	AzureContainer string
+	BucketPath     string
+}
+
+// Environment variables used to authenticate with Azure blob storage.
+const (
+	AzureStorageAccountEnv  = "AZURE_STORAGE_ACCOUNT"
+	AzureStorageKeyEnv      = "AZURE_STORAGE_KEY"
+	AzureStorageSASTokenEnv = "AZURE_STORAGE_SAS_TOKEN"
+)
+
 type DatastoreCreationPayload struct {
 	DirectCopyPl *DirectCopyPayload
 	GCPPl        *GCPPayload
 	S3Pl         *S3Payload
+	AzurePl      *AzurePayload
 	LocalPathPl  *LocalPathPayload
 
 	TestFailedWriteToBucket bool
@@ -99,7 +161,7 @@ func GenerateDatastore(
 
 	switch t := cfg.(type) {
 	case *DirectCopyPayload:
-		src = NewCopyCRDBDirect(logger, t.TargetConnForCopy)
+		src = NewCopyCRDBDirect(logger, t.TargetConnForCopy, t.RetrySettings)
 	case *GCPPayload:
 		var creds *google.Credentials
 		var err error
@@ -148,23 +210,50 @@ func GenerateDatastore(
 			}
 		}
 		src = NewS3Store(logger, sess, creds, t.S3Bucket, t.BucketPath, testOnly)
+	case *AzurePayload:
+		creds := AzureCredentials{
+			AccountName: os.Getenv(AzureStorageAccountEnv),
+			AccountKey:  os.Getenv(AzureStorageKeyEnv),
+			SASToken:    strings.TrimPrefix(os.Getenv(AzureStorageSASTokenEnv), "?"),
+		}
+		if testOnly {
+			creds = AzureCredentials{
+				AccountName: AzuriteAccountName,
+				AccountKey:  AzuriteAccountKey,
+			}
+		} else if creds.AccountName == "" {
+			return nil, errors.Newf("%s must be set to use azure blob storage", AzureStorageAccountEnv)
+		} else if creds.AccountKey == "" && creds.SASToken == "" {
+			return nil, errors.Newf("one of %s or %s must be set to use azure blob storage", AzureStorageKeyEnv, AzureStorageSASTokenEnv)
+		}
+		var azureClient *azblob.Client
+		// For this test, we don't need the real credentials or client.
+		if !testFailedWriteToBucket {
+			if azureClient, err = NewAzureClient(creds, testOnly); err != nil {
+				return nil, errors.Wrapf(err, "failed to make new azure client")
+			}
+		}
+		src = NewAzureStore(logger, azureClient, creds, t.AzureContainer, t.BucketPath, testOnly)
 	case *LocalPathPayload:
 		src, err = NewLocalStore(logger, t.LocalPath, t.LocalPathListenAddr, t.LocalPathCRDBAccessAddr)
 		if err != nil {
 			return nil, err
 		}
 	default:
-		return nil, errors.AssertionFailedf("data source must be configured (--s3-bucket, --gcp-bucket, --direct-copy)")
+		return nil, errors.AssertionFailedf("data source must be configured (--bucket-path, --local-path, --direct-copy)")
 	}
 
 	return src, err
 }
 
-func SetCopyEnvVars(ctx context.Context, conn *pgx.Conn) error {
+// SetCopyEnvVars sets the session variables required for COPY. An atomic
+// COPY either copies every row or none, so that it can be run again if it
+// failed.
+func SetCopyEnvVars(ctx context.Context, conn *pgx.Conn, atomic bool) error {
 	if _, err := conn.Exec(ctx, "SET copy_from_retries_enabled = true"); err != nil {
 		return err
 	}
-	if _, err := conn.Exec(ctx, "SET copy_from_atomic_enabled = false"); err != nil {
+	if _, err := conn.Exec(ctx, fmt.Sprintf("SET copy_from_atomic_enabled = %t", atomic)); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,40 @@
+package datablobstorage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShardFileName(t *testing.T) {
+	shardNum, fileNum, ok := ParseShardFileName("bucket/public.tbl/shard_100_part_00000004.csv.tar.gz")
+	require.True(t, ok)
+	require.Equal(t, 100, shardNum)
+	require.Equal(t, 4, fileNum)
+
+	_, _, ok = ParseShardFileName("bucket/public.tbl/part_00000004.csv")
+	require.False(t, ok)
+}
+
+func TestIsAtOrAfterFile(t *testing.T) {
+	for _, tc := range []struct {
+		key      string
+		expected bool
+	}{
+		{key: "public.tbl/shard_02_part_00000004.csv", expected: true},
+		{key: "public.tbl/shard_02_part_00000005.csv", expected: true},
+		{key: "public.tbl/shard_02_part_00000003.csv", expected: false},
+		{key: "public.tbl/shard_01_part_00000009.csv", expected: false},
+		{key: "public.tbl/shard_11_part_00000001.csv", expected: true},
+		// Shard numbers over 99 sort before the continuation key by name.
+		{key: "public.tbl/shard_100_part_00000001.csv", expected: true},
+	} {
+		t.Run(tc.key, func(t *testing.T) {
+			require.Equal(t, tc.expected, isAtOrAfterFile(tc.key, "public.tbl/shard_02_part_00000004.csv"))
+		})
+	}
+
+	t.Run("from the beginning", func(t *testing.T) {
+		require.True(t, isAtOrAfterFile("public.tbl/shard_01_part_00000001.csv", "public.tbl/"))
+	})
+}
@@ -135,13 +135,11 @@ func (s *gcpStore) ListFromContinuationPoint(
 func listFromContinuationPointGCP(
 	ctx context.Context, client *storage.Client, key, prefix, bucket string, gcpStore *gcpStore,
 ) ([]Resource, error) {
+	// Objects are listed in lexicographical order, which is not the order
+	// of the shards once there are more than 99, so we list everything under
+	// the prefix and filter rather than starting the listing at key.
 	it := client.Bucket(bucket).Objects(ctx, &storage.Query{
 		Prefix: prefix,
-		// The StartOffeset parameter is similar to the StartAfter flag
-		// for S3 except that it is inclusive of the key so
-		// we don't need to do any extra filtering of the
-		// results.
-		StartOffset: key,
 	})
 
 	resources := []Resource{}
@@ -153,7 +151,7 @@ func listFromContinuationPointGCP(
 			}
 			return nil, err
 		} else {
-			if utils.MatchesFileConvention(attrs.Name) {
+			if isAtOrAfterFile(attrs.Name, key) && utils.MatchesFileConvention(attrs.Name) {
 				numRows := 0
 				mdNumRows, ok := attrs.Metadata[numRowsKeyGCP]
 				if !ok {
@@ -246,6 +244,21 @@ func (r *gcpResource) Reader(ctx context.Context) (io.ReadCloser, error) {
 	return r.store.client.Bucket(r.store.bucket).Object(r.key).NewReader(ctx)
 }
 
+func (r *gcpResource) ReaderAt(ctx context.Context) (ReadAtCloser, int64, error) {
+	obj := r.store.client.Bucket(r.store.bucket).Object(r.key)
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &rangeReader{
+		ctx:  ctx,
+		size: attrs.Size,
+		readRange: func(ctx context.Context, off, n int64) (io.ReadCloser, error) {
+			return obj.NewRangeReader(ctx, off, n)
+		},
+	}, attrs.Size, nil
+}
+
 func (r *gcpResource) MarkForCleanup(ctx context.Context) error {
 	return r.store.client.Bucket(r.store.bucket).Object(r.key).Delete(ctx)
 }
@@ -16,6 +16,7 @@ import (
 
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fileformat"
 	"github.com/cockroachdb/molt/testutils"
 	"github.com/cockroachdb/molt/utils"
 	"github.com/rs/zerolog"
@@ -117,7 +118,11 @@ func (l *localStore) CreateFromReader(
 	buf := make([]byte, 1024*1024)
 	rows := <-numRows
 
-	if fileExt == "tar.gz" {
+	switch fileExt {
+	case fileformat.ParquetFileExt:
+		// Parquet files record the number of rows in their footer, so they
+		// do not need a header.
+	case "tar.gz":
 		// Need to create a GZIP writer so we can write GZIP data
 		// to the file for the header.
 		gf := gzip.NewWriter(f)
@@ -131,7 +136,7 @@ func (l *localStore) CreateFromReader(
 		if err := gf.Close(); err != nil {
 			return nil, err
 		}
-	} else {
+	default:
 		if _, err := f.WriteString(fmt.Sprintf("%d\n", rows)); err != nil {
 			return nil, err
 		}
@@ -167,8 +172,20 @@ func (l *localStore) ListFromContinuationPoint(
 
 	resources := []Resource{}
 	for _, f := range files {
-		if f.Name() >= fileName && utils.MatchesFileConvention(f.Name()) {
+		if isAtOrAfterFile(f.Name(), fileName) && utils.MatchesFileConvention(f.Name()) {
 			p := path.Join(baseDir, f.Name())
+			if filepath.Ext(p) == "."+fileformat.ParquetFileExt {
+				numRows, err := readParquetNumRows(p)
+				if err != nil {
+					l.logger.Err(err).Msg("failed to detect number of rows")
+				}
+				resources = append(resources, &localResource{
+					path:  p,
+					store: l,
+					rows:  int(numRows),
+				})
+				continue
+			}
 			numRows, err := readFirstLine(p)
 			if err != nil {
 				l.logger.Err(err).Msg("failed to detect number of rows")
@@ -190,6 +207,19 @@ func (l *localStore) ListFromContinuationPoint(
 	return resources, nil
 }
 
+func readParquetNumRows(filePath string) (int64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return fileformat.ParquetNumRows(f, stat.Size())
+}
+
 func readFirstLine(filePath string) (string, error) {
 	var reader io.Reader
 	f, err := os.Open(filePath)
@@ -255,6 +285,18 @@ func (l *localResource) Reader(ctx context.Context) (io.ReadCloser, error) {
 	return os.Open(l.path)
 }
 
+func (l *localResource) ReaderAt(ctx context.Context) (ReadAtCloser, int64, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, 0, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		return nil, 0, errors.CombineErrors(err, f.Close())
+	}
+	return f, st.Size(), nil
+}
+
 func (l *localResource) ImportURL() (string, error) {
 	if l.store.crdbAccessAddr == "" {
 		return "", errors.AssertionFailedf("cannot IMPORT from a local path unless file server is set")
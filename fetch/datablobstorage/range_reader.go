@@ -0,0 +1,49 @@
+package datablobstorage
+
+import (
+	"context"
+	"io"
+)
+
+// ReadAtCloser reads a resource at any offset. Parquet files are read this
+// way, as their metadata is at the end of the file, so that they need not be
+// held in memory.
+type ReadAtCloser interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// rangeReader reads a remote object of a known size by requesting the
+// ranges of bytes which are read.
+type rangeReader struct {
+	ctx  context.Context
+	size int64
+	// readRange returns the n bytes of the object starting at off.
+	readRange func(ctx context.Context, off, n int64) (io.ReadCloser, error)
+}
+
+var _ ReadAtCloser = (*rangeReader)(nil)
+
+func (r *rangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	n := min(int64(len(p)), r.size-off)
+	body, err := r.readRange(r.ctx, off, n)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	read, err := io.ReadFull(body, p[:n])
+	if err != nil {
+		return read, err
+	}
+	if read < len(p) {
+		return read, io.EOF
+	}
+	return read, nil
+}
+
+func (r *rangeReader) Close() error {
+	return nil
+}
@@ -106,6 +106,33 @@ func (s *s3Resource) Reader(ctx context.Context) (io.ReadCloser, error) {
 	return s3Reader{Reader: bytes.NewReader(b.Bytes())}, nil
 }
 
+func (s *s3Resource) ReaderAt(ctx context.Context) (ReadAtCloser, int64, error) {
+	client := s3.New(s.store.session)
+	head, err := client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Key:    aws.String(s.key),
+		Bucket: aws.String(s.store.bucket),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	size := aws.Int64Value(head.ContentLength)
+	return &rangeReader{
+		ctx:  ctx,
+		size: size,
+		readRange: func(ctx context.Context, off, n int64) (io.ReadCloser, error) {
+			out, err := client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+				Key:    aws.String(s.key),
+				Bucket: aws.String(s.store.bucket),
+				Range:  aws.String(fmt.Sprintf("bytes=%d-%d", off, off+n-1)),
+			})
+			if err != nil {
+				return nil, err
+			}
+			return out.Body, nil
+		},
+	}, size, nil
+}
+
 type s3Reader struct {
 	*bytes.Reader
 }
@@ -224,12 +251,11 @@ func listFromContinuationPointAWS(
 	for i, obj := range contents {
 		curI := i
 		curObj := obj
-		// Find the key we want to start at. Because we name the files
-		// in a specific pattern, we can guarantee lexicographical ordering
-		// based on the guarantee of return order from the S3 API.
-		// eg. If key = fetch/public.inventory/part_00000004.tar.gz,
-		// fetch/public.inventory/part_00000005.tar.gz is >= to key meaning,
-		// it is a file we need to include.
+		// Find the files at or after the key we want to start at.
+		// eg. If key = fetch/public.inventory/shard_01_part_00000004.tar.gz,
+		// fetch/public.inventory/shard_01_part_00000005.tar.gz and
+		// fetch/public.inventory/shard_100_part_00000001.tar.gz are files we
+		// need to include.
 		g.Go(func() error {
 			objResp, err := s3Client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
 				Bucket: aws.String(s3Store.bucket),
@@ -253,7 +279,7 @@ func listFromContinuationPointAWS(
 			// Continue even if the integer conversion or metadata get fails because
 			// file is likely still fine, but metadata was not updated properly.
 			// Log to let user know.
-			if aws.StringValue(curObj.Key) >= key && utils.MatchesFileConvention(aws.StringValue(curObj.Key)) {
+			if isAtOrAfterFile(aws.StringValue(curObj.Key), key) && utils.MatchesFileConvention(aws.StringValue(curObj.Key)) {
 				resources[curI] = &s3Resource{
 					key:     aws.StringValue(curObj.Key),
 					session: s3Store.session,
@@ -39,6 +39,17 @@ func (c *crdbSource) Conn(ctx context.Context) (SourceConn, error) {
 	return &crdbSourceConn{conn: conn, src: c}, nil
 }
 
+func (c *crdbSource) SnapshotConn(
+	ctx context.Context, shard rowverify.TableShard,
+) (dbconn.Conn, rowverify.TableShard, error) {
+	conn, err := c.conn.Clone(ctx)
+	if err != nil {
+		return nil, shard, err
+	}
+	shard.SourceAOST = &c.aost
+	return conn, shard, nil
+}
+
 func (c *crdbSource) Close(ctx context.Context) error {
 	return nil
 }
@@ -51,16 +62,13 @@ type crdbSourceConn struct {
 func (c *crdbSourceConn) Export(
 	ctx context.Context, writer io.Writer, table dbtable.VerifiedTable, shard rowverify.TableShard,
 ) error {
-	return scanWithRowIterator(ctx, c.src.settings, c.conn, writer, rowiterator.ScanTable{
-		Table: rowiterator.Table{
-			Name:              table.Name,
-			ColumnNames:       table.Columns,
-			ColumnOIDs:        table.ColumnOIDs[0],
-			PrimaryKeyColumns: table.PrimaryKeyColumns,
-		},
-		AOST:        &c.src.aost,
-		StartPKVals: shard.StartPKVals,
-		EndPKVals:   shard.EndPKVals,
+	return scanWithRowIterator(ctx, c.src.settings.rowBatchSize(shard), c.conn, writer, table, rowiterator.ScanTable{
+		Table:        exportTable(table, ""),
+		AOST:         &c.src.aost,
+		StartPKVals:  shard.StartPKVals,
+		EndPKVals:    shard.EndPKVals,
+		CursorPKVals: shard.CursorPKVals,
+		Splitter:     shard.Splitter,
 	})
 }
 
@@ -10,6 +10,8 @@ import (
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/cockroachdb/molt/utils/transform"
+	"github.com/cockroachdb/molt/utils/typeconv"
 	"github.com/cockroachdb/molt/verify/rowverify"
 )
 
@@ -19,6 +21,22 @@ type Source interface {
 	Close(ctx context.Context) error
 }
 
+// SnapshotSource is a Source whose snapshot can be read again once the
+// export is done, such as to verify the rows which were loaded against it.
+type SnapshotSource interface {
+	Source
+	// SnapshotConn returns a connection to the source on which the returned
+	// shard is read at the snapshot of the export. The caller closes the
+	// connection.
+	SnapshotConn(ctx context.Context, shard rowverify.TableShard) (dbconn.Conn, rowverify.TableShard, error)
+}
+
+var (
+	_ SnapshotSource = (*crdbSource)(nil)
+	_ SnapshotSource = (*pgSource)(nil)
+	_ SnapshotSource = (*oracleSource)(nil)
+)
+
 type SourceConn interface {
 	Export(ctx context.Context, writer io.Writer, table dbtable.VerifiedTable, shard rowverify.TableShard) error
 	Close(ctx context.Context) error
@@ -30,6 +48,14 @@ type Settings struct {
 	PG PGReplicationSlotSettings
 }
 
+// rowBatchSize returns the number of rows of the shard to read at a time.
+func (s Settings) rowBatchSize(shard rowverify.TableShard) int {
+	if shard.RowBatchSize > 0 {
+		return shard.RowBatchSize
+	}
+	return s.RowBatchSize
+}
+
 func InferExportSource(ctx context.Context, settings Settings, conn dbconn.Conn) (Source, error) {
 	switch conn := conn.(type) {
 	case *dbconn.PGConn:
@@ -39,47 +65,69 @@ func InferExportSource(ctx context.Context, settings Settings, conn dbconn.Conn)
 		return NewPGSource(ctx, settings, conn)
 	case *dbconn.MySQLConn:
 		return NewMySQLSource(ctx, settings, conn)
+	case *dbconn.OracleConn:
+		return NewOracleSource(ctx, settings, conn)
 	}
 	return nil, errors.AssertionFailedf("unknown conn type: %T", conn)
 }
 
+// exportTable returns the table to scan to export the given table. Tables
+// without a unique key are paged through using rowIDColumn, the physical row
+// identifier of the source, if there is one. Otherwise, they are read in a
+// single scan, which is not ordered as the order of the rows does not matter
+// to the export.
+func exportTable(table dbtable.VerifiedTable, rowIDColumn tree.Name) rowiterator.Table {
+	ret := rowiterator.Table{
+		Name:              table.Name,
+		ColumnNames:       table.Columns,
+		ColumnOIDs:        table.ColumnOIDs[0],
+		PrimaryKeyColumns: table.PrimaryKeyColumns,
+		KeyType:           table.KeyType,
+	}
+	if table.KeyType == dbtable.KeyTypeNone {
+		ret.PrimaryKeyColumns = nil
+		if rowIDColumn != "" {
+			ret.KeyType = dbtable.KeyTypeRowID
+			ret.PrimaryKeyColumns = []tree.Name{rowIDColumn}
+		}
+	}
+	return ret
+}
+
 func scanWithRowIterator(
 	ctx context.Context,
-	settings Settings,
+	rowBatchSize int,
 	c dbconn.Conn,
 	writer io.Writer,
-	table rowiterator.ScanTable,
+	table dbtable.VerifiedTable,
+	scan rowiterator.ScanTable,
 ) error {
 	cw := csv.NewWriter(writer)
+	scan.Predicate = table.Predicate
 	it, err := rowiterator.NewScanIterator(
 		ctx,
 		c,
-		table,
-		settings.RowBatchSize,
+		scan,
+		rowBatchSize,
 		nil,
 	)
 	if err != nil {
 		return err
 	}
-	strings := make([]string, 0, len(table.ColumnNames))
+	strings := make([]string, 0, len(scan.ColumnNames))
 	for it.HasNext(ctx) {
 		strings = strings[:0]
-		datums := it.Next(ctx)
-		var fmtFlags tree.FmtFlags
+		// Values are written as the type of the target column when it is
+		// overridden, and transformed as given by the transforms file.
+		datums, err := typeconv.ConvertOverriddenValues(table, it.Next(ctx))
+		if err != nil {
+			return err
+		}
+		if datums, err = transform.Apply(table, datums); err != nil {
+			return err
+		}
 		for _, d := range datums {
-			// FmtPgwireText is needed so that null columns get written as "" instead of string NULL
-			// which happens inside f.FormatNode for type dNull datums.
-			switch d.(type) {
-			case *tree.DFloat:
-				// With tree.FmtParsableNumerics, negative value will be bracketed, making it unable to be imported from
-				// csv.
-				fmtFlags = tree.FmtExport | tree.FmtPgwireText
-			default:
-				fmtFlags = tree.FmtExport | tree.FmtParsableNumerics | tree.FmtPgwireText
-			}
-			f := tree.NewFmtCtx(fmtFlags)
-			f.FormatNode(d)
-			strings = append(strings, f.CloseAndGetString())
+			strings = append(strings, FormatDatum(d))
 		}
 		if err := cw.Write(strings); err != nil {
 			return err
@@ -91,3 +139,18 @@ func scanWithRowIterator(
 	cw.Flush()
 	return nil
 }
+
+// FormatDatum formats a datum as it is written to exported CSVs.
+func FormatDatum(d tree.Datum) string {
+	// FmtPgwireText is needed so that null columns get written as "" instead of string NULL
+	// which happens inside f.FormatNode for type dNull datums.
+	fmtFlags := tree.FmtExport | tree.FmtParsableNumerics | tree.FmtPgwireText
+	if _, ok := d.(*tree.DFloat); ok {
+		// With tree.FmtParsableNumerics, negative value will be bracketed, making it unable to be imported from
+		// csv.
+		fmtFlags = tree.FmtExport | tree.FmtPgwireText
+	}
+	f := tree.NewFmtCtx(fmtFlags)
+	f.FormatNode(d)
+	return f.CloseAndGetString()
+}
@@ -0,0 +1,33 @@
+package dataexport
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportTable(t *testing.T) {
+	table := dbtable.VerifiedTable{
+		Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+		KeyType:           dbtable.KeyTypeNone,
+		PrimaryKeyColumns: []tree.Name{"id", "t"},
+		Columns:           []tree.Name{"id", "t"},
+	}
+
+	// Without a row identifier, the table is read in a single unordered scan.
+	ret := exportTable(table, "")
+	require.Equal(t, dbtable.KeyTypeNone, ret.KeyType)
+	require.Empty(t, ret.PrimaryKeyColumns)
+
+	ret = exportTable(table, "ROWID")
+	require.Equal(t, dbtable.KeyTypeRowID, ret.KeyType)
+	require.Equal(t, []tree.Name{"ROWID"}, ret.PrimaryKeyColumns)
+
+	table.KeyType = dbtable.KeyTypePrimaryKey
+	table.PrimaryKeyColumns = []tree.Name{"id"}
+	ret = exportTable(table, "ROWID")
+	require.Equal(t, dbtable.KeyTypePrimaryKey, ret.KeyType)
+	require.Equal(t, []tree.Name{"id"}, ret.PrimaryKeyColumns)
+}
@@ -79,15 +79,12 @@ type mysqlConn struct {
 func (m *mysqlConn) Export(
 	ctx context.Context, writer io.Writer, table dbtable.VerifiedTable, shard rowverify.TableShard,
 ) error {
-	return scanWithRowIterator(ctx, m.src.settings, m.conn, writer, rowiterator.ScanTable{
-		Table: rowiterator.Table{
-			Name:              table.Name,
-			ColumnNames:       table.Columns,
-			ColumnOIDs:        table.ColumnOIDs[0],
-			PrimaryKeyColumns: table.PrimaryKeyColumns,
-		},
-		StartPKVals: shard.StartPKVals,
-		EndPKVals:   shard.EndPKVals,
+	return scanWithRowIterator(ctx, m.src.settings.rowBatchSize(shard), m.conn, writer, table, rowiterator.ScanTable{
+		Table:        exportTable(table, ""),
+		StartPKVals:  shard.StartPKVals,
+		EndPKVals:    shard.EndPKVals,
+		CursorPKVals: shard.CursorPKVals,
+		Splitter:     shard.Splitter,
 	})
 }
 
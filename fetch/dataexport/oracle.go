@@ -0,0 +1,86 @@
+package dataexport
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/cockroachdb/molt/verify/rowverify"
+)
+
+const SCNHelpInstructions = `please ensure that the user has SELECT privileges on V$DATABASE and FLASHBACK privileges on the exported tables`
+
+type oracleSource struct {
+	scn      string
+	settings Settings
+	conn     dbconn.Conn
+}
+
+func NewOracleSource(
+	ctx context.Context, settings Settings, conn *dbconn.OracleConn,
+) (*oracleSource, error) {
+	// Every shard is read AS OF this SCN, so all exported data is from
+	// a consistent point in time which can also be used to start replication.
+	var scn int64
+	if err := conn.QueryRowContext(ctx, "SELECT current_scn FROM v$database").Scan(&scn); err != nil {
+		return nil, errors.Wrapf(err, "failed to export snapshot: %s", SCNHelpInstructions)
+	}
+	return &oracleSource{
+		scn:      strconv.FormatInt(scn, 10),
+		conn:     conn,
+		settings: settings,
+	}, nil
+}
+
+func (o *oracleSource) CDCCursor() string {
+	return o.scn
+}
+
+func (o *oracleSource) Close(ctx context.Context) error {
+	return nil
+}
+
+func (o *oracleSource) Conn(ctx context.Context) (SourceConn, error) {
+	conn, err := o.conn.Clone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oracleSourceConn{conn: conn, src: o}, nil
+}
+
+func (o *oracleSource) SnapshotConn(
+	ctx context.Context, shard rowverify.TableShard,
+) (dbconn.Conn, rowverify.TableShard, error) {
+	conn, err := o.conn.Clone(ctx)
+	if err != nil {
+		return nil, shard, err
+	}
+	shard.SourceAsOfSCN = o.scn
+	return conn, shard, nil
+}
+
+type oracleSourceConn struct {
+	conn dbconn.Conn
+	src  *oracleSource
+}
+
+func (o *oracleSourceConn) Export(
+	ctx context.Context, writer io.Writer, table dbtable.VerifiedTable, shard rowverify.TableShard,
+) error {
+	return scanWithRowIterator(ctx, o.src.settings.rowBatchSize(shard), o.conn, writer, table, rowiterator.ScanTable{
+		Table:        exportTable(table, "ROWID"),
+		AsOfSCN:      o.src.scn,
+		StartPKVals:  shard.StartPKVals,
+		EndPKVals:    shard.EndPKVals,
+		CursorPKVals: shard.CursorPKVals,
+		Splitter:     shard.Splitter,
+	})
+}
+
+func (o *oracleSourceConn) Close(ctx context.Context) error {
+	return o.conn.Close(ctx)
+}
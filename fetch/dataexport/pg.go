@@ -107,6 +107,19 @@ func (p *pgSource) Conn(ctx context.Context) (SourceConn, error) {
 	}, nil
 }
 
+// SnapshotConn returns a connection in a transaction which imports the
+// snapshot of the export. The snapshot can be imported until the source is
+// closed.
+func (p *pgSource) SnapshotConn(
+	ctx context.Context, shard rowverify.TableShard,
+) (dbconn.Conn, rowverify.TableShard, error) {
+	conn, err := p.Conn(ctx)
+	if err != nil {
+		return nil, shard, err
+	}
+	return conn.(*pgSourceConn).conn, shard, nil
+}
+
 type pgSourceConn struct {
 	conn dbconn.Conn
 	tx   pgx.Tx
@@ -116,15 +129,15 @@ type pgSourceConn struct {
 func (p *pgSourceConn) Export(
 	ctx context.Context, writer io.Writer, table dbtable.VerifiedTable, shard rowverify.TableShard,
 ) error {
-	return scanWithRowIterator(ctx, p.src.settings, p.conn, writer, rowiterator.ScanTable{
-		Table: rowiterator.Table{
-			Name:              table.Name,
-			ColumnNames:       table.Columns,
-			ColumnOIDs:        table.ColumnOIDs[0],
-			PrimaryKeyColumns: table.PrimaryKeyColumns,
-		},
-		StartPKVals: shard.StartPKVals,
-		EndPKVals:   shard.EndPKVals,
+	return scanWithRowIterator(ctx, p.src.settings.rowBatchSize(shard), p.conn, writer, table, rowiterator.ScanTable{
+		// ctid is not indexed and TID range scans do not return rows in
+		// order, so paging through ctid would sort the rest of the table for
+		// every batch. Tables without a unique key are read in one scan.
+		Table:        exportTable(table, ""),
+		StartPKVals:  shard.StartPKVals,
+		EndPKVals:    shard.EndPKVals,
+		CursorPKVals: shard.CursorPKVals,
+		Splitter:     shard.Splitter,
 	})
 	// TODO: Figure out if we can still use CopyTo with a select clause
 	// or if doing chunked selects we no longer need the benefit of CopyTo.
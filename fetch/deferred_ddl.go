@@ -0,0 +1,635 @@
+package fetch
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/cockroachdb/molt/utils"
+	"github.com/rs/zerolog"
+)
+
+// GetDeferredDDL returns the secondary indexes and the UNIQUE, CHECK and
+// FOREIGN KEY constraints of a source table, translated into statements
+// creating them on the target table made by GetCreateTableStmt, along with
+// statements advancing the sequences of the table past the imported values.
+// Indexes and constraints which cannot be translated are returned in the
+// failed state.
+func GetDeferredDDL(
+	ctx context.Context,
+	logger zerolog.Logger,
+	conn dbconn.Conn,
+	table dbtable.DBTable,
+	mapping utils.NameMapping,
+) ([]*status.DeferredDDL, error) {
+	const (
+		pgConstraintsQuery = `SELECT
+        c.conname,
+        c.contype::text,
+        pg_catalog.pg_get_constraintdef(c.oid) AS constraint_def
+        FROM pg_catalog.pg_class s
+        JOIN pg_catalog.pg_constraint c ON (s.oid = c.conrelid)
+        WHERE conparentid = 0
+          AND s.relkind = 'r' -- 'r' indicates a table (relation)
+          AND c.contype NOT IN ('p', 'n') -- primary keys and NOT NULL are part of the table
+          AND s.relname= $1
+          AND s.relnamespace::regnamespace::text = $2
+        ORDER BY conname;`
+		// Indexes backing constraints are created by the constraint.
+		pgIndexesQuery = `SELECT
+        i.relname,
+        pg_catalog.pg_get_indexdef(ix.indexrelid) AS index_def
+        FROM pg_catalog.pg_index ix
+        JOIN pg_catalog.pg_class i ON (i.oid = ix.indexrelid)
+        JOIN pg_catalog.pg_class s ON (s.oid = ix.indrelid)
+        WHERE NOT ix.indisprimary
+          AND NOT EXISTS (
+            SELECT 1 FROM pg_catalog.pg_constraint c
+            WHERE c.conindid = ix.indexrelid
+              AND c.conrelid = ix.indrelid
+              AND c.contype IN ('p', 'u', 'x')
+          )
+          AND s.relname = $1
+          AND s.relnamespace::regnamespace::text = $2
+        ORDER BY i.relname;`
+		mysqlQuery = `SHOW CREATE TABLE %s`
+		// The schema of an Oracle table is its tablespace, as in verify.
+		oracleConstraintsQuery = `SELECT
+        k.constraint_name,
+        k.constraint_type,
+        k.search_condition_vc,
+        (SELECT LISTAGG(kc.column_name, ',') WITHIN GROUP (ORDER BY kc.position)
+          FROM all_cons_columns kc
+          WHERE kc.owner = k.owner AND kc.constraint_name = k.constraint_name) AS column_names,
+        r.table_name,
+        (SELECT LISTAGG(rc.column_name, ',') WITHIN GROUP (ORDER BY rc.position)
+          FROM all_cons_columns rc
+          WHERE rc.owner = r.owner AND rc.constraint_name = r.constraint_name) AS ref_column_names,
+        k.delete_rule,
+        k.validated
+        FROM all_constraints k
+        JOIN all_tables t ON (t.owner = k.owner AND t.table_name = k.table_name)
+        LEFT JOIN all_constraints r ON (r.owner = k.r_owner AND r.constraint_name = k.r_constraint_name)
+        WHERE k.constraint_type IN ('U', 'R', 'C')
+          AND k.table_name = :1
+          AND t.tablespace_name = :2
+        ORDER BY k.constraint_name`
+		// Indexes backing constraints are created by the constraint.
+		oracleIndexesQuery = `SELECT
+        i.index_name,
+        i.uniqueness,
+        LISTAGG(ic.column_name, ',') WITHIN GROUP (ORDER BY ic.column_position) AS column_names
+        FROM all_indexes i
+        JOIN all_tables t ON (t.owner = i.table_owner AND t.table_name = i.table_name)
+        JOIN all_ind_columns ic ON (ic.index_owner = i.owner AND ic.index_name = i.index_name)
+        WHERE i.index_type = 'NORMAL'
+          AND NOT EXISTS (
+            SELECT 1 FROM all_constraints k
+            WHERE k.owner = i.table_owner
+              AND k.index_name = i.index_name
+              AND k.constraint_type IN ('P', 'U')
+          )
+          AND i.table_name = :1
+          AND t.tablespace_name = :2
+        GROUP BY i.index_name, i.uniqueness
+        ORDER BY i.index_name`
+	)
+
+	// The DDL is applied to the table created on the target, but is recorded
+	// under the name of the source table.
+	target := TargetTable(conn, mapping, table)
+
+	var res []*status.DeferredDDL
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		if err := func() error {
+			rows, err := conn.Query(ctx, pgConstraintsQuery, table.Table, table.Schema)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var name, contype, def string
+				if err := rows.Scan(&name, &contype, &def); err != nil {
+					return err
+				}
+				res = append(res, translatePGConstraint(target, name, contype, def))
+			}
+			return rows.Err()
+		}(); err != nil {
+			return nil, errors.Wrapf(err, "failed to get the constraints for table %s", table.Table)
+		}
+		if err := func() error {
+			rows, err := conn.Query(ctx, pgIndexesQuery, table.Table, table.Schema)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var name, def string
+				if err := rows.Scan(&name, &def); err != nil {
+					return err
+				}
+				res = append(res, translateIndex(target, name, def, def))
+			}
+			return rows.Err()
+		}(); err != nil {
+			return nil, errors.Wrapf(err, "failed to get the indexes for table %s", table.Table)
+		}
+	case *dbconn.MySQLConn:
+		rows, err := conn.Query(fmt.Sprintf(mysqlQuery, table.Table))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the constraints for table %s", table.Table)
+		}
+		defer rows.Close()
+		var tableName string
+		var createTableStmt string
+		for rows.Next() {
+			if err := rows.Scan(&tableName, &createTableStmt); err != nil {
+				return nil, errors.Wrapf(err, "failed to scan results to get the constraints for table %s", table.Table)
+			}
+			res = append(res, translateMySQLCreateTable(target, createTableStmt)...)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, errors.Wrapf(err, "failed to get the constraints for table %s", table.Table)
+		}
+	case *dbconn.OracleConn:
+		if err := func() error {
+			rows, err := conn.QueryContext(ctx, oracleConstraintsQuery, string(table.Table), string(table.Schema))
+			if err != nil {
+				return err
+			}
+			defer func() { _ = rows.Close() }()
+			for rows.Next() {
+				var name, contype string
+				var searchCondition, columns, refTable, refColumns, deleteRule, validated sql.NullString
+				if err := rows.Scan(&name, &contype, &searchCondition, &columns, &refTable, &refColumns, &deleteRule, &validated); err != nil {
+					return err
+				}
+				if d := translateOracleConstraint(target, oracleConstraint{
+					name:            name,
+					contype:         contype,
+					searchCondition: searchCondition.String,
+					columns:         columns.String,
+					refTable:        refTable.String,
+					refColumns:      refColumns.String,
+					deleteRule:      deleteRule.String,
+					validated:       validated.String,
+				}); d != nil {
+					res = append(res, d)
+				}
+			}
+			return rows.Err()
+		}(); err != nil {
+			return nil, errors.Wrapf(err, "failed to get the constraints for table %s", table.Table)
+		}
+		if err := func() error {
+			rows, err := conn.QueryContext(ctx, oracleIndexesQuery, string(table.Table), string(table.Schema))
+			if err != nil {
+				return err
+			}
+			defer func() { _ = rows.Close() }()
+			for rows.Next() {
+				var name, uniqueness, columns string
+				if err := rows.Scan(&name, &uniqueness, &columns); err != nil {
+					return err
+				}
+				res = append(res, translateOracleIndex(target, name, uniqueness == "UNIQUE", columns))
+			}
+			return rows.Err()
+		}(); err != nil {
+			return nil, errors.Wrapf(err, "failed to get the indexes for table %s", table.Table)
+		}
+	default:
+		return nil, errors.New("not supported conn type")
+	}
+
+	cols, err := GetColumnTypes(ctx, logger, conn, table, false /* skipUnsupportedTypeErr */)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed get columns for target table: %s", table.String())
+	}
+	cols.setTarget(target)
+	res = append(res, advanceSequenceDDL(target, cols)...)
+
+	for _, d := range res {
+		if d.Kind == status.DDLKindForeignKey && d.State == status.DDLStatePending {
+			mapForeignKeyReference(d, conn, mapping, table)
+		}
+		d.Schema, d.Table = string(table.Schema), string(table.Table)
+		if d.State == status.DDLStateFailed {
+			logger.Warn().
+				Str("table", table.SafeString()).
+				Str("name", d.Name).
+				Msgf("unable to recreate %q on the target: %s", d.SourceDef, d.Message)
+		}
+	}
+	return res, nil
+}
+
+// targetTableName returns the name of the table created on the target, as
+// returned by TargetTable.
+func targetTableName(table dbtable.DBTable) *tree.UnresolvedObjectName {
+	tn := makeTargetTableName(table)
+	return tn.ToUnresolvedObjectName()
+}
+
+// makeTargetTableName returns the name of the table created on the target,
+// which is qualified by its schema if it has one.
+func makeTargetTableName(table dbtable.DBTable) tree.TableName {
+	if table.Schema == "" {
+		return tree.MakeUnqualifiedTableName(table.Table)
+	}
+	return table.MakeTableName()
+}
+
+// mapForeignKeyReference replaces the table referenced by a foreign key, which
+// is in the same schema as the source table, with the name of the table it is
+// mapped to on the target.
+func mapForeignKeyReference(
+	d *status.DeferredDDL, conn dbconn.Conn, mapping utils.NameMapping, table dbtable.DBTable,
+) {
+	parsed, err := parser.ParseOne(d.Stmt)
+	if err != nil {
+		return
+	}
+	alterTable, ok := parsed.AST.(*tree.AlterTable)
+	if !ok || len(alterTable.Cmds) != 1 {
+		return
+	}
+	addConstraint, ok := alterTable.Cmds[0].(*tree.AlterTableAddConstraint)
+	if !ok {
+		return
+	}
+	def, ok := addConstraint.ConstraintDef.(*tree.ForeignKeyConstraintTableDef)
+	if !ok {
+		return
+	}
+	ref := TargetTable(conn, mapping, dbtable.DBTable{
+		Name: dbtable.Name{Schema: table.Schema, Table: def.Table.ObjectName},
+	})
+	if ref.Schema == "" && ref.Table == def.Table.ObjectName {
+		return
+	}
+	def.Table = makeTargetTableName(ref)
+	d.Stmt = alterTable.String()
+}
+
+// translatePGConstraint translates a constraint as formatted by
+// pg_get_constraintdef.
+func translatePGConstraint(table dbtable.DBTable, name, contype, def string) *status.DeferredDDL {
+	switch contype {
+	case "u", "c":
+		return translateConstraint(table, name, def, def)
+	case "f":
+		ret := translateConstraint(table, name, def, def)
+		ret.Kind = status.DDLKindForeignKey
+		return ret
+	default:
+		return failedDDL(table, name, status.DDLKindConstraint, def, errors.Newf("constraints of type %q are not supported", contype))
+	}
+}
+
+// translateIndex translates a CREATE INDEX statement into one creating the
+// index on the target table.
+func translateIndex(table dbtable.DBTable, name, sourceDef, stmt string) *status.DeferredDDL {
+	parsed, err := parser.ParseOne(stmt)
+	if err != nil {
+		return failedDDL(table, name, status.DDLKindIndex, sourceDef, err)
+	}
+	createIndex, ok := parsed.AST.(*tree.CreateIndex)
+	if !ok {
+		return failedDDL(table, name, status.DDLKindIndex, sourceDef, errors.Newf("expected CREATE INDEX, got %s", parsed.AST.StatementTag()))
+	}
+	createIndex.Table = makeTargetTableName(table)
+	createIndex.Name = tree.Name(name)
+	return &status.DeferredDDL{
+		Table:     string(table.Table),
+		Schema:    string(table.Schema),
+		Name:      name,
+		Kind:      status.DDLKindIndex,
+		SourceDef: sourceDef,
+		Stmt:      createIndex.String(),
+		State:     status.DDLStatePending,
+	}
+}
+
+// translateConstraint translates the definition of a constraint, as used in
+// ALTER TABLE ... ADD CONSTRAINT, into a statement adding it to the target
+// table. CHECK and FOREIGN KEY constraints are added without validating the
+// imported rows, which is done by a separate statement so that a violation
+// leaves the constraint in place to be investigated.
+func translateConstraint(table dbtable.DBTable, name, sourceDef, def string) *status.DeferredDDL {
+	stmt := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", targetTableName(table), tree.NameString(name), def)
+	parsed, err := parser.ParseOne(stmt)
+	if err != nil {
+		return failedDDL(table, name, status.DDLKindConstraint, sourceDef, err)
+	}
+	alterTable, ok := parsed.AST.(*tree.AlterTable)
+	if !ok || len(alterTable.Cmds) != 1 {
+		return failedDDL(table, name, status.DDLKindConstraint, sourceDef, errors.Newf("unexpected constraint definition"))
+	}
+	addConstraint, ok := alterTable.Cmds[0].(*tree.AlterTableAddConstraint)
+	if !ok {
+		return failedDDL(table, name, status.DDLKindConstraint, sourceDef, errors.Newf("unexpected constraint definition"))
+	}
+
+	ret := &status.DeferredDDL{
+		Table:     string(table.Table),
+		Schema:    string(table.Schema),
+		Name:      name,
+		Kind:      status.DDLKindConstraint,
+		SourceDef: sourceDef,
+		State:     status.DDLStatePending,
+	}
+	validate := false
+	switch def := addConstraint.ConstraintDef.(type) {
+	case *tree.ForeignKeyConstraintTableDef:
+		ret.Kind = status.DDLKindForeignKey
+		// The referenced table is created in the same schema as the table.
+		def.Table = tree.MakeUnqualifiedTableName(def.Table.ObjectName)
+		validate = true
+	case *tree.CheckConstraintTableDef:
+		validate = true
+	}
+	// Constraints which were not validated on the source are not validated
+	// on the target either.
+	if validate && addConstraint.ValidationBehavior == tree.ValidationDefault {
+		addConstraint.ValidationBehavior = tree.ValidationSkip
+		ret.ValidateStmt = (&tree.AlterTable{
+			Table: alterTable.Table,
+			Cmds: tree.AlterTableCmds{
+				&tree.AlterTableValidateConstraint{Constraint: tree.Name(name)},
+			},
+		}).String()
+	}
+	ret.Stmt = alterTable.String()
+	return ret
+}
+
+// advanceSequenceDDL returns statements advancing the sequences created for
+// the columns of the table past the largest imported value, so that values
+// generated after the fetch do not conflict with imported rows.
+func advanceSequenceDDL(table dbtable.DBTable, cols columnsWithType) []*status.DeferredDDL {
+	var res []*status.DeferredDDL
+	for _, col := range cols {
+		seqName := col.sequenceName()
+		if seqName == "" {
+			continue
+		}
+		colName := tree.NameString(col.columnName)
+		res = append(res, &status.DeferredDDL{
+			Table:     string(table.Table),
+			Schema:    string(table.Schema),
+			Name:      seqName,
+			Kind:      status.DDLKindSequence,
+			SourceDef: fmt.Sprintf("%s %s", col.columnName, col.sourceGenerator()),
+			Stmt: fmt.Sprintf(
+				"SELECT setval(%s, max(%s)) FROM %s HAVING max(%s) > (SELECT last_value FROM %s)",
+				tree.NewStrVal(col.targetSequenceName().String()),
+				colName,
+				targetTableName(table),
+				colName,
+				col.targetSequenceName(),
+			),
+			State: status.DDLStatePending,
+		})
+	}
+	return res
+}
+
+func failedDDL(
+	table dbtable.DBTable, name string, kind string, sourceDef string, err error,
+) *status.DeferredDDL {
+	return &status.DeferredDDL{
+		Table:     string(table.Table),
+		Schema:    string(table.Schema),
+		Name:      name,
+		Kind:      kind,
+		SourceDef: sourceDef,
+		State:     status.DDLStateFailed,
+		Message:   fmt.Sprintf("unable to translate: %s", err),
+	}
+}
+
+var (
+	mysqlIndexRegex       = regexp.MustCompile(`^(UNIQUE |FULLTEXT |SPATIAL )?KEY (` + "`" + `[^` + "`" + `]+` + "`" + `) (\(.+\))( USING (?:BTREE|HASH))?$`)
+	mysqlConstraintRegex  = regexp.MustCompile(`^CONSTRAINT (` + "`" + `[^` + "`" + `]+` + "`" + `) ((?:FOREIGN KEY|CHECK) .+)$`)
+	mysqlPrefixIndexRegex = regexp.MustCompile("`[^`]+`\\(\\d+\\)")
+)
+
+// translateMySQLCreateTable translates the secondary indexes and constraints
+// of a table, as output by SHOW CREATE TABLE.
+func translateMySQLCreateTable(table dbtable.DBTable, createTableStmt string) []*status.DeferredDDL {
+	var res []*status.DeferredDDL
+	for _, line := range strings.Split(createTableStmt, "\n") {
+		line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+		if m := mysqlIndexRegex.FindStringSubmatch(line); m != nil {
+			name := mysqlUnquote(m[2])
+			switch {
+			case m[1] == "FULLTEXT " || m[1] == "SPATIAL ":
+				res = append(res, failedDDL(table, name, status.DDLKindIndex, line, errors.Newf("%s indexes are not supported", strings.TrimSpace(m[1]))))
+			case mysqlPrefixIndexRegex.MatchString(m[3]):
+				res = append(res, failedDDL(table, name, status.DDLKindIndex, line, errors.Newf("prefix indexes are not supported")))
+			default:
+				stmt := fmt.Sprintf("CREATE %sINDEX %s ON %s %s", m[1], tree.NameString(name), targetTableName(table), mysqlToPGIdents(m[3]))
+				res = append(res, translateIndex(table, name, line, stmt))
+			}
+		} else if m := mysqlConstraintRegex.FindStringSubmatch(line); m != nil {
+			res = append(res, translateConstraint(table, mysqlUnquote(m[1]), line, mysqlToPGIdents(m[2])))
+		}
+	}
+	return res
+}
+
+// mysqlUnquote returns the name of a MySQL quoted identifier.
+func mysqlUnquote(ident string) string {
+	return strings.ReplaceAll(strings.Trim(ident, "`"), "``", "`")
+}
+
+// mysqlToPGIdents replaces the MySQL identifier quotes in an expression with
+// the quotes used by CockroachDB.
+func mysqlToPGIdents(expr string) string {
+	return strings.ReplaceAll(expr, "`", `"`)
+}
+
+// oracleConstraint is a constraint of an Oracle table, as stored in
+// ALL_CONSTRAINTS.
+type oracleConstraint struct {
+	name    string
+	contype string
+	// searchCondition is the condition of a CHECK constraint.
+	searchCondition string
+	// columns and refColumns are the comma separated columns of the
+	// constraint and of the constraint referenced by a foreign key.
+	columns    string
+	refTable   string
+	refColumns string
+	deleteRule string
+	validated  string
+}
+
+var (
+	// oracleNotNullRegex matches the CHECK constraints Oracle creates for
+	// NOT NULL columns, which are part of the table.
+	oracleNotNullRegex = regexp.MustCompile(`^"[^"]+" IS NOT NULL$`)
+	// oracleQuotedIdentRegex matches the quoted upper case identifiers in a
+	// CHECK constraint.
+	oracleQuotedIdentRegex = regexp.MustCompile(`"[A-Z0-9_$#]+"`)
+)
+
+// translateOracleConstraint translates a constraint of an Oracle table. It
+// returns nil for the NOT NULL constraints, which are part of the table.
+// Oracle identifiers are lower cased, as the columns of the target table.
+func translateOracleConstraint(table dbtable.DBTable, c oracleConstraint) *status.DeferredDDL {
+	name := strings.ToLower(c.name)
+	var sourceDef, def string
+	switch c.contype {
+	case "C":
+		if oracleNotNullRegex.MatchString(c.searchCondition) {
+			return nil
+		}
+		sourceDef = fmt.Sprintf("CHECK (%s)", c.searchCondition)
+		def = fmt.Sprintf("CHECK (%s)", oracleQuotedIdentRegex.ReplaceAllStringFunc(c.searchCondition, strings.ToLower))
+	case "U":
+		sourceDef = fmt.Sprintf("UNIQUE (%s)", c.columns)
+		def = fmt.Sprintf("UNIQUE (%s)", oracleColumnList(c.columns))
+	case "R":
+		sourceDef = fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", c.columns, c.refTable, c.refColumns)
+		def = fmt.Sprintf(
+			"FOREIGN KEY (%s) REFERENCES %s (%s)",
+			oracleColumnList(c.columns),
+			tree.NameString(strings.ToLower(c.refTable)),
+			oracleColumnList(c.refColumns),
+		)
+		if c.deleteRule == "CASCADE" || c.deleteRule == "SET NULL" {
+			sourceDef += " ON DELETE " + c.deleteRule
+			def += " ON DELETE " + c.deleteRule
+		}
+	default:
+		return failedDDL(table, name, status.DDLKindConstraint, c.searchCondition, errors.Newf("constraints of type %q are not supported", c.contype))
+	}
+	// Constraints which were not validated on the source are not validated
+	// on the target either.
+	if c.validated == "NOT VALIDATED" {
+		def += " NOT VALID"
+	}
+	return translateConstraint(table, name, sourceDef, def)
+}
+
+// translateOracleIndex translates an index of an Oracle table, given its
+// comma separated columns.
+func translateOracleIndex(
+	table dbtable.DBTable, name string, unique bool, columns string,
+) *status.DeferredDDL {
+	name = strings.ToLower(name)
+	var uniqueStr string
+	if unique {
+		uniqueStr = "UNIQUE "
+	}
+	stmt := fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", uniqueStr, tree.NameString(name), targetTableName(table), oracleColumnList(columns))
+	return translateIndex(table, name, fmt.Sprintf("%sINDEX (%s)", uniqueStr, columns), stmt)
+}
+
+// oracleColumnList formats comma separated Oracle columns as the columns of
+// the target table.
+func oracleColumnList(columns string) string {
+	var ret []string
+	for _, c := range strings.Split(columns, ",") {
+		ret = append(ret, tree.NameString(tree.Name(c).Normalize()))
+	}
+	return strings.Join(ret, ", ")
+}
+
+// applyTableDeferredDDL applies the pending foreign keys, or the pending
+// indexes and other constraints, recording whether each was created and
+// validated. Failures are logged and recorded rather than returned, so that
+// one failure does not prevent the others from being created.
+func applyTableDeferredDDL(
+	ctx context.Context,
+	logger zerolog.Logger,
+	targetConn dbconn.Conn,
+	ddls []*status.DeferredDDL,
+	foreignKeys bool,
+) error {
+	var pending []*status.DeferredDDL
+	for _, d := range ddls {
+		if d.State == status.DDLStatePending && (d.Kind == status.DDLKindForeignKey) == foreignKeys {
+			pending = append(pending, d)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	connCopy, err := targetConn.Clone(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to clone the target connection to create indexes and constraints")
+	}
+	defer func() {
+		if err := connCopy.Close(ctx); err != nil {
+			logger.Err(err).Msg("failed to close connection to create indexes and constraints")
+		}
+	}()
+	conn := connCopy.(*dbconn.PGConn).Conn
+
+	for _, d := range pending {
+		ddlLogger := logger.With().
+			Str("table", fmt.Sprintf("%s.%s", d.Schema, d.Table)).
+			Str("name", d.Name).
+			Logger()
+		ddlLogger.Info().Msgf("creating %s with %q", d.Kind, d.Stmt)
+		if _, err := conn.Exec(ctx, d.Stmt); err != nil {
+			d.State = status.DDLStateFailed
+			d.Message = err.Error()
+			ddlLogger.Warn().Err(err).Msgf("failed to create %s", d.Kind)
+		} else if d.ValidateStmt == "" {
+			d.State = status.DDLStateApplied
+		} else if _, err := conn.Exec(ctx, d.ValidateStmt); err != nil {
+			d.State = status.DDLStateNotValid
+			d.Message = err.Error()
+			ddlLogger.Warn().Err(err).Msgf("imported data failed to validate against %s, which is left NOT VALID", d.Kind)
+		} else {
+			d.State = status.DDLStateApplied
+		}
+		if err := d.UpdateState(ctx, conn); err != nil {
+			return errors.Wrapf(err, "failed to record the state of %s %s", d.Kind, d.Name)
+		}
+	}
+	return nil
+}
+
+// logDeferredDDLSummary logs how many of the indexes and constraints of the
+// fetch were recreated.
+func logDeferredDDLSummary(
+	summaryLogger zerolog.Logger, mapping map[string][]*status.DeferredDDL,
+) {
+	numByState := map[string]int{}
+	var failed []string
+	for _, ddls := range mapping {
+		for _, d := range ddls {
+			numByState[d.State]++
+			if d.State != status.DDLStateApplied {
+				failed = append(failed, fmt.Sprintf("%s.%s.%s", d.Schema, d.Table, d.Name))
+			}
+		}
+	}
+	if len(numByState) == 0 {
+		return
+	}
+	sort.Strings(failed)
+	summaryLogger.Info().
+		Int("num_applied", numByState[status.DDLStateApplied]).
+		Int("num_not_valid", numByState[status.DDLStateNotValid]).
+		Int("num_failed", numByState[status.DDLStateFailed]).
+		Strs("not_applied", failed).
+		Msgf("indexes and constraints recreated")
+}
@@ -0,0 +1,297 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/cockroachdb/molt/utils"
+	"github.com/stretchr/testify/require"
+)
+
+type translatedDDL struct {
+	name, kind, state, stmt, validateStmt, message string
+}
+
+func toTranslatedDDL(d *status.DeferredDDL) translatedDDL {
+	return translatedDDL{
+		name:         d.Name,
+		kind:         d.Kind,
+		state:        d.State,
+		stmt:         d.Stmt,
+		validateStmt: d.ValidateStmt,
+		message:      d.Message,
+	}
+}
+
+func TestTranslatePGDeferredDDL(t *testing.T) {
+	source := dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "posts"}}
+	table := TargetTable(nil /* conn */, utils.NameMapping{}, source)
+	for _, tc := range []struct {
+		desc     string
+		contype  string
+		def      string
+		expected translatedDDL
+	}{
+		{
+			desc:    "unique",
+			contype: "u",
+			def:     "UNIQUE (author_id)",
+			expected: translatedDDL{
+				kind:  status.DDLKindConstraint,
+				state: status.DDLStatePending,
+				stmt:  "ALTER TABLE posts ADD CONSTRAINT c UNIQUE (author_id)",
+			},
+		},
+		{
+			desc:    "check",
+			contype: "c",
+			def:     "CHECK ((number_pg > 10))",
+			expected: translatedDDL{
+				kind:         status.DDLKindConstraint,
+				state:        status.DDLStatePending,
+				stmt:         "ALTER TABLE posts ADD CONSTRAINT c CHECK ((number_pg > 10)) NOT VALID",
+				validateStmt: "ALTER TABLE posts VALIDATE CONSTRAINT c",
+			},
+		},
+		{
+			desc:    "check not validated on the source",
+			contype: "c",
+			def:     "CHECK ((number_pg > 10)) NOT VALID",
+			expected: translatedDDL{
+				kind:  status.DDLKindConstraint,
+				state: status.DDLStatePending,
+				stmt:  "ALTER TABLE posts ADD CONSTRAINT c CHECK ((number_pg > 10)) NOT VALID",
+			},
+		},
+		{
+			desc:    "foreign key",
+			contype: "f",
+			def:     "FOREIGN KEY (tenant_id) REFERENCES other.tenants(tenant_id) ON DELETE RESTRICT",
+			expected: translatedDDL{
+				kind:         status.DDLKindForeignKey,
+				state:        status.DDLStatePending,
+				stmt:         "ALTER TABLE posts ADD CONSTRAINT c FOREIGN KEY (tenant_id) REFERENCES tenants (tenant_id) ON DELETE RESTRICT NOT VALID",
+				validateStmt: "ALTER TABLE posts VALIDATE CONSTRAINT c",
+			},
+		},
+		{
+			desc:    "unsupported foreign key action",
+			contype: "f",
+			def:     "FOREIGN KEY (tenant_id, author_id) REFERENCES users(tenant_id, user_id) ON DELETE SET NULL (author_id)",
+			expected: translatedDDL{
+				kind:    status.DDLKindForeignKey,
+				state:   status.DDLStateFailed,
+				message: `unable to translate: at or near "(": syntax error`,
+			},
+		},
+		{
+			desc:    "exclusion",
+			contype: "x",
+			def:     "EXCLUDE USING gist (c WITH &&)",
+			expected: translatedDDL{
+				kind:    status.DDLKindConstraint,
+				state:   status.DDLStateFailed,
+				message: `unable to translate: constraints of type "x" are not supported`,
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			tc.expected.name = "c"
+			require.Equal(t, tc.expected, toTranslatedDDL(translatePGConstraint(table, "c", tc.contype, tc.def)))
+		})
+	}
+
+	t.Run("index", func(t *testing.T) {
+		def := "CREATE INDEX posts_idx ON public.posts USING btree (author_id DESC) WHERE (number_pg > 1)"
+		require.Equal(t, translatedDDL{
+			name:  "posts_idx",
+			kind:  status.DDLKindIndex,
+			state: status.DDLStatePending,
+			stmt:  "CREATE INDEX posts_idx ON posts (author_id DESC) WHERE (number_pg > 1)",
+		}, toTranslatedDDL(translateIndex(table, "posts_idx", def, def)))
+	})
+
+	t.Run("mapped table", func(t *testing.T) {
+		mapping := utils.NameMapping{
+			Schema: "blog",
+			Pairs: []utils.NamePair{
+				{Source: dbtable.Name{Table: "posts"}, Target: dbtable.Name{Table: "articles"}},
+				{Source: dbtable.Name{Table: "tenants"}, Target: dbtable.Name{Table: "accounts"}},
+			},
+		}
+		target := TargetTable(nil /* conn */, mapping, source)
+		require.Equal(t, dbtable.Name{Schema: "blog", Table: "articles"}, target.Name)
+
+		def := "CREATE INDEX posts_idx ON public.posts USING btree (author_id)"
+		require.Equal(t, "CREATE INDEX posts_idx ON blog.articles (author_id)", translateIndex(target, "posts_idx", def, def).Stmt)
+
+		d := translatePGConstraint(target, "c", "f", "FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)")
+		mapForeignKeyReference(d, nil /* conn */, mapping, source)
+		require.Equal(t, translatedDDL{
+			name:         "c",
+			kind:         status.DDLKindForeignKey,
+			state:        status.DDLStatePending,
+			stmt:         "ALTER TABLE blog.articles ADD CONSTRAINT c FOREIGN KEY (tenant_id) REFERENCES blog.accounts (tenant_id) NOT VALID",
+			validateStmt: "ALTER TABLE blog.articles VALIDATE CONSTRAINT c",
+		}, toTranslatedDDL(d))
+	})
+}
+
+func TestTranslateMySQLDeferredDDL(t *testing.T) {
+	table := TargetTable(nil /* conn */, utils.NameMapping{}, dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "posts"}})
+	createTableStmt := "CREATE TABLE `posts` (\n" +
+		"  `id` int NOT NULL,\n" +
+		"  `a` varchar(10) DEFAULT NULL,\n" +
+		"  `Tenant` int DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `a_uniq` (`a`,`Tenant`),\n" +
+		"  KEY `a_idx` (`a`) USING BTREE,\n" +
+		"  KEY `a_prefix` (`a`(5)),\n" +
+		"  KEY `fn_idx` ((lower(`a`))),\n" +
+		"  FULLTEXT KEY `ft` (`a`),\n" +
+		"  CONSTRAINT `posts_ibfk_1` FOREIGN KEY (`Tenant`) REFERENCES `tenants` (`id`) ON DELETE CASCADE,\n" +
+		"  CONSTRAINT `posts_chk_1` CHECK ((`id` > 10))\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+
+	var res []translatedDDL
+	for _, d := range translateMySQLCreateTable(table, createTableStmt) {
+		res = append(res, toTranslatedDDL(d))
+	}
+	require.Equal(t, []translatedDDL{
+		{
+			name:  "a_uniq",
+			kind:  status.DDLKindIndex,
+			state: status.DDLStatePending,
+			stmt:  `CREATE UNIQUE INDEX a_uniq ON posts (a, "Tenant")`,
+		},
+		{
+			name:  "a_idx",
+			kind:  status.DDLKindIndex,
+			state: status.DDLStatePending,
+			stmt:  "CREATE INDEX a_idx ON posts (a)",
+		},
+		{
+			name:    "a_prefix",
+			kind:    status.DDLKindIndex,
+			state:   status.DDLStateFailed,
+			message: "unable to translate: prefix indexes are not supported",
+		},
+		{
+			name:  "fn_idx",
+			kind:  status.DDLKindIndex,
+			state: status.DDLStatePending,
+			stmt:  "CREATE INDEX fn_idx ON posts (lower(a))",
+		},
+		{
+			name:    "ft",
+			kind:    status.DDLKindIndex,
+			state:   status.DDLStateFailed,
+			message: "unable to translate: FULLTEXT indexes are not supported",
+		},
+		{
+			name:         "posts_ibfk_1",
+			kind:         status.DDLKindForeignKey,
+			state:        status.DDLStatePending,
+			stmt:         `ALTER TABLE posts ADD CONSTRAINT posts_ibfk_1 FOREIGN KEY ("Tenant") REFERENCES tenants (id) ON DELETE CASCADE NOT VALID`,
+			validateStmt: "ALTER TABLE posts VALIDATE CONSTRAINT posts_ibfk_1",
+		},
+		{
+			name:         "posts_chk_1",
+			kind:         status.DDLKindConstraint,
+			state:        status.DDLStatePending,
+			stmt:         "ALTER TABLE posts ADD CONSTRAINT posts_chk_1 CHECK ((id > 10)) NOT VALID",
+			validateStmt: "ALTER TABLE posts VALIDATE CONSTRAINT posts_chk_1",
+		},
+	}, res)
+}
+
+func TestTranslateOracleDeferredDDL(t *testing.T) {
+	table := TargetTable(nil /* conn */, utils.NameMapping{}, dbtable.DBTable{Name: dbtable.Name{Schema: "users", Table: "staff"}})
+	for _, tc := range []struct {
+		desc       string
+		constraint oracleConstraint
+		expected   *translatedDDL
+	}{
+		{
+			desc:       "not null",
+			constraint: oracleConstraint{name: "SYS_C008", contype: "C", searchCondition: `"NAME" IS NOT NULL`},
+		},
+		{
+			desc:       "check",
+			constraint: oracleConstraint{name: "STAFF_AGE_CHECK", contype: "C", searchCondition: `"AGE" > 18 AND age < 100`, validated: "VALIDATED"},
+			expected: &translatedDDL{
+				name:         "staff_age_check",
+				kind:         status.DDLKindConstraint,
+				state:        status.DDLStatePending,
+				stmt:         "ALTER TABLE staff ADD CONSTRAINT staff_age_check CHECK ((age > 18) AND (age < 100)) NOT VALID",
+				validateStmt: "ALTER TABLE staff VALIDATE CONSTRAINT staff_age_check",
+			},
+		},
+		{
+			desc:       "unique",
+			constraint: oracleConstraint{name: "STAFF_UNIQ", contype: "U", columns: "NAME,DEPT_ID", validated: "VALIDATED"},
+			expected: &translatedDDL{
+				name:  "staff_uniq",
+				kind:  status.DDLKindConstraint,
+				state: status.DDLStatePending,
+				stmt:  "ALTER TABLE staff ADD CONSTRAINT staff_uniq UNIQUE (name, dept_id)",
+			},
+		},
+		{
+			desc: "foreign key",
+			constraint: oracleConstraint{
+				name:       "STAFF_DEPT_FK",
+				contype:    "R",
+				columns:    "DEPT_ID",
+				refTable:   "DEPARTMENTS",
+				refColumns: "ID",
+				deleteRule: "CASCADE",
+				validated:  "VALIDATED",
+			},
+			expected: &translatedDDL{
+				name:         "staff_dept_fk",
+				kind:         status.DDLKindForeignKey,
+				state:        status.DDLStatePending,
+				stmt:         "ALTER TABLE staff ADD CONSTRAINT staff_dept_fk FOREIGN KEY (dept_id) REFERENCES departments (id) ON DELETE CASCADE NOT VALID",
+				validateStmt: "ALTER TABLE staff VALIDATE CONSTRAINT staff_dept_fk",
+			},
+		},
+		{
+			desc: "foreign key not validated on the source",
+			constraint: oracleConstraint{
+				name:       "STAFF_DEPT_FK",
+				contype:    "R",
+				columns:    "DEPT_ID",
+				refTable:   "DEPARTMENTS",
+				refColumns: "ID",
+				deleteRule: "NO ACTION",
+				validated:  "NOT VALIDATED",
+			},
+			expected: &translatedDDL{
+				name:  "staff_dept_fk",
+				kind:  status.DDLKindForeignKey,
+				state: status.DDLStatePending,
+				stmt:  "ALTER TABLE staff ADD CONSTRAINT staff_dept_fk FOREIGN KEY (dept_id) REFERENCES departments (id) NOT VALID",
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			d := translateOracleConstraint(table, tc.constraint)
+			if tc.expected == nil {
+				require.Nil(t, d)
+				return
+			}
+			require.Equal(t, *tc.expected, toTranslatedDDL(d))
+		})
+	}
+
+	t.Run("index", func(t *testing.T) {
+		require.Equal(t, translatedDDL{
+			name:  "staff_name_idx",
+			kind:  status.DDLKindIndex,
+			state: status.DDLStatePending,
+			stmt:  "CREATE UNIQUE INDEX staff_name_idx ON staff (name, dept_id)",
+		}, toTranslatedDDL(translateOracleIndex(table, "STAFF_NAME_IDX", true /* unique */, "NAME,DEPT_ID")))
+	})
+}
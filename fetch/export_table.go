@@ -11,6 +11,7 @@ import (
 	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/fetch/datablobstorage"
 	"github.com/cockroachdb/molt/fetch/dataexport"
+	"github.com/cockroachdb/molt/fileformat"
 	"github.com/cockroachdb/molt/testutils"
 	"github.com/cockroachdb/molt/verify/rowverify"
 	"github.com/rs/zerolog"
@@ -41,16 +42,22 @@ func exportTable(
 	datasource datablobstorage.Store,
 	table dbtable.VerifiedTable,
 	shard rowverify.TableShard,
+	checkpointer *exportCheckpointer,
+	queue *resourceQueue,
 	testingKnobs testutils.FetchTestingKnobs,
 ) (exportResult, error) {
 	importFileExt := "csv"
-	if cfg.Compression == compression.GZIP {
+	if cfg.Format == fileformat.Parquet {
+		importFileExt = fileformat.ParquetFileExt
+	} else if cfg.Compression == compression.GZIP {
 		importFileExt = "tar.gz"
 	}
 
 	ret := exportResult{
 		StartTime: time.Now(),
 	}
+	// The row batch size may be overridden for the table.
+	shard.RowBatchSize = cfg.ExportSettings.RowBatchSize
 
 	cancellableCtx, cancelFunc := context.WithCancel(ctx)
 	defer cancelFunc()
@@ -83,7 +90,14 @@ func exportTable(
 
 	resourceWG, _ := errgroup.WithContext(ctx)
 	resourceWG.SetLimit(1)
-	itNum := 0
+	// Files of a resumed shard are numbered after the files which were
+	// already written.
+	itNum := checkpointer.fileNum(shard.ShardNum)
+	startItNum := itNum
+	var flushedCh chan flushedFile
+	if checkpointer != nil {
+		flushedCh = make(chan flushedFile, 1)
+	}
 	// Errors must be buffered, as pipe can exit without taking the error channel.
 	pipe := newCSVPipe(sqlRead, logger, cfg.FlushSize, cfg.FlushRows, shard.ShardNum, func(numRowsCh chan int) (io.WriteCloser, error) {
 		if err := resourceWG.Wait(); err != nil {
@@ -105,6 +119,13 @@ func exportTable(
 					return err
 				}
 				ret.Resources = append(ret.Resources, resource)
+				if flushedCh != nil {
+					checkpointer.flushed(ctx, shard.ShardNum, itNum, <-flushedCh)
+				}
+				queue.add(queuedResource{Resource: resource, shardNum: shard.ShardNum, fileNum: itNum})
+				if n := testingKnobs.FailedExportAfterNumFiles; n > 0 && itNum-startItNum >= n {
+					return errors.Newf("forced error after exporting %d files", n)
+				}
 				return nil
 			}(); err != nil {
 				logger.Err(err).Msgf("error during data store write")
@@ -118,6 +139,13 @@ func exportTable(
 		return wrappedWriter, nil
 	})
 
+	if cfg.Format == fileformat.Parquet {
+		pipe.newRecordWriter = func(out io.Writer) (recordWriter, error) {
+			return fileformat.NewParquetRecordWriter(out, table.Columns, table.ColumnOIDs[1])
+		}
+	}
+	pipe.flushedCh = flushedCh
+	pipe.numKeyCols = len(table.PrimaryKeyColumns)
 	// This is so we can simulate corrupted CSVs for testing.
 	pipe.testingKnobs = testingKnobs
 	err := pipe.Pipe(table.Name)
@@ -201,7 +201,7 @@ func TestFailedWriteToStore(t *testing.T) {
 
 							require.NoError(t, err)
 
-							tables, err := tableverify.VerifyCommonTables(ctx, conns, logger, dbTables.Verified)
+							tables, err := tableverify.VerifyCommonTables(ctx, conns, logger, dbTables.Verified, nil /* overrides */, nil /* transforms */, nil /* predicates */)
 							require.NoError(t, err)
 							require.Equal(t, 1, len(tables))
 							verifiedTable := tables[0].VerifiedTable
@@ -226,6 +226,7 @@ func TestFailedWriteToStore(t *testing.T) {
 									StartPKVals: []tree.Datum{tree.NewDInt(tree.DInt(1))},
 									EndPKVals:   []tree.Datum{},
 								},
+								nil /* checkpointer */, nil, /* queue */
 								tb.FetchTestingKnobs,
 							)
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -12,15 +13,21 @@ import (
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/compression"
 	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/fetch/datablobstorage"
 	"github.com/cockroachdb/molt/fetch/dataexport"
 	"github.com/cockroachdb/molt/fetch/fetchcontext"
 	"github.com/cockroachdb/molt/fetch/fetchmetrics"
 	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/cockroachdb/molt/fileformat"
 	"github.com/cockroachdb/molt/moltlogger"
 	"github.com/cockroachdb/molt/molttelemetry"
+	"github.com/cockroachdb/molt/retry"
+	"github.com/cockroachdb/molt/shardmode"
 	"github.com/cockroachdb/molt/testutils"
 	"github.com/cockroachdb/molt/utils"
+	"github.com/cockroachdb/molt/utils/transform"
+	"github.com/cockroachdb/molt/utils/typeconv"
 	"github.com/cockroachdb/molt/verify"
 	"github.com/cockroachdb/molt/verify/dbverify"
 	"github.com/cockroachdb/molt/verify/rowverify"
@@ -36,6 +43,7 @@ type Config struct {
 	FlushRows            int
 	Cleanup              bool
 	UseCopy              bool
+	CopyConcurrency      int
 	TableConcurrency     int
 	Shards               int
 	FetchID              string
@@ -55,8 +63,52 @@ type Config struct {
 	// If true, user prompting will be skipped and actions will be confirmed automatically.
 	NonInteractive bool
 
-	Compression    compression.Flag
+	Compression compression.Flag
+	// Format is the file format of the intermediate files written to the
+	// data store.
+	Format fileformat.Flag
+	// ShardMode determines how the boundaries between the shards of each
+	// table are chosen.
+	ShardMode shardmode.Flag
+	// WorkStealing allows idle export workers to split the remaining rows
+	// of slow shards.
+	WorkStealing bool
+	// PipelineImport imports the files of a table as they are written to
+	// the data store, instead of once the whole table has been exported.
+	PipelineImport bool
+	// TypeOverrides override the default mapping of source types to
+	// CockroachDB types when creating tables, exporting and verifying them.
+	TypeOverrides *typeconv.TypeOverrides
+	// NameMapping maps the names of the source tables to the names of the
+	// tables they are created in, loaded into and verified against on the
+	// target.
+	NameMapping utils.NameMapping
+	// Transforms transform the values of source columns before they are
+	// loaded into the target.
+	Transforms *transform.Transforms
+	// Predicates restrict the rows of the source tables which are fetched.
+	Predicates     utils.TablePredicates
 	ExportSettings dataexport.Settings
+	// RetrySettings are used to retry the imports and copies into the
+	// target which fail with transient errors, such as serialization
+	// failures and lost connections. The zero settings disable retries.
+	RetrySettings retry.Settings
+	// AtomicCopy copies each file in a single transaction with UseCopy,
+	// which allows the files which fail with transient errors to be retried.
+	// Files which are not copied atomically are not retried.
+	AtomicCopy bool
+	// VerifyAfterLoad verifies the rows of each table against the source
+	// once it has been loaded. The fetch fails if more than
+	// VerifyMaxMismatches inconsistent rows are found across all tables.
+	VerifyAfterLoad     bool
+	VerifyMaxMismatches int
+	// VerifySourceQuiesced asserts that the source is not written to during
+	// the fetch. It is required to verify tables after load on sources whose
+	// snapshot cannot be read again, as their rows are read as of now, and
+	// on continuations, which cannot read the snapshot of the original fetch.
+	VerifySourceQuiesced bool
+	// TableOverrides override the settings above for some tables.
+	TableOverrides []TableOverride
 }
 
 func Fetch(
@@ -113,7 +165,7 @@ func Fetch(
 		Msg("initial config")
 
 	logger.Info().Msgf("checking database details")
-	dbTables, err := dbverify.Verify(ctx, conns)
+	dbTables, err := dbverify.VerifyWithMapping(ctx, conns, cfg.NameMapping)
 	if err != nil {
 		return err
 	}
@@ -126,8 +178,8 @@ func Fetch(
 			Msgf("ignoring table as it is missing a definition on the source")
 	}
 
-	if !cfg.DropAndRecreateNewSchema {
-		for _, tbl := range dbTables.MissingTables {
+	for _, tbl := range dbTables.MissingTables {
+		if !cfg.ForTable(tbl.Name).DropAndRecreateNewSchema {
 			logger.Warn().
 				Str("table", tbl.SafeString()).
 				Msgf("ignoring table as it is missing a definition on the target")
@@ -140,8 +192,16 @@ func Fetch(
 			Msgf("found matching table")
 	}
 
-	if cfg.DropAndRecreateNewSchema {
-		tablesToProcess := dbTables.AllTablesFromSource()
+	// newDeferredDDL is the indexes and constraints of the tables recreated
+	// on the target, which are recorded once the fetch is set up.
+	var newDeferredDDL []*status.DeferredDDL
+	if cfg.recreatesAnyTable() {
+		var tablesToProcess []dbtable.DBTable
+		for _, t := range dbTables.AllTablesFromSource() {
+			if cfg.ForTable(t.Name).DropAndRecreateNewSchema {
+				tablesToProcess = append(tablesToProcess, t)
+			}
+		}
 		if len(tablesToProcess) == 0 {
 			logger.Info().Msgf("no tables to drop and recreate on the target")
 		} else {
@@ -152,7 +212,8 @@ func Fetch(
 			}
 
 			for _, t := range tablesToProcess {
-				dropTableStmt, err := GetDropTableStmt(t)
+				targetTable := TargetTable(conns[0], cfg.NameMapping, t)
+				dropTableStmt, err := GetDropTableStmt(targetTable)
 				if err != nil {
 					return err
 				}
@@ -162,7 +223,7 @@ func Fetch(
 				}
 				logger.Debug().Msgf("finished dropping table with %q", dropTableStmt)
 
-				createTableStmt, err := GetCreateTableStmt(ctx, logger, conns[0], t)
+				createTableStmt, err := GetCreateTableStmt(ctx, logger, conns[0], t, cfg.NameMapping, cfg.TypeOverrides)
 				if err != nil {
 					return err
 				}
@@ -172,18 +233,20 @@ func Fetch(
 				}
 				logger.Debug().Msgf("finished creating new table with %q", createTableStmt)
 
-				// TODO(janexing): maybe persist it in table?
-				droppedConstraints, err := GetConstraints(ctx, logger, conns[0], t)
+				// Indexes and constraints are created once the data of the
+				// table has been loaded, which is faster than maintaining them
+				// during the import.
+				ddls, err := GetDeferredDDL(ctx, logger, conns[0], t, cfg.NameMapping)
 				if err != nil {
 					return err
 				}
-				if len(droppedConstraints) != 0 {
-					consWithTable := constraintsWithTable{table: t, cons: droppedConstraints}
-					logger.Warn().Msgf("newly created schema doesn't contain the following constraints:\n%s", consWithTable.String())
+				if len(ddls) != 0 {
+					logger.Info().Msgf("deferring the creation of %d indexes and constraints of %s until its data is loaded", len(ddls), t.SafeString())
 				}
+				newDeferredDDL = append(newDeferredDDL, ddls...)
 			}
 			// Redo the verify.
-			dbTables, err = dbverify.Verify(ctx, conns)
+			dbTables, err = dbverify.VerifyWithMapping(ctx, conns, cfg.NameMapping)
 			if err != nil {
 				return errors.Wrap(err, "failed to re-verify tables after schema creation")
 			}
@@ -195,7 +258,7 @@ func Fetch(
 	}
 
 	logger.Info().Msgf("verifying common tables")
-	tables, err := tableverify.VerifyCommonTables(ctx, conns, logger, dbTables.Verified)
+	tables, err := tableverify.VerifyCommonTables(ctx, conns, logger, dbTables.Verified, cfg.TypeOverrides, cfg.Transforms, cfg.Predicates)
 	if err != nil {
 		return err
 	}
@@ -209,6 +272,14 @@ func Fetch(
 			logger.Err(err).Msgf("error closing export source")
 		}
 	}()
+	if _, ok := sqlSrc.(dataexport.SnapshotSource); cfg.VerifyAfterLoad && !ok && !cfg.VerifySourceQuiesced {
+		return errors.New("the source cannot be verified after load at the snapshot of the fetch, so rows written to it during the fetch would be reported as inconsistent; stop writes to the source and set --verify-after-load-source-quiesced")
+	}
+	// A continuation reads the source at a new snapshot, but the files it
+	// imports were exported at the snapshot of the original fetch.
+	if cfg.VerifyAfterLoad && cfg.FetchID != "" && !cfg.VerifySourceQuiesced {
+		return errors.New("a continued fetch cannot verify after load at the snapshot the files it imports were exported at, so rows written to the source since the original fetch would be reported as inconsistent; stop writes to the source and set --verify-after-load-source-quiesced")
+	}
 
 	// Wait until all the verification portions are completed first before deferring this.
 	// If verify fails, we don't need to report fetch_id.
@@ -233,6 +304,7 @@ func Fetch(
 		sync.Mutex
 		numImportedTables int
 		importedTables    []string
+		verified          loadVerificationResult
 	}
 	var stats statsMu
 
@@ -253,7 +325,18 @@ func Fetch(
 		return err
 	}
 
-	if IsImportCopyOnlyMode(cfg) && len(exceptionLogMapping) == 0 {
+	exportCheckpointMapping, err := getExportCheckpointMapping(ctx, cfg, targetPgxConn)
+	if err != nil {
+		return err
+	}
+	numResumableExports := 0
+	for _, checkpoints := range exportCheckpointMapping {
+		if hasUnfinishedShards(checkpoints) {
+			numResumableExports++
+		}
+	}
+
+	if IsImportCopyOnlyMode(cfg) && len(exceptionLogMapping) == 0 && numResumableExports == 0 {
 		errMsg := fmt.Sprintf("no exception logs that correspond to fetch-id of %s", cfg.FetchID)
 		if cfg.ContinuationToken != "" {
 			errMsg = contTokenNotFoundErr
@@ -283,6 +366,22 @@ func Fetch(
 		if err := status.DeleteAllExceptionLogs(ctx, targetPgxConn); err != nil {
 			return err
 		}
+		if err := status.DeleteAllExportCheckpoints(ctx, targetPgxConn); err != nil {
+			return err
+		}
+		if err := status.DeleteAllDeferredDDL(ctx, targetPgxConn); err != nil {
+			return err
+		}
+	}
+
+	deferredDDLMapping, err := getDeferredDDLMapping(ctx, fetchStatus.ID, targetPgxConn, newDeferredDDL)
+	if err != nil {
+		return err
+	}
+
+	tableStatusMapping, err := initTableStatuses(ctx, cfg, targetPgxConn, fetchStatus.ID, tables)
+	if err != nil {
+		return err
 	}
 
 	workCh := make(chan tableverify.Result)
@@ -301,7 +400,8 @@ func Fetch(
 					return err
 				}
 				defer shardClone.Close(ctx)
-				tableShards, err := verify.ShardTable(ctx, shardClone, table, nil, cfg.Shards)
+				tableCfg := cfg.ForTable(table.Name)
+				tableShards, err := verify.ShardTable(ctx, shardClone, table, nil, tableCfg.Shards, tableCfg.ShardMode)
 				if err != nil {
 					return errors.Wrapf(err, "error splitting tables")
 				}
@@ -311,21 +411,32 @@ func Fetch(
 					relevantExceptionLog = v
 				}
 
-				// We want to run the fetch in only two cases:
+				exportCheckpoints := exportCheckpointMapping[table.SafeString()]
+
+				// We want to run the fetch in only three cases:
 				// 1. Export + import mode combined (when fetch ID is not passed in; means new fetch)
 				// 2. When the fetch ID is passed in and exception log is not nil, which means it is a table we want to continue from.
+				// 3. When the fetch ID is passed in and the export of the table did not finish, which means we resume the export.
 				// This means we want to skip if we are trying to continue but there is no entry that specifies where to continue from.
-				if (cfg.FetchID != "" && relevantExceptionLog != nil) || (cfg.FetchID == "") {
-					if err := fetchTable(ctx, cfg, logger, conns, blobStore, sqlSrc, table, tableShards, relevantExceptionLog, isClearContinuationTokenMode, testingKnobs); err != nil {
+				var verified loadVerificationResult
+				if (cfg.FetchID != "" && (relevantExceptionLog != nil || hasUnfinishedShards(exportCheckpoints))) || (cfg.FetchID == "") {
+					if err := fetchTable(ctx, tableCfg, logger, conns, blobStore, sqlSrc, table, tableShards, shardClone, relevantExceptionLog, exportCheckpoints, tableStatusMapping[table.SafeString()], isClearContinuationTokenMode, &verified, testingKnobs); err != nil {
 						return err
 					}
 				} else {
 					logger.Warn().Msgf("skipping fetch for %s", table.SafeString())
 				}
 
+				// Foreign keys are only created once every table they may
+				// reference has been loaded.
+				if err := applyTableDeferredDDL(ctx, logger, conns[1], deferredDDLMapping[table.SafeString()], false /* foreignKeys */); err != nil {
+					return err
+				}
+
 				stats.Lock()
 				stats.numImportedTables++
 				stats.importedTables = append(stats.importedTables, table.SafeString())
+				stats.verified.add(verified)
 				stats.Unlock()
 			}
 		})
@@ -342,15 +453,33 @@ func Fetch(
 		return err
 	}
 
+	var foreignKeys []*status.DeferredDDL
+	for _, ddls := range deferredDDLMapping {
+		foreignKeys = append(foreignKeys, ddls...)
+	}
+	if err := applyTableDeferredDDL(ctx, logger, conns[1], foreignKeys, true /* foreignKeys */); err != nil {
+		return err
+	}
+	logDeferredDDLSummary(summaryLogger, deferredDDLMapping)
+
 	ovrDuration := utils.MaybeFormatDurationForTest(cfg.TestOnly, timer.ObserveDuration())
-	summaryLogger.Info().
+	summaryEvent := summaryLogger.Info().
 		Str("fetch_id", utils.MaybeFormatFetchID(cfg.TestOnly, fetchStatus.ID.String())).
 		Int("num_tables", stats.numImportedTables).
 		Strs("tables", stats.importedTables).
 		Str("cdc_cursor", utils.MaybeFormatCDCCursor(cfg.TestOnly, sqlSrc.CDCCursor())).
 		Dur("net_duration_ms", ovrDuration).
-		Str("net_duration", utils.FormatDurationToTimeString(ovrDuration)).
-		Msgf("fetch complete")
+		Str("net_duration", utils.FormatDurationToTimeString(ovrDuration))
+	if cfg.VerifyAfterLoad {
+		summaryEvent = summaryEvent.
+			Int("num_missing", stats.verified.NumMissing).
+			Int("num_mismatch", stats.verified.NumMismatching).
+			Int("num_extraneous", stats.verified.NumExtraneous)
+	}
+	summaryEvent.Msgf("fetch complete")
+	if cfg.VerifyAfterLoad {
+		return checkLoadVerification(stats.verified, cfg.VerifyMaxMismatches)
+	}
 	return nil
 }
 
@@ -361,7 +490,7 @@ func truncateTable(
 	truncateTargetTableConn dbconn.Conn,
 ) error {
 	logger.Info().Msgf("truncating table")
-	_, err := truncateTargetTableConn.(*dbconn.PGConn).Conn.Exec(ctx, "TRUNCATE TABLE "+table.SafeString())
+	_, err := truncateTargetTableConn.(*dbconn.PGConn).Conn.Exec(ctx, "TRUNCATE TABLE "+table.Target().SafeString())
 	if err != nil {
 		return errors.Wrap(err, "failed executing the TRUNCATE TABLE statement")
 	}
@@ -371,7 +500,10 @@ func truncateTable(
 
 // Note that if `ExceptionLog` is not nil, then that means
 // there is an exception log and import/copy only mode
-// was specified.
+// was specified. If exportCheckpoints contains shards which
+// were not completely exported, the export resumes from them.
+// The progress of the table is recorded in the table status
+// ledger, carrying on from tableStatus if it is not nil.
 func fetchTable(
 	ctx context.Context,
 	cfg Config,
@@ -381,8 +513,12 @@ func fetchTable(
 	sqlSrc dataexport.Source,
 	table tableverify.Result,
 	shards []rowverify.TableShard,
+	shardConn dbconn.Conn,
 	exceptionLog *status.ExceptionLog,
+	exportCheckpoints []*status.ExportCheckpoint,
+	tableStatus *status.TableStatus,
 	isClearContinuationTokenMode bool,
+	verified *loadVerificationResult,
 	testingKnobs testutils.FetchTestingKnobs,
 ) (retErr error) {
 	tableStartTime := time.Now()
@@ -390,6 +526,28 @@ func fetchTable(
 	fetchmetrics.ExportedRows.WithLabelValues(table.SafeString())
 	fetchmetrics.ImportedRows.WithLabelValues(table.SafeString())
 
+	statusConn, err := conns[1].Clone(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to clone the target connection for the table status")
+	}
+	defer func() {
+		if err := statusConn.Close(ctx); err != nil {
+			logger.Err(err).Msg("failed to close connection for the table status")
+		}
+	}()
+	statusRecorder := newTableStatusRecorder(
+		logger,
+		statusConn.(*dbconn.PGConn).Conn,
+		fetchcontext.GetFetchContextData(ctx).RunID,
+		table.VerifiedTable,
+		tableStatus,
+	)
+	defer func() {
+		if retErr != nil {
+			statusRecorder.failed(ctx, retErr)
+		}
+	}()
+
 	for _, col := range table.MismatchingTableDefinitions {
 		logger.Warn().
 			Str("reason", col.Info).
@@ -397,8 +555,12 @@ func fetchTable(
 	}
 	if !table.RowVerifiable {
 		logger.Error().Msgf("table %s do not have matching primary keys, cannot migrate", table.SafeString())
+		statusRecorder.failed(ctx, errors.Newf("table %s do not have matching primary keys", table.SafeString()))
 		return nil
 	}
+	// The shards are resumed and split while exporting, so the ones the table
+	// is verified with once loaded are kept aside.
+	verifyShards := loadVerificationShards(shards)
 
 	targetTableConnCopy, err := conns[1].Clone(ctx)
 	if err != nil {
@@ -422,37 +584,154 @@ func fetchTable(
 
 	logger.Info().Msgf("data extraction phase starting")
 
+	// Export progress is checkpointed so that it can be resumed.
+	checkpointConn, err := conns[1].Clone(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to clone the target connection for export checkpoints")
+	}
+	defer func() {
+		if err := checkpointConn.Close(ctx); err != nil {
+			logger.Err(err).Msg("failed to close connection for export checkpoints")
+		}
+	}()
+	checkpointer := newExportCheckpointer(
+		logger,
+		checkpointConn.(*dbconn.PGConn).Conn,
+		fetchcontext.GetFetchContextData(ctx).RunID,
+		table.VerifiedTable,
+		exportCheckpoints,
+		!blobStore.CanBeTarget(), /* direct */
+	)
+	resumeExport := hasUnfinishedShards(exportCheckpoints)
+	// Tables which are only imported keep the export progress recorded by
+	// the previous attempt.
+	exportsTable := exceptionLog == nil || cfg.FetchID == "" || resumeExport
+	statusRecorder.update(ctx, func(s *status.TableStatus) {
+		s.State = status.TableStateImporting
+		if exportsTable {
+			s.State = status.TableStateExporting
+			s.ExportedRows, s.ExportedFiles, s.ExportDuration = 0, 0, 0
+		}
+		s.Error = ""
+		s.CDCCursor = sqlSrc.CDCCursor()
+		s.StartedAt = time.Now().UTC()
+	})
+
 	var e exportResult
+	// queue hands out the exported files to import. It is nil if the data
+	// is copied directly to the target.
+	var queue *resourceQueue
+	// runExport exports the table, if needed, adding each file to the queue
+	// as it is written.
+	var runExport func(ctx context.Context) error
 	// In the case that exception log is nil or fetch id is empty,
 	// this means that we want to export the table because it means
 	// we want export + copy mode.
-	if exceptionLog == nil || cfg.FetchID == "" {
+	if exportsTable {
 		// Set up the upper and lower bounds for start/end min max comparisons
 		e.StartTime = time.Unix(math.MaxInt, 0)
 		e.EndTime = time.Unix(math.MinInt, 0)
-		orderedResults := make([]exportResult, len(shards))
-		wg, _ := errgroup.WithContext(ctx)
-		for i, s := range shards {
-			it, sh := i, s
-			wg.Go(func() error {
-				er, err := exportTable(ctx, cfg, logger, sqlSrc, blobStore, table.VerifiedTable, sh, testingKnobs)
-				if err != nil {
-					return err
-				}
-				orderedResults[it] = er
-				return nil
-			})
+		var committed []queuedResource
+		if resumeExport {
+			var err error
+			if shards, err = checkpointer.resumeShards(); err != nil {
+				return err
+			}
+			// Files written by the previous attempt up to the checkpoint of
+			// each shard are imported along with the newly exported files.
+			// When copying directly, every row up to the checkpoint has
+			// already been copied and there are no files.
+			rsc, err := blobStore.ListFromContinuationPoint(ctx, table.VerifiedTable, "")
+			if err != nil {
+				return err
+			}
+			if committed, err = checkpointer.committedResources(rsc); err != nil {
+				return err
+			}
+			for _, r := range committed {
+				e.Resources = append(e.Resources, r.Resource)
+			}
+			e.NumRows = checkpointer.committedRows
+			logger.Info().
+				Int("num_shards", len(shards)).
+				Int("num_committed_files", len(committed)).
+				Msgf("resuming export of table %s from checkpoints", table.SafeString())
 		}
-		if err = wg.Wait(); err != nil {
-			return err
+		if blobStore.CanBeTarget() {
+			shardNums := make([]int, len(shards))
+			for i, sh := range shards {
+				shardNums[i] = sh.ShardNum
+			}
+			queue = newResourceQueue(shardNums)
+			for _, r := range committed {
+				if !checkpointer.wasImported(r) {
+					queue.add(r)
+				}
+			}
 		}
-		for _, er := range orderedResults {
-			e.StartTime = time.Unix(min(e.StartTime.Unix(), er.StartTime.Unix()), 0)
-			e.EndTime = time.Unix(max(e.EndTime.Unix(), er.EndTime.Unix()), 0)
-			e.NumRows += er.NumRows
-			e.Resources = append(e.Resources, er.Resources...)
+		runExport = func(ctx context.Context) error {
+			numWorkers := len(shards)
+			if cfg.WorkStealing {
+				// Use the full export concurrency, as idle workers split the
+				// remaining rows of slow shards.
+				numWorkers = max(numWorkers, cfg.Shards)
+			}
+			scheduler := verify.NewShardScheduler(shardConn, logger, shards, cfg.WorkStealing)
+			scheduler.OnSplit(func(ctx context.Context, shard rowverify.TableShard, split rowverify.TableShard) {
+				// Files of the new shard are imported after those of the
+				// shard it was split from.
+				queue.startShard(split.ShardNum)
+				checkpointer.split(ctx, shard, split)
+			})
+			// Shards created by splitting slow shards are numbered after the
+			// initial shards, so results are keyed by shard number.
+			var resultsMu sync.Mutex
+			resultsByShard := make(map[int]exportResult)
+			wg, _ := errgroup.WithContext(ctx)
+			for i := 0; i < numWorkers; i++ {
+				wg.Go(func() error {
+					for {
+						sh, ok := scheduler.Next(ctx)
+						if !ok {
+							return nil
+						}
+						if err := checkpointer.startShard(ctx, sh); err != nil {
+							scheduler.Done(sh)
+							return err
+						}
+						er, err := exportTable(ctx, cfg, logger, sqlSrc, blobStore, table.VerifiedTable, sh, checkpointer, queue, testingKnobs)
+						scheduler.Done(sh)
+						if err != nil {
+							return err
+						}
+						// The shard is only marked as done once it can no longer
+						// be split.
+						checkpointer.done(ctx, sh.ShardNum)
+						queue.finishShard(sh.ShardNum)
+						resultsMu.Lock()
+						resultsByShard[sh.ShardNum] = er
+						resultsMu.Unlock()
+					}
+				})
+			}
+			if err := wg.Wait(); err != nil {
+				return err
+			}
+			shardNums := make([]int, 0, len(resultsByShard))
+			for shardNum := range resultsByShard {
+				shardNums = append(shardNums, shardNum)
+			}
+			sort.Ints(shardNums)
+			for _, shardNum := range shardNums {
+				er := resultsByShard[shardNum]
+				e.StartTime = time.Unix(min(e.StartTime.Unix(), er.StartTime.Unix()), 0)
+				e.EndTime = time.Unix(max(e.EndTime.Unix(), er.EndTime.Unix()), 0)
+				e.NumRows += er.NumRows
+				e.Resources = append(e.Resources, er.Resources...)
+			}
+			queue.close()
+			return nil
 		}
-
 	} else {
 		if exceptionLog.FileName == "" {
 			logger.Warn().Msgf("skipping table %s because no file name is present in the exception log", table.SafeString())
@@ -471,6 +750,17 @@ func fetchTable(
 		if len(e.Resources) == 0 {
 			return errors.Newf("exported resources for table %s is empty, please make sure you did not accidentally delete from the intermediate store", table.SafeString())
 		}
+		queued := make([]queuedResource, 0, len(rsc))
+		for _, r := range rsc {
+			qr, err := newQueuedResource(r)
+			if err != nil {
+				return err
+			}
+			if !checkpointer.wasImported(qr) {
+				queued = append(queued, qr)
+			}
+		}
+		queue = newClosedResourceQueue(queued)
 	}
 	// We actually need to skip the cleanup for something that has an error
 	// On a continuation run we can cleanup so long as it's successful.
@@ -494,15 +784,74 @@ func fetchTable(
 		}()
 	}
 
-	// TODO: consider if we want to skip this portion since we don't export anything....
-	exportDuration := utils.MaybeFormatDurationForTest(cfg.TestOnly, e.EndTime.Sub(e.StartTime))
 	summaryLogger := moltlogger.GetSummaryLogger(logger)
-	summaryLogger.Info().
-		Int("num_rows", e.NumRows).
-		Dur("export_duration_ms", exportDuration).
-		Str("export_duration", utils.FormatDurationToTimeString(exportDuration)).
-		Msgf("data extraction from source complete")
-	fetchmetrics.TableExportDuration.WithLabelValues(table.SafeString()).Set(float64(exportDuration.Milliseconds()))
+	// verifyLoad verifies the rows of the table against the source once it
+	// has been loaded, if requested.
+	verifyLoad := func() error {
+		if !cfg.VerifyAfterLoad {
+			return nil
+		}
+		statusRecorder.update(ctx, func(s *status.TableStatus) {
+			s.State = status.TableStateVerifying
+		})
+		logger.Info().Msgf("verifying rows of table after load")
+		r, err := verifyLoadedTable(ctx, logger, conns, sqlSrc, verifyShards, cfg.Shards, cfg.ExportSettings.RowBatchSize)
+		if err != nil {
+			return err
+		}
+		*verified = r
+		summaryLogger.Info().
+			Int("num_missing", r.NumMissing).
+			Int("num_mismatch", r.NumMismatching).
+			Int("num_extraneous", r.NumExtraneous).
+			Msgf("verification of table after load complete")
+		statusRecorder.update(ctx, func(s *status.TableStatus) {
+			s.Verified = true
+			s.MissingRows = r.NumMissing
+			s.MismatchingRows = r.NumMismatching
+			s.ExtraneousRows = r.NumExtraneous
+		})
+		return nil
+	}
+	var exportDuration time.Duration
+	// TODO: consider if we want to skip this portion since we don't export anything....
+	logExportSummary := func() {
+		exportDuration = utils.MaybeFormatDurationForTest(cfg.TestOnly, e.EndTime.Sub(e.StartTime))
+		summaryLogger.Info().
+			Int("num_rows", e.NumRows).
+			Dur("export_duration_ms", exportDuration).
+			Str("export_duration", utils.FormatDurationToTimeString(exportDuration)).
+			Msgf("data extraction from source complete")
+		fetchmetrics.TableExportDuration.WithLabelValues(table.SafeString()).Set(float64(exportDuration.Milliseconds()))
+		if !exportsTable {
+			return
+		}
+		statusRecorder.update(ctx, func(s *status.TableStatus) {
+			s.ExportedRows = e.NumRows
+			s.ExportedFiles = len(e.Resources)
+			s.ExportDuration = e.EndTime.Sub(e.StartTime)
+			if blobStore.CanBeTarget() {
+				s.State = status.TableStateImporting
+				return
+			}
+			// Rows copied directly are imported as they are exported.
+			s.State = status.TableStateDone
+			s.ImportedRows = e.NumRows
+			s.ImportedFiles = len(e.Resources)
+		})
+	}
+
+	// Files are imported as they are exported in pipelined mode, and
+	// otherwise once the whole table has been exported.
+	pipelined := cfg.PipelineImport && runExport != nil && blobStore.CanBeTarget()
+	if !pipelined {
+		if runExport != nil {
+			if err := runExport(ctx); err != nil {
+				return err
+			}
+		}
+		logExportSummary()
+	}
 
 	if blobStore.CanBeTarget() {
 		var importDuration time.Duration
@@ -527,15 +876,23 @@ func fetchTable(
 			}
 		}()
 
-		if err := func() error {
+		// Tables whose export was resumed have no exception log to update
+		// if the import fails, so a new one is created.
+		createExceptionLog := isClearContinuationTokenMode || exceptionLog == nil
+		// recordImport records the rows imported by this attempt, which add
+		// to those imported by previous attempts as files which were already
+		// imported are skipped.
+		recordImport := func(numRows, numFiles int, duration time.Duration) {
+			statusRecorder.update(ctx, func(s *status.TableStatus) {
+				s.ImportedRows += numRows
+				s.ImportedFiles += numFiles
+				s.ImportDuration = duration
+			})
+		}
+		runImport := func(ctx context.Context) error {
 			logger.Info().
 				Msgf("starting data import on target")
 
-			isLocal := false
-			if len(e.Resources) > 0 {
-				isLocal = e.Resources[0].IsLocal()
-			}
-
 			if !cfg.UseCopy {
 				go func() {
 					err := reportImportTableProgress(ctx,
@@ -549,22 +906,46 @@ func fetchTable(
 					}
 				}()
 
-				r, err := importTable(ctx, cfg, targetTableConnCopy, logger, table.VerifiedTable, e.Resources, isLocal, isClearContinuationTokenMode, exceptionLog)
+				r, err := importTable(ctx, cfg, targetTableConnCopy, logger, table.VerifiedTable, queue, checkpointer, createExceptionLog, exceptionLog)
 				if err != nil {
+					recordImport(r.NumRows, r.NumFiles, time.Since(r.StartTime))
 					return err
 				}
+				recordImport(r.NumRows, r.NumFiles, r.EndTime.Sub(r.StartTime))
 				importDuration = utils.MaybeFormatDurationForTest(cfg.TestOnly, r.EndTime.Sub(r.StartTime))
 			} else {
-				r, err := Copy(ctx, targetTableConnCopy, logger, table.VerifiedTable, e.Resources, isLocal, isClearContinuationTokenMode, exceptionLog)
+				r, err := Copy(ctx, targetTableConnCopy, logger, table.VerifiedTable, cfg.CopyConcurrency, cfg.AtomicCopy, cfg.RetrySettings, queue, checkpointer, createExceptionLog, exceptionLog)
 				if err != nil {
+					recordImport(r.NumRows, r.NumFiles, time.Since(r.StartTime))
 					return err
 				}
+				recordImport(r.NumRows, r.NumFiles, r.EndTime.Sub(r.StartTime))
 				importDuration = utils.MaybeFormatDurationForTest(cfg.TestOnly, r.EndTime.Sub(r.StartTime))
 			}
 			return nil
-		}(); err != nil {
+		}
+		if pipelined {
+			// A failure of either the export or the import stops the other.
+			wg, wgCtx := errgroup.WithContext(ctx)
+			wg.Go(func() error {
+				if err := runExport(wgCtx); err != nil {
+					return err
+				}
+				logExportSummary()
+				return nil
+			})
+			wg.Go(func() error {
+				return runImport(wgCtx)
+			})
+			if err := wg.Wait(); err != nil {
+				return errors.CombineErrors(err, targetTableConnCopy.Close(ctx))
+			}
+		} else if err := runImport(ctx); err != nil {
 			return errors.CombineErrors(err, targetTableConnCopy.Close(ctx))
 		}
+		if err := verifyLoad(); err != nil {
+			return err
+		}
 
 		netDuration := utils.MaybeFormatDurationForTest(cfg.TestOnly, time.Since(tableStartTime))
 		cdcCursor := utils.MaybeFormatCDCCursor(cfg.TestOnly, sqlSrc.CDCCursor())
@@ -580,9 +961,21 @@ func fetchTable(
 			Msgf("data import on target for table complete")
 		fetchmetrics.TableImportDuration.WithLabelValues(table.SafeString()).Set(float64(importDuration.Milliseconds()))
 		fetchmetrics.TableOverallDuration.WithLabelValues(table.SafeString()).Set(float64(netDuration.Milliseconds()))
+		statusRecorder.update(ctx, func(s *status.TableStatus) {
+			s.State = status.TableStateDone
+		})
 
 		return nil
 	}
+	// Rows copied directly were loaded as they were exported.
+	if exportsTable && cfg.VerifyAfterLoad {
+		if err := verifyLoad(); err != nil {
+			return err
+		}
+		statusRecorder.update(ctx, func(s *status.TableStatus) {
+			s.State = status.TableStateDone
+		})
+	}
 	return nil
 }
 
@@ -672,6 +1065,51 @@ func getExceptionLogMapping(
 	return exceptionLogMapping, nil
 }
 
+// getExportCheckpointMapping returns the export checkpoints of each table
+// written by the fetch being continued, keyed by table.
+func getExportCheckpointMapping(
+	ctx context.Context, cfg Config, targetPgxConn *pgx.Conn,
+) (map[string][]*status.ExportCheckpoint, error) {
+	// Continuing from a single continuation token only imports the files of
+	// its table.
+	if !IsImportCopyOnlyMode(cfg) || strings.TrimSpace(cfg.ContinuationToken) != "" {
+		return nil, nil
+	}
+	checkpoints, err := status.GetAllExportCheckpointsByFetchID(ctx, targetPgxConn, cfg.FetchID)
+	if err != nil {
+		return nil, err
+	}
+	return status.GetTableSchemaToExportCheckpoints(checkpoints), nil
+}
+
+// getDeferredDDLMapping records the indexes and constraints of the tables
+// recreated by the fetch, and returns every index and constraint of the
+// fetch, keyed by table. When continuing a fetch, this includes those which
+// were not applied by the previous attempt.
+func getDeferredDDLMapping(
+	ctx context.Context,
+	fetchID uuid.UUID,
+	targetPgxConn *pgx.Conn,
+	newDeferredDDL []*status.DeferredDDL,
+) (map[string][]*status.DeferredDDL, error) {
+	for _, d := range newDeferredDDL {
+		d.FetchID = fetchID
+		if err := d.CreateEntry(ctx, targetPgxConn); err != nil {
+			return nil, errors.Wrapf(err, "failed to record %s %s of table %s.%s", d.Kind, d.Name, d.Schema, d.Table)
+		}
+	}
+	ddls, err := status.GetAllDeferredDDLByFetchID(ctx, targetPgxConn, fetchID.String())
+	if err != nil {
+		return nil, err
+	}
+	mapping := map[string][]*status.DeferredDDL{}
+	for _, d := range ddls {
+		key := fmt.Sprintf("%s.%s", d.Schema, d.Table)
+		mapping[key] = append(mapping[key], d)
+	}
+	return mapping, nil
+}
+
 func IsImportCopyOnlyMode(cfg Config) bool {
 	return strings.TrimSpace(cfg.FetchID) != ""
 }
@@ -13,6 +13,8 @@ import (
 	"testing"
 
 	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -25,6 +27,8 @@ import (
 	"github.com/cockroachdb/molt/fetch/datablobstorage"
 	"github.com/cockroachdb/molt/fetch/dataexport"
 	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/cockroachdb/molt/fileformat"
+	"github.com/cockroachdb/molt/retry"
 	"github.com/cockroachdb/molt/testutils"
 	"github.com/cockroachdb/molt/utils"
 	"github.com/rs/zerolog"
@@ -101,10 +105,14 @@ func TestDataDriven(t *testing.T) {
 							t.Errorf("table filter not specified")
 						}
 						showDroppedConstraints := false
+						showDeferredDDL := false
 						for _, arg := range d.CmdArgs {
 							if arg.Key == "show-dropped-constraints" && arg.Vals[0] == "true" {
 								showDroppedConstraints = true
 							}
+							if arg.Key == "show-deferred-ddl" && arg.Vals[0] == "true" {
+								showDeferredDDL = true
+							}
 						}
 						return func() string {
 							var stmts []string
@@ -128,7 +136,7 @@ func TestDataDriven(t *testing.T) {
 
 							for _, missingTable := range missingTables {
 								srcConn := conns[0]
-								stmt, err := GetCreateTableStmt(ctx, logger, srcConn, missingTable.DBTable)
+								stmt, err := GetCreateTableStmt(ctx, logger, srcConn, missingTable.DBTable, utils.NameMapping{}, nil /* overrides */)
 								if err != nil {
 									stmts = append(stmts, err.Error())
 									// Somehow we need to recreate the connection, otherwise pg will show "conn busy" error.
@@ -148,9 +156,40 @@ func TestDataDriven(t *testing.T) {
 										stmts = append(stmts, droppedConstraints...)
 									}
 								}
+								if showDeferredDDL {
+									stmts = append(stmts, `------ DEFERRED DDL ------`)
+									ddls, err := GetDeferredDDL(ctx, logger, conns[0], missingTable.DBTable, utils.NameMapping{})
+									if err != nil {
+										stmts = append(stmts, err.Error())
+									}
+									for _, ddl := range ddls {
+										if ddl.State == status.DDLStateFailed {
+											stmts = append(stmts, fmt.Sprintf("%s: %s", ddl.Name, ddl.Message))
+										} else {
+											stmts = append(stmts, fmt.Sprintf("%s: %s", ddl.Name, ddl.Stmt))
+										}
+									}
+								}
 							}
 							return strings.Join(stmts, "\n")
 						}()
+					case "convert-schema":
+						filter := utils.DefaultFilterConfig()
+						for _, arg := range d.CmdArgs {
+							if arg.Key == "table-filter" {
+								filter.TableFilter = arg.Vals[0]
+							}
+						}
+						script, err := ConvertSchema(ctx, logger, conns[0], filter, utils.NameMapping{}, nil /* overrides */)
+						if err != nil {
+							script += fmt.Sprintf("error: %s\n", err)
+						}
+						return script
+					case "apply-schema":
+						if err := ApplySchema(ctx, logger, conns[1].(*dbconn.PGConn), d.Input, d.HasArg("post-load")); err != nil {
+							return fmt.Sprintf("error: %s\n", err)
+						}
+						return ""
 					case "exec":
 						return testutils.ExecConnTestdata(t, d, conns)
 					case "query":
@@ -161,8 +200,10 @@ func TestDataDriven(t *testing.T) {
 						useCopy := false
 						direct := false
 						compress := false
+						parquet := false
 						corruptCSVFile := false
 						failedEstablishConnForExport := false
+						failedExportAfterNumFiles := 0
 						fetchId := ""
 						passedInDir := ""
 						cleanup := false
@@ -174,6 +215,11 @@ func TestDataDriven(t *testing.T) {
 						bucketPath := ""
 						sDetails := storeDetails{}
 						numShards := 1
+						pipelineImport := false
+						copyConcurrency := 1
+						verifyAfterLoad := false
+						sourceQuiesced := false
+						maxMismatches := 0
 
 						for _, cmd := range d.CmdArgs {
 							switch cmd.Key {
@@ -185,10 +231,15 @@ func TestDataDriven(t *testing.T) {
 								direct = true
 							case "compress":
 								compress = true
+							case "parquet":
+								parquet = true
 							case "corrupt-csv":
 								corruptCSVFile = true
 							case "failed-conn-export":
 								failedEstablishConnForExport = true
+							case "failed-export-after-files":
+								failedExportAfterNumFiles, err = strconv.Atoi(cmd.Vals[0])
+								require.NoError(t, err)
 							case "fetch-id":
 								fetchId = cmd.Vals[0]
 							case "store-dir":
@@ -221,6 +272,18 @@ func TestDataDriven(t *testing.T) {
 									subpath: subPath,
 									url:     url,
 								}
+							case "pipeline-import":
+								pipelineImport = true
+							case "copy-concurrency":
+								copyConcurrency, err = strconv.Atoi(cmd.Vals[0])
+								require.NoError(t, err)
+							case "verify-after-load":
+								verifyAfterLoad = true
+							case "source-quiesced":
+								sourceQuiesced = true
+							case "max-mismatches":
+								maxMismatches, err = strconv.Atoi(cmd.Vals[0])
+								require.NoError(t, err)
 							case "shards":
 								s := cmd.Vals[0]
 								numShards, err = strconv.Atoi(s)
@@ -253,7 +316,7 @@ func TestDataDriven(t *testing.T) {
 							}
 						}()
 						if direct {
-							src = datablobstorage.NewCopyCRDBDirect(logger, conns[1].(*dbconn.PGConn).Conn)
+							src = datablobstorage.NewCopyCRDBDirect(logger, conns[1].(*dbconn.PGConn).Conn, retry.Settings{})
 						} else if bucketPath != "" {
 							switch sDetails.scheme {
 							case "s3", "S3":
@@ -262,8 +325,11 @@ func TestDataDriven(t *testing.T) {
 							case "gs", "GS":
 								gcpClient := createGCPBucket(t, ctx, sDetails)
 								src = datablobstorage.NewGCPStore(logger, gcpClient, nil, sDetails.host, sDetails.subpath, true)
+							case "azure", "AZURE":
+								azureClient, creds := createAzureContainer(t, ctx, sDetails)
+								src = datablobstorage.NewAzureStore(logger, azureClient, creds, sDetails.host, sDetails.subpath, true)
 							default:
-								require.Contains(t, []string{"s3", "S3", "gs", "GS"}, sDetails.scheme)
+								require.Contains(t, []string{"s3", "S3", "gs", "GS", "azure", "AZURE"}, sDetails.scheme)
 							}
 						} else {
 							t.Logf("stored in local dir %q", dir)
@@ -279,6 +345,11 @@ func TestDataDriven(t *testing.T) {
 							compressionFlag = compression.GZIP
 						}
 
+						formatFlag := fileformat.CSV
+						if parquet {
+							formatFlag = fileformat.Parquet
+						}
+
 						knobs := testutils.FetchTestingKnobs{}
 						if corruptCSVFile {
 							knobs.TriggerCorruptCSVFile = true
@@ -286,6 +357,7 @@ func TestDataDriven(t *testing.T) {
 						if failedEstablishConnForExport {
 							knobs.FailedEstablishSrcConnForExport = true
 						}
+						knobs.FailedExportAfterNumFiles = failedExportAfterNumFiles
 
 						err = Fetch(
 							ctx,
@@ -297,6 +369,7 @@ func TestDataDriven(t *testing.T) {
 									RowBatchSize: 2,
 								},
 								Compression:          compressionFlag,
+								Format:               formatFlag,
 								FetchID:              fetchId,
 								Cleanup:              cleanup,
 								ContinuationToken:    continuationToken,
@@ -304,6 +377,11 @@ func TestDataDriven(t *testing.T) {
 								FlushRows:            flushRows,
 								NonInteractive:       true,
 								Shards:               numShards,
+								PipelineImport:       pipelineImport,
+								CopyConcurrency:      copyConcurrency,
+								VerifyAfterLoad:      verifyAfterLoad,
+								VerifyMaxMismatches:  maxMismatches,
+								VerifySourceQuiesced: sourceQuiesced,
 							},
 							logger,
 							conns,
@@ -330,13 +408,15 @@ func TestDataDriven(t *testing.T) {
 						}
 						require.NoError(t, err)
 						return ""
-					case "list-tokens":
+					case "list-tokens", "delete-tokens":
 						// We don't want to clean the database in this case.
 						targetConn := conns[1]
 						targetPgConn, valid := targetConn.(*dbconn.PGConn)
 						require.Equal(t, true, valid)
 
 						numResults := 5
+						filter := status.DefaultExceptionLogFilter()
+						asJSON := false
 
 						for _, cmd := range d.CmdArgs {
 							switch cmd.Key {
@@ -344,12 +424,24 @@ func TestDataDriven(t *testing.T) {
 								res, err := strconv.Atoi(cmd.Vals[0])
 								require.NoError(t, err)
 								numResults = res
+							case "stage":
+								filter.Stage = cmd.Vals[0]
+							case "table":
+								filter.Tables.Include = append(filter.Tables.Include, cmd.Vals...)
+							case "json":
+								asJSON = true
 							default:
 								t.Errorf("unknown key %s", cmd.Key)
 							}
 						}
 
-						val, err := ListContinuationTokens(ctx, true /*testOnly*/, targetPgConn.Conn, numResults)
+						var val string
+						var err error
+						if d.Cmd == "list-tokens" {
+							val, err = ListContinuationTokens(ctx, true /*testOnly*/, targetPgConn.Conn, numResults, filter, asJSON)
+						} else {
+							val, err = DeleteContinuationTokens(ctx, true /*testOnly*/, targetPgConn.Conn, filter, asJSON)
+						}
 
 						if !expectError {
 							require.NoError(t, err)
@@ -427,6 +519,28 @@ func createGCPBucket(t *testing.T, ctx context.Context, sDetails storeDetails) *
 	return gcpClient
 }
 
+func createAzureContainer(
+	t *testing.T, ctx context.Context, sDetails storeDetails,
+) (*azblob.Client, datablobstorage.AzureCredentials) {
+	creds := datablobstorage.AzureCredentials{
+		AccountName: datablobstorage.AzuriteAccountName,
+		AccountKey:  datablobstorage.AzuriteAccountKey,
+	}
+	azureClient, err := datablobstorage.NewAzureClient(creds, true /* useLocalInfra */)
+	require.NoError(t, err)
+
+	// Create the test container.
+	if _, err := azureClient.CreateContainer(ctx, sDetails.host, nil); err != nil {
+		if bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+			// Skip creating the container.
+			fmt.Printf("skipping creation of container %s because it already exists\n", sDetails.host)
+			return azureClient, creds
+		}
+		require.NoError(t, err)
+	}
+	return azureClient, creds
+}
+
 func TestInitStatusEntry(t *testing.T) {
 	ctx := context.Background()
 	dbName := "fetch_test_status"
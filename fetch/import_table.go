@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -13,7 +14,6 @@ import (
 	"github.com/cockroachdb/molt/compression"
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/dbtable"
-	"github.com/cockroachdb/molt/fetch/datablobstorage"
 	"github.com/cockroachdb/molt/fetch/fetchmetrics"
 	"github.com/cockroachdb/molt/fetch/internal/dataquery"
 	"github.com/cockroachdb/molt/fetch/status"
@@ -27,6 +27,10 @@ import (
 type importResult struct {
 	StartTime time.Time
 	EndTime   time.Time
+	// NumRows and NumFiles are the number of rows and files imported, which
+	// are set even if the import fails.
+	NumRows  int
+	NumFiles int
 }
 
 type importProgress struct {
@@ -43,6 +47,12 @@ const (
 	pattern     = `%`
 	replacement = `\%`
 	batchSize   = 10
+	// importJobPollInterval is how often the job of an interrupted IMPORT
+	// is polled.
+	importJobPollInterval = 10 * time.Second
+	// maxImportReruns is the number of times an interrupted IMPORT which
+	// did not start a job is run again.
+	maxImportReruns = 3
 )
 
 var re = regexp.MustCompile(pattern)
@@ -128,14 +138,16 @@ func reportImportTableProgress(
 	return err
 }
 
+// importTable imports the files handed out by the queue in batches, as they
+// become ready.
 func importTable(
 	ctx context.Context,
 	cfg Config,
 	baseConn dbconn.Conn,
 	logger zerolog.Logger,
 	table dbtable.VerifiedTable,
-	resources []datablobstorage.Resource,
-	isLocal bool,
+	queue *resourceQueue,
+	checkpointer *exportCheckpointer,
 	isClearContinuationTokenMode bool,
 	exceptionLog *status.ExceptionLog,
 ) (importResult, error) {
@@ -152,19 +164,70 @@ func importTable(
 	ret := importResult{
 		StartTime: time.Now(),
 	}
+	// Transient errors are retried before bisecting the batch, so that only
+	// the files which cannot be imported are reported. IMPORT is not
+	// atomic, so imports which were interrupted are resolved from their job
+	// rather than retried.
+	conn := newRetryingConn(logger, cfg.RetrySettings, false /* atomic */, baseConn.(*dbconn.PGConn).Conn, nil /* onConnect */)
+	defer func() {
+		if err := conn.Close(ctx); err != nil {
+			logger.Err(err).Msg("failed to close connection for import")
+		}
+	}()
+	jobs, err := newImportJobs(ctx, baseConn.(*dbconn.PGConn).Conn)
+	if err != nil {
+		return ret, err
+	}
 
-	var locs []string
-	var numRows []int
-	for _, resource := range resources {
-		u, err := resource.ImportURL()
+	numFiles := 0
+	for {
+		batch, err := queue.next(ctx, batchSize)
 		if err != nil {
-			return importResult{}, err
+			return ret, err
+		}
+		if len(batch) == 0 {
+			break
 		}
-		locs = append(locs, u)
-		numRows = append(numRows, resource.Rows())
+
+		var locs []string
+		var numRows []int
+		for _, resource := range batch {
+			u, err := resource.ImportURL()
+			if err != nil {
+				return importResult{}, err
+			}
+			locs = append(locs, u)
+			numRows = append(numRows, resource.Rows())
+		}
+		totalRows := sumSlice(numRows)
+
+		file, err := importWithBisect(ctx, importKVOptions(cfg, batch[0].IsLocal()), table, logger, conn, jobs, locs)
+		if err != nil {
+			// Files before the failing file have been imported.
+			for i, loc := range locs {
+				if loc == file {
+					recordImported(ctx, checkpointer, batch[:i])
+					break
+				}
+			}
+			fileName := status.ExtractFileNameFromErr(file)
+			pgErr := status.MaybeReportException(ctx, logger, exceptionConn.(*dbconn.PGConn).Conn, table.Name, err, fileName,
+				status.StageDataLoad, isClearContinuationTokenMode, exceptionLog)
+			return ret, errors.Wrap(pgErr, "error importing data")
+		}
+		recordImported(ctx, checkpointer, batch)
+
+		logger.Info().Msgf("imported %d rows for batch for files %d to %d", totalRows, numFiles+1, numFiles+len(batch))
+		fetchmetrics.ImportedRows.WithLabelValues(table.SafeString()).Add(float64(totalRows))
+		numFiles += len(batch)
+		ret.NumRows += totalRows
+		ret.NumFiles = numFiles
 	}
-	conn := baseConn.(*dbconn.PGConn)
+	ret.EndTime = time.Now()
+	return ret, nil
+}
 
+func importKVOptions(cfg Config, isLocal bool) tree.KVOptions {
 	kvOptions := tree.KVOptions{}
 	if cfg.Compression == compression.GZIP {
 		kvOptions = append(kvOptions, tree.KVOption{
@@ -180,29 +243,137 @@ func importTable(
 			Value: tree.NewStrVal("1"),
 		})
 	}
+	return kvOptions
+}
 
-	for i := 0; i < len(locs); i += batchSize {
-		end := i + batchSize
-		// necessary to prevent going over len
-		if end > len(locs) {
-			end = len(locs)
+// recordImported records the import of the given files, which are in import
+// order, in the export checkpoints of their shards.
+func recordImported(ctx context.Context, checkpointer *exportCheckpointer, resources []queuedResource) {
+	for i, r := range resources {
+		if r.fileNum == 0 {
+			continue
+		}
+		if i+1 < len(resources) && resources[i+1].shardNum == r.shardNum {
+			continue
 		}
-		locBatch := locs[i:end]
-		totalRows := sumSlice(numRows[i:end])
+		checkpointer.imported(ctx, r.shardNum, r.fileNum)
+	}
+}
 
-		file, err := importWithBisect(ctx, kvOptions, table, logger, conn, locBatch)
-		if err != nil {
-			fileName := status.ExtractFileNameFromErr(file)
-			pgErr := status.MaybeReportException(ctx, logger, exceptionConn.(*dbconn.PGConn).Conn, table.Name, err, fileName,
-				status.StageDataLoad, isClearContinuationTokenMode, exceptionLog)
-			return ret, errors.Wrap(pgErr, "error importing data")
+// importJob is the status of the job of an IMPORT.
+type importJob struct {
+	ID     int64
+	Status string
+	Error  string
+}
+
+// importJobs finds the jobs of IMPORTs which were interrupted, such as by a
+// lost connection or an ambiguous result. The job of an interrupted IMPORT
+// may still be running, or may have succeeded, so running the IMPORT again
+// could import its files twice.
+type importJobs struct {
+	// find returns the most recent job which was created after since and
+	// imports the given files, if any.
+	find         func(ctx context.Context, since time.Time, locs []string) (importJob, bool, error)
+	pollInterval time.Duration
+}
+
+// newImportJobs returns the importJobs which find jobs on new connections to
+// the target of conn, as conn may have been lost.
+func newImportJobs(ctx context.Context, conn *pgx.Conn) (importJobs, error) {
+	// Jobs are found by the time they were created, which is measured by the
+	// clock of the target.
+	var now time.Time
+	start := time.Now()
+	if err := conn.QueryRow(ctx, "SELECT now()").Scan(&now); err != nil {
+		return importJobs{}, err
+	}
+	clockOffset := now.Sub(start)
+	config := conn.Config()
+	return importJobs{
+		find: func(ctx context.Context, since time.Time, locs []string) (importJob, bool, error) {
+			return findImportJob(ctx, config, since.Add(clockOffset), locs)
+		},
+		pollInterval: importJobPollInterval,
+	}, nil
+}
+
+func findImportJob(
+	ctx context.Context, config *pgx.ConnConfig, since time.Time, locs []string,
+) (importJob, bool, error) {
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return importJob{}, false, err
+	}
+	defer func() { _ = conn.Close(ctx) }()
+
+	var job importJob
+	if err := conn.QueryRow(ctx, `WITH x AS (SHOW JOBS)
+SELECT job_id, status, coalesce(error, '')
+FROM x
+WHERE job_type = 'IMPORT'
+    AND created > $1
+    AND strpos(description, $2) > 0
+    AND strpos(description, $3) > 0
+ORDER BY created DESC
+LIMIT 1`,
+		since.UTC(), importJobFile(locs[0]), importJobFile(locs[len(locs)-1]),
+	).Scan(&job.ID, &job.Status, &job.Error); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return importJob{}, false, nil
 		}
+		return importJob{}, false, err
+	}
+	return job, true, nil
+}
 
-		logger.Info().Msgf("imported %d rows for batch for files %d to %d", totalRows, i+1, end)
-		fetchmetrics.ImportedRows.WithLabelValues(table.SafeString()).Add(float64(totalRows))
+// importJobFile returns the part of the location of a file which is kept in
+// the description of a job, which redacts credentials.
+func importJobFile(loc string) string {
+	u, err := url.Parse(loc)
+	if err != nil || u.Path == "" {
+		return loc
+	}
+	return u.Path
+}
+
+// await waits for the job of an IMPORT of the files which was started at
+// since and interrupted with err, and returns the error of the job. It
+// returns false if no job was started, so that the IMPORT can be run again.
+func (j importJobs) await(
+	ctx context.Context, logger zerolog.Logger, err error, since time.Time, locs []string,
+) (bool, error) {
+	logger.Warn().Err(err).Msg("import was interrupted, waiting for its job to finish")
+	for {
+		// The first poll also waits for a job which was being created when
+		// the IMPORT was interrupted.
+		t := time.NewTimer(j.pollInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return true, errors.CombineErrors(err, ctx.Err())
+		case <-t.C:
+		}
+
+		job, ok, findErr := j.find(ctx, since, locs)
+		if findErr != nil {
+			if retry.IsTransient(findErr) || retry.IsInterrupted(findErr) {
+				logger.Warn().Err(findErr).Msg("failed to find the job of an interrupted import, retrying")
+				continue
+			}
+			return true, errors.CombineErrors(err, findErr)
+		}
+		if !ok {
+			return false, err
+		}
+		switch job.Status {
+		case "succeeded":
+			logger.Info().Int64("job_id", job.ID).Msg("job of interrupted import succeeded")
+			return true, nil
+		case "failed", "canceled", "revert-failed":
+			return true, errors.Newf("import job %d %s: %s", job.ID, job.Status, job.Error)
+		}
 	}
-	ret.EndTime = time.Now()
-	return ret, nil
 }
 
 func sumSlice(input []int) int {
@@ -228,21 +399,36 @@ func sumSlice(input []int) int {
 // 3 and 5 had errors, 3 would be returned first since the row showing the
 // breakdown [1][2][3][4,5] shows that 3 is being processed before 5 in the
 // stack.
+//
+// If an IMPORT is interrupted, its outcome is taken from its job once the job
+// finished. If it did not start a job, it is run again.
 func importWithBisect(
 	ctx context.Context,
 	kvOptions tree.KVOptions,
 	table dbtable.VerifiedTable,
 	logger zerolog.Logger,
 	conn PGIface,
+	jobs importJobs,
 	locs []string,
 ) (string, error) {
+	reruns := 0
 	stack := [][]string{locs}
 	for len(stack) > 0 {
 		curr := stack[len(stack)-1]
 		stack = stack[:len(stack)-1]
 		importQuery, redactedQuery := dataquery.ImportInto(table, curr, kvOptions)
 		logger.Debug().Msgf("running import query: %q", redactedQuery)
+		since := time.Now()
 		_, err := conn.Exec(ctx, importQuery)
+		if retry.IsInterrupted(err) {
+			var started bool
+			started, err = jobs.await(ctx, logger, err, since, curr)
+			if !started && reruns < maxImportReruns {
+				reruns++
+				stack = append(stack, curr)
+				continue
+			}
+		}
 
 		// If the import query returns an error, then we need to bisect.
 		// Otherwise do nothing. If the len is more than 1, we have
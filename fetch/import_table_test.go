@@ -256,6 +256,7 @@ func TestImportWithBisect(t *testing.T) {
 				tbl,
 				logger,
 				db,
+				importJobs{},
 				[]string{"part_00000001.csv",
 					"part_00000002.csv",
 					"part_00000003.csv",
@@ -274,3 +275,103 @@ func TestImportWithBisect(t *testing.T) {
 		})
 	}
 }
+
+func TestImportWithBisectInterrupted(t *testing.T) {
+	tbl := dbtable.VerifiedTable{
+		Name: dbtable.Name{
+			Schema: "public",
+			Table:  "test_table",
+		},
+	}
+	shutdownErr := &pgconn.PgError{Code: "57P01", Message: "server is shutting down"}
+	locs := []string{"part_00000001.csv", "part_00000002.csv"}
+	for _, tc := range []struct {
+		name           string
+		dbExpectations func(mock pgxmock.PgxConnIface)
+		jobs           []importJob
+		findErrs       []error
+		expectedFile   string
+		expectedErr    string
+	}{
+		{
+			name: "job succeeded",
+			dbExpectations: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectExec("IMPORT INTO").WillReturnError(shutdownErr)
+			},
+			jobs: []importJob{{ID: 1, Status: "succeeded"}},
+		},
+		{
+			name: "job running then succeeded",
+			dbExpectations: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectExec("IMPORT INTO").WillReturnError(&pgconn.PgError{Code: "40003"})
+			},
+			jobs: []importJob{{ID: 1, Status: "running"}, {ID: 1, Status: "succeeded"}},
+		},
+		{
+			name: "job lookup failed transiently",
+			dbExpectations: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectExec("IMPORT INTO").WillReturnError(errors.New("read tcp: connection reset by peer"))
+			},
+			findErrs: []error{&pgconn.PgError{Code: "57P03"}},
+			jobs:     []importJob{{}, {ID: 1, Status: "succeeded"}},
+		},
+		{
+			name: "job failed",
+			dbExpectations: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectExec("IMPORT INTO").WillReturnError(shutdownErr)
+				mock.ExpectExec("IMPORT INTO").WillReturnResult(pgconn.NewCommandTag("tag"))
+				mock.ExpectExec("IMPORT INTO").WillReturnError(errors.New("mock error"))
+			},
+			jobs:         []importJob{{ID: 1, Status: "failed", Error: "node unavailable"}},
+			expectedFile: "part_00000002.csv",
+			expectedErr:  "mock error",
+		},
+		{
+			name: "no job",
+			dbExpectations: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectExec("IMPORT INTO").WillReturnError(shutdownErr)
+				mock.ExpectExec("IMPORT INTO").WillReturnResult(pgconn.NewCommandTag("tag"))
+			},
+			jobs: []importJob{{}},
+		},
+		{
+			name: "job of single file failed",
+			dbExpectations: func(mock pgxmock.PgxConnIface) {
+				mock.ExpectExec("IMPORT INTO").Times(2).WillReturnError(shutdownErr)
+			},
+			jobs: []importJob{
+				{ID: 1, Status: "failed", Error: "node unavailable"},
+				{ID: 2, Status: "failed", Error: "node unavailable"},
+			},
+			expectedFile: "part_00000001.csv",
+			expectedErr:  "import job 2 failed: node unavailable",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := pgxmock.NewConn(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherRegexp))
+			require.NoError(t, err)
+			tc.dbExpectations(db)
+			polls := 0
+			jobs := importJobs{
+				find: func(ctx context.Context, since time.Time, locs []string) (importJob, bool, error) {
+					polls++
+					if polls <= len(tc.findErrs) {
+						return importJob{}, false, tc.findErrs[polls-1]
+					}
+					job := tc.jobs[polls-1]
+					return job, job.ID != 0, nil
+				},
+				pollInterval: time.Microsecond,
+			}
+			file, err := importWithBisect(context.Background(), nil, tbl, zerolog.Nop(), db, jobs, locs)
+			require.NoError(t, db.ExpectationsWereMet())
+			require.Equal(t, len(tc.jobs), polls)
+			require.Equal(t, tc.expectedFile, file)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
@@ -29,7 +29,7 @@ func NewPGCopyTo(table dbtable.VerifiedTable) string {
 func ImportInto(table dbtable.VerifiedTable, locs []string, opts tree.KVOptions) (string, string) {
 	importInto := &tree.Import{
 		Into:       true,
-		Table:      table.NewTableName(),
+		Table:      table.Target().NewTableName(),
 		FileFormat: "CSV",
 		IntoCols:   table.Columns,
 	}
@@ -61,7 +61,7 @@ func ImportInto(table dbtable.VerifiedTable, locs []string, opts tree.KVOptions)
 
 func CopyFrom(table dbtable.VerifiedTable, skipHeader bool) string {
 	copyFrom := &tree.CopyFrom{
-		Table:   table.MakeTableName(),
+		Table:   table.Target().MakeTableName(),
 		Columns: table.Columns,
 		Stdin:   true,
 		Options: tree.CopyOptions{
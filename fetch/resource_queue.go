@@ -0,0 +1,244 @@
+package fetch
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/cockroachdb/molt/fetch/datablobstorage"
+)
+
+// queuedResource is a file written to the data store by the export of a
+// shard.
+type queuedResource struct {
+	datablobstorage.Resource
+	shardNum int
+	// fileNum is the number of the file within the shard, or 0 if it is not
+	// known.
+	fileNum int
+}
+
+// newQueuedResource returns the resource along with the shard and file
+// number parsed from its name, if it was named by an export.
+func newQueuedResource(r datablobstorage.Resource) (queuedResource, error) {
+	key, err := r.Key()
+	if err != nil {
+		return queuedResource{}, err
+	}
+	ret := queuedResource{Resource: r}
+	ret.shardNum, ret.fileNum, _ = datablobstorage.ParseShardFileName(key)
+	return ret, nil
+}
+
+// resourceQueue hands out the files of a table to import while the table is
+// being exported.
+//
+// Files are handed out in order of their shard, and in the order they were
+// written within a shard. The files of a shard are only handed out once every
+// shard numbered before it has been completely exported. When the import of a
+// file fails, every file before it has therefore been imported and none after
+// it, which continuation tokens rely on. Files may also be taken by concurrent
+// importers, in which case the files of each shard are still imported one at
+// a time and in order.
+type resourceQueue struct {
+	mu struct {
+		sync.Mutex
+		pending map[int][]queuedResource
+		// exporting is the set of shards which may still add files.
+		exporting map[int]struct{}
+		// importing is the set of shards with a file taken by take which has
+		// not been released.
+		importing map[int]struct{}
+		closed    bool
+		// changedCh is closed whenever files may have become ready to hand
+		// out.
+		changedCh chan struct{}
+	}
+}
+
+// newResourceQueue returns a queue for the files of the given shards, which
+// are being exported.
+func newResourceQueue(exportingShards []int) *resourceQueue {
+	q := &resourceQueue{}
+	q.mu.changedCh = make(chan struct{})
+	q.mu.pending = make(map[int][]queuedResource)
+	q.mu.exporting = make(map[int]struct{})
+	q.mu.importing = make(map[int]struct{})
+	for _, shardNum := range exportingShards {
+		q.mu.exporting[shardNum] = struct{}{}
+	}
+	return q
+}
+
+// newClosedResourceQueue returns a queue handing out files which have all
+// been exported.
+func newClosedResourceQueue(resources []queuedResource) *resourceQueue {
+	q := newResourceQueue(nil)
+	for _, r := range resources {
+		q.add(r)
+	}
+	q.close()
+	return q
+}
+
+// startShard registers a shard which is being exported, so that files of
+// later shards are held back until it is done.
+func (q *resourceQueue) startShard(shardNum int) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.mu.exporting[shardNum] = struct{}{}
+}
+
+// add queues a file which has been written to the data store.
+func (q *resourceQueue) add(r queuedResource) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.mu.pending[r.shardNum] = append(q.mu.pending[r.shardNum], r)
+	q.signalLocked()
+}
+
+// finishShard records that every file of the shard has been added.
+func (q *resourceQueue) finishShard(shardNum int) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.mu.exporting, shardNum)
+	q.signalLocked()
+}
+
+// close records that no more files will be added.
+func (q *resourceQueue) close() {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.mu.closed = true
+	q.signalLocked()
+}
+
+// signalLocked wakes up every caller waiting for files.
+func (q *resourceQueue) signalLocked() {
+	close(q.mu.changedCh)
+	q.mu.changedCh = make(chan struct{})
+}
+
+// next waits until files are ready and returns up to max of them. It returns
+// no files once the queue is closed and every file has been handed out.
+func (q *resourceQueue) next(ctx context.Context, max int) ([]queuedResource, error) {
+	for {
+		ret, done, changedCh := q.takeReady(max, false /* exclusive */)
+		if len(ret) > 0 || done {
+			return ret, nil
+		}
+		if err := wait(ctx, changedCh); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// take waits until a file is ready whose shard has no other file being
+// imported, and returns it. No other file of the shard is handed out until it
+// is released, so that the files of each shard are imported in order. It
+// returns false once the queue is closed and every file has been handed out.
+func (q *resourceQueue) take(ctx context.Context) (queuedResource, bool, error) {
+	for {
+		ret, done, changedCh := q.takeReady(1, true /* exclusive */)
+		if len(ret) > 0 {
+			return ret[0], true, nil
+		}
+		if done {
+			return queuedResource{}, false, nil
+		}
+		if err := wait(ctx, changedCh); err != nil {
+			return queuedResource{}, false, err
+		}
+	}
+}
+
+// release records that the import of a file returned by take has finished.
+func (q *resourceQueue) release(r queuedResource) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.mu.importing, r.shardNum)
+	q.signalLocked()
+}
+
+// firstPending returns the first file which has not been handed out.
+func (q *resourceQueue) firstPending() (queuedResource, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	first, ok := -1, false
+	for shardNum, pending := range q.mu.pending {
+		if len(pending) > 0 && (!ok || shardNum < first) {
+			first, ok = shardNum, true
+		}
+	}
+	if !ok {
+		return queuedResource{}, false
+	}
+	return q.mu.pending[first][0], true
+}
+
+func wait(ctx context.Context, changedCh chan struct{}) error {
+	select {
+	case <-changedCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// takeReady returns up to max files which are ready to be handed out,
+// whether every file has been handed out, and a channel closed once more
+// files may be ready. If exclusive is set, files of
+// shards being imported are skipped, and at most one file of each shard is
+// returned and marked as being imported.
+func (q *resourceQueue) takeReady(
+	max int, exclusive bool,
+) ([]queuedResource, bool, chan struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	shardNums := make([]int, 0, len(q.mu.pending)+len(q.mu.exporting))
+	for shardNum := range q.mu.pending {
+		shardNums = append(shardNums, shardNum)
+	}
+	for shardNum := range q.mu.exporting {
+		if _, ok := q.mu.pending[shardNum]; !ok {
+			shardNums = append(shardNums, shardNum)
+		}
+	}
+	sort.Ints(shardNums)
+	var ret []queuedResource
+	for _, shardNum := range shardNums {
+		if _, ok := q.mu.importing[shardNum]; !ok {
+			pending := q.mu.pending[shardNum]
+			n := min(len(pending), max-len(ret))
+			if exclusive && n > 0 {
+				n = 1
+				q.mu.importing[shardNum] = struct{}{}
+			}
+			ret = append(ret, pending[:n]...)
+			if n == len(pending) {
+				delete(q.mu.pending, shardNum)
+			} else {
+				q.mu.pending[shardNum] = pending[n:]
+			}
+		}
+		if len(ret) == max {
+			break
+		}
+		if _, ok := q.mu.exporting[shardNum]; ok && !q.mu.closed {
+			break
+		}
+	}
+	return ret, q.mu.closed && len(q.mu.pending) == 0, q.mu.changedCh
+}
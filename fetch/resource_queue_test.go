@@ -0,0 +1,131 @@
+package fetch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceQueue(t *testing.T) {
+	ctx := context.Background()
+	file := func(shardNum, fileNum int) queuedResource {
+		return queuedResource{shardNum: shardNum, fileNum: fileNum}
+	}
+	nextNow := func(q *resourceQueue, max int) []queuedResource {
+		ret, _, _ := q.takeReady(max, false /* exclusive */)
+		return ret
+	}
+
+	q := newResourceQueue([]int{1, 2})
+	require.Empty(t, nextNow(q, 10))
+
+	// Files of later shards are held back until earlier shards are done.
+	q.add(file(2, 1))
+	require.Empty(t, nextNow(q, 10))
+	q.add(file(1, 1))
+	q.add(file(1, 2))
+	require.Equal(t, []queuedResource{file(1, 1)}, nextNow(q, 1))
+	require.Equal(t, []queuedResource{file(1, 2)}, nextNow(q, 10))
+
+	// A shard split off from a running shard is imported after it.
+	q.startShard(3)
+	q.add(file(3, 1))
+	q.finishShard(1)
+	require.Equal(t, []queuedResource{file(2, 1)}, nextNow(q, 10))
+	q.add(file(2, 2))
+	q.finishShard(2)
+	require.Equal(t, []queuedResource{file(2, 2), file(3, 1)}, nextNow(q, 10))
+
+	// next blocks until files are ready.
+	resCh := make(chan []queuedResource)
+	go func() {
+		ret, _ := q.next(ctx, 10)
+		resCh <- ret
+	}()
+	select {
+	case <-resCh:
+		t.Fatal("expected next to block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	q.add(file(3, 2))
+	require.Equal(t, []queuedResource{file(3, 2)}, <-resCh)
+
+	// Once closed, the remaining files are handed out and then none.
+	q.add(file(3, 3))
+	q.close()
+	ret, err := q.next(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, []queuedResource{file(3, 3)}, ret)
+	ret, err = q.next(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, ret)
+
+	// Waiting is interrupted by the context.
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = newResourceQueue([]int{1}).next(cancelCtx, 10)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// A closed queue hands out its files in order of shard.
+	q = newClosedResourceQueue([]queuedResource{file(2, 1), file(1, 1), file(1, 2)})
+	ret, err = q.next(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, []queuedResource{file(1, 1), file(1, 2), file(2, 1)}, ret)
+}
+
+func TestNewQueuedResource(t *testing.T) {
+	qr, err := newQueuedResource(testResource{key: "bucket/public.t/shard_02_part_00000013.csv"})
+	require.NoError(t, err)
+	require.Equal(t, 2, qr.shardNum)
+	require.Equal(t, 13, qr.fileNum)
+
+	qr, err = newQueuedResource(testResource{key: "bucket/public.t/other.csv"})
+	require.NoError(t, err)
+	require.Equal(t, 0, qr.shardNum)
+	require.Equal(t, 0, qr.fileNum)
+}
+
+func TestResourceQueueTake(t *testing.T) {
+	ctx := context.Background()
+	file := func(shardNum, fileNum int) queuedResource {
+		return queuedResource{shardNum: shardNum, fileNum: fileNum}
+	}
+	take := func(q *resourceQueue) queuedResource {
+		r, ok, err := q.take(ctx)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return r
+	}
+
+	q := newClosedResourceQueue([]queuedResource{file(1, 1), file(1, 2), file(2, 1), file(2, 2)})
+	// Only one file of each shard is taken at a time.
+	require.Equal(t, file(1, 1), take(q))
+	require.Equal(t, file(2, 1), take(q))
+	r, _ := q.firstPending()
+	require.Equal(t, file(1, 2), r)
+
+	takenCh := make(chan queuedResource)
+	go func() {
+		r, _, _ := q.take(ctx)
+		takenCh <- r
+	}()
+	select {
+	case <-takenCh:
+		t.Fatal("expected take to block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	q.release(file(2, 1))
+	require.Equal(t, file(2, 2), <-takenCh)
+	q.release(file(1, 1))
+	require.Equal(t, file(1, 2), take(q))
+	_, ok := q.firstPending()
+	require.False(t, ok)
+
+	q.release(file(1, 2))
+	q.release(file(2, 2))
+	_, ok, err := q.take(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,126 @@
+package fetch
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/retry"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog"
+)
+
+// retryTransient runs do, retrying it with the given settings while it fails
+// with transient errors. If the operation is atomic, errors which interrupted
+// it are retried too. The zero settings disable retries.
+func retryTransient(
+	ctx context.Context,
+	logger zerolog.Logger,
+	settings retry.Settings,
+	atomic bool,
+	op string,
+	do func() error,
+) error {
+	if settings == (retry.Settings{}) {
+		return do()
+	}
+	r, err := retry.NewRetry(settings)
+	if err != nil {
+		return err
+	}
+	onRetry := func(err error) {
+		logger.Warn().Err(err).
+			Int("attempt", r.Iteration).
+			Msgf("retrying %s after transient error", op)
+	}
+	if atomic {
+		return r.DoAtomic(ctx, do, onRetry)
+	}
+	return r.DoTransient(ctx, do, onRetry)
+}
+
+// retryingConn runs operations on a connection to the target, retrying them
+// while they fail with transient errors. If the connection was lost, it is
+// re-established before retrying.
+type retryingConn struct {
+	logger   zerolog.Logger
+	settings retry.Settings
+	// atomic is whether the operations are atomic, so that operations which
+	// were interrupted are retried too.
+	atomic bool
+	// onConnect sets up a connection which replaces a lost one.
+	onConnect func(ctx context.Context, conn *pgx.Conn) error
+
+	conn *pgx.Conn
+	// owned is whether conn replaced the connection retryingConn was created
+	// with, and must be closed by it.
+	owned bool
+}
+
+var _ PGIface = (*retryingConn)(nil)
+
+func newRetryingConn(
+	logger zerolog.Logger,
+	settings retry.Settings,
+	atomic bool,
+	conn *pgx.Conn,
+	onConnect func(ctx context.Context, conn *pgx.Conn) error,
+) *retryingConn {
+	return &retryingConn{
+		logger:    logger,
+		settings:  settings,
+		atomic:    atomic,
+		onConnect: onConnect,
+		conn:      conn,
+	}
+}
+
+// do runs the operation on the connection, retrying transient errors.
+func (c *retryingConn) do(ctx context.Context, op string, fn func(conn *pgx.Conn) error) error {
+	return retryTransient(ctx, c.logger, c.settings, c.atomic, op, func() error {
+		if err := c.maybeReconnect(ctx); err != nil {
+			return err
+		}
+		return fn(c.conn)
+	})
+}
+
+func (c *retryingConn) Exec(
+	ctx context.Context, sql string, arguments ...any,
+) (pgconn.CommandTag, error) {
+	var tag pgconn.CommandTag
+	err := c.do(ctx, "statement", func(conn *pgx.Conn) error {
+		var err error
+		tag, err = conn.Exec(ctx, sql, arguments...)
+		return err
+	})
+	return tag, err
+}
+
+func (c *retryingConn) maybeReconnect(ctx context.Context) error {
+	if !c.conn.IsClosed() {
+		return nil
+	}
+	c.logger.Debug().Msg("reconnecting to the target after losing the connection")
+	conn, err := pgx.ConnectConfig(ctx, c.conn.Config())
+	if err != nil {
+		return err
+	}
+	if c.onConnect != nil {
+		if err := c.onConnect(ctx, conn); err != nil {
+			return errors.CombineErrors(err, conn.Close(ctx))
+		}
+	}
+	c.conn = conn
+	c.owned = true
+	return nil
+}
+
+// Close closes the connection if it replaced the one retryingConn was
+// created with, which is left to its owner.
+func (c *retryingConn) Close(ctx context.Context) error {
+	if !c.owned {
+		return nil
+	}
+	return c.conn.Close(ctx)
+}
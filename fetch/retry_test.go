@@ -0,0 +1,56 @@
+package fetch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/molt/retry"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryTransient(t *testing.T) {
+	ctx := context.Background()
+	transientErr := &pgconn.PgError{Code: "40001"}
+	shutdownErr := &pgconn.PgError{Code: "57P01"}
+	settings := retry.Settings{
+		InitialBackoff: time.Microsecond,
+		Multiplier:     2,
+		MaxRetries:     3,
+	}
+	failOnce := func(attempts *int, err error) func() error {
+		return func() error {
+			*attempts++
+			if *attempts == 1 {
+				return err
+			}
+			return nil
+		}
+	}
+
+	t.Run("retries transient errors", func(t *testing.T) {
+		attempts := 0
+		require.NoError(t, retryTransient(ctx, zerolog.Nop(), settings, false /* atomic */, "import", failOnce(&attempts, transientErr)))
+		require.Equal(t, 2, attempts)
+	})
+
+	t.Run("retries interrupted atomic operations", func(t *testing.T) {
+		attempts := 0
+		require.NoError(t, retryTransient(ctx, zerolog.Nop(), settings, true /* atomic */, "copy", failOnce(&attempts, shutdownErr)))
+		require.Equal(t, 2, attempts)
+	})
+
+	t.Run("does not retry interrupted operations which are not atomic", func(t *testing.T) {
+		attempts := 0
+		require.Equal(t, shutdownErr, retryTransient(ctx, zerolog.Nop(), settings, false /* atomic */, "import", failOnce(&attempts, shutdownErr)))
+		require.Equal(t, 1, attempts)
+	})
+
+	t.Run("zero settings disable retries", func(t *testing.T) {
+		attempts := 0
+		require.Equal(t, transientErr, retryTransient(ctx, zerolog.Nop(), retry.Settings{}, true /* atomic */, "import", failOnce(&attempts, transientErr)))
+		require.Equal(t, 1, attempts)
+	})
+}
@@ -1,8 +1,9 @@
 package fetch
 
 import (
-	"bytes"
 	"context"
+	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"regexp"
 	"strings"
@@ -13,7 +14,10 @@ import (
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/oracleconv"
+	"github.com/cockroachdb/molt/utils"
 	"github.com/cockroachdb/molt/utils/typeconv"
+	"github.com/cockroachdb/molt/verify/dbverify"
 	"github.com/lib/pq/oid"
 	"github.com/rs/zerolog"
 )
@@ -27,6 +31,12 @@ func (cs columnsWithType) CRDBCreateTableStmt(logger zerolog.Logger) (string, er
 	if err != nil {
 		return "", err
 	}
+	if cs[0].targetSchema != "" {
+		tName.ObjectNamePrefix = tree.ObjectNamePrefix{
+			SchemaName:     tree.Name(cs[0].targetSchema),
+			ExplicitSchema: true,
+		}
+	}
 	res := tree.CreateTable{
 		Table: *tName,
 	}
@@ -50,6 +60,13 @@ func (cs columnsWithType) CRDBCreateTableStmt(logger zerolog.Logger) (string, er
 		res.Defs = append(res.Defs, colDef)
 	}
 
+	if len(pkList) == 0 {
+		// Tables without a primary key are given a hidden row ID, matching
+		// what CockroachDB creates implicitly, so that the key is explicit in
+		// the schema.
+		res.Defs = append(res.Defs, rowIDColDef(cs))
+	}
+
 	if !includePkForEachCol {
 		pkColNode := tree.IndexElemList{}
 		for _, pk := range pkList {
@@ -67,6 +84,34 @@ func (cs columnsWithType) CRDBCreateTableStmt(logger zerolog.Logger) (string, er
 	return res.String(), nil
 }
 
+// rowIDColDef returns the definition of a hidden row ID primary key column
+// for a table with the given columns. The column is named rowid, with a
+// suffix added if a column of that name already exists.
+func rowIDColDef(cs columnsWithType) *tree.ColumnTableDef {
+	existing := make(map[string]struct{}, len(cs))
+	for _, col := range cs {
+		existing[col.columnName] = struct{}{}
+	}
+	name := "rowid"
+	for i := 1; ; i++ {
+		if _, ok := existing[name]; !ok {
+			break
+		}
+		name = fmt.Sprintf("rowid_%d", i)
+	}
+	res := &tree.ColumnTableDef{
+		Name:   tree.Name(name),
+		Type:   crdbtypes.Int,
+		Hidden: true,
+	}
+	res.Nullable.Nullability = tree.NotNull
+	res.DefaultExpr.Expr = &tree.FuncExpr{
+		Func: tree.ResolvableFunctionReference{FunctionReference: tree.NewUnresolvedName("unique_rowid")},
+	}
+	res.PrimaryKey.IsPrimaryKey = true
+	return res
+}
+
 type columnWithType struct {
 	// The following fields are shared information for columns from all dialects.
 	schemaName      string
@@ -81,9 +126,47 @@ type columnWithType struct {
 	udtDefinition   string
 	arrDim          int
 	ordinalPosition int
+	// defaultExpr is the default expression of a PG column, formatted by
+	// pg_get_expr.
+	defaultExpr string
+	// identity is the attidentity of a PG column, which is 'a' or 'd' for
+	// identity columns. Oracle identity columns are mapped to the same
+	// values.
+	identity string
 
 	// mysqlMeta stores the mysql column dedicated information.
 	mysqlMeta *typeconv.MySQLColumnMeta
+	// oracleMeta stores the oracle column dedicated information.
+	oracleMeta *typeconv.OracleColumnMeta
+
+	// overrideType, if set, is the type of the column on the target given by
+	// the type overrides, which replaces the default type mapping.
+	overrideType *crdbtypes.T
+	// targetSchema, if set, is the schema the table and its sequences are
+	// created in on the target, as given by the name mapping.
+	targetSchema string
+}
+
+// setTarget renames the table of the columns to the table created on the
+// target, as returned by TargetTable.
+func (cs columnsWithType) setTarget(target dbtable.DBTable) {
+	for i := range cs {
+		cs[i].tableName = string(target.Table)
+		cs[i].targetSchema = string(target.Schema)
+	}
+}
+
+// sourceTypeNames returns the names of the source type of the column, which
+// type overrides are matched against.
+func (t *columnWithType) sourceTypeNames() []string {
+	switch {
+	case t.mysqlMeta != nil:
+		return []string{t.dataType, t.mysqlMeta.ColumnType}
+	case t.oracleMeta != nil:
+		return []string{t.dataType, oracleconv.NormalizeDataType(t.dataType)}
+	default:
+		return []string{t.dataType}
+	}
 }
 
 func (t *columnWithType) CRDBColDef(
@@ -92,7 +175,9 @@ func (t *columnWithType) CRDBColDef(
 	var colType tree.ResolvableTypeReference
 	var err error
 	var scs []*typeconv.TypeConvError
-	if t.udtDefinition != "" {
+	if t.overrideType != nil {
+		colType = t.overrideType
+	} else if t.udtDefinition != "" {
 		if t.udtName == "" {
 			// This should not happen, but as a sanity check.
 			return nil, errors.AssertionFailedf("user defined type definition %q is not null, but the type name is null", t.udtDefinition)
@@ -102,26 +187,34 @@ func (t *columnWithType) CRDBColDef(
 			return nil, errors.Wrapf(err, "unable to parse the type name %q", t.udtName)
 		}
 	} else {
-		if t.mysqlMeta == nil {
-			// If this is from a PG source.
-			colType = crdbtypes.OidToType[t.typeOid]
-		} else {
+		var dialect string
+		switch {
+		case t.mysqlMeta != nil:
 			// If this is from a mysql source.
+			dialect = "mysql"
 			colType, scs = t.mysqlMeta.ToDefaultCRDBType(t.dataType, t.columnName)
-			for _, sc := range scs {
-				if sc.Blocking {
-					err = errors.Newf(
-						"failed to get crdb type from mysql type %s for column %s.%s: %s",
-						t.dataType,
-						t.tableName,
-						t.columnName,
-						sc.ShortDescription,
-					)
-					logger.Err(err)
-					return nil, err
-				}
-				logger.Warn().Msgf("mysql type %s for column %s.%s: %s", t.dataType, t.tableName, t.columnName, sc.ShortDescription)
+		case t.oracleMeta != nil:
+			// If this is from an oracle source.
+			dialect = "oracle"
+			colType, scs = t.oracleMeta.ToDefaultCRDBType(t.dataType, t.columnName)
+		default:
+			// If this is from a PG source.
+			colType = crdbtypes.OidToType[t.typeOid]
+		}
+		for _, sc := range scs {
+			if sc.Blocking {
+				err = errors.Newf(
+					"failed to get crdb type from %s type %s for column %s.%s: %s",
+					dialect,
+					t.dataType,
+					t.tableName,
+					t.columnName,
+					sc.ShortDescription,
+				)
+				logger.Err(err)
+				return nil, err
 			}
+			logger.Warn().Msgf("%s type %s for column %s.%s: %s", dialect, t.dataType, t.tableName, t.columnName, sc.ShortDescription)
 		}
 	}
 
@@ -135,9 +228,265 @@ func (t *columnWithType) CRDBColDef(
 	if t.isPrimaryKey && includePk {
 		res.PrimaryKey.IsPrimaryKey = true
 	}
+	res.DefaultExpr.Expr, res.OnUpdateExpr.Expr = t.crdbDefaultExprs(logger, colType)
 	return res, nil
 }
 
+var (
+	pgNextvalRegex          = regexp.MustCompile(`^nextval\('(.+)'::regclass\)$`)
+	mysqlCurrentTimestampRe = regexp.MustCompile(`(?i)^(current_timestamp|now|localtimestamp|localtime)(\(\d*\))?$`)
+)
+
+// sequenceName returns the name of the sequence generating the values of the
+// column on the target, or an empty string if the column is not generated by
+// a sequence. PG serial, identity and sequence default columns and MySQL
+// AUTO_INCREMENT columns are generated by a sequence.
+func (t *columnWithType) sequenceName() string {
+	if t.mysqlMeta != nil {
+		if strings.Contains(strings.ToLower(t.mysqlMeta.Extra), "auto_increment") {
+			return fmt.Sprintf("%s_%s_seq", t.tableName, t.columnName)
+		}
+		return ""
+	}
+	if t.identity != "" {
+		return fmt.Sprintf("%s_%s_seq", t.tableName, t.columnName)
+	}
+	if m := pgNextvalRegex.FindStringSubmatch(t.defaultExpr); m != nil {
+		// The sequence is created in the same schema as the table.
+		if seqName, err := parser.ParseTableName(strings.ReplaceAll(m[1], "''", "'")); err == nil {
+			return seqName.Object()
+		}
+	}
+	return ""
+}
+
+// sourceGenerator describes how the values of a column generated by a
+// sequence are generated on the source.
+func (t *columnWithType) sourceGenerator() string {
+	switch {
+	case t.mysqlMeta != nil:
+		return "AUTO_INCREMENT"
+	case t.identity == "a":
+		return "GENERATED ALWAYS AS IDENTITY"
+	case t.identity == "d":
+		return "GENERATED BY DEFAULT AS IDENTITY"
+	default:
+		return "DEFAULT " + t.defaultExpr
+	}
+}
+
+// crdbDefaultExprs returns the default and ON UPDATE expressions of the
+// column of the given type on the target. Default expressions which cannot be
+// parsed, or which call functions CockroachDB does not have, are dropped with
+// a warning.
+func (t *columnWithType) crdbDefaultExprs(
+	logger zerolog.Logger, colType tree.ResolvableTypeReference,
+) (tree.Expr, tree.Expr) {
+	if seqName := t.sequenceName(); seqName != "" {
+		if t.identity == "a" {
+			logger.Warn().Msgf("column %s.%s is GENERATED ALWAYS AS IDENTITY, which is created as a column defaulting to the sequence %s", t.tableName, t.columnName, seqName)
+		}
+		return nextvalExpr(t.targetSequenceName()), nil
+	}
+	if t.mysqlMeta == nil {
+		if t.defaultExpr == "" {
+			return nil, nil
+		}
+		expr, err := parseDefaultExpr(t.defaultExpr)
+		if err != nil {
+			logger.Warn().Err(err).Msgf("dropping default %q of column %s.%s", t.defaultExpr, t.tableName, t.columnName)
+			return nil, nil
+		}
+		return expr, nil
+	}
+
+	var defaultExpr, onUpdateExpr tree.Expr
+	extra := strings.ToLower(t.mysqlMeta.Extra)
+	def := t.mysqlMeta.ColumnDefault
+	switch {
+	case def == "":
+	case mysqlCurrentTimestampRe.MatchString(def):
+		defaultExpr = nowExpr()
+	case strings.Contains(extra, "default_generated"):
+		// Expression defaults are formatted as an expression, while other
+		// defaults are formatted as the literal value.
+		expr, err := parseDefaultExpr(mysqlToPGIdents(def))
+		if err != nil {
+			logger.Warn().Err(err).Msgf("dropping default %q of column %s.%s", def, t.tableName, t.columnName)
+		} else {
+			defaultExpr = expr
+		}
+	default:
+		expr, err := mysqlLiteralDefault(def, t.dataType, colType)
+		if err != nil {
+			logger.Warn().Err(err).Msgf("dropping default %q of column %s.%s", def, t.tableName, t.columnName)
+		} else {
+			defaultExpr = expr
+		}
+	}
+	if strings.Contains(extra, "on update current_timestamp") {
+		onUpdateExpr = nowExpr()
+	}
+	return defaultExpr, onUpdateExpr
+}
+
+// crdbDefaultFuncs are the CockroachDB builtin functions which default
+// expressions of the source may call. Defaults calling other functions, such
+// as functions of extensions or of the source database, are dropped, as the
+// table could not be created with them.
+var crdbDefaultFuncs = map[string]struct{}{
+	"abs":                    {},
+	"array_length":           {},
+	"btrim":                  {},
+	"ceil":                   {},
+	"ceiling":                {},
+	"clock_timestamp":        {},
+	"concat":                 {},
+	"current_date":           {},
+	"current_database":       {},
+	"current_schema":         {},
+	"current_time":           {},
+	"current_timestamp":      {},
+	"current_user":           {},
+	"date_trunc":             {},
+	"decode":                 {},
+	"encode":                 {},
+	"extract":                {},
+	"floor":                  {},
+	"gen_random_ulid":        {},
+	"gen_random_uuid":        {},
+	"json_build_array":       {},
+	"json_build_object":      {},
+	"jsonb_build_array":      {},
+	"jsonb_build_object":     {},
+	"length":                 {},
+	"localtime":              {},
+	"localtimestamp":         {},
+	"lower":                  {},
+	"ltrim":                  {},
+	"md5":                    {},
+	"nextval":                {},
+	"now":                    {},
+	"random":                 {},
+	"round":                  {},
+	"rtrim":                  {},
+	"session_user":           {},
+	"sha256":                 {},
+	"statement_timestamp":    {},
+	"timezone":               {},
+	"to_char":                {},
+	"to_timestamp":           {},
+	"transaction_timestamp":  {},
+	"trim":                   {},
+	"trunc":                  {},
+	"unique_rowid":           {},
+	"unordered_unique_rowid": {},
+	"upper":                  {},
+	"uuid_v4":                {},
+}
+
+// parseDefaultExpr parses a default expression of the source, returning an
+// error if it calls a function which CockroachDB does not have. Functions
+// are resolved by name, qualified by no schema or pg_catalog.
+func parseDefaultExpr(s string) (tree.Expr, error) {
+	expr, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tree.SimpleVisit(expr, func(expr tree.Expr) (bool, tree.Expr, error) {
+		f, ok := expr.(*tree.FuncExpr)
+		if !ok {
+			return true, expr, nil
+		}
+		name, ok := f.Func.FunctionReference.(*tree.UnresolvedName)
+		if !ok {
+			return false, expr, errors.Newf("unable to resolve function %s", tree.AsString(&f.Func))
+		}
+		if name.NumParts > 2 || (name.NumParts == 2 && !strings.EqualFold(name.Parts[1], "pg_catalog")) {
+			return false, expr, errors.Newf("function %s is not a CockroachDB builtin", name)
+		}
+		if _, ok := crdbDefaultFuncs[strings.ToLower(name.Parts[0])]; !ok {
+			return false, expr, errors.Newf("function %s is not a CockroachDB builtin", name)
+		}
+		return true, expr, nil
+	}); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+var mysqlBitLiteralRe = regexp.MustCompile(`^[bB]'([01]*)'$`)
+
+// mysqlLiteralDefault returns the literal default of a column of the given
+// MySQL type on the target. MySQL formats the defaults of BIT columns as bit
+// literals and those of binary columns as hexadecimal literals, which are
+// converted as such. Other defaults are formatted as a string, which is cast
+// to the type of the column on the target.
+func mysqlLiteralDefault(
+	def string, dataType string, colType tree.ResolvableTypeReference,
+) (tree.Expr, error) {
+	family := crdbtypes.StringFamily
+	if typ, ok := colType.(*crdbtypes.T); ok {
+		family = typ.Family()
+	}
+	switch strings.ToLower(dataType) {
+	case "bit":
+		m := mysqlBitLiteralRe.FindStringSubmatch(def)
+		if m == nil {
+			return nil, errors.Newf("unexpected bit literal %q", def)
+		}
+		if family != crdbtypes.BitFamily {
+			return nil, errors.Newf("bit literal cannot be converted to %s", colType.SQLString())
+		}
+		bits, err := tree.ParseDBitArray(m[1])
+		if err != nil {
+			return nil, err
+		}
+		return bits, nil
+	case "binary", "varbinary", "blob", "tinyblob", "mediumblob", "longblob":
+		b := []byte(def)
+		if hexStr, ok := strings.CutPrefix(def, "0x"); ok {
+			var err error
+			if b, err = hex.DecodeString(hexStr); err != nil {
+				return nil, errors.Wrapf(err, "unexpected hexadecimal literal %q", def)
+			}
+		}
+		switch family {
+		case crdbtypes.BytesFamily:
+			return tree.NewDBytes(tree.DBytes(b)), nil
+		case crdbtypes.StringFamily:
+			return tree.NewStrVal(string(b)), nil
+		default:
+			return nil, errors.Newf("binary literal cannot be converted to %s", colType.SQLString())
+		}
+	default:
+		return tree.NewStrVal(def), nil
+	}
+}
+
+// targetSequenceName returns the name of the sequence generating the values
+// of the column on the target, which is created in the schema of the table.
+func (t *columnWithType) targetSequenceName() *tree.TableName {
+	seqName := tree.Name(t.sequenceName())
+	if t.targetSchema == "" {
+		return tree.NewUnqualifiedTableName(seqName)
+	}
+	return dbtable.Name{Schema: tree.Name(t.targetSchema), Table: seqName}.NewTableName()
+}
+
+func nextvalExpr(seqName *tree.TableName) tree.Expr {
+	return &tree.FuncExpr{
+		Func:  tree.ResolvableFunctionReference{FunctionReference: tree.NewUnresolvedName("nextval")},
+		Exprs: tree.Exprs{tree.NewStrVal(seqName.String())},
+	}
+}
+
+func nowExpr() tree.Expr {
+	return &tree.FuncExpr{
+		Func: tree.ResolvableFunctionReference{FunctionReference: tree.NewUnresolvedName("now")},
+	}
+}
+
 func (t *columnWithType) Name() string {
 	return fmt.Sprintf("%s.%s.%s", t.schemaName, t.tableName, t.columnName)
 }
@@ -166,7 +515,9 @@ func GetColumnTypes(
     t1.arr_dim,
     COALESCE(t2.udt_name, '') AS enum_type,
     COALESCE(t2.udt_def, '') AS enum_type_definition,
-    t2.ordinal_position
+    t2.ordinal_position,
+    t1.default_expr,
+    t1.identity
 FROM (
     SELECT
         c.relnamespace::regnamespace::text AS schema_name,
@@ -188,10 +539,17 @@ FROM (
             ) THEN true
             ELSE false
         END AS is_primary_key,
-        a.attndims AS arr_dim
+        a.attndims AS arr_dim,
+        -- The expression of generated columns is not a default.
+        CASE
+            WHEN a.attgenerated = '' THEN COALESCE(pg_get_expr(d.adbin, d.adrelid), '')
+            ELSE ''
+        END AS default_expr,
+        a.attidentity::text AS identity
     FROM
         pg_catalog.pg_class c
     JOIN pg_catalog.pg_attribute a ON c.oid = a.attrelid
+    LEFT JOIN pg_catalog.pg_attrdef d ON a.attrelid = d.adrelid AND a.attnum = d.adnum
     LEFT JOIN pg_catalog.pg_index ix ON c.oid = ix.indrelid AND a.attnum = ANY(ix.indkey)
     WHERE
         c.relkind = 'r'  -- 'r' indicates a table (relation)
@@ -244,6 +602,7 @@ ORDER BY
     COALESCE(c.CHARACTER_SET_NAME,'') AS CHARACTER_SET_NAME,
     COALESCE(c.COLLATION_NAME,'') AS COLLATION_NAME,
     COALESCE(c.COLUMN_DEFAULT, '') AS COLUMN_DEFAULT,
+    COALESCE(c.EXTRA, '') AS EXTRA,
     CASE 
         WHEN c.IS_NULLABLE = 'YES' THEN 'TRUE'
         ELSE 'FALSE' 
@@ -264,6 +623,46 @@ ORDER BY
     c.TABLE_SCHEMA, 
     c.TABLE_NAME,  
     c.ORDINAL_POSITION;
+`
+		// The schema of an Oracle table is its tablespace, as in verify.
+		oracleQuery = `
+SELECT
+    t.tablespace_name,
+    c.table_name,
+    c.column_name,
+    c.data_type,
+    c.data_precision,
+    c.data_scale,
+    c.char_length,
+    c.nullable,
+    CASE
+        WHEN EXISTS (
+            SELECT 1
+            FROM all_constraints k
+            JOIN all_cons_columns kc ON k.owner = kc.owner AND k.constraint_name = kc.constraint_name
+            WHERE k.constraint_type = 'P'
+                AND k.owner = c.owner
+                AND k.table_name = c.table_name
+                AND kc.column_name = c.column_name
+        ) THEN 1
+        ELSE 0
+    END AS is_primary_key,
+    CASE ic.generation_type
+        WHEN 'ALWAYS' THEN 'a'
+        WHEN 'BY DEFAULT' THEN 'd'
+    END AS identity,
+    c.column_id
+FROM
+    all_tab_columns c
+JOIN
+    all_tables t ON c.owner = t.owner AND c.table_name = t.table_name
+LEFT JOIN
+    all_tab_identity_cols ic ON c.owner = ic.owner AND c.table_name = ic.table_name AND c.column_name = ic.column_name
+WHERE
+    c.table_name = :1
+    AND t.tablespace_name = :2
+ORDER BY
+    c.column_id
 `
 	)
 
@@ -276,6 +675,7 @@ ORDER BY
 		if err != nil {
 			return nil, err
 		}
+		defer rows.Close()
 		for rows.Next() {
 			newCol := columnWithType{}
 			if err := rows.Scan(
@@ -290,6 +690,8 @@ ORDER BY
 				&newCol.udtName,
 				&newCol.udtDefinition,
 				&newCol.ordinalPosition,
+				&newCol.defaultExpr,
+				&newCol.identity,
 			); err != nil {
 				return nil, errors.Wrap(err, "failed to scan query result to a columnWithType object")
 			}
@@ -305,6 +707,7 @@ ORDER BY
 		if err != nil {
 			return nil, err
 		}
+		defer func() { _ = rows.Close() }()
 		for rows.Next() {
 			mysqlMt := &typeconv.MySQLColumnMeta{}
 			newCol := columnWithType{mysqlMeta: mysqlMt}
@@ -322,6 +725,7 @@ ORDER BY
 				&mysqlMt.CharSetName,
 				&mysqlMt.CollationName,
 				&mysqlMt.ColumnDefault,
+				&mysqlMt.Extra,
 				&newCol.nullable, &newCol.isPrimaryKey); err != nil {
 				return nil, errors.Wrap(err, "failed to scan query result to a columnWithType object")
 			}
@@ -337,6 +741,47 @@ ORDER BY
 			}
 			res = append(res, newCol)
 		}
+	case *dbconn.OracleConn:
+		rows, err := conn.QueryContext(ctx, oracleQuery, string(table.Table), string(table.Schema))
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			oracleMt := &typeconv.OracleColumnMeta{}
+			newCol := columnWithType{oracleMeta: oracleMt}
+			var nullable string
+			var isPrimaryKey int
+			var identity sql.NullString
+			if err := rows.Scan(
+				&newCol.schemaName,
+				&newCol.tableName,
+				&newCol.columnName,
+				&newCol.dataType,
+				&oracleMt.DataPrecision,
+				&oracleMt.DataScale,
+				&oracleMt.CharLength,
+				&nullable,
+				&isPrimaryKey,
+				&identity,
+				&newCol.ordinalPosition,
+			); err != nil {
+				return nil, errors.Wrap(err, "failed to scan query result to a columnWithType object")
+			}
+			// Oracle stores unquoted identifiers in upper case, which are
+			// lower cased to match the tables and columns compared by verify.
+			newCol.schemaName = strings.ToLower(newCol.schemaName)
+			newCol.tableName = strings.ToLower(newCol.tableName)
+			newCol.columnName = tree.Name(newCol.columnName).Normalize()
+			newCol.nullable = nullable == "Y"
+			newCol.isPrimaryKey = isPrimaryKey == 1
+			newCol.identity = identity.String
+			logger.Debug().Msgf("collected column:%s", newCol.String())
+			res = append(res, newCol)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, errors.New("not supported conn type")
 	}
@@ -345,11 +790,35 @@ ORDER BY
 	return res, nil
 }
 
+// TargetTable returns the name of the table created on the target for a
+// source table, as given by the name mapping. Oracle stores unquoted
+// identifiers in upper case, which are lower cased on the target. The schema
+// is only kept if the mapping moves the table to another schema, as tables are
+// otherwise created in the current schema of the target.
+func TargetTable(conn dbconn.Conn, mapping utils.NameMapping, table dbtable.DBTable) dbtable.DBTable {
+	mapping = dbverify.SourceNameMapping(conn, mapping)
+	if _, ok := conn.(*dbconn.OracleConn); ok {
+		mapping.Lowercase = true
+	}
+	ret := table
+	ret.Name = mapping.TargetName(table.Name)
+	if strings.EqualFold(string(ret.Schema), string(table.Schema)) {
+		ret.Schema = ""
+	}
+	return ret
+}
+
 func GetDropTableStmt(table dbtable.DBTable) (string, error) {
 	tName, err := parser.ParseQualifiedTableName(table.Table.String())
 	if err != nil {
 		return "", err
 	}
+	if table.Schema != "" {
+		tName.ObjectNamePrefix = tree.ObjectNamePrefix{
+			SchemaName:     table.Schema,
+			ExplicitSchema: true,
+		}
+	}
 	res := tree.DropTable{
 		Names:    tree.TableNames{*tName},
 		IfExists: true,
@@ -358,20 +827,59 @@ func GetDropTableStmt(table dbtable.DBTable) (string, error) {
 	return res.String(), nil
 }
 
+// GetCreateTableStmt returns the statements creating the table on the target,
+// along with the schema, enums and sequences it uses. The table is named as
+// given by the name mapping, and the types of the columns matched by the type
+// overrides replace the default type mapping.
 func GetCreateTableStmt(
-	ctx context.Context, logger zerolog.Logger, conn dbconn.Conn, table dbtable.DBTable,
+	ctx context.Context,
+	logger zerolog.Logger,
+	conn dbconn.Conn,
+	table dbtable.DBTable,
+	mapping utils.NameMapping,
+	overrides *typeconv.TypeOverrides,
 ) (string, error) {
 	newCols, err := GetColumnTypes(ctx, logger, conn, table, false /* skipUnsupportedTypeErr */)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed get columns for target table: %s", table.String())
 	}
+	target := TargetTable(conn, mapping, table)
 
 	var res string
-	for _, col := range newCols {
+	if target.Schema != "" {
+		res = (&tree.CreateSchema{
+			IfNotExists: true,
+			Schema: tree.ObjectNamePrefix{
+				SchemaName:     target.Schema,
+				ExplicitSchema: true,
+			},
+		}).String() + ";"
+	}
+	newCols.setTarget(target)
+	for i := range newCols {
+		col := &newCols[i]
+		typ, ok, err := overrides.Lookup(table.Name, col.columnName, col.isPrimaryKey, col.sourceTypeNames()...)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			logger.Info().Msgf("column %s.%s of type %s is overridden as %s", col.tableName, col.columnName, col.dataType, typ.SQLString())
+			col.overrideType = typ
+			// Overridden enums are not created.
+			col.udtName, col.udtDefinition = "", ""
+		}
 		if col.udtDefinition != "" {
 			logger.Info().Msgf("the original schema contains enum type %q. A tentative enum type will be created as %q", col.udtName, col.udtDefinition)
 			res = strings.Join([]string{res, col.udtDefinition}, " ")
 		}
+		if col.sequenceName() != "" {
+			createSeq := (&tree.CreateSequence{
+				IfNotExists: true,
+				Name:        *col.targetSequenceName(),
+			}).String() + ";"
+			logger.Info().Msgf("column %s is generated by a sequence, which will be created as %q", col.columnName, createSeq)
+			res = strings.Join([]string{res, createSeq}, " ")
+		}
 	}
 	createTableStmt, err := newCols.CRDBCreateTableStmt(logger)
 	if err != nil {
@@ -410,25 +918,6 @@ func convertMySQLEnum(
 	return createEnumStmt, enumTypeName, nil
 }
 
-type constraints []string
-
-type constraintsWithTable struct {
-	table dbtable.DBTable
-	cons  constraints
-}
-
-func (ct *constraintsWithTable) String() string {
-	var b bytes.Buffer
-	b.WriteString(fmt.Sprintf("table: %s,", ct.table))
-	for i, con := range ct.cons {
-		b.WriteString(fmt.Sprintf("%q", con))
-		if i != len(ct.cons)-1 {
-			b.WriteString(",")
-		}
-	}
-	return b.String()
-}
-
 func GetConstraints(
 	ctx context.Context, logger zerolog.Logger, conn dbconn.Conn, table dbtable.DBTable,
 ) ([]string, error) {
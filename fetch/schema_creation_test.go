@@ -2,14 +2,17 @@ package fetch
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"os"
 	"testing"
 
+	crdbtypes "github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/testutils"
 	"github.com/cockroachdb/molt/utils"
+	"github.com/cockroachdb/molt/utils/typeconv"
 	"github.com/cockroachdb/molt/verify/dbverify"
 	"github.com/lib/pq/oid"
 	"github.com/rs/zerolog"
@@ -545,3 +548,154 @@ func checkIfColInfoEqual(actual, expected columnWithType) error {
 	}
 	return nil
 }
+
+func TestCRDBColDefDefaults(t *testing.T) {
+	logger := zerolog.Nop()
+	for _, tc := range []struct {
+		desc        string
+		col         columnWithType
+		expectedDef string
+		expectedSeq string
+	}{
+		{
+			desc:        "pg serial",
+			col:         columnWithType{columnName: "id", typeOid: oid.T_int4, defaultExpr: "nextval('employees_id_seq'::regclass)"},
+			expectedDef: "id INT4 NOT NULL DEFAULT nextval('employees_id_seq')",
+			expectedSeq: "employees_id_seq",
+		},
+		{
+			desc:        "pg sequence in another schema",
+			col:         columnWithType{columnName: "id", typeOid: oid.T_int8, defaultExpr: `nextval('other."MySeq"'::regclass)`},
+			expectedDef: `id INT8 NOT NULL DEFAULT nextval('"MySeq"')`,
+			expectedSeq: "MySeq",
+		},
+		{
+			desc:        "pg identity",
+			col:         columnWithType{columnName: "id", typeOid: oid.T_int8, identity: "a"},
+			expectedDef: "id INT8 NOT NULL DEFAULT nextval('employees_id_seq')",
+			expectedSeq: "employees_id_seq",
+		},
+		{
+			desc:        "pg expression",
+			col:         columnWithType{columnName: "created_at", typeOid: oid.T_timestamptz, nullable: true, defaultExpr: "now()"},
+			expectedDef: "created_at TIMESTAMPTZ DEFAULT now()",
+		},
+		{
+			desc:        "pg literal",
+			col:         columnWithType{columnName: "name", typeOid: oid.T_varchar, nullable: true, defaultExpr: "'abc'::character varying"},
+			expectedDef: "name VARCHAR DEFAULT 'abc'::VARCHAR",
+		},
+		{
+			desc:        "pg function of an extension",
+			col:         columnWithType{columnName: "id", typeOid: oid.T_uuid, defaultExpr: "uuid_generate_v4()"},
+			expectedDef: "id UUID NOT NULL",
+		},
+		{
+			desc:        "pg qualified function",
+			col:         columnWithType{columnName: "n", typeOid: oid.T_int8, nullable: true, defaultExpr: "public.next_n()"},
+			expectedDef: "n INT8",
+		},
+		{
+			desc:        "pg builtin function",
+			col:         columnWithType{columnName: "id", typeOid: oid.T_uuid, defaultExpr: "gen_random_uuid()"},
+			expectedDef: "id UUID NOT NULL DEFAULT gen_random_uuid()",
+		},
+		{
+			desc: "mysql auto increment",
+			col: columnWithType{columnName: "id", dataType: "int", mysqlMeta: &typeconv.MySQLColumnMeta{
+				ColumnType: "int", Extra: "auto_increment",
+			}},
+			expectedDef: "id INT4 NOT NULL DEFAULT nextval('employees_id_seq')",
+			expectedSeq: "employees_id_seq",
+		},
+		{
+			desc: "mysql on update current timestamp",
+			col: columnWithType{columnName: "updated_at", dataType: "timestamp", nullable: true, mysqlMeta: &typeconv.MySQLColumnMeta{
+				ColumnDefault: "CURRENT_TIMESTAMP", Extra: "DEFAULT_GENERATED on update CURRENT_TIMESTAMP",
+			}},
+			expectedDef: "updated_at TIMESTAMPTZ(0) DEFAULT now() ON UPDATE now()",
+		},
+		{
+			desc: "mysql literal",
+			col: columnWithType{columnName: "name", dataType: "varchar", nullable: true, mysqlMeta: &typeconv.MySQLColumnMeta{
+				ColumnDefault: "it's", CharMaxLen: 10,
+			}},
+			expectedDef: `name VARCHAR DEFAULT e'it\'s'`,
+		},
+		{
+			desc: "mysql expression",
+			col: columnWithType{columnName: "total", dataType: "int", nullable: true, mysqlMeta: &typeconv.MySQLColumnMeta{
+				ColumnDefault: "(`amount` + 1)", Extra: "DEFAULT_GENERATED",
+			}},
+			expectedDef: "total INT4 DEFAULT (amount + 1)",
+		},
+		{
+			desc: "mysql function",
+			col: columnWithType{columnName: "id", dataType: "varchar", mysqlMeta: &typeconv.MySQLColumnMeta{
+				ColumnDefault: "uuid()", Extra: "DEFAULT_GENERATED",
+			}},
+			expectedDef: "id VARCHAR NOT NULL",
+		},
+		{
+			desc: "mysql bit literal",
+			col: columnWithType{columnName: "flags", dataType: "bit", nullable: true, mysqlMeta: &typeconv.MySQLColumnMeta{
+				ColumnType: "bit(4)", ColumnDefault: "b'0101'",
+			}},
+			expectedDef: "flags VARBIT DEFAULT B'0101'",
+		},
+		{
+			desc: "mysql binary literal",
+			col: columnWithType{columnName: "data", dataType: "varbinary", nullable: true, mysqlMeta: &typeconv.MySQLColumnMeta{
+				ColumnType: "varbinary(4)", ColumnDefault: "0x00ff", NumPrecision: -1,
+			}},
+			expectedDef: `data BYTES DEFAULT '\x00ff'`,
+		},
+		{
+			desc: "mysql bit literal of overridden type",
+			col: columnWithType{columnName: "flag", dataType: "bit", nullable: true, overrideType: crdbtypes.Bool, mysqlMeta: &typeconv.MySQLColumnMeta{
+				ColumnType: "bit(1)", ColumnDefault: "b'1'",
+			}},
+			expectedDef: "flag BOOL",
+		},
+		{
+			desc: "oracle identity",
+			col: columnWithType{columnName: "id", dataType: "NUMBER", identity: "d", oracleMeta: &typeconv.OracleColumnMeta{
+				DataPrecision: sql.NullInt64{Int64: 10, Valid: true}, DataScale: sql.NullInt64{Valid: true},
+			}},
+			expectedDef: "id INT8 NOT NULL DEFAULT nextval('employees_id_seq')",
+			expectedSeq: "employees_id_seq",
+		},
+		{
+			desc:        "oracle timestamp with time zone",
+			col:         columnWithType{columnName: "created_at", dataType: "TIMESTAMP(6) WITH TIME ZONE", nullable: true, oracleMeta: &typeconv.OracleColumnMeta{DataScale: sql.NullInt64{Int64: 6, Valid: true}}},
+			expectedDef: "created_at TIMESTAMPTZ(6)",
+		},
+		{
+			desc: "mysql overridden type",
+			col: columnWithType{columnName: "active", dataType: "tinyint", nullable: true, overrideType: crdbtypes.Bool, mysqlMeta: &typeconv.MySQLColumnMeta{
+				ColumnType: "tinyint(1)", ColumnDefault: "1",
+			}},
+			expectedDef: "active BOOL DEFAULT '1'",
+		},
+		{
+			desc:        "pg identity in a mapped schema",
+			col:         columnWithType{columnName: "id", typeOid: oid.T_int8, identity: "d", targetSchema: "sales"},
+			expectedDef: "id INT8 NOT NULL DEFAULT nextval('sales.employees_id_seq')",
+			expectedSeq: "employees_id_seq",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			tc.col.tableName = "employees"
+			def, err := tc.col.CRDBColDef(false /* includePk */, logger)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedDef, def.String())
+			require.Equal(t, tc.expectedSeq, tc.col.sequenceName())
+		})
+	}
+
+	t.Run("oracle unsupported type", func(t *testing.T) {
+		col := columnWithType{tableName: "employees", columnName: "doc", dataType: "XMLTYPE", oracleMeta: &typeconv.OracleColumnMeta{}}
+		_, err := col.CRDBColDef(false /* includePk */, logger)
+		require.EqualError(t, err, "failed to get crdb type from oracle type XMLTYPE for column employees.doc: Unsupported column type xmltype")
+	})
+}
@@ -0,0 +1,212 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/cockroachdb/molt/utils"
+	"github.com/cockroachdb/molt/utils/typeconv"
+	"github.com/cockroachdb/molt/verify/dbverify"
+	"github.com/rs/zerolog"
+)
+
+// tableSchema is the DDL converted for a source table.
+type tableSchema struct {
+	table dbtable.DBTable
+	// createStmt creates the table, and the enums and sequences it uses.
+	createStmt  string
+	deferredDDL []*status.DeferredDDL
+	// warnings are the warnings logged converting the table.
+	warnings []string
+	err      error
+}
+
+// ConvertSchema converts the schema of the source tables matching the filter
+// into a script of the DDL creating them on the target, which can be reviewed
+// and edited before it is executed with ApplySchema. The warnings raised
+// converting each table are written as comments above its DDL. Tables which
+// fail to convert are written as comments with their error, in which case the
+// script is returned with an error. Tables are named as given by the name
+// mapping, and the types of the columns matched by the type overrides replace
+// the default type mapping.
+func ConvertSchema(
+	ctx context.Context,
+	logger zerolog.Logger,
+	conn dbconn.Conn,
+	tableFilter utils.FilterConfig,
+	mapping utils.NameMapping,
+	overrides *typeconv.TypeOverrides,
+) (string, error) {
+	tables, err := dbverify.ListTables(ctx, conn)
+	if err != nil {
+		return "", err
+	}
+	if tables, err = utils.FilterTables(tableFilter, tables); err != nil {
+		return "", err
+	}
+
+	var schemas []tableSchema
+	var numFailed int
+	for _, table := range tables {
+		s := tableSchema{table: table}
+		// Warnings are written to the script whatever the logging level.
+		level := logger.GetLevel()
+		if level > zerolog.WarnLevel {
+			level = zerolog.WarnLevel
+		}
+		tableLogger := logger.Level(level).Hook(zerolog.HookFunc(func(e *zerolog.Event, l zerolog.Level, msg string) {
+			if l == zerolog.WarnLevel {
+				s.warnings = append(s.warnings, msg)
+			}
+		}))
+		s.createStmt, s.err = GetCreateTableStmt(ctx, tableLogger, conn, table, mapping, overrides)
+		if s.err == nil {
+			s.deferredDDL, s.err = GetDeferredDDL(ctx, tableLogger, conn, table, mapping)
+		}
+		if s.err != nil {
+			logger.Err(s.err).Str("table", table.SafeString()).Msgf("failed to convert table")
+			numFailed++
+		}
+		schemas = append(schemas, s)
+	}
+	logger.Info().Msgf("converted the schema of %d tables", len(tables)-numFailed)
+
+	script := formatSchemaScript(schemas)
+	if numFailed > 0 {
+		return script, errors.Newf("failed to convert %d of %d tables", numFailed, len(tables))
+	}
+	return script, nil
+}
+
+// schemaScriptPostLoadMarker separates the statements of a schema script
+// which are executed before the data is loaded from those executed once it
+// is loaded.
+const schemaScriptPostLoadMarker = "-- molt:post-load"
+
+// formatSchemaScript formats the converted tables as a script. The tables
+// are created before the data is loaded. The post-load section, which
+// follows the post-load marker, holds the statements fetch executes once the
+// data is loaded, in the same order: the indexes, constraints and sequence
+// advancements of each table, and then the foreign keys, which may
+// reference any table.
+func formatSchemaScript(schemas []tableSchema) string {
+	var sb strings.Builder
+	writeComment := func(comment string) {
+		for _, line := range strings.Split(comment, "\n") {
+			sb.WriteString(strings.TrimRight("-- "+line, " "))
+			sb.WriteString("\n")
+		}
+	}
+	writeStmt := func(stmt string) {
+		sb.WriteString(strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+		sb.WriteString(";\n")
+	}
+
+	writeComment("Generated by molt schema convert. Review the statements below before\nexecuting them with molt schema apply.")
+	var ddls, foreignKeys []*status.DeferredDDL
+	for _, s := range schemas {
+		sb.WriteString("\n")
+		writeComment(fmt.Sprintf("Table: %s", s.table.SafeString()))
+		for _, w := range s.warnings {
+			writeComment(fmt.Sprintf("WARNING: %s", w))
+		}
+		if s.err != nil {
+			writeComment(fmt.Sprintf("ERROR: %s", s.err))
+			continue
+		}
+		// The statement creating the table may be preceded by statements
+		// creating enums and sequences, which are written one per line.
+		if stmts, err := parser.Parse(s.createStmt); err == nil {
+			for _, stmt := range stmts {
+				writeStmt(stmt.SQL)
+			}
+		} else {
+			writeStmt(s.createStmt)
+		}
+		for _, d := range s.deferredDDL {
+			switch {
+			case d.State == status.DDLStateFailed:
+				// Failed DDL is reported by the warnings of the table.
+			case d.Kind == status.DDLKindForeignKey:
+				foreignKeys = append(foreignKeys, d)
+			default:
+				ddls = append(ddls, d)
+			}
+		}
+	}
+
+	if len(ddls) == 0 && len(foreignKeys) == 0 {
+		return sb.String()
+	}
+	sb.WriteString("\n")
+	sb.WriteString(schemaScriptPostLoadMarker)
+	sb.WriteString("\n")
+	writeComment("The statements below are executed by molt schema apply --post-load\nonce the data is loaded.")
+	for _, section := range []struct {
+		comment string
+		ddls    []*status.DeferredDDL
+	}{
+		{comment: "Indexes, constraints and sequences.", ddls: ddls},
+		{comment: "Foreign keys.", ddls: foreignKeys},
+	} {
+		if len(section.ddls) == 0 {
+			continue
+		}
+		sb.WriteString("\n")
+		writeComment(section.comment)
+		for _, d := range section.ddls {
+			writeStmt(d.Stmt)
+			if d.ValidateStmt != "" {
+				writeStmt(d.ValidateStmt)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// splitSchemaScript splits a schema script into the statements executed
+// before the data is loaded and those executed once it is loaded, which
+// follow the post-load marker. A script without the marker is executed
+// before the data is loaded.
+func splitSchemaScript(script string) (preLoad string, postLoad string) {
+	lines := strings.SplitAfter(script, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == schemaScriptPostLoadMarker {
+			return strings.Join(lines[:i], ""), strings.Join(lines[i+1:], "")
+		}
+	}
+	return script, ""
+}
+
+// ApplySchema executes the statements of a schema script, such as one written
+// by ConvertSchema, on the target one at a time. The statements before the
+// post-load marker are executed, or those after it if postLoad is set. The
+// whole section is parsed before any statement is executed.
+func ApplySchema(
+	ctx context.Context, logger zerolog.Logger, conn *dbconn.PGConn, script string, postLoad bool,
+) error {
+	preLoadScript, postLoadScript := splitSchemaScript(script)
+	if postLoad {
+		script = postLoadScript
+	} else {
+		script = preLoadScript
+	}
+	stmts, err := parser.Parse(script)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the schema script")
+	}
+	for i, stmt := range stmts {
+		logger.Info().Msgf("executing %q", stmt.SQL)
+		if _, err := conn.Exec(ctx, stmt.SQL); err != nil {
+			return errors.Wrapf(err, "failed to execute statement %d of %d: %s", i+1, len(stmts), stmt.SQL)
+		}
+	}
+	logger.Info().Msgf("executed %d statements", len(stmts))
+	return nil
+}
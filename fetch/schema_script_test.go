@@ -0,0 +1,88 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatSchemaScript(t *testing.T) {
+	departments := dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "departments"}}
+	staff := dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "staff"}}
+	broken := dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "broken"}}
+	script := formatSchemaScript([]tableSchema{
+		{
+			table:      departments,
+			createStmt: "CREATE SEQUENCE IF NOT EXISTS departments_id_seq; CREATE TABLE departments (id INT8 NOT NULL PRIMARY KEY DEFAULT nextval('departments_id_seq'), name STRING NOT NULL)",
+			deferredDDL: []*status.DeferredDDL{
+				{Kind: status.DDLKindConstraint, State: status.DDLStatePending, Stmt: "ALTER TABLE departments ADD CONSTRAINT departments_name_key UNIQUE (name)"},
+				{Kind: status.DDLKindSequence, State: status.DDLStatePending, Stmt: "SELECT setval('departments_id_seq', max(id)) FROM departments HAVING max(id) > (SELECT last_value FROM departments_id_seq)"},
+			},
+		},
+		{
+			table:      staff,
+			createStmt: "CREATE TABLE staff (id INT8 NOT NULL PRIMARY KEY, dept_id INT8, name STRING)",
+			deferredDDL: []*status.DeferredDDL{
+				{
+					Kind:         status.DDLKindForeignKey,
+					State:        status.DDLStatePending,
+					Stmt:         "ALTER TABLE staff ADD CONSTRAINT staff_dept_id_fkey FOREIGN KEY (dept_id) REFERENCES departments (id) NOT VALID",
+					ValidateStmt: "ALTER TABLE staff VALIDATE CONSTRAINT staff_dept_id_fkey",
+				},
+				{Kind: status.DDLKindIndex, State: status.DDLStateFailed, Message: "unable to translate: FULLTEXT indexes are not supported"},
+				{Kind: status.DDLKindIndex, State: status.DDLStatePending, Stmt: "CREATE INDEX staff_name_idx ON staff (name)"},
+			},
+			warnings: []string{
+				"mysql type tinyint for column staff.flag: TINYINT not supported",
+				`unable to recreate "FULLTEXT KEY ft (name)" on the target: unable to translate: FULLTEXT indexes are not supported`,
+			},
+		},
+		{
+			table: broken,
+			err:   errors.New("failed get columns for target table: public.broken:\nnested arrays are not supported"),
+		},
+	})
+	require.Equal(t, `-- Generated by molt schema convert. Review the statements below before
+-- executing them with molt schema apply.
+
+-- Table: public.departments
+CREATE SEQUENCE IF NOT EXISTS departments_id_seq;
+CREATE TABLE departments (id INT8 NOT NULL PRIMARY KEY DEFAULT nextval('departments_id_seq'), name STRING NOT NULL);
+
+-- Table: public.staff
+-- WARNING: mysql type tinyint for column staff.flag: TINYINT not supported
+-- WARNING: unable to recreate "FULLTEXT KEY ft (name)" on the target: unable to translate: FULLTEXT indexes are not supported
+CREATE TABLE staff (id INT8 NOT NULL PRIMARY KEY, dept_id INT8, name STRING);
+
+-- Table: public.broken
+-- ERROR: failed get columns for target table: public.broken:
+-- nested arrays are not supported
+
+-- molt:post-load
+-- The statements below are executed by molt schema apply --post-load
+-- once the data is loaded.
+
+-- Indexes, constraints and sequences.
+ALTER TABLE departments ADD CONSTRAINT departments_name_key UNIQUE (name);
+SELECT setval('departments_id_seq', max(id)) FROM departments HAVING max(id) > (SELECT last_value FROM departments_id_seq);
+CREATE INDEX staff_name_idx ON staff (name);
+
+-- Foreign keys.
+ALTER TABLE staff ADD CONSTRAINT staff_dept_id_fkey FOREIGN KEY (dept_id) REFERENCES departments (id) NOT VALID;
+ALTER TABLE staff VALIDATE CONSTRAINT staff_dept_id_fkey;
+`, script)
+}
+
+func TestSplitSchemaScript(t *testing.T) {
+	preLoad, postLoad := splitSchemaScript("CREATE TABLE t (id INT8 PRIMARY KEY);\n  -- molt:post-load \nCREATE INDEX ON t (id);\n")
+	require.Equal(t, "CREATE TABLE t (id INT8 PRIMARY KEY);\n", preLoad)
+	require.Equal(t, "CREATE INDEX ON t (id);\n", postLoad)
+
+	// Scripts without the marker are executed before the data is loaded.
+	preLoad, postLoad = splitSchemaScript("CREATE TABLE t (id INT8 PRIMARY KEY);\n")
+	require.Equal(t, "CREATE TABLE t (id INT8 PRIMARY KEY);\n", preLoad)
+	require.Empty(t, postLoad)
+}
@@ -0,0 +1,168 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const exportCheckpointsTable = "_molt_fetch_checkpoints"
+
+var (
+	deleteExportCheckpointsQuery = fmt.Sprintf("TRUNCATE %s;", exportCheckpointsTable)
+	createExportCheckpointsTable = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    fetch_id UUID NOT NULL REFERENCES _molt_fetch_status (id),
+    schema_name STRING NOT NULL,
+    table_name STRING NOT NULL,
+    shard_num INT8 NOT NULL,
+    start_pk STRING[],
+    end_pk STRING[],
+    last_pk STRING[],
+    file_num INT8 NOT NULL DEFAULT 0,
+    num_rows INT8 NOT NULL DEFAULT 0,
+    imported_file_num INT8 NOT NULL DEFAULT 0,
+    done BOOL NOT NULL DEFAULT false,
+    time TIMESTAMP,
+    PRIMARY KEY (fetch_id, schema_name, table_name, shard_num)
+);
+`, exportCheckpointsTable)
+)
+
+// ExportCheckpoint records how far the export of a shard of a table has
+// progressed. Every row of the shard up to and including LastPK has been
+// written to the files of the shard numbered up to FileNum.
+type ExportCheckpoint struct {
+	FetchID  uuid.UUID
+	Table    string
+	Schema   string
+	ShardNum int
+	// StartPK and EndPK are the bounds of the shard, formatted as text. An
+	// empty bound is the start or end of the table.
+	StartPK []string
+	EndPK   []string
+	// LastPK is the primary key of the last row written to a file, formatted
+	// as text. It is empty if the export of the shard cannot be resumed from
+	// a row.
+	LastPK  []string
+	FileNum int
+	NumRows int
+	// ImportedFileNum is the number of the last file of the shard which has
+	// been imported into the target. Files are imported in order.
+	ImportedFileNum int
+	Done            bool
+	Time            time.Time
+}
+
+func (c *ExportCheckpoint) args() pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"fetch_id":          c.FetchID,
+		"schema_name":       c.Schema,
+		"table_name":        c.Table,
+		"shard_num":         c.ShardNum,
+		"start_pk":          c.StartPK,
+		"end_pk":            c.EndPK,
+		"last_pk":           c.LastPK,
+		"file_num":          c.FileNum,
+		"num_rows":          c.NumRows,
+		"imported_file_num": c.ImportedFileNum,
+		"done":              c.Done,
+		"time":              c.Time,
+	}
+}
+
+var upsertExportCheckpointQuery = fmt.Sprintf(`UPSERT INTO %s (fetch_id, schema_name, table_name, shard_num, start_pk, end_pk, last_pk, file_num, num_rows, imported_file_num, done, time) VALUES(@fetch_id, @schema_name, @table_name, @shard_num, @start_pk, @end_pk, @last_pk, @file_num, @num_rows, @imported_file_num, @done, @time)`, exportCheckpointsTable)
+
+// CreateEntry writes the checkpoint for a shard which is starting to be
+// exported.
+func (c *ExportCheckpoint) CreateEntry(ctx context.Context, conn *pgx.Conn) error {
+	c.Time = time.Now().UTC()
+	_, err := conn.Exec(ctx, upsertExportCheckpointQuery, c.args())
+	return err
+}
+
+// UpdateEntry persists the progress of the export and import of the shard.
+func (c *ExportCheckpoint) UpdateEntry(ctx context.Context, conn *pgx.Conn) error {
+	c.Time = time.Now().UTC()
+	query := fmt.Sprintf(`UPDATE %s SET last_pk=@last_pk, file_num=@file_num, num_rows=@num_rows, imported_file_num=@imported_file_num, done=@done, time=@time
+	WHERE fetch_id=@fetch_id AND schema_name=@schema_name AND table_name=@table_name AND shard_num=@shard_num`, exportCheckpointsTable)
+	_, err := conn.Exec(ctx, query, c.args())
+	return err
+}
+
+// CreateSplitEntry writes the checkpoint for a shard split off from the
+// remaining rows of the shard numbered parentShardNum, and moves the end of
+// the parent shard to the start of the new shard. Both are written in a
+// single transaction, so that every row of the table is always covered by
+// exactly one checkpoint.
+func (c *ExportCheckpoint) CreateSplitEntry(
+	ctx context.Context, conn *pgx.Conn, parentShardNum int,
+) error {
+	c.Time = time.Now().UTC()
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		query := fmt.Sprintf(`UPDATE %s SET end_pk=@start_pk, time=@time
+	WHERE fetch_id=@fetch_id AND schema_name=@schema_name AND table_name=@table_name AND shard_num=@parent_shard_num`, exportCheckpointsTable)
+		args := c.args()
+		args["parent_shard_num"] = parentShardNum
+		if _, err := tx.Exec(ctx, query, args); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, upsertExportCheckpointQuery, c.args())
+		return err
+	})
+}
+
+// GetAllExportCheckpointsByFetchID returns the checkpoints of every shard
+// exported by the given fetch, ordered by table and shard.
+func GetAllExportCheckpointsByFetchID(
+	ctx context.Context, conn *pgx.Conn, fetchID string,
+) ([]*ExportCheckpoint, error) {
+	query := fmt.Sprintf(`SELECT fetch_id, schema_name, table_name, shard_num, start_pk, end_pk, last_pk, file_num, num_rows, imported_file_num, done, time
+	FROM %s
+	WHERE fetch_id=@fetch_id
+	ORDER BY schema_name, table_name, shard_num`, exportCheckpointsTable)
+	args := pgx.NamedArgs{
+		"fetch_id": fetchID,
+	}
+	checkpoints := []*ExportCheckpoint{}
+
+	rows, err := conn.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := &ExportCheckpoint{}
+		if err := rows.Scan(&c.FetchID, &c.Schema, &c.Table, &c.ShardNum, &c.StartPK, &c.EndPK,
+			&c.LastPK, &c.FileNum, &c.NumRows, &c.ImportedFileNum, &c.Done, &c.Time); err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, c)
+	}
+
+	return checkpoints, rows.Err()
+}
+
+func GetTableSchemaToExportCheckpoints(
+	checkpoints []*ExportCheckpoint,
+) map[string][]*ExportCheckpoint {
+	mapping := map[string][]*ExportCheckpoint{}
+
+	for _, c := range checkpoints {
+		key := fmt.Sprintf("%s.%s", c.Schema, c.Table)
+		mapping[key] = append(mapping[key], c)
+	}
+	return mapping
+}
+
+// Used to clear the export checkpoints on fresh runs.
+func DeleteAllExportCheckpoints(ctx context.Context, conn *pgx.Conn) error {
+	if _, err := conn.Exec(ctx, deleteExportCheckpointsQuery); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package status
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	dbName := "fetch_test_export_checkpoints"
+
+	conn, err := dbconn.TestOnlyCleanDatabase(ctx, "target", testutils.CRDBConnStr(), dbName)
+	require.NoError(t, err)
+	pgConn := conn.(*dbconn.PGConn).Conn
+	// Setup the tables that we need to write for status.
+	require.NoError(t, CreateStatusAndExceptionTables(ctx, pgConn))
+
+	s := &FetchStatus{
+		Name:          "run 1",
+		StartedAt:     time.Now(),
+		SourceDialect: "postgres",
+	}
+	require.NoError(t, s.CreateEntry(ctx, pgConn))
+
+	first := &ExportCheckpoint{
+		FetchID:  s.ID,
+		Table:    "employees",
+		Schema:   "public",
+		ShardNum: 1,
+		EndPK:    []string{"100"},
+	}
+	require.NoError(t, first.CreateEntry(ctx, pgConn))
+	second := &ExportCheckpoint{
+		FetchID:  s.ID,
+		Table:    "employees",
+		Schema:   "public",
+		ShardNum: 2,
+		StartPK:  []string{"100"},
+	}
+	require.NoError(t, second.CreateEntry(ctx, pgConn))
+
+	// Record progress on the first shard.
+	first.LastPK = []string{"10"}
+	first.FileNum = 1
+	first.NumRows = 10
+	require.NoError(t, first.UpdateEntry(ctx, pgConn))
+
+	// Split the remaining rows of the first shard.
+	split := &ExportCheckpoint{
+		FetchID:  s.ID,
+		Table:    "employees",
+		Schema:   "public",
+		ShardNum: 3,
+		StartPK:  []string{"50"},
+		EndPK:    []string{"100"},
+	}
+	require.NoError(t, split.CreateSplitEntry(ctx, pgConn, first.ShardNum))
+
+	second.Done = true
+	require.NoError(t, second.UpdateEntry(ctx, pgConn))
+
+	checkpoints, err := GetAllExportCheckpointsByFetchID(ctx, pgConn, s.ID.String())
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 3)
+	mapping := GetTableSchemaToExportCheckpoints(checkpoints)
+	require.Len(t, mapping["public.employees"], 3)
+
+	type shardState struct {
+		startPK, endPK, lastPK []string
+		fileNum, numRows       int
+		done                   bool
+	}
+	var states []shardState
+	for _, c := range checkpoints {
+		states = append(states, shardState{
+			startPK: c.StartPK,
+			endPK:   c.EndPK,
+			lastPK:  c.LastPK,
+			fileNum: c.FileNum,
+			numRows: c.NumRows,
+			done:    c.Done,
+		})
+	}
+	require.Equal(t, []shardState{
+		{endPK: []string{"50"}, lastPK: []string{"10"}, fileNum: 1, numRows: 10},
+		{startPK: []string{"100"}, done: true},
+		{startPK: []string{"50"}, endPK: []string{"100"}},
+	}, states)
+
+	require.NoError(t, DeleteAllExportCheckpoints(ctx, pgConn))
+	checkpoints, err = GetAllExportCheckpointsByFetchID(ctx, pgConn, s.ID.String())
+	require.NoError(t, err)
+	require.Empty(t, checkpoints)
+}
@@ -0,0 +1,152 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const deferredDDLTable = "_molt_fetch_deferred_ddl"
+
+// The kinds of deferred DDL.
+const (
+	DDLKindIndex      = "index"
+	DDLKindConstraint = "constraint"
+	DDLKindForeignKey = "foreign_key"
+	// DDLKindSequence advances a sequence past the imported values.
+	DDLKindSequence = "sequence"
+)
+
+// The states of deferred DDL.
+const (
+	// DDLStatePending is DDL which has not been applied yet.
+	DDLStatePending = "pending"
+	// DDLStateApplied is DDL which has been applied and validated.
+	DDLStateApplied = "applied"
+	// DDLStateNotValid is a constraint which has been added, but which the
+	// imported data failed to validate against.
+	DDLStateNotValid = "not_valid"
+	// DDLStateFailed is DDL which could not be translated or applied.
+	DDLStateFailed = "failed"
+)
+
+var (
+	deleteDeferredDDLQuery = fmt.Sprintf("TRUNCATE %s;", deferredDDLTable)
+	createDeferredDDLTable = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    fetch_id UUID NOT NULL REFERENCES _molt_fetch_status (id),
+    schema_name STRING NOT NULL,
+    table_name STRING NOT NULL,
+    name STRING NOT NULL,
+    kind STRING NOT NULL,
+    source_def STRING NOT NULL DEFAULT '',
+    stmt STRING NOT NULL DEFAULT '',
+    validate_stmt STRING NOT NULL DEFAULT '',
+    state STRING NOT NULL,
+    message STRING NOT NULL DEFAULT '',
+    time TIMESTAMP,
+    PRIMARY KEY (fetch_id, schema_name, table_name, name)
+);
+`, deferredDDLTable)
+)
+
+// DeferredDDL is an index or constraint of a source table which is created
+// on the target once the data of the table has been imported, or a sequence
+// which is advanced past the imported values.
+type DeferredDDL struct {
+	FetchID uuid.UUID
+	Table   string
+	Schema  string
+	Name    string
+	Kind    string
+	// SourceDef is the definition of the index or constraint on the source.
+	SourceDef string
+	// Stmt creates the index or constraint on the target. Constraints are
+	// created without validating the existing rows.
+	Stmt string
+	// ValidateStmt validates a constraint created by Stmt. It is empty if
+	// the statement validates the rows itself.
+	ValidateStmt string
+	State        string
+	// Message is the error encountered translating, applying or validating
+	// the DDL.
+	Message string
+	Time    time.Time
+}
+
+func (d *DeferredDDL) args() pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"fetch_id":      d.FetchID,
+		"schema_name":   d.Schema,
+		"table_name":    d.Table,
+		"name":          d.Name,
+		"kind":          d.Kind,
+		"source_def":    d.SourceDef,
+		"stmt":          d.Stmt,
+		"validate_stmt": d.ValidateStmt,
+		"state":         d.State,
+		"message":       d.Message,
+		"time":          d.Time,
+	}
+}
+
+// CreateEntry records the DDL to apply for the fetch.
+func (d *DeferredDDL) CreateEntry(ctx context.Context, conn *pgx.Conn) error {
+	d.Time = time.Now().UTC()
+	query := fmt.Sprintf(`UPSERT INTO %s (fetch_id, schema_name, table_name, name, kind, source_def, stmt, validate_stmt, state, message, time) VALUES(@fetch_id, @schema_name, @table_name, @name, @kind, @source_def, @stmt, @validate_stmt, @state, @message, @time)`, deferredDDLTable)
+	_, err := conn.Exec(ctx, query, d.args())
+	return err
+}
+
+// UpdateState persists the state of the DDL and the error encountered, if
+// any.
+func (d *DeferredDDL) UpdateState(ctx context.Context, conn *pgx.Conn) error {
+	d.Time = time.Now().UTC()
+	query := fmt.Sprintf(`UPDATE %s SET state=@state, message=@message, time=@time
+	WHERE fetch_id=@fetch_id AND schema_name=@schema_name AND table_name=@table_name AND name=@name`, deferredDDLTable)
+	_, err := conn.Exec(ctx, query, d.args())
+	return err
+}
+
+// GetAllDeferredDDLByFetchID returns the deferred DDL of every table of the
+// given fetch, ordered by table and name.
+func GetAllDeferredDDLByFetchID(
+	ctx context.Context, conn *pgx.Conn, fetchID string,
+) ([]*DeferredDDL, error) {
+	query := fmt.Sprintf(`SELECT fetch_id, schema_name, table_name, name, kind, source_def, stmt, validate_stmt, state, message, time
+	FROM %s
+	WHERE fetch_id=@fetch_id
+	ORDER BY schema_name, table_name, name`, deferredDDLTable)
+	args := pgx.NamedArgs{
+		"fetch_id": fetchID,
+	}
+	ddls := []*DeferredDDL{}
+
+	rows, err := conn.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		d := &DeferredDDL{}
+		if err := rows.Scan(&d.FetchID, &d.Schema, &d.Table, &d.Name, &d.Kind, &d.SourceDef,
+			&d.Stmt, &d.ValidateStmt, &d.State, &d.Message, &d.Time); err != nil {
+			return nil, err
+		}
+		ddls = append(ddls, d)
+	}
+
+	return ddls, rows.Err()
+}
+
+// Used to clear the deferred DDL on fresh runs.
+func DeleteAllDeferredDDL(ctx context.Context, conn *pgx.Conn) error {
+	if _, err := conn.Exec(ctx, deleteDeferredDDLQuery); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package status
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeferredDDL(t *testing.T) {
+	ctx := context.Background()
+	dbName := "fetch_test_deferred_ddl"
+
+	conn, err := dbconn.TestOnlyCleanDatabase(ctx, "target", testutils.CRDBConnStr(), dbName)
+	require.NoError(t, err)
+	pgConn := conn.(*dbconn.PGConn).Conn
+	// Setup the tables that we need to write for status.
+	require.NoError(t, CreateStatusAndExceptionTables(ctx, pgConn))
+
+	s := &FetchStatus{
+		Name:          "run 1",
+		StartedAt:     time.Now(),
+		SourceDialect: "postgres",
+	}
+	require.NoError(t, s.CreateEntry(ctx, pgConn))
+
+	idx := &DeferredDDL{
+		FetchID:   s.ID,
+		Table:     "employees",
+		Schema:    "public",
+		Name:      "employees_name_idx",
+		Kind:      DDLKindIndex,
+		SourceDef: "CREATE INDEX employees_name_idx ON public.employees USING btree (name)",
+		Stmt:      "CREATE INDEX employees_name_idx ON employees (name)",
+		State:     DDLStatePending,
+	}
+	require.NoError(t, idx.CreateEntry(ctx, pgConn))
+	fk := &DeferredDDL{
+		FetchID:      s.ID,
+		Table:        "employees",
+		Schema:       "public",
+		Name:         "employees_dept_fkey",
+		Kind:         DDLKindForeignKey,
+		SourceDef:    "FOREIGN KEY (dept_id) REFERENCES departments(id)",
+		Stmt:         "ALTER TABLE employees ADD CONSTRAINT employees_dept_fkey FOREIGN KEY (dept_id) REFERENCES departments (id) NOT VALID",
+		ValidateStmt: "ALTER TABLE employees VALIDATE CONSTRAINT employees_dept_fkey",
+		State:        DDLStatePending,
+	}
+	require.NoError(t, fk.CreateEntry(ctx, pgConn))
+
+	fk.State = DDLStateNotValid
+	fk.Message = "foreign key violation"
+	require.NoError(t, fk.UpdateState(ctx, pgConn))
+
+	ddls, err := GetAllDeferredDDLByFetchID(ctx, pgConn, s.ID.String())
+	require.NoError(t, err)
+	require.Len(t, ddls, 2)
+	// Entries are ordered by name.
+	require.Equal(t, fk.Name, ddls[0].Name)
+	require.Equal(t, DDLStateNotValid, ddls[0].State)
+	require.Equal(t, "foreign key violation", ddls[0].Message)
+	require.Equal(t, fk.ValidateStmt, ddls[0].ValidateStmt)
+	require.Equal(t, idx.Name, ddls[1].Name)
+	require.Equal(t, DDLStatePending, ddls[1].State)
+	require.Equal(t, idx.Stmt, ddls[1].Stmt)
+
+	require.NoError(t, DeleteAllDeferredDDL(ctx, pgConn))
+	ddls, err = GetAllDeferredDDLByFetchID(ctx, pgConn, s.ID.String())
+	require.NoError(t, err)
+	require.Empty(t, ddls)
+}
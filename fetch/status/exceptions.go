@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbtable"
@@ -43,16 +44,16 @@ var (
 )
 
 type ExceptionLog struct {
-	ID       uuid.UUID
-	FetchID  uuid.UUID
-	Table    string
-	Schema   string
-	Message  string
-	SQLState string
-	FileName string
-	Command  string
-	Stage    string
-	Time     time.Time
+	ID       uuid.UUID `json:"id"`
+	FetchID  uuid.UUID `json:"fetch_id"`
+	Table    string    `json:"table"`
+	Schema   string    `json:"schema"`
+	Message  string    `json:"message"`
+	SQLState string    `json:"sql_state"`
+	FileName string    `json:"file_name"`
+	Command  string    `json:"command"`
+	Stage    string    `json:"stage"`
+	Time     time.Time `json:"time"`
 }
 
 // Implementing the utils.OutputFormat interface.
@@ -63,11 +64,11 @@ func (l ExceptionLog) JSONFormat() string {
 }
 
 func (l ExceptionLog) TableFormat() []string {
-	return []string{l.ID.String(), l.FetchID.String(), fmt.Sprintf("%s.%s", l.Schema, l.Table), l.FileName}
+	return []string{l.ID.String(), l.FetchID.String(), fmt.Sprintf("%s.%s", l.Schema, l.Table), l.Stage, l.FileName}
 }
 
 func (l ExceptionLog) TableHeaders() []string {
-	return []string{"ID", "FETCH ID", "TABLE NAME", "FILE NAME"}
+	return []string{"ID", "FETCH ID", "TABLE NAME", "STAGE", "FILE NAME"}
 }
 
 func (l ExceptionLog) Caption() string {
@@ -177,19 +178,71 @@ func GetExceptionLogByToken(
 
 const defaultNumTokens = 10
 
+// ExceptionLogFilter selects exception logs. Fields which are empty select
+// every log.
+type ExceptionLogFilter struct {
+	IDs     []string
+	FetchID string
+	Stage   string
+	Tables  utils.FilterConfig
+}
+
+// DefaultExceptionLogFilter returns the filter selecting every log.
+func DefaultExceptionLogFilter() ExceptionLogFilter {
+	return ExceptionLogFilter{Tables: utils.DefaultFilterConfig()}
+}
+
+// IsDefault returns whether the filter selects every log.
+func (f ExceptionLogFilter) IsDefault() bool {
+	return len(f.IDs) == 0 && f.FetchID == "" && f.Stage == "" && f.Tables.IsDefault()
+}
+
+// GetAllExceptionLogs returns up to numResults exception logs selected by the
+// filter.
 func GetAllExceptionLogs(
-	ctx context.Context, conn *pgx.Conn, numResults int,
+	ctx context.Context, conn *pgx.Conn, numResults int, filter ExceptionLogFilter,
 ) ([]ExceptionLog, error) {
 	if numResults == 0 {
 		numResults = defaultNumTokens
 	}
+	return getExceptionLogs(ctx, conn, numResults, filter)
+}
 
-	query := fmt.Sprintf(`SELECT id, fetch_id, table_name, schema_name, file_name, time 
-	FROM %s
-	ORDER BY table_name DESC
-	LIMIT @limit`, exceptionsTable)
-	args := pgx.NamedArgs{
-		"limit": numResults,
+// getExceptionLogs returns up to limit exception logs selected by the filter,
+// or all of them if limit is negative.
+func getExceptionLogs(
+	ctx context.Context, conn *pgx.Conn, limit int, filter ExceptionLogFilter,
+) ([]ExceptionLog, error) {
+	matcher, err := utils.NewTableMatcher(filter.Tables)
+	if err != nil {
+		return nil, err
+	}
+
+	var conds []string
+	args := pgx.NamedArgs{}
+	if len(filter.IDs) > 0 {
+		conds = append(conds, "id = ANY(@ids)")
+		args["ids"] = filter.IDs
+	}
+	if filter.FetchID != "" {
+		conds = append(conds, "fetch_id = @fetch_id")
+		args["fetch_id"] = filter.FetchID
+	}
+	if filter.Stage != "" {
+		conds = append(conds, "stage = @stage")
+		args["stage"] = filter.Stage
+	}
+	query := fmt.Sprintf(`SELECT id, fetch_id, table_name, schema_name, COALESCE(message, ''), COALESCE(sql_state, ''), COALESCE(file_name, ''), COALESCE(command, ''), COALESCE(stage, ''), time 
+	FROM %s`, exceptionsTable)
+	if len(conds) > 0 {
+		query += "\n\tWHERE " + strings.Join(conds, " AND ")
+	}
+	query += "\n\tORDER BY table_name DESC"
+	// The logs are filtered by table once read, so they are only limited in
+	// the query if every table is selected.
+	if limit >= 0 && filter.Tables.IsDefault() {
+		query += "\n\tLIMIT @limit"
+		args["limit"] = limit
 	}
 	excLogs := []ExceptionLog{}
 
@@ -199,15 +252,44 @@ func GetAllExceptionLogs(
 	}
 	defer rows.Close()
 
-	for rows.Next() {
+	for rows.Next() && (limit < 0 || len(excLogs) < limit) {
 		e := ExceptionLog{}
-		if err := rows.Scan(&e.ID, &e.FetchID, &e.Table, &e.Schema, &e.FileName, &e.Time); err != nil {
+		if err := rows.Scan(&e.ID, &e.FetchID, &e.Table, &e.Schema, &e.Message,
+			&e.SQLState, &e.FileName, &e.Command, &e.Stage, &e.Time); err != nil {
 			return nil, err
 		}
+		if !matcher.Matches(dbtable.Name{Schema: tree.Name(e.Schema), Table: tree.Name(e.Table)}) {
+			continue
+		}
 		excLogs = append(excLogs, e)
 	}
 
-	return excLogs, nil
+	return excLogs, rows.Err()
+}
+
+// DeleteExceptionLogs deletes the exception logs selected by the filter, and
+// returns them.
+func DeleteExceptionLogs(
+	ctx context.Context, conn *pgx.Conn, filter ExceptionLogFilter,
+) ([]ExceptionLog, error) {
+	var deleted []ExceptionLog
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		var err error
+		if deleted, err = getExceptionLogs(ctx, tx.Conn(), -1 /* limit */, filter); err != nil {
+			return err
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+		ids := make([]string, len(deleted))
+		for i, e := range deleted {
+			ids[i] = e.ID.String()
+		}
+		query := fmt.Sprintf(`DELETE FROM %s WHERE id = ANY(@ids)`, exceptionsTable)
+		_, err = tx.Exec(ctx, query, pgx.NamedArgs{"ids": ids})
+		return err
+	})
+	return deleted, err
 }
 
 func GetAllExceptionLogsByFetchID(
@@ -336,7 +418,7 @@ func MaybeReportException(
 	return inputErr
 }
 
-var fileNameRegEx = regexp.MustCompile(`part_[\d+]{8}(\.csv|\.tar\.gz)`)
+var fileNameRegEx = regexp.MustCompile(`part_[\d+]{8}(\.csv|\.tar\.gz|\.parquet)`)
 
 func ExtractFileNameFromErr(errString string) string {
 	return fileNameRegEx.FindString(errString)
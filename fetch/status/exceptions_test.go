@@ -2,12 +2,15 @@ package status
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/testutils"
+	"github.com/cockroachdb/molt/utils"
 	"github.com/stretchr/testify/require"
 )
 
@@ -231,6 +234,104 @@ func TestGetAllExceptionLogsByFetchID(t *testing.T) {
 	})
 }
 
+func TestFilterAndDeleteExceptionLogs(t *testing.T) {
+	ctx := context.Background()
+	dbName := "fetch_test_filter_exception_logs"
+
+	conn, err := dbconn.TestOnlyCleanDatabase(ctx, "target", testutils.CRDBConnStr(), dbName)
+	require.NoError(t, err)
+	pgConn := conn.(*dbconn.PGConn).Conn
+	// Setup the tables that we need to write for status.
+	require.NoError(t, CreateStatusAndExceptionTables(ctx, pgConn))
+
+	var fetchIDs []string
+	var logs []*ExceptionLog
+	for i, run := range []string{"run 1", "run 2"} {
+		s := &FetchStatus{
+			Name:          run,
+			StartedAt:     time.Now(),
+			SourceDialect: "postgres",
+		}
+		require.NoError(t, s.CreateEntry(ctx, pgConn))
+		fetchIDs = append(fetchIDs, s.ID.String())
+		for _, tc := range []struct {
+			table, stage string
+		}{
+			{"employees", StageDataLoad},
+			{"salary", StageSchemaCreation},
+		} {
+			e := &ExceptionLog{
+				FetchID:  s.ID,
+				FileName: fmt.Sprintf("test%d.log", i),
+				Table:    tc.table,
+				Schema:   "public",
+				Message:  "this all failed",
+				SQLState: "1000",
+				Command:  "SELECT VERSION()",
+			}
+			require.NoError(t, e.CreateEntry(ctx, pgConn, tc.stage))
+			logs = append(logs, e)
+		}
+	}
+
+	tableFilter := func(include ...string) utils.FilterConfig {
+		ret := utils.DefaultFilterConfig()
+		ret.Include = include
+		return ret
+	}
+	ids := func(logs []ExceptionLog) []string {
+		var ret []string
+		for _, l := range logs {
+			ret = append(ret, l.ID.String())
+		}
+		sort.Strings(ret)
+		return ret
+	}
+	expectedIDs := func(logs ...*ExceptionLog) []string {
+		var ret []string
+		for _, l := range logs {
+			ret = append(ret, l.ID.String())
+		}
+		sort.Strings(ret)
+		return ret
+	}
+
+	for _, tc := range []struct {
+		desc     string
+		filter   ExceptionLogFilter
+		expected []string
+	}{
+		{desc: "all", filter: DefaultExceptionLogFilter(), expected: expectedIDs(logs...)},
+		{desc: "fetch ID", filter: ExceptionLogFilter{FetchID: fetchIDs[1], Tables: tableFilter()}, expected: expectedIDs(logs[2], logs[3])},
+		{desc: "stage", filter: ExceptionLogFilter{Stage: StageSchemaCreation, Tables: tableFilter()}, expected: expectedIDs(logs[1], logs[3])},
+		{desc: "table", filter: ExceptionLogFilter{Tables: tableFilter("employees")}, expected: expectedIDs(logs[0], logs[2])},
+		{desc: "IDs", filter: ExceptionLogFilter{IDs: []string{logs[0].ID.String(), logs[3].ID.String()}, Tables: tableFilter()}, expected: expectedIDs(logs[0], logs[3])},
+		{desc: "combined", filter: ExceptionLogFilter{FetchID: fetchIDs[0], Stage: StageDataLoad, Tables: tableFilter()}, expected: expectedIDs(logs[0])},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			res, err := GetAllExceptionLogs(ctx, pgConn, 100, tc.filter)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ids(res))
+		})
+	}
+
+	// Every detail of the logs is returned.
+	res, err := GetAllExceptionLogs(ctx, pgConn, 1, ExceptionLogFilter{IDs: []string{logs[1].ID.String()}, Tables: tableFilter()})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, "this all failed", res[0].Message)
+	require.Equal(t, "1000", res[0].SQLState)
+	require.Equal(t, "SELECT VERSION()", res[0].Command)
+	require.Equal(t, StageSchemaCreation, res[0].Stage)
+
+	deleted, err := DeleteExceptionLogs(ctx, pgConn, ExceptionLogFilter{FetchID: fetchIDs[0], Tables: tableFilter()})
+	require.NoError(t, err)
+	require.Equal(t, expectedIDs(logs[0], logs[1]), ids(deleted))
+	res, err = GetAllExceptionLogs(ctx, pgConn, 100, DefaultExceptionLogFilter())
+	require.NoError(t, err)
+	require.Equal(t, expectedIDs(logs[2], logs[3]), ids(res))
+}
+
 func TestExtractFileNameFromErr(t *testing.T) {
 	type args struct {
 		errString string
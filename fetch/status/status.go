@@ -40,3 +40,16 @@ func (s *FetchStatus) CreateEntry(ctx context.Context, conn *pgx.Conn) error {
 	s.StartedAt = startTime
 	return nil
 }
+
+// GetFetchStatusByID returns the fetch with the given ID.
+func GetFetchStatusByID(ctx context.Context, conn *pgx.Conn, fetchID string) (*FetchStatus, error) {
+	query := `SELECT id, name, started_at, source_dialect FROM _molt_fetch_status WHERE id=@id`
+	args := pgx.NamedArgs{
+		"id": fetchID,
+	}
+	s := &FetchStatus{}
+	if err := conn.QueryRow(ctx, query, args).Scan(&s.ID, &s.Name, &s.StartedAt, &s.SourceDialect); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
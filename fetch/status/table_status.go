@@ -0,0 +1,236 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+	"github.com/cockroachdb/molt/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+const tableStatusTable = "_molt_fetch_table_status"
+
+var createTableStatusTable = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    fetch_id UUID NOT NULL REFERENCES _molt_fetch_status (id),
+    schema_name STRING NOT NULL,
+    table_name STRING NOT NULL,
+    state STRING NOT NULL,
+    exported_rows INT8 NOT NULL DEFAULT 0,
+    imported_rows INT8 NOT NULL DEFAULT 0,
+    exported_files INT8 NOT NULL DEFAULT 0,
+    imported_files INT8 NOT NULL DEFAULT 0,
+    export_duration_ms INT8 NOT NULL DEFAULT 0,
+    import_duration_ms INT8 NOT NULL DEFAULT 0,
+    verified BOOL NOT NULL DEFAULT false,
+    missing_rows INT8 NOT NULL DEFAULT 0,
+    mismatching_rows INT8 NOT NULL DEFAULT 0,
+    extraneous_rows INT8 NOT NULL DEFAULT 0,
+    cdc_cursor STRING,
+    error STRING,
+    started_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    PRIMARY KEY (fetch_id, schema_name, table_name)
+);
+`, tableStatusTable)
+
+// TableState is the stage a table of a fetch has reached.
+type TableState string
+
+const (
+	TableStatePending   TableState = "pending"
+	TableStateExporting TableState = "exporting"
+	TableStateImporting TableState = "importing"
+	TableStateVerifying TableState = "verifying"
+	TableStateDone      TableState = "done"
+	TableStateFailed    TableState = "failed"
+)
+
+// TableStatus records the progress of a table of a fetch. Continuing a
+// fetch with its ID updates the status of the tables it fetches again.
+type TableStatus struct {
+	FetchID        uuid.UUID     `json:"fetch_id"`
+	Schema         string        `json:"schema"`
+	Table          string        `json:"table"`
+	State          TableState    `json:"state"`
+	ExportedRows   int           `json:"exported_rows"`
+	ImportedRows   int           `json:"imported_rows"`
+	ExportedFiles  int           `json:"exported_files"`
+	ImportedFiles  int           `json:"imported_files"`
+	ExportDuration time.Duration `json:"-"`
+	ImportDuration time.Duration `json:"-"`
+	CDCCursor      string        `json:"cdc_cursor"`
+	// Verified is whether the rows of the table were verified after it was
+	// loaded, which found the given numbers of inconsistent rows.
+	Verified        bool `json:"verified"`
+	MissingRows     int  `json:"missing_rows"`
+	MismatchingRows int  `json:"mismatching_rows"`
+	ExtraneousRows  int  `json:"extraneous_rows"`
+	// Error is the error which failed the table, if its state is failed.
+	Error string `json:"error,omitempty"`
+	// StartedAt is zero if the table is pending.
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MarshalJSON formats the durations in milliseconds.
+func (s TableStatus) MarshalJSON() ([]byte, error) {
+	type tableStatus TableStatus
+	return json.Marshal(struct {
+		tableStatus
+		ExportDurationMs int64 `json:"export_duration_ms"`
+		ImportDurationMs int64 `json:"import_duration_ms"`
+	}{
+		tableStatus:      tableStatus(s),
+		ExportDurationMs: s.ExportDuration.Milliseconds(),
+		ImportDurationMs: s.ImportDuration.Milliseconds(),
+	})
+}
+
+// Implementing the utils.OutputFormat interface.
+var _ utils.OutputFormat = TableStatus{}
+
+func (s TableStatus) JSONFormat() string {
+	return utils.PrettyJSON(s)
+}
+
+func (s TableStatus) TableFormat() []string {
+	return []string{
+		fmt.Sprintf("%s.%s", s.Schema, s.Table),
+		string(s.State),
+		strconv.Itoa(s.ExportedRows),
+		strconv.Itoa(s.ImportedRows),
+		strconv.Itoa(s.ExportedFiles),
+		strconv.Itoa(s.ImportedFiles),
+		utils.FormatDurationToTimeString(s.ExportDuration),
+		utils.FormatDurationToTimeString(s.ImportDuration),
+		s.CDCCursor,
+		s.verificationString(),
+		s.Error,
+	}
+}
+
+// verificationString summarizes the verification of the table after it was
+// loaded, if any.
+func (s TableStatus) verificationString() string {
+	if !s.Verified {
+		return ""
+	}
+	var counts []string
+	for _, c := range []struct {
+		n    int
+		kind string
+	}{
+		{s.MissingRows, "missing"},
+		{s.MismatchingRows, "mismatching"},
+		{s.ExtraneousRows, "extraneous"},
+	} {
+		if c.n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", c.n, c.kind))
+		}
+	}
+	if len(counts) == 0 {
+		return "ok"
+	}
+	return strings.Join(counts, ", ")
+}
+
+func (s TableStatus) TableHeaders() []string {
+	return []string{
+		"TABLE NAME", "STATE", "EXPORTED ROWS", "IMPORTED ROWS", "EXPORTED FILES",
+		"IMPORTED FILES", "EXPORT DURATION", "IMPORT DURATION", "CDC CURSOR", "VERIFICATION", "ERROR",
+	}
+}
+
+func (s TableStatus) Caption() string {
+	return "Table Status."
+}
+
+// UpsertEntry persists the status of the table.
+func (s *TableStatus) UpsertEntry(ctx context.Context, conn *pgx.Conn) error {
+	s.UpdatedAt = time.Now().UTC()
+	var startedAt *time.Time
+	if !s.StartedAt.IsZero() {
+		startedAt = &s.StartedAt
+	}
+	query := fmt.Sprintf(`UPSERT INTO %s (fetch_id, schema_name, table_name, state, exported_rows, imported_rows, exported_files, imported_files, export_duration_ms, import_duration_ms, verified, missing_rows, mismatching_rows, extraneous_rows, cdc_cursor, error, started_at, updated_at)
+	VALUES(@fetch_id, @schema_name, @table_name, @state, @exported_rows, @imported_rows, @exported_files, @imported_files, @export_duration_ms, @import_duration_ms, @verified, @missing_rows, @mismatching_rows, @extraneous_rows, @cdc_cursor, @error, @started_at, @updated_at)`, tableStatusTable)
+	args := pgx.NamedArgs{
+		"fetch_id":           s.FetchID,
+		"schema_name":        s.Schema,
+		"table_name":         s.Table,
+		"state":              string(s.State),
+		"exported_rows":      s.ExportedRows,
+		"imported_rows":      s.ImportedRows,
+		"exported_files":     s.ExportedFiles,
+		"imported_files":     s.ImportedFiles,
+		"export_duration_ms": s.ExportDuration.Milliseconds(),
+		"import_duration_ms": s.ImportDuration.Milliseconds(),
+		"verified":           s.Verified,
+		"missing_rows":       s.MissingRows,
+		"mismatching_rows":   s.MismatchingRows,
+		"extraneous_rows":    s.ExtraneousRows,
+		"cdc_cursor":         s.CDCCursor,
+		"error":              s.Error,
+		"started_at":         startedAt,
+		"updated_at":         s.UpdatedAt,
+	}
+	_, err := conn.Exec(ctx, query, args)
+	return err
+}
+
+// GetAllTableStatusesByFetchID returns the status of every table of the given
+// fetch, ordered by table.
+func GetAllTableStatusesByFetchID(
+	ctx context.Context, conn *pgx.Conn, fetchID string,
+) ([]*TableStatus, error) {
+	query := fmt.Sprintf(`SELECT fetch_id, schema_name, table_name, state, exported_rows, imported_rows, exported_files, imported_files, export_duration_ms, import_duration_ms, verified, missing_rows, mismatching_rows, extraneous_rows, COALESCE(cdc_cursor, ''), COALESCE(error, ''), started_at, updated_at
+	FROM %s
+	WHERE fetch_id=@fetch_id
+	ORDER BY schema_name, table_name`, tableStatusTable)
+	args := pgx.NamedArgs{
+		"fetch_id": fetchID,
+	}
+	statuses := []*TableStatus{}
+
+	rows, err := conn.Query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := &TableStatus{}
+		var state string
+		var startedAt *time.Time
+		var exportMillis, importMillis int64
+		if err := rows.Scan(&s.FetchID, &s.Schema, &s.Table, &state, &s.ExportedRows, &s.ImportedRows,
+			&s.ExportedFiles, &s.ImportedFiles, &exportMillis, &importMillis, &s.Verified, &s.MissingRows,
+			&s.MismatchingRows, &s.ExtraneousRows, &s.CDCCursor, &s.Error,
+			&startedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		s.State = TableState(state)
+		s.ExportDuration = time.Duration(exportMillis) * time.Millisecond
+		s.ImportDuration = time.Duration(importMillis) * time.Millisecond
+		if startedAt != nil {
+			s.StartedAt = *startedAt
+		}
+		statuses = append(statuses, s)
+	}
+
+	return statuses, rows.Err()
+}
+
+func GetTableSchemaToTableStatus(statuses []*TableStatus) map[string]*TableStatus {
+	mapping := map[string]*TableStatus{}
+
+	for _, s := range statuses {
+		mapping[fmt.Sprintf("%s.%s", s.Schema, s.Table)] = s
+	}
+	return mapping
+}
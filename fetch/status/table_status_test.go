@@ -0,0 +1,117 @@
+package status
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/testutils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableStatuses(t *testing.T) {
+	ctx := context.Background()
+	dbName := "fetch_test_table_statuses"
+
+	conn, err := dbconn.TestOnlyCleanDatabase(ctx, "target", testutils.CRDBConnStr(), dbName)
+	require.NoError(t, err)
+	pgConn := conn.(*dbconn.PGConn).Conn
+	// Setup the tables that we need to write for status.
+	require.NoError(t, CreateStatusAndExceptionTables(ctx, pgConn))
+
+	s := &FetchStatus{
+		Name:          "run 1",
+		StartedAt:     time.Now(),
+		SourceDialect: "postgres",
+	}
+	require.NoError(t, s.CreateEntry(ctx, pgConn))
+
+	fs, err := GetFetchStatusByID(ctx, pgConn, s.ID.String())
+	require.NoError(t, err)
+	require.Equal(t, s.ID, fs.ID)
+	require.Equal(t, "run 1", fs.Name)
+	require.Equal(t, "postgres", fs.SourceDialect)
+
+	employees := &TableStatus{
+		FetchID: s.ID,
+		Schema:  "public",
+		Table:   "employees",
+		State:   TableStatePending,
+	}
+	require.NoError(t, employees.UpsertEntry(ctx, pgConn))
+	departments := &TableStatus{
+		FetchID: s.ID,
+		Schema:  "public",
+		Table:   "departments",
+		State:   TableStatePending,
+	}
+	require.NoError(t, departments.UpsertEntry(ctx, pgConn))
+
+	// Record the progress of the tables.
+	employees.State = TableStateDone
+	employees.ExportedRows = 100
+	employees.ImportedRows = 100
+	employees.ExportedFiles = 2
+	employees.ImportedFiles = 2
+	employees.ExportDuration = 2 * time.Second
+	employees.ImportDuration = 3 * time.Second
+	employees.CDCCursor = "0/19E3610"
+	employees.Verified = true
+	employees.ExtraneousRows = 1
+	employees.StartedAt = time.Now().UTC()
+	require.NoError(t, employees.UpsertEntry(ctx, pgConn))
+	departments.State = TableStateFailed
+	departments.Error = "error importing data"
+	require.NoError(t, departments.UpsertEntry(ctx, pgConn))
+
+	statuses, err := GetAllTableStatusesByFetchID(ctx, pgConn, s.ID.String())
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	mapping := GetTableSchemaToTableStatus(statuses)
+	require.Len(t, mapping, 2)
+
+	type tableState struct {
+		table                          string
+		state                          TableState
+		exportedRows, importedRows     int
+		exportedFiles, importedFiles   int
+		exportDuration, importDuration time.Duration
+		cdcCursor, err                 string
+		verification                   string
+		started                        bool
+	}
+	var states []tableState
+	for _, st := range statuses {
+		states = append(states, tableState{
+			table:          st.Table,
+			state:          st.State,
+			exportedRows:   st.ExportedRows,
+			importedRows:   st.ImportedRows,
+			exportedFiles:  st.ExportedFiles,
+			importedFiles:  st.ImportedFiles,
+			exportDuration: st.ExportDuration,
+			importDuration: st.ImportDuration,
+			cdcCursor:      st.CDCCursor,
+			err:            st.Error,
+			verification:   st.verificationString(),
+			started:        !st.StartedAt.IsZero(),
+		})
+	}
+	require.Equal(t, []tableState{
+		{table: "departments", state: TableStateFailed, err: "error importing data"},
+		{
+			table:          "employees",
+			state:          TableStateDone,
+			exportedRows:   100,
+			importedRows:   100,
+			exportedFiles:  2,
+			importedFiles:  2,
+			exportDuration: 2 * time.Second,
+			importDuration: 3 * time.Second,
+			cdcCursor:      "0/19E3610",
+			verification:   "1 extraneous",
+			started:        true,
+		},
+	}, states)
+}
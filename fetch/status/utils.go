@@ -15,5 +15,17 @@ func CreateStatusAndExceptionTables(ctx context.Context, conn *pgx.Conn) error {
 		return err
 	}
 
+	if _, err := conn.Exec(ctx, createExportCheckpointsTable); err != nil {
+		return err
+	}
+
+	if _, err := conn.Exec(ctx, createDeferredDDLTable); err != nil {
+		return err
+	}
+
+	if _, err := conn.Exec(ctx, createTableStatusTable); err != nil {
+		return err
+	}
+
 	return nil
 }
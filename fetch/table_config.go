@@ -0,0 +1,74 @@
+package fetch
+
+import (
+	"github.com/cockroachdb/molt/compression"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/utils"
+)
+
+// TableOverride overrides settings of the config for the tables matching
+// Table, which is a glob as used by utils.FilterConfig. Settings which are
+// nil are not overridden.
+type TableOverride struct {
+	Table        string
+	Shards       *int
+	RowBatchSize *int
+	FlushSize    *int
+	FlushRows    *int
+	Compression  *compression.Flag
+	// Truncate and DropAndRecreateNewSchema override the table handling mode,
+	// and are either both set or both nil.
+	Truncate                 *bool
+	DropAndRecreateNewSchema *bool
+}
+
+// ForTable returns the config of a table, which is the config with the
+// settings of the overrides matching the table. Each setting takes the value
+// of the first matching override which sets it.
+func (cfg Config) ForTable(n dbtable.Name) Config {
+	ret := cfg
+	ret.TableOverrides = nil
+	for i := len(cfg.TableOverrides) - 1; i >= 0; i-- {
+		o := cfg.TableOverrides[i]
+		// Patterns are validated when the config is loaded.
+		if ok, _ := utils.MatchesTablePattern(o.Table, n); !ok {
+			continue
+		}
+		if o.Shards != nil {
+			ret.Shards = *o.Shards
+		}
+		if o.RowBatchSize != nil {
+			ret.ExportSettings.RowBatchSize = *o.RowBatchSize
+		}
+		if o.FlushSize != nil {
+			ret.FlushSize = *o.FlushSize
+		}
+		if o.FlushRows != nil {
+			ret.FlushRows = *o.FlushRows
+		}
+		if o.Compression != nil {
+			ret.Compression = *o.Compression
+		}
+		if o.Truncate != nil {
+			ret.Truncate = *o.Truncate
+		}
+		if o.DropAndRecreateNewSchema != nil {
+			ret.DropAndRecreateNewSchema = *o.DropAndRecreateNewSchema
+		}
+	}
+	return ret
+}
+
+// recreatesAnyTable returns whether any table may be dropped and recreated
+// on the target.
+func (cfg Config) recreatesAnyTable() bool {
+	if cfg.DropAndRecreateNewSchema {
+		return true
+	}
+	for _, o := range cfg.TableOverrides {
+		if o.DropAndRecreateNewSchema != nil && *o.DropAndRecreateNewSchema {
+			return true
+		}
+	}
+	return false
+}
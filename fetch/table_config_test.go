@@ -0,0 +1,52 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/compression"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/dataexport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigForTable(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	boolPtr := func(b bool) *bool { return &b }
+	gzip := compression.GZIP
+
+	cfg := Config{
+		Shards:         4,
+		FlushSize:      100,
+		ExportSettings: dataexport.Settings{RowBatchSize: 1000},
+		Compression:    compression.None,
+		TableOverrides: []TableOverride{
+			{Table: "public.big", Shards: intPtr(16)},
+			{Table: "public.b*", Shards: intPtr(8), RowBatchSize: intPtr(50), Compression: &gzip},
+			{Table: "other.*", Truncate: boolPtr(true), DropAndRecreateNewSchema: boolPtr(false)},
+		},
+	}
+	name := func(schema, table string) dbtable.Name {
+		return dbtable.Name{Schema: tree.Name(schema), Table: tree.Name(table)}
+	}
+
+	big := cfg.ForTable(name("public", "big"))
+	require.Equal(t, 16, big.Shards)
+	require.Equal(t, 50, big.ExportSettings.RowBatchSize)
+	require.Equal(t, compression.GZIP, big.Compression)
+	require.Equal(t, 100, big.FlushSize)
+	require.Nil(t, big.TableOverrides)
+
+	bar := cfg.ForTable(name("public", "bar"))
+	require.Equal(t, 8, bar.Shards)
+	require.Equal(t, 50, bar.ExportSettings.RowBatchSize)
+
+	other := cfg.ForTable(name("other", "t"))
+	require.Equal(t, 4, other.Shards)
+	require.True(t, other.Truncate)
+	require.Equal(t, compression.None, other.Compression)
+
+	require.False(t, cfg.recreatesAnyTable())
+	cfg.TableOverrides = append(cfg.TableOverrides, TableOverride{Table: "*", DropAndRecreateNewSchema: boolPtr(true)})
+	require.True(t, cfg.recreatesAnyTable())
+}
@@ -0,0 +1,213 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/cockroachdb/molt/utils"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+// tableStatusRecorder records the progress of a table in the table status
+// ledger, which is shown by molt fetch status.
+//
+// A nil tableStatusRecorder records nothing.
+type tableStatusRecorder struct {
+	logger zerolog.Logger
+	mu     struct {
+		sync.Mutex
+		conn   *pgx.Conn
+		status status.TableStatus
+	}
+}
+
+func newTableStatusRecorder(
+	logger zerolog.Logger,
+	conn *pgx.Conn,
+	fetchID uuid.UUID,
+	table dbtable.VerifiedTable,
+	previous *status.TableStatus,
+) *tableStatusRecorder {
+	r := &tableStatusRecorder{logger: logger}
+	r.mu.conn = conn
+	if previous != nil {
+		r.mu.status = *previous
+	} else {
+		r.mu.status = newTableStatus(fetchID, table)
+	}
+	return r
+}
+
+func newTableStatus(fetchID uuid.UUID, table dbtable.VerifiedTable) status.TableStatus {
+	return status.TableStatus{
+		FetchID: fetchID,
+		Schema:  table.Schema.String(),
+		Table:   table.Table.String(),
+		State:   status.TableStatePending,
+	}
+}
+
+// update applies the change to the status of the table and persists it.
+// Failing to do so only leaves the ledger stale, so the fetch carries on.
+func (r *tableStatusRecorder) update(ctx context.Context, change func(s *status.TableStatus)) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	change(&r.mu.status)
+	if err := r.mu.status.UpsertEntry(ctx, r.mu.conn); err != nil {
+		r.logger.Warn().Err(err).
+			Str("state", string(r.mu.status.State)).
+			Msgf("unable to record table status")
+	}
+}
+
+// failed records that the table failed with the given error.
+func (r *tableStatusRecorder) failed(ctx context.Context, err error) {
+	r.update(ctx, func(s *status.TableStatus) {
+		s.State = status.TableStateFailed
+		s.Error = err.Error()
+	})
+}
+
+// initTableStatuses returns the status of each table of the fetch, keyed by
+// table. The tables of a new fetch are recorded as pending, while continuing
+// a fetch carries on from the statuses recorded by the previous attempts.
+func initTableStatuses(
+	ctx context.Context,
+	cfg Config,
+	targetPgxConn *pgx.Conn,
+	fetchID uuid.UUID,
+	tables []tableverify.Result,
+) (map[string]*status.TableStatus, error) {
+	if IsImportCopyOnlyMode(cfg) {
+		statuses, err := status.GetAllTableStatusesByFetchID(ctx, targetPgxConn, fetchID.String())
+		if err != nil {
+			return nil, err
+		}
+		return status.GetTableSchemaToTableStatus(statuses), nil
+	}
+	mapping := map[string]*status.TableStatus{}
+	for _, table := range tables {
+		s := newTableStatus(fetchID, table.VerifiedTable)
+		if err := s.UpsertEntry(ctx, targetPgxConn); err != nil {
+			return nil, errors.Wrapf(err, "failed to record status of table %s", table.SafeString())
+		}
+		mapping[table.SafeString()] = &s
+	}
+	return mapping, nil
+}
+
+// ShowFetchStatus returns the status of each table of the given fetch,
+// formatted as a table or as JSON.
+func ShowFetchStatus(
+	ctx context.Context,
+	testOnly bool,
+	targetPgxConn *pgx.Conn,
+	fetchID string,
+	asJSON bool,
+) (string, error) {
+	if _, err := uuid.FromString(fetchID); err != nil {
+		return "", errors.Wrapf(err, "invalid fetch ID %q", fetchID)
+	}
+	fetchStatus, err := status.GetFetchStatusByID(ctx, targetPgxConn, fetchID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", errors.Newf("no fetch with ID %s", fetchID)
+		}
+		return "", err
+	}
+	tableStatuses, err := status.GetAllTableStatusesByFetchID(ctx, targetPgxConn, fetchID)
+	if err != nil {
+		return "", err
+	}
+	return formatFetchStatus(testOnly, fetchStatus, tableStatuses, asJSON)
+}
+
+func formatFetchStatus(
+	testOnly bool, fetchStatus *status.FetchStatus, tableStatuses []*status.TableStatus, asJSON bool,
+) (string, error) {
+	fetchStatus.ID = utils.MaybeFormatID(testOnly, fetchStatus.ID)
+	fetchStatus.StartedAt = utils.MaybeFormatTimeForTest(testOnly, fetchStatus.StartedAt)
+	counts := map[status.TableState]int{}
+	for _, s := range tableStatuses {
+		counts[s.State]++
+		if !testOnly {
+			continue
+		}
+		// Values which are not set yet are kept as is.
+		s.FetchID = utils.MaybeFormatID(testOnly, s.FetchID)
+		s.UpdatedAt = utils.MaybeFormatTimeForTest(testOnly, s.UpdatedAt)
+		if s.CDCCursor != "" {
+			s.CDCCursor = utils.MaybeFormatCDCCursor(testOnly, s.CDCCursor)
+		}
+		if s.ExportDuration != 0 {
+			s.ExportDuration = utils.MaybeFormatDurationForTest(testOnly, s.ExportDuration)
+		}
+		if s.ImportDuration != 0 {
+			s.ImportDuration = utils.MaybeFormatDurationForTest(testOnly, s.ImportDuration)
+		}
+		if !s.StartedAt.IsZero() {
+			s.StartedAt = utils.MaybeFormatTimeForTest(testOnly, s.StartedAt)
+		}
+	}
+
+	if asJSON {
+		return utils.PrettyJSON(struct {
+			ID            uuid.UUID             `json:"id"`
+			Name          string                `json:"name"`
+			StartedAt     time.Time             `json:"started_at"`
+			SourceDialect string                `json:"source_dialect"`
+			Tables        []*status.TableStatus `json:"tables"`
+		}{
+			ID:            fetchStatus.ID,
+			Name:          fetchStatus.Name,
+			StartedAt:     fetchStatus.StartedAt,
+			SourceDialect: fetchStatus.SourceDialect,
+			Tables:        tableStatuses,
+		}) + "\n", nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Fetch %s (%s) from %s, started at %s.\n",
+		fetchStatus.ID, fetchStatus.Name, fetchStatus.SourceDialect, fetchStatus.StartedAt.Format(time.RFC3339))
+	if len(tableStatuses) == 0 {
+		sb.WriteString("No table status found.\n")
+		return sb.String(), nil
+	}
+	var stateCounts []string
+	for _, state := range []status.TableState{
+		status.TableStatePending,
+		status.TableStateExporting,
+		status.TableStateImporting,
+		status.TableStateVerifying,
+		status.TableStateDone,
+		status.TableStateFailed,
+	} {
+		if counts[state] > 0 {
+			stateCounts = append(stateCounts, fmt.Sprintf("%d %s", counts[state], state))
+		}
+	}
+	fmt.Fprintf(&sb, "%d tables: %s.\n", len(tableStatuses), strings.Join(stateCounts, ", "))
+
+	outputFormat := make([]utils.OutputFormat, 0, len(tableStatuses))
+	for _, s := range tableStatuses {
+		outputFormat = append(outputFormat, *s)
+	}
+	tableStr, err := utils.BuildTable(outputFormat)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(tableStr)
+	return sb.String(), nil
+}
@@ -0,0 +1,80 @@
+package fetch
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+	"github.com/cockroachdb/datadriven"
+	"github.com/cockroachdb/molt/fetch/status"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatFetchStatus(t *testing.T) {
+	datadriven.Walk(t, "testdata/fetchstatus", func(t *testing.T, path string) {
+		var tables []*status.TableStatus
+		datadriven.RunTest(t, path, func(t *testing.T, d *datadriven.TestData) string {
+			switch d.Cmd {
+			case "table":
+				s := &status.TableStatus{
+					FetchID:   uuid.MakeV4(),
+					State:     status.TableStatePending,
+					UpdatedAt: time.Now(),
+				}
+				for _, arg := range d.CmdArgs {
+					intVal := func() int {
+						v, err := strconv.Atoi(arg.Vals[0])
+						require.NoError(t, err)
+						return v
+					}
+					switch arg.Key {
+					case "schema":
+						s.Schema = arg.Vals[0]
+					case "name":
+						s.Table = arg.Vals[0]
+					case "state":
+						s.State = status.TableState(arg.Vals[0])
+					case "exported":
+						s.ExportedRows, s.ExportedFiles = intVal(), 1
+						s.ExportDuration = time.Minute
+						s.CDCCursor = "0/1234"
+						s.StartedAt = time.Now()
+					case "imported":
+						s.ImportedRows, s.ImportedFiles = intVal(), 1
+						s.ImportDuration = time.Hour
+					case "error":
+						s.Error = arg.Vals[0]
+					case "verified":
+						require.Len(t, arg.Vals, 3)
+						counts := make([]int, len(arg.Vals))
+						for i, v := range arg.Vals {
+							n, err := strconv.Atoi(v)
+							require.NoError(t, err)
+							counts[i] = n
+						}
+						s.Verified = true
+						s.MissingRows, s.MismatchingRows, s.ExtraneousRows = counts[0], counts[1], counts[2]
+					default:
+						t.Fatalf("unknown argument %s", arg.Key)
+					}
+				}
+				tables = append(tables, s)
+				return ""
+			case "show":
+				fetchStatus := &status.FetchStatus{
+					ID:            uuid.MakeV4(),
+					Name:          "run at 1",
+					StartedAt:     time.Now(),
+					SourceDialect: "PostgreSQL",
+				}
+				out, err := formatFetchStatus(true /* testOnly */, fetchStatus, tables, d.HasArg("json"))
+				require.NoError(t, err)
+				return out
+			default:
+				t.Fatalf("unknown command %s", d.Cmd)
+			}
+			return ""
+		})
+	})
+}
@@ -2,32 +2,155 @@ package fetch
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/fetch/status"
 	"github.com/cockroachdb/molt/utils"
 	"github.com/jackc/pgx/v5"
 )
 
 func ListContinuationTokens(
-	ctx context.Context, testOnly bool, targetPgxConn *pgx.Conn, numResults int,
+	ctx context.Context,
+	testOnly bool,
+	targetPgxConn *pgx.Conn,
+	numResults int,
+	filter status.ExceptionLogFilter,
+	asJSON bool,
 ) (string, error) {
-	exceptionLogs, err := status.GetAllExceptionLogs(ctx, targetPgxConn, numResults)
+	if err := validateExceptionLogFilter(filter); err != nil {
+		return "", err
+	}
+	exceptionLogs, err := status.GetAllExceptionLogs(ctx, targetPgxConn, numResults, filter)
 	if err != nil {
 		return "", err
 	}
 
+	if len(exceptionLogs) == 0 && !asJSON {
+		return "No continuation tokens found.\n", nil
+	}
+	return formatContinuationTokens(testOnly, exceptionLogs, asJSON)
+}
+
+// ShowContinuationToken returns every detail of the continuation token with
+// the given ID.
+func ShowContinuationToken(
+	ctx context.Context, testOnly bool, targetPgxConn *pgx.Conn, id string, asJSON bool,
+) (string, error) {
+	if _, err := uuid.FromString(id); err != nil {
+		return "", errors.Wrapf(err, "invalid continuation token %q", id)
+	}
+	exceptionLogs, err := status.GetAllExceptionLogs(ctx, targetPgxConn, 1, status.ExceptionLogFilter{
+		IDs:    []string{id},
+		Tables: utils.DefaultFilterConfig(),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(exceptionLogs) == 0 {
+		return "", errors.Newf("no continuation token with ID %s", id)
+	}
+	l := exceptionLogs[0]
+	l.ID = utils.MaybeFormatID(testOnly, l.ID)
+	l.FetchID = utils.MaybeFormatID(testOnly, l.FetchID)
+	l.Time = utils.MaybeFormatTimeForTest(testOnly, l.Time)
+	if asJSON {
+		return l.JSONFormat() + "\n", nil
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 1, ' ', 0)
+	for _, field := range []struct {
+		name, value string
+	}{
+		{"ID", l.ID.String()},
+		{"FETCH ID", l.FetchID.String()},
+		{"TABLE NAME", fmt.Sprintf("%s.%s", l.Schema, l.Table)},
+		{"STAGE", l.Stage},
+		{"FILE NAME", l.FileName},
+		{"SQL STATE", l.SQLState},
+		{"MESSAGE", l.Message},
+		{"COMMAND", l.Command},
+		{"TIME", l.Time.Format("2006-01-02T15:04:05Z07:00")},
+	} {
+		fmt.Fprintf(w, "%s:\t%s\n", field.name, field.value)
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// DeleteContinuationTokens deletes the continuation tokens selected by the
+// filter, which must select some tokens, and returns the deleted tokens.
+func DeleteContinuationTokens(
+	ctx context.Context,
+	testOnly bool,
+	targetPgxConn *pgx.Conn,
+	filter status.ExceptionLogFilter,
+	asJSON bool,
+) (string, error) {
+	if filter.IsDefault() {
+		return "", errors.New("continuation tokens to delete must be selected by ID, fetch ID, stage or table")
+	}
+	if err := validateExceptionLogFilter(filter); err != nil {
+		return "", err
+	}
+	exceptionLogs, err := status.DeleteExceptionLogs(ctx, targetPgxConn, filter)
+	if err != nil {
+		return "", err
+	}
+
+	if asJSON {
+		return formatContinuationTokens(testOnly, exceptionLogs, asJSON)
+	}
 	if len(exceptionLogs) == 0 {
 		return "No continuation tokens found.\n", nil
 	}
+	tableStr, err := formatContinuationTokens(testOnly, exceptionLogs, asJSON)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Deleted %d continuation tokens.\n%s", len(exceptionLogs), tableStr), nil
+}
 
+// validateExceptionLogFilter checks that the IDs of the filter are valid.
+func validateExceptionLogFilter(filter status.ExceptionLogFilter) error {
+	for _, id := range filter.IDs {
+		if _, err := uuid.FromString(id); err != nil {
+			return errors.Wrapf(err, "invalid continuation token %q", id)
+		}
+	}
+	if filter.FetchID != "" {
+		if _, err := uuid.FromString(filter.FetchID); err != nil {
+			return errors.Wrapf(err, "invalid fetch ID %q", filter.FetchID)
+		}
+	}
+	return nil
+}
+
+func formatContinuationTokens(
+	testOnly bool, exceptionLogs []status.ExceptionLog, asJSON bool,
+) (string, error) {
 	// Loop through the exception log results.
 	outputFormat := []utils.OutputFormat{}
-	for _, item := range exceptionLogs {
+	for i := range exceptionLogs {
+		item := &exceptionLogs[i]
 		item.ID = utils.MaybeFormatID(testOnly, item.ID)
 		item.FetchID = utils.MaybeFormatID(testOnly, item.FetchID)
-		outputFormat = append(outputFormat, item)
+		item.Time = utils.MaybeFormatTimeForTest(testOnly, item.Time)
+		outputFormat = append(outputFormat, *item)
 	}
 
+	if asJSON {
+		if exceptionLogs == nil {
+			exceptionLogs = []status.ExceptionLog{}
+		}
+		return utils.PrettyJSON(exceptionLogs) + "\n", nil
+	}
 	tableStr, err := utils.BuildTable(outputFormat)
 	if err != nil {
 		return "", err
@@ -0,0 +1,149 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/fetch/dataexport"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/errgroup"
+)
+
+// loadVerificationResult is the number of inconsistent rows found by
+// verifying a table after it was loaded.
+type loadVerificationResult struct {
+	NumMissing     int
+	NumMismatching int
+	NumExtraneous  int
+}
+
+func (r loadVerificationResult) total() int {
+	return r.NumMissing + r.NumMismatching + r.NumExtraneous
+}
+
+func (r *loadVerificationResult) add(o loadVerificationResult) {
+	r.NumMissing += o.NumMissing
+	r.NumMismatching += o.NumMismatching
+	r.NumExtraneous += o.NumExtraneous
+}
+
+// countingReporter counts the inconsistent rows it reports.
+type countingReporter struct {
+	inconsistency.Reporter
+	mu struct {
+		sync.Mutex
+		result loadVerificationResult
+	}
+}
+
+func (r *countingReporter) Report(obj inconsistency.ReportableObject) {
+	r.mu.Lock()
+	switch obj.(type) {
+	case inconsistency.MissingRow:
+		r.mu.result.NumMissing++
+	case inconsistency.MismatchingRow:
+		r.mu.result.NumMismatching++
+	case inconsistency.ExtraneousRow:
+		r.mu.result.NumExtraneous++
+	}
+	r.mu.Unlock()
+	r.Reporter.Report(obj)
+}
+
+// loadVerificationShards returns the shards to verify a table with once it
+// has been loaded, which are the shards it was initially split into. They
+// are copied before the export resumes or splits them.
+func loadVerificationShards(shards []rowverify.TableShard) []rowverify.TableShard {
+	ret := make([]rowverify.TableShard, len(shards))
+	for i, sh := range shards {
+		sh.CursorPKVals = nil
+		sh.Splitter = nil
+		ret[i] = sh
+	}
+	return ret
+}
+
+// verifyLoadedTable verifies the rows of the shards of a table against the
+// source once it has been loaded, verifying up to concurrency shards at a
+// time. Inconsistent rows are logged, and counted in the result.
+//
+// If the source is a dataexport.SnapshotSource, rows are read from it at the
+// snapshot the table was exported at. Otherwise, rows are read as of now, so
+// the source must not be written to during the fetch.
+func verifyLoadedTable(
+	ctx context.Context,
+	logger zerolog.Logger,
+	conns dbconn.OrderedConns,
+	src dataexport.Source,
+	shards []rowverify.TableShard,
+	concurrency int,
+	rowBatchSize int,
+) (loadVerificationResult, error) {
+	reporter := &countingReporter{Reporter: inconsistency.LogReporter{Logger: logger}}
+	defer reporter.Close()
+
+	wg, wgCtx := errgroup.WithContext(ctx)
+	wg.SetLimit(max(concurrency, 1))
+	// verifyShard returns a function verifying the shard on connections of
+	// its own.
+	verifyShard := func(sh rowverify.TableShard) func() error {
+		return func() error {
+			var workerConns dbconn.OrderedConns
+			for i := range workerConns {
+				var err error
+				if snapshotSrc, ok := src.(dataexport.SnapshotSource); ok && i == 0 {
+					workerConns[i], sh, err = snapshotSrc.SnapshotConn(wgCtx, sh)
+				} else {
+					workerConns[i], err = conns[i].Clone(wgCtx)
+				}
+				if err != nil {
+					return errors.Wrap(err, "error establishing connection to verify")
+				}
+				defer func(conn dbconn.Conn) {
+					_ = conn.Close(ctx)
+				}(workerConns[i])
+			}
+			return rowverify.VerifyRowsOnShard(
+				wgCtx,
+				workerConns,
+				sh,
+				rowBatchSize,
+				reporter,
+				logger,
+				nil, /* liveReverifySettings */
+				nil, /* rateLimiter */
+			)
+		}
+	}
+	for _, sh := range shards {
+		wg.Go(verifyShard(sh))
+	}
+	if err := wg.Wait(); err != nil {
+		return loadVerificationResult{}, errors.Wrap(err, "error verifying rows after load")
+	}
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	return reporter.mu.result, nil
+}
+
+// checkLoadVerification returns an error if the verification of the tables
+// found more inconsistent rows than allowed.
+func checkLoadVerification(result loadVerificationResult, maxMismatches int) error {
+	if result.total() <= maxMismatches {
+		return nil
+	}
+	return errors.Newf(
+		"verification after load found %s, which is more than the maximum of %d",
+		result, maxMismatches,
+	)
+}
+
+func (r loadVerificationResult) String() string {
+	return fmt.Sprintf("%d inconsistent rows (%d missing, %d mismatching, %d extraneous)",
+		r.total(), r.NumMissing, r.NumMismatching, r.NumExtraneous)
+}
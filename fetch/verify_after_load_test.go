@@ -0,0 +1,60 @@
+package fetch
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/cockroachdb/molt/verify/inconsistency"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/stretchr/testify/require"
+)
+
+type nopReporter struct{}
+
+func (nopReporter) Report(obj inconsistency.ReportableObject) {}
+func (nopReporter) Close()                                    {}
+
+func TestLoadVerification(t *testing.T) {
+	t.Run("shards", func(t *testing.T) {
+		shards := []rowverify.TableShard{
+			{
+				ShardNum:     1,
+				EndPKVals:    tree.Datums{tree.NewDInt(10)},
+				CursorPKVals: tree.Datums{tree.NewDInt(5)},
+				Splitter:     &rowiterator.ShardSplitter{},
+			},
+			{ShardNum: 2, StartPKVals: tree.Datums{tree.NewDInt(10)}},
+		}
+		verifyShards := loadVerificationShards(shards)
+		require.Len(t, verifyShards, 2)
+		require.Nil(t, verifyShards[0].CursorPKVals)
+		require.Nil(t, verifyShards[0].Splitter)
+		require.Equal(t, shards[0].EndPKVals, verifyShards[0].EndPKVals)
+		// The shards of the export are left as is.
+		require.NotNil(t, shards[0].Splitter)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		r := &countingReporter{Reporter: nopReporter{}}
+		for _, obj := range []inconsistency.ReportableObject{
+			inconsistency.MissingRow{},
+			inconsistency.MissingRow{},
+			inconsistency.MismatchingRow{},
+			inconsistency.ExtraneousRow{},
+			inconsistency.MismatchingColumn{},
+			inconsistency.SummaryReport{},
+		} {
+			r.Report(obj)
+		}
+		result := r.mu.result
+		require.Equal(t, loadVerificationResult{NumMissing: 2, NumMismatching: 1, NumExtraneous: 1}, result)
+
+		require.NoError(t, checkLoadVerification(result, 4))
+		require.EqualError(
+			t,
+			checkLoadVerification(result, 3),
+			"verification after load found 4 inconsistent rows (2 missing, 1 mismatching, 1 extraneous), which is more than the maximum of 3",
+		)
+	})
+}
@@ -0,0 +1,18 @@
+package fileformat
+
+import "github.com/thediveo/enumflag/v2"
+
+//go:generate go run github.com/alvaroloes/enumer -type=Flag -output fileformat_enumer.gen.go
+type Flag enumflag.Flag
+
+const (
+	CSV Flag = iota + 1
+	Parquet
+)
+
+var FileFormatStringRepresentations = map[Flag][]string{
+	CSV:     {"csv"},
+	Parquet: {"parquet"},
+}
+
+const ParquetFileExt = "parquet"
@@ -0,0 +1,221 @@
+package fileformat
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+	"github.com/lib/pq/oid"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/zstd"
+)
+
+// ParquetRecordWriter writes CSV formatted records as typed Parquet rows.
+// Integer, float and boolean columns are stored using the equivalent
+// Parquet physical type. All other columns are stored as UTF8 strings in
+// the same text representation used for CSV files, so that the values
+// round trip exactly. Empty values are treated as NULL, mirroring how
+// COPY and IMPORT treat empty CSV fields.
+type ParquetRecordWriter struct {
+	w *parquet.Writer
+	// families and leafIdxs are indexed by the position of the column in the
+	// record. leafIdxs maps to the position of the column in the Parquet schema.
+	families []types.Family
+	leafIdxs []int
+	row      parquet.Row
+}
+
+// NewParquetRecordWriter returns a ParquetRecordWriter which writes rows with
+// the given columns and types to out.
+func NewParquetRecordWriter(
+	out io.Writer, columns []tree.Name, typOIDs []oid.Oid,
+) (*ParquetRecordWriter, error) {
+	if len(columns) != len(typOIDs) {
+		return nil, errors.AssertionFailedf("found %d columns but %d types", len(columns), len(typOIDs))
+	}
+	group := make(parquet.Group, len(columns))
+	families := make([]types.Family, len(columns))
+	for i, col := range columns {
+		var node parquet.Node
+		if typ, ok := types.OidToType[typOIDs[i]]; ok {
+			families[i] = typ.Family()
+		}
+		switch families[i] {
+		case types.IntFamily:
+			node = parquet.Leaf(parquet.Int64Type)
+		case types.FloatFamily:
+			node = parquet.Leaf(parquet.DoubleType)
+		case types.BoolFamily:
+			node = parquet.Leaf(parquet.BooleanType)
+		default:
+			node = parquet.String()
+		}
+		group[string(col)] = parquet.Optional(node)
+	}
+	schema := parquet.NewSchema("molt_fetch", group)
+	// Parquet groups order their fields by name, so find where each column
+	// lives in the schema.
+	leafIdxs := make([]int, len(columns))
+	for i, col := range columns {
+		leaf, ok := schema.Lookup(string(col))
+		if !ok {
+			return nil, errors.AssertionFailedf("column %s not found in parquet schema", col)
+		}
+		leafIdxs[i] = leaf.ColumnIndex
+	}
+	return &ParquetRecordWriter{
+		w:        parquet.NewWriter(out, schema, parquet.Compression(&zstd.Codec{})),
+		families: families,
+		leafIdxs: leafIdxs,
+		row:      make(parquet.Row, len(columns)),
+	}, nil
+}
+
+// Write writes a single record to the Parquet file.
+func (p *ParquetRecordWriter) Write(record []string) error {
+	if len(record) != len(p.families) {
+		return errors.AssertionFailedf("expected %d values in record, found %d", len(p.families), len(record))
+	}
+	for i, s := range record {
+		var v parquet.Value
+		if s == "" {
+			v = parquet.NullValue()
+		} else {
+			switch p.families[i] {
+			case types.IntFamily:
+				n, err := strconv.ParseInt(s, 10, 64)
+				if err != nil {
+					return errors.Wrapf(err, "error converting %q to an integer", s)
+				}
+				v = parquet.Int64Value(n)
+			case types.FloatFamily:
+				f, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					return errors.Wrapf(err, "error converting %q to a float", s)
+				}
+				v = parquet.DoubleValue(f)
+			case types.BoolFamily:
+				b, err := strconv.ParseBool(s)
+				if err != nil {
+					return errors.Wrapf(err, "error converting %q to a bool", s)
+				}
+				v = parquet.BooleanValue(b)
+			default:
+				v = parquet.ByteArrayValue([]byte(s))
+			}
+		}
+		defLevel := 1
+		if v.IsNull() {
+			defLevel = 0
+		}
+		p.row[p.leafIdxs[i]] = v.Level(0, defLevel, p.leafIdxs[i])
+	}
+	_, err := p.w.WriteRows([]parquet.Row{p.row})
+	return err
+}
+
+// Close writes the Parquet footer. It does not close the underlying writer.
+func (p *ParquetRecordWriter) Close() error {
+	return p.w.Close()
+}
+
+// parquetReadBufferSize is the size of the reads of each column of a Parquet
+// file. Files are read from cloud storage one range request per read, so the
+// default of 4 KiB would send a request for every few pages. Column chunks
+// up to this size are read with a single request.
+const parquetReadBufferSize = 4 << 20
+
+// openParquetFile opens the Parquet file of the given size read from r. The
+// page indexes and bloom filters are not used, so they are not read.
+func openParquetFile(r io.ReaderAt, size int64) (*parquet.File, error) {
+	return parquet.OpenFile(
+		r,
+		size,
+		parquet.ReadBufferSize(parquetReadBufferSize),
+		parquet.SkipPageIndex(true),
+		parquet.SkipBloomFilters(true),
+	)
+}
+
+// ParquetNumRows returns the number of rows in the given Parquet file.
+func ParquetNumRows(r io.ReaderAt, size int64) (int64, error) {
+	f, err := openParquetFile(r, size)
+	if err != nil {
+		return 0, err
+	}
+	return f.NumRows(), nil
+}
+
+// NewParquetCSVReader decodes the Parquet file of the given size read from r
+// into CSV, with the columns in the given order. This allows Parquet files to
+// be used by COPY, which only understands CSV. Only the pages being decoded
+// are read into memory.
+func NewParquetCSVReader(r io.ReaderAt, size int64, columns []tree.Name) (io.ReadCloser, error) {
+	f, err := openParquetFile(r, size)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening parquet file")
+	}
+	// leafToRecordIdx maps the position of the column in the Parquet schema
+	// to the position of the column in the output record.
+	leafToRecordIdx := make(map[int]int, len(columns))
+	for i, col := range columns {
+		leaf, ok := f.Schema().Lookup(string(col))
+		if !ok {
+			return nil, errors.Newf("column %s not found in parquet file", col)
+		}
+		leafToRecordIdx[leaf.ColumnIndex] = i
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(func() error {
+			reader := parquet.NewReader(f)
+			defer reader.Close()
+			cw := csv.NewWriter(pw)
+			record := make([]string, len(columns))
+			rows := make([]parquet.Row, 1)
+			for {
+				n, err := reader.ReadRows(rows)
+				if n > 0 {
+					for _, v := range rows[0] {
+						idx, ok := leafToRecordIdx[v.Column()]
+						if !ok {
+							continue
+						}
+						record[idx] = parquetValueToString(v)
+					}
+					if err := cw.Write(record); err != nil {
+						return err
+					}
+				}
+				if err != nil {
+					if err == io.EOF {
+						break
+					}
+					return err
+				}
+			}
+			cw.Flush()
+			return cw.Error()
+		}())
+	}()
+	return pr, nil
+}
+
+func parquetValueToString(v parquet.Value) string {
+	if v.IsNull() {
+		return ""
+	}
+	switch v.Kind() {
+	case parquet.Int64:
+		return strconv.FormatInt(v.Int64(), 10)
+	case parquet.Double:
+		return strconv.FormatFloat(v.Double(), 'g', -1, 64)
+	case parquet.Boolean:
+		return strconv.FormatBool(v.Boolean())
+	}
+	return string(v.ByteArray())
+}
@@ -0,0 +1,95 @@
+package fileformat
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/lib/pq/oid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParquetRoundTrip(t *testing.T) {
+	// Columns are intentionally not in alphabetical order, as parquet orders
+	// columns by name.
+	columns := []tree.Name{"id", "name", "amount", "active", "created_at"}
+	typOIDs := []oid.Oid{oid.T_int8, oid.T_text, oid.T_float8, oid.T_bool, oid.T_timestamptz}
+	records := [][]string{
+		{"1", "hello, world", "1.5", "true", "2024-01-01 00:00:00+00"},
+		{"2", "", "", "", ""},
+		{"-3", "\"quoted\"\nnewline", "1e+100", "false", "2024-02-29 12:34:56.789+00"},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewParquetRecordWriter(&buf, columns, typOIDs)
+	require.NoError(t, err)
+	for _, record := range records {
+		require.NoError(t, w.Write(record))
+	}
+	require.NoError(t, w.Close())
+
+	numRows, err := ParquetNumRows(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.EqualValues(t, len(records), numRows)
+
+	r, err := NewParquetCSVReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), columns)
+	require.NoError(t, err)
+	defer r.Close()
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	var expected bytes.Buffer
+	cw := csv.NewWriter(&expected)
+	require.NoError(t, cw.WriteAll(records))
+	require.Equal(t, expected.String(), string(out))
+}
+
+// countingReaderAt counts the reads of a file, each of which is a range
+// request when the file is in cloud storage.
+type countingReaderAt struct {
+	io.ReaderAt
+	reads int
+}
+
+func (r *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.reads++
+	return r.ReaderAt.ReadAt(p, off)
+}
+
+func TestParquetCSVReaderReads(t *testing.T) {
+	columns := []tree.Name{"id", "name"}
+	var buf bytes.Buffer
+	w, err := NewParquetRecordWriter(&buf, columns, []oid.Oid{oid.T_int8, oid.T_text})
+	require.NoError(t, err)
+	const numRows = 100000
+	for i := 0; i < numRows; i++ {
+		require.NoError(t, w.Write([]string{strconv.Itoa(i), fmt.Sprintf("name %x", i*7919)}))
+	}
+	require.NoError(t, w.Close())
+	// The file spans many default sized reads of 4 KiB.
+	require.Greater(t, buf.Len(), 64<<10)
+
+	ra := &countingReaderAt{ReaderAt: bytes.NewReader(buf.Bytes())}
+	r, err := NewParquetCSVReader(ra, int64(buf.Len()), columns)
+	require.NoError(t, err)
+	defer r.Close()
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, numRows, bytes.Count(out, []byte("\n")))
+	// The footer and each column chunk are read with a few reads.
+	require.LessOrEqual(t, ra.reads, 10)
+}
+
+func TestParquetRecordWriterErrors(t *testing.T) {
+	_, err := NewParquetRecordWriter(io.Discard, []tree.Name{"id"}, nil)
+	require.Error(t, err)
+
+	w, err := NewParquetRecordWriter(io.Discard, []tree.Name{"id"}, []oid.Oid{oid.T_int8})
+	require.NoError(t, err)
+	require.ErrorContains(t, w.Write([]string{"abc"}), `error converting "abc" to an integer`)
+	require.Error(t, w.Write([]string{"1", "2"}))
+}
@@ -20,7 +20,7 @@ func ConvertRowValue(typMap *pgtype.Map, val []byte, typOID oid.Oid) (tree.Datum
 		return tree.DNull, nil
 	}
 	switch typOID {
-	case pgtype.VarcharOID, pgtype.TextOID:
+	case pgtype.VarcharOID, pgtype.TextOID, pgtype.BPCharOID:
 		return tree.NewDString(string(val)), nil
 	case pgtype.Float4OID, pgtype.Float8OID:
 		return tree.ParseDFloat(string(val))
@@ -64,6 +64,10 @@ func ConvertRowValue(typMap *pgtype.Map, val []byte, typOID oid.Oid) (tree.Datum
 			return ret, errors.Wrapf(err, "input %q cannot be parsed as time or interval", v)
 		}
 		return ret, nil
+	case pgtype.IntervalOID:
+		// Oracle returns intervals in the SQL standard format, e.g.
+		// '+02 03:04:05.000000' or '+01-02'.
+		return tree.ParseDInterval(duration.IntervalStyle_SQL_STANDARD, string(val))
 	case pgtype.DateOID:
 		ret, _, err := tree.ParseDDate(parsectx.ParseContext, string(val))
 		return ret, err
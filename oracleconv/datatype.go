@@ -2,15 +2,27 @@ package oracleconv
 
 import (
 	"database/sql"
+	"regexp"
 	"strings"
 
 	"github.com/lib/pq/oid"
 )
 
+// typeModifierRegex matches the precision included in the name of types
+// such as TIMESTAMP(6) WITH TIME ZONE or INTERVAL DAY(2) TO SECOND(6), as
+// reported by ALL_TAB_COLUMNS.
+var typeModifierRegex = regexp.MustCompile(`\(\d+\)`)
+
+// NormalizeDataType returns the upper case name of a data type, without any
+// precision included in the name.
+func NormalizeDataType(typName string) string {
+	return strings.Join(strings.Fields(typeModifierRegex.ReplaceAllString(strings.ToUpper(typName), "")), " ")
+}
+
 func DataTypeToOID(
 	typName string, dataPrecision sql.NullInt64, dataScale sql.NullInt64,
 ) (oid.Oid, bool) {
-	typName = strings.ToUpper(typName)
+	typName = NormalizeDataType(typName)
 	switch typName {
 	case "INTEGER", "INT", "SIMPLE_INTEGER":
 		return oid.T_oid, true
@@ -35,32 +47,35 @@ func DataTypeToOID(
 		return oid.T_numeric, true
 	case "DOUBLE", "BINARY_DOUBLE":
 		return oid.T_float8, true
-	case "FLOAT", "BINARY_FLOAT", "REAL":
+	case "FLOAT", "REAL":
+		// FLOAT is a NUMBER with a binary precision of up to 126 bits.
+		return oid.T_float8, true
+	case "BINARY_FLOAT":
 		return oid.T_float4, true
-	case "LONG":
-		return oid.T_int8, true
 	case "BOOLEAN":
 		return oid.T_bool, true
 	case "DATE":
-		return oid.T_date, true
+		// DATE includes the time of day, to the second.
+		return oid.T_timestamp, true
 	case "TIMESTAMP":
-		if strings.HasSuffix(typName, "TIME ZONE") {
-			return oid.T_timestamptz, true
-		}
 		return oid.T_timestamp, true
+	case "TIMESTAMP WITH TIME ZONE", "TIMESTAMP WITH LOCAL TIME ZONE":
+		return oid.T_timestamptz, true
+	case "INTERVAL DAY TO SECOND", "INTERVAL YEAR TO MONTH":
+		return oid.T_interval, true
 	case "TIMESTAMP_UNCONSTRAINED":
 		return oid.T_timestamp, true
 	case "TIMESTAMP_TZ_UNCONSTRAINED", "TIMESTAMP_LTZ_UNCONSTRAINED":
 		return oid.T_timestamptz, true
 	case "BLOB", "RAW":
 		return oid.T_bytea, true
-	case "CLOB":
+	case "CLOB", "NCLOB", "LONG":
 		return oid.T_text, true
 	case "NCHAR", "CHAR", "CHARACTER", "VARCHAR", "VARCHAR2", "NVARCHAR2":
 		if strings.Contains(typName, "VAR") {
 			return oid.T_varchar, true
 		}
-		return oid.T_char, true
+		return oid.T_bpchar, true
 	case "STRING":
 		return oid.T_text, true
 	}
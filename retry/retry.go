@@ -1,6 +1,7 @@
 package retry
 
 import (
+	"context"
 	"math"
 	"time"
 
@@ -82,12 +83,17 @@ func (rm *Retry) ShouldContinue() bool {
 }
 
 func (rm *Retry) Next() {
-	nextDuration := rm.settings.InitialBackoff * time.Duration(math.Pow(float64(rm.settings.Multiplier), float64(rm.Iteration)))
-	if rm.settings.MaxBackoff > 0 && nextDuration > rm.settings.MaxBackoff {
-		nextDuration = rm.settings.MaxBackoff
-	}
+	rm.NextRetry = rm.NextRetry.Add(rm.backoff(rm.Iteration))
 	rm.Iteration++
-	rm.NextRetry = rm.NextRetry.Add(nextDuration)
+}
+
+// backoff returns the duration to wait for after the given iteration.
+func (rm *Retry) backoff(iteration int) time.Duration {
+	d := rm.settings.InitialBackoff * time.Duration(math.Pow(float64(rm.settings.Multiplier), float64(iteration)))
+	if rm.settings.MaxBackoff > 0 && d > rm.settings.MaxBackoff {
+		d = rm.settings.MaxBackoff
+	}
+	return d
 }
 
 func (rm *Retry) Do(do func() error, onRetry func(error)) error {
@@ -104,3 +110,41 @@ func (rm *Retry) Do(do func() error, onRetry func(error)) error {
 		rm.Next()
 	}
 }
+
+// DoTransient is like Do, but only retries errors which are transient, as
+// reported by IsTransient. As the operations it retries can take a while,
+// each wait starts once the operation failed, and is cut short if the
+// context is done.
+func (rm *Retry) DoTransient(ctx context.Context, do func() error, onRetry func(error)) error {
+	return rm.doRetryable(ctx, do, IsTransient, onRetry)
+}
+
+// DoAtomic is like DoTransient, but also retries errors which interrupted
+// the operation, as reported by IsInterrupted. It must only be used for
+// operations which are atomic, so that an interrupted operation was either
+// applied in full or not at all.
+func (rm *Retry) DoAtomic(ctx context.Context, do func() error, onRetry func(error)) error {
+	return rm.doRetryable(ctx, do, func(err error) bool {
+		return IsTransient(err) || IsInterrupted(err)
+	}, onRetry)
+}
+
+func (rm *Retry) doRetryable(
+	ctx context.Context, do func() error, retryable func(error) bool, onRetry func(error),
+) error {
+	for {
+		err := do()
+		if err == nil || !retryable(err) || !rm.ShouldContinue() {
+			return err
+		}
+		onRetry(err)
+		t := time.NewTimer(rm.backoff(rm.Iteration - 1))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return errors.CombineErrors(err, ctx.Err())
+		case <-t.C:
+		}
+		rm.Next()
+	}
+}
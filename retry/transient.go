@@ -0,0 +1,140 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// transientCodes are the SQLSTATEs of errors which may succeed if the
+// statement is run again, and which leave nothing behind if the statement
+// did not succeed.
+var transientCodes = map[string]struct{}{
+	// serialization_failure, which includes CockroachDB's retryable
+	// transaction errors.
+	"40001": {},
+	// sqlclient_unable_to_establish_sqlconnection and
+	// sqlserver_rejected_establishment_of_sqlconnection, which are returned
+	// before the connection is established.
+	"08001": {},
+	"08004": {},
+	// cannot_connect_now, which is returned by nodes which are starting up or
+	// draining when connecting.
+	"57P03": {},
+}
+
+// transientMessages are found in the messages of transient errors which lost
+// their type, such as the errors of IMPORT jobs.
+var transientMessages = []string{
+	"connection refused",
+}
+
+// interruptedCodes are the SQLSTATEs of errors which interrupt the statement
+// and leave its outcome unknown.
+var interruptedCodes = map[string]struct{}{
+	// statement_completion_unknown, which CockroachDB returns for ambiguous
+	// results.
+	"40003": {},
+	// admin_shutdown and crash_shutdown, which are returned by nodes which
+	// are shut down while running the statement, such as during a rolling
+	// upgrade.
+	"57P01": {},
+	"57P02": {},
+	// connection_exception, connection_does_not_exist, connection_failure
+	// and transaction_resolution_unknown.
+	"08000": {},
+	"08003": {},
+	"08006": {},
+	"08007": {},
+}
+
+// interruptedMessages are found in the messages of interrupted errors which
+// lost their type.
+var interruptedMessages = []string{
+	"ambiguous result",
+	"result is ambiguous",
+	"connection reset by peer",
+	"broken pipe",
+}
+
+// IsTransient returns whether the error is a transient failure of the
+// connection or the database, which may succeed if retried.
+//
+// Only errors which are known to have left nothing behind are transient:
+// errors returned before the statement was sent, such as a refused
+// connection, and serialization failures. Errors which leave the outcome of
+// the statement unknown are reported by IsInterrupted instead. Errors which
+// are caused by the statement or the data, such as a constraint violation,
+// are not transient.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if _, ok := transientCodes[pgErr.Code]; ok {
+			return true
+		}
+		return containsTransientMessage(pgErr.Message)
+	}
+	// pgconn only marks errors which happened before any of the statement
+	// was sent to the server as safe to retry.
+	var safeErr interface{ SafeToRetry() bool }
+	if errors.As(err, &safeErr) && safeErr.SafeToRetry() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	return containsTransientMessage(err.Error())
+}
+
+// IsInterrupted returns whether the error interrupted the statement and left
+// its outcome unknown, such as an ambiguous result, a node which was shut
+// down or a connection which was lost while the statement ran. The statement
+// may or may not have been applied, so it may only be run again if it is
+// atomic or its outcome was checked.
+func IsInterrupted(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if _, ok := interruptedCodes[pgErr.Code]; ok {
+			return true
+		}
+		return containsMessage(pgErr.Message, interruptedMessages)
+	}
+	for _, target := range []error{
+		io.EOF, io.ErrUnexpectedEOF, net.ErrClosed, syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EPIPE,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return containsMessage(err.Error(), interruptedMessages)
+}
+
+func containsTransientMessage(msg string) bool {
+	return containsMessage(msg, transientMessages)
+}
+
+func containsMessage(msg string, messages []string) bool {
+	for _, m := range messages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// safeToRetryError is an error which pgconn returns before any of the
+// statement was sent.
+type safeToRetryError struct{}
+
+func (safeToRetryError) Error() string     { return "failed to write" }
+func (safeToRetryError) SafeToRetry() bool { return true }
+
+func TestIsTransient(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		err      error
+		expected bool
+	}{
+		{desc: "nil", err: nil},
+		{desc: "serialization failure", err: &pgconn.PgError{Code: "40001"}, expected: true},
+		{desc: "cannot connect now", err: errors.Wrap(&pgconn.PgError{Code: "57P03"}, "connecting"), expected: true},
+		{desc: "unable to connect", err: &pgconn.PgError{Code: "08001"}, expected: true},
+		{desc: "ambiguous result code", err: &pgconn.PgError{Code: "40003"}},
+		{desc: "admin shutdown", err: errors.Wrap(&pgconn.PgError{Code: "57P01"}, "importing")},
+		{desc: "connection failure", err: &pgconn.PgError{Code: "08006"}},
+		{
+			desc: "ambiguous result message",
+			err:  &pgconn.PgError{Code: "XXUUU", Message: "ambiguous result: error=rpc error"},
+		},
+		{desc: "unique violation", err: &pgconn.PgError{Code: "23505"}},
+		{desc: "syntax error", err: &pgconn.PgError{Code: "42601"}},
+		{
+			desc:     "connection refused",
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+			expected: true,
+		},
+		{desc: "flattened connection refused", err: errors.New("dial tcp: connection refused"), expected: true},
+		{desc: "safe to retry", err: errors.Wrap(safeToRetryError{}, "copying"), expected: true},
+		{desc: "connection reset", err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}},
+		{desc: "broken pipe", err: &net.OpError{Op: "write", Net: "tcp", Err: syscall.EPIPE}},
+		{desc: "EOF", err: errors.Wrap(io.EOF, "copying")},
+		{desc: "unexpected EOF", err: errors.Wrap(io.ErrUnexpectedEOF, "copying")},
+		{desc: "flattened connection reset", err: errors.New("read tcp: connection reset by peer")},
+		{desc: "context canceled", err: errors.Wrap(context.Canceled, "copying")},
+		{desc: "other error", err: errors.New("file not found")},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, IsTransient(tc.err))
+		})
+	}
+}
+
+func TestIsInterrupted(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		err      error
+		expected bool
+	}{
+		{desc: "nil", err: nil},
+		{desc: "ambiguous result code", err: &pgconn.PgError{Code: "40003"}, expected: true},
+		{desc: "admin shutdown", err: errors.Wrap(&pgconn.PgError{Code: "57P01"}, "importing"), expected: true},
+		{desc: "crash shutdown", err: &pgconn.PgError{Code: "57P02"}, expected: true},
+		{desc: "connection failure", err: &pgconn.PgError{Code: "08006"}, expected: true},
+		{desc: "transaction resolution unknown", err: &pgconn.PgError{Code: "08007"}, expected: true},
+		{
+			desc:     "ambiguous result message",
+			err:      &pgconn.PgError{Code: "XXUUU", Message: "ambiguous result: error=rpc error"},
+			expected: true,
+		},
+		{desc: "flattened ambiguous result", err: errors.New("result is ambiguous: node unavailable"), expected: true},
+		{desc: "connection reset", err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, expected: true},
+		{desc: "broken pipe", err: &net.OpError{Op: "write", Net: "tcp", Err: syscall.EPIPE}, expected: true},
+		{desc: "closed connection", err: errors.Wrap(net.ErrClosed, "copying"), expected: true},
+		{desc: "EOF", err: errors.Wrap(io.EOF, "copying"), expected: true},
+		{desc: "unexpected EOF", err: errors.Wrap(io.ErrUnexpectedEOF, "copying"), expected: true},
+		{desc: "flattened connection reset", err: errors.New("read tcp: connection reset by peer"), expected: true},
+		{desc: "serialization failure", err: &pgconn.PgError{Code: "40001"}},
+		{desc: "cannot connect now", err: &pgconn.PgError{Code: "57P03"}},
+		{desc: "unable to connect", err: &pgconn.PgError{Code: "08001"}},
+		{desc: "connection refused", err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+		{desc: "unique violation", err: &pgconn.PgError{Code: "23505"}},
+		{desc: "context canceled", err: errors.Wrap(context.Canceled, "copying")},
+		{desc: "other error", err: errors.New("file not found")},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, IsInterrupted(tc.err))
+		})
+	}
+}
+
+func TestRetry_DoTransient(t *testing.T) {
+	transientErr := &pgconn.PgError{Code: "40001"}
+	permanentErr := &pgconn.PgError{Code: "23505"}
+	settings := Settings{
+		InitialBackoff: time.Microsecond,
+		Multiplier:     2,
+		MaxRetries:     3,
+	}
+	for _, tc := range []struct {
+		desc          string
+		errs          []error
+		expectedSeen  int
+		expectedFinal error
+	}{
+		{
+			desc: "success",
+			errs: []error{nil},
+		},
+		{
+			desc:         "transient then success",
+			errs:         []error{transientErr, nil},
+			expectedSeen: 1,
+		},
+		{
+			desc:          "permanent",
+			errs:          []error{permanentErr},
+			expectedFinal: permanentErr,
+		},
+		{
+			desc:          "transient then permanent",
+			errs:          []error{transientErr, permanentErr},
+			expectedSeen:  1,
+			expectedFinal: permanentErr,
+		},
+		{
+			desc:          "retries exhausted",
+			errs:          []error{transientErr, transientErr, transientErr},
+			expectedSeen:  2,
+			expectedFinal: transientErr,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			r, err := NewRetry(settings)
+			require.NoError(t, err)
+
+			it := 0
+			seen := 0
+			err = r.DoTransient(context.Background(), func() error {
+				it++
+				return tc.errs[it-1]
+			}, func(err error) {
+				seen++
+			})
+			require.Equal(t, tc.expectedSeen, seen)
+			require.Equal(t, tc.expectedFinal, err)
+		})
+	}
+
+	t.Run("context cancelled", func(t *testing.T) {
+		r, err := NewRetry(Settings{InitialBackoff: time.Hour, Multiplier: 1})
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		err = r.DoTransient(ctx, func() error {
+			return transientErr
+		}, func(err error) {
+			cancel()
+		})
+		// The error of the operation is kept, so that it can be reported.
+		require.ErrorIs(t, err, transientErr)
+		require.Error(t, ctx.Err())
+	})
+}
+
+func TestRetry_DoAtomic(t *testing.T) {
+	settings := Settings{
+		InitialBackoff: time.Microsecond,
+		Multiplier:     2,
+		MaxRetries:     3,
+	}
+	for _, tc := range []struct {
+		desc          string
+		errs          []error
+		expectedSeen  int
+		expectedFinal error
+	}{
+		{
+			desc:         "transient then success",
+			errs:         []error{&pgconn.PgError{Code: "40001"}, nil},
+			expectedSeen: 1,
+		},
+		{
+			desc:         "admin shutdown then success",
+			errs:         []error{&pgconn.PgError{Code: "57P01"}, nil},
+			expectedSeen: 1,
+		},
+		{
+			desc:         "lost connection then success",
+			errs:         []error{errors.Wrap(io.ErrUnexpectedEOF, "copying"), nil},
+			expectedSeen: 1,
+		},
+		{
+			desc:         "ambiguous result then success",
+			errs:         []error{&pgconn.PgError{Code: "40003"}, nil},
+			expectedSeen: 1,
+		},
+		{
+			desc:          "permanent",
+			errs:          []error{&pgconn.PgError{Code: "23505"}},
+			expectedFinal: &pgconn.PgError{Code: "23505"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			r, err := NewRetry(settings)
+			require.NoError(t, err)
+
+			it := 0
+			seen := 0
+			err = r.DoAtomic(context.Background(), func() error {
+				it++
+				return tc.errs[it-1]
+			}, func(err error) {
+				seen++
+			})
+			require.Equal(t, tc.expectedSeen, seen)
+			require.Equal(t, tc.expectedFinal, err)
+		})
+	}
+
+	t.Run("interrupted errors are not retried by DoTransient", func(t *testing.T) {
+		r, err := NewRetry(settings)
+		require.NoError(t, err)
+		shutdownErr := &pgconn.PgError{Code: "57P01"}
+		attempts := 0
+		err = r.DoTransient(context.Background(), func() error {
+			attempts++
+			return shutdownErr
+		}, func(err error) {})
+		require.Equal(t, shutdownErr, err)
+		require.Equal(t, 1, attempts)
+	})
+}
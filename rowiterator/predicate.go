@@ -0,0 +1,93 @@
+package rowiterator
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	mysqlparser "github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
+)
+
+// ValidatePredicate returns an error if the predicate of the table cannot be
+// run on both the source, whose connection is given, and the target. Rows
+// are restricted by the same predicate on both, so it cannot reference
+// columns whose values are converted by type overrides or transforms, as it
+// would not match the same rows on the target.
+func ValidatePredicate(source dbconn.Conn, table dbtable.VerifiedTable) error {
+	if table.Predicate == "" {
+		return nil
+	}
+	expr, err := parser.ParseExpr(table.Predicate)
+	if err != nil {
+		return errors.Wrapf(err, "invalid predicate %q for table %s on the target", table.Predicate, table.SafeString())
+	}
+	switch source.(type) {
+	case *dbconn.MySQLConn:
+		if _, err := parseMySQLPredicate(table.Predicate); err != nil {
+			return errors.Wrapf(err, "invalid predicate for table %s on the source", table.SafeString())
+		}
+	case *dbconn.OracleConn:
+		if err := checkOraclePredicate(table.Predicate); err != nil {
+			return errors.Wrapf(err, "invalid predicate for table %s on the source", table.SafeString())
+		}
+	}
+
+	referenced := make(map[string]struct{})
+	if _, err := tree.SimpleVisit(expr, func(expr tree.Expr) (bool, tree.Expr, error) {
+		if n, ok := expr.(*tree.UnresolvedName); ok {
+			referenced[strings.ToLower(n.Parts[0])] = struct{}{}
+		}
+		return true, expr, nil
+	}); err != nil {
+		return err
+	}
+	for i, col := range table.Columns {
+		if _, ok := referenced[strings.ToLower(string(col))]; !ok {
+			continue
+		}
+		overridden := i < len(table.TypeOverridden) && table.TypeOverridden[i]
+		transformed := i < len(table.Transforms) && table.Transforms[i] != nil
+		if overridden || transformed {
+			return errors.Newf(
+				"predicate %q of table %s references column %s, whose values are converted on the target",
+				table.Predicate,
+				table.SafeString(),
+				col,
+			)
+		}
+	}
+	return nil
+}
+
+// parseMySQLPredicate parses a boolean expression in the MySQL dialect.
+func parseMySQLPredicate(pred string) (ast.ExprNode, error) {
+	stmt, err := mysqlparser.New().ParseOneStmt("SELECT 1 FROM t WHERE "+pred, "", "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid predicate %q", pred)
+	}
+	sel, ok := stmt.(*ast.SelectStmt)
+	if !ok || sel.Where == nil || sel.GroupBy != nil || sel.Having != nil ||
+		sel.OrderBy != nil || sel.Limit != nil || sel.LockInfo != nil {
+		return nil, errors.Newf("invalid predicate %q, expected a boolean expression", pred)
+	}
+	return sel.Where, nil
+}
+
+// checkOraclePredicate returns an error if a predicate cannot be added as is
+// to the WHERE clause of an Oracle query. There is no Oracle parser, so the
+// predicate must parse as a single boolean expression in the CockroachDB
+// dialect, which it is also run in on the target. Comments are rejected, as
+// Oracle and CockroachDB do not end them the same way.
+func checkOraclePredicate(pred string) error {
+	if strings.Contains(pred, "--") || strings.Contains(pred, "/*") {
+		return errors.Newf("invalid predicate %q, comments are not supported", pred)
+	}
+	if _, err := parser.ParseExpr(pred); err != nil {
+		return errors.Wrapf(err, "invalid predicate %q", pred)
+	}
+	return nil
+}
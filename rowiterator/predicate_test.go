@@ -0,0 +1,78 @@
+package rowiterator
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/stretchr/testify/require"
+)
+
+type identityTransform struct{}
+
+func (identityTransform) Transform(row tree.Datums, idx int) (tree.Datum, error) {
+	return row[idx], nil
+}
+
+func TestValidatePredicate(t *testing.T) {
+	table := dbtable.VerifiedTable{
+		Name:           dbtable.Name{Schema: "public", Table: "orders"},
+		Columns:        []tree.Name{"id", "tenant_id", "flag", "email"},
+		TypeOverridden: []bool{false, false, true, false},
+		Transforms:     []dbtable.ColumnTransform{nil, nil, nil, identityTransform{}},
+	}
+	pg := dbconn.MakeFakeConn("pg")
+	for _, tc := range []struct {
+		desc        string
+		source      dbconn.Conn
+		predicate   string
+		expectedErr string
+	}{
+		{desc: "no predicate", source: pg},
+		{desc: "valid", source: pg, predicate: "tenant_id = 42 AND id > 10"},
+		{desc: "valid for mysql", source: &dbconn.MySQLConn{}, predicate: "tenant_id IN (1, 2)"},
+		{desc: "valid for oracle", source: &dbconn.OracleConn{}, predicate: "tenant_id = 42"},
+		{
+			desc:        "invalid on the target",
+			source:      &dbconn.MySQLConn{},
+			predicate:   "`tenant_id` = 42",
+			expectedErr: "invalid predicate \"`tenant_id` = 42\" for table public.orders on the target",
+		},
+		{
+			desc:        "invalid on mysql",
+			source:      &dbconn.MySQLConn{},
+			predicate:   "tenant_id::INT8 = 42",
+			expectedErr: "invalid predicate for table public.orders on the source",
+		},
+		{
+			desc:        "comment on oracle",
+			source:      &dbconn.OracleConn{},
+			predicate:   "tenant_id = 42 /* x */",
+			expectedErr: "invalid predicate for table public.orders on the source: invalid predicate \"tenant_id = 42 /* x */\", comments are not supported",
+		},
+		{
+			desc:        "overridden column",
+			source:      pg,
+			predicate:   "tenant_id = 42 AND NOT FLAG",
+			expectedErr: "predicate \"tenant_id = 42 AND NOT FLAG\" of table public.orders references column flag, whose values are converted on the target",
+		},
+		{
+			desc:        "transformed column",
+			source:      pg,
+			predicate:   "lower(orders.email) LIKE '%@example.com'",
+			expectedErr: "predicate \"lower(orders.email) LIKE '%@example.com'\" of table public.orders references column email, whose values are converted on the target",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			table := table
+			table.Predicate = tc.predicate
+			err := ValidatePredicate(tc.source, table)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
@@ -28,13 +28,42 @@ type Table struct {
 	ColumnNames       []tree.Name
 	ColumnOIDs        []oid.Oid
 	PrimaryKeyColumns []tree.Name
+	// KeyType is the kind of key in PrimaryKeyColumns. For
+	// dbtable.KeyTypeRowID, PrimaryKeyColumns is the row identifier
+	// pseudo-column, which is read as text but not returned.
+	KeyType dbtable.KeyType
+}
+
+// numHiddenColumns is the number of columns read before ColumnNames which are
+// not returned by the iterator.
+func (t Table) numHiddenColumns() int {
+	if t.KeyType == dbtable.KeyTypeRowID {
+		return len(t.PrimaryKeyColumns)
+	}
+	return 0
+}
+
+// paged returns whether the table can be read in pages by primary key.
+func (t Table) paged() bool {
+	return t.KeyType != dbtable.KeyTypeNone
 }
 
 type ScanTable struct {
 	Table
-	AOST        *time.Time
+	AOST *time.Time
+	// AsOfSCN is the Oracle system change number to read the table at.
+	AsOfSCN     string
 	StartPKVals []tree.Datum
 	EndPKVals   []tree.Datum
+	// CursorPKVals, if set, is the primary key of the last row which has
+	// already been read. The scan resumes after it.
+	CursorPKVals []tree.Datum
+	// Splitter, if set, allows the end of the scan to be moved while it is
+	// running. It overrides EndPKVals.
+	Splitter *ShardSplitter
+	// Predicate, if set, is a SQL boolean expression in the dialect of the
+	// database which restricts the rows scanned, e.g. tenant_id = 42.
+	Predicate string
 }
 
 type rows interface {
@@ -7,11 +7,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree/treecmp"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/mysqlconv"
+	"github.com/lib/pq/oid"
 	"github.com/pingcap/tidb/parser/ast"
 	"github.com/pingcap/tidb/parser/format"
 	"github.com/pingcap/tidb/parser/model"
@@ -31,6 +34,11 @@ type scanIterator struct {
 	err           error
 	scanQuery     scanQuery
 	rateLimiter   *rate.Limiter
+	// typOIDs are the types of each column read, including hidden columns.
+	typOIDs []oid.Oid
+	// openRows are the rows of a scan which is not paged, which are read
+	// across multiple pages.
+	openRows rows
 }
 
 type scanIteratorResult struct {
@@ -59,6 +67,16 @@ func NewScanIterator(
 		currCacheSize: rowBatchSize,
 		waitCh:        make(chan scanIteratorResult, 1),
 		rateLimiter:   rateLimiter,
+		typOIDs:       table.ColumnOIDs,
+		pkCursor:      table.CursorPKVals,
+	}
+	if numHidden := table.numHiddenColumns(); numHidden > 0 {
+		// Row identifiers are read as text.
+		it.typOIDs = make([]oid.Oid, 0, numHidden+len(table.ColumnOIDs))
+		for i := 0; i < numHidden; i++ {
+			it.typOIDs = append(it.typOIDs, oid.T_text)
+		}
+		it.typOIDs = append(it.typOIDs, table.ColumnOIDs...)
 	}
 	switch conn := conn.(type) {
 	case *dbconn.PGConn:
@@ -85,6 +103,12 @@ func (it *scanIterator) HasNext(ctx context.Context) bool {
 		}
 
 		if len(it.cache) > 0 {
+			if it.table.Splitter != nil && !it.table.Splitter.claim(it.cache[0][:len(it.table.PrimaryKeyColumns)]) {
+				// The remaining rows have been split off into another shard.
+				it.cache = nil
+				it.currCacheSize = 0
+				return false
+			}
 			return true
 		}
 
@@ -114,77 +138,92 @@ func (it *scanIterator) HasNext(ctx context.Context) bool {
 func (it *scanIterator) nextPage(ctx context.Context) {
 	go func() {
 		datums, err := func() ([]tree.Datums, error) {
-			var currRows rows
-			q, args, err := it.scanQuery.generate(it.pkCursor)
-			if err != nil {
-				return nil, err
-			}
 			if it.rateLimiter != nil {
 				if err := it.rateLimiter.Wait(ctx); err != nil {
 					return nil, err
 				}
 			}
-			switch conn := it.conn.(type) {
-			case *dbconn.PGConn:
-				newRows, err := conn.Query(ctx, q, args...)
-				if err != nil {
-					return nil, errors.Wrapf(err, "error getting rows for table %s.%s from %s", it.table.Schema, it.table.Table.Name, it.conn.ID)
-				}
-				currRows = &pgRows{
-					Rows:    newRows,
-					typMap:  it.conn.TypeMap(),
-					typOIDs: it.table.ColumnOIDs,
-				}
-			case *dbconn.MySQLConn:
-				newRows, err := conn.QueryContext(ctx, q, args...)
-				if err != nil {
-					return nil, errors.Wrapf(err, "error getting rows for table %s in %s", it.table.Table.Name, it.conn.ID())
-				}
-				currRows = &mysqlRows{
-					Rows:    newRows,
-					typMap:  it.conn.TypeMap(),
-					typOIDs: it.table.ColumnOIDs,
-				}
-			case *dbconn.OracleConn:
-				newRows, err := conn.QueryContext(ctx, q, args...)
+			currRows := it.openRows
+			if currRows == nil {
+				q, args, err := it.scanQuery.generate(it.pkCursor)
 				if err != nil {
-					return nil, errors.Wrapf(err, "error getting rows for table %s in %s", it.table.Table.Name, it.conn.ID())
+					return nil, err
 				}
-				currRows = &oracleRows{
-					Rows:    newRows,
-					typMap:  it.conn.TypeMap(),
-					typOIDs: it.table.ColumnOIDs,
+				if currRows, err = it.query(ctx, q, args); err != nil {
+					return nil, err
 				}
-			default:
-				return nil, errors.AssertionFailedf("unhandled conn type: %T", conn)
 			}
-			defer func() { currRows.Close() }()
-
+			// Scans which are not paged keep their rows open until every row
+			// has been read.
+			it.openRows = nil
 			datums := make([]tree.Datums, 0, it.rowBatchSize)
-			for currRows.Next() {
+			for len(datums) < it.rowBatchSize && currRows.Next() {
 				d, err := currRows.Datums()
 				if err != nil {
+					currRows.Close()
 					return nil, errors.Wrapf(err, "error getting datums")
 				}
 				it.pkCursor = d[:len(it.table.PrimaryKeyColumns)]
 				datums = append(datums, d)
 			}
+			if !it.table.paged() && len(datums) == it.rowBatchSize {
+				it.openRows = currRows
+				return datums, nil
+			}
+			defer func() { currRows.Close() }()
 			return datums, currRows.Err()
 		}()
 		it.waitCh <- scanIteratorResult{r: datums, err: err}
 	}()
 }
 
+func (it *scanIterator) query(ctx context.Context, q string, args []any) (rows, error) {
+	switch conn := it.conn.(type) {
+	case *dbconn.PGConn:
+		newRows, err := conn.Query(ctx, q, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting rows for table %s.%s from %s", it.table.Schema, it.table.Table.Name, it.conn.ID)
+		}
+		return &pgRows{
+			Rows:    newRows,
+			typMap:  it.conn.TypeMap(),
+			typOIDs: it.typOIDs,
+		}, nil
+	case *dbconn.MySQLConn:
+		newRows, err := conn.QueryContext(ctx, q, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting rows for table %s in %s", it.table.Table.Name, it.conn.ID())
+		}
+		return &mysqlRows{
+			Rows:    newRows,
+			typMap:  it.conn.TypeMap(),
+			typOIDs: it.typOIDs,
+		}, nil
+	case *dbconn.OracleConn:
+		newRows, err := conn.QueryContext(ctx, q, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting rows for table %s in %s", it.table.Table.Name, it.conn.ID())
+		}
+		return &oracleRows{
+			Rows:    newRows,
+			typMap:  it.conn.TypeMap(),
+			typOIDs: it.typOIDs,
+		}, nil
+	default:
+		return nil, errors.AssertionFailedf("unhandled conn type: %T", conn)
+	}
+}
+
 func (it *scanIterator) Peek(ctx context.Context) tree.Datums {
 	if it.HasNext(ctx) {
-		return it.cache[0]
+		return it.cache[0][it.table.numHiddenColumns():]
 	}
 	return nil
 }
 
 func (it *scanIterator) Next(ctx context.Context) tree.Datums {
 	if it.HasNext(ctx) {
-		ret := it.cache[0]
+		ret := it.cache[0][it.table.numHiddenColumns():]
 		it.cache = it.cache[1:]
 		return ret
 	}
@@ -202,7 +241,9 @@ type scanQuery struct {
 
 func newPGScanQuery(table ScanTable, rowBatchSize int) scanQuery {
 	baseSelectExpr := NewPGBaseSelectClause(table.Table)
-	baseSelectExpr.Limit = &tree.Limit{Count: tree.NewNumVal(constant.MakeUint64(uint64(rowBatchSize)), "", false)}
+	if table.paged() {
+		baseSelectExpr.Limit = &tree.Limit{Count: tree.NewNumVal(constant.MakeUint64(uint64(rowBatchSize)), "", false)}
+	}
 	if table.AOST != nil {
 		var err error
 		baseSelectExpr.Select.(*tree.SelectClause).From.AsOf.Expr, err = tree.MakeDTimestamp(*table.AOST, time.Microsecond)
@@ -238,10 +279,12 @@ func NewPGBaseSelectClause(table Table) *tree.Select {
 		Select: selectClause,
 	}
 	for _, pkCol := range table.PrimaryKeyColumns {
-		baseSelectExpr.OrderBy = append(
-			baseSelectExpr.OrderBy,
-			&tree.Order{Expr: tree.NewUnresolvedName(string(pkCol))},
-		)
+		order := &tree.Order{Expr: tree.NewUnresolvedName(string(pkCol))}
+		if table.KeyType == dbtable.KeyTypeNone {
+			// Columns may be NULL, so sort them the same way as datums.
+			order.NullsOrder = tree.NullsFirst
+		}
+		baseSelectExpr.OrderBy = append(baseSelectExpr.OrderBy, order)
 	}
 	return baseSelectExpr
 }
@@ -251,9 +294,6 @@ type oracleStatement struct {
 }
 
 func newOracleScanQuery(table ScanTable, rowBatchSize int) scanQuery {
-	if len(table.StartPKVals) > 0 || len(table.EndPKVals) > 0 {
-		panic("sharding on oracle not yet supported")
-	}
 	return scanQuery{
 		table: table,
 		base: &oracleStatement{
@@ -264,7 +304,9 @@ func newOracleScanQuery(table ScanTable, rowBatchSize int) scanQuery {
 
 func newMySQLScanQuery(table ScanTable, rowBatchSize int) scanQuery {
 	stmt := newMySQLBaseSelectClause(table.Table)
-	stmt.Limit = &ast.Limit{Count: ast.NewValueExpr(rowBatchSize, "", "")}
+	if table.paged() {
+		stmt.Limit = &ast.Limit{Count: ast.NewValueExpr(rowBatchSize, "", "")}
+	}
 	return scanQuery{
 		base:  stmt,
 		table: table,
@@ -280,12 +322,17 @@ func newMySQLBaseSelectClause(table Table) *ast.SelectStmt {
 			Expr: mysqlconv.MySQLASTColumnField(col),
 		}
 	}
-	orderBy := &ast.OrderByClause{
-		Items: make([]*ast.ByItem, len(table.PrimaryKeyColumns)),
-	}
-	for i, pkCol := range table.PrimaryKeyColumns {
-		orderBy.Items[i] = &ast.ByItem{
-			Expr: mysqlconv.MySQLASTColumnField(pkCol),
+	// Scans of tables without a unique key which do not need their rows in
+	// order have no ordering columns.
+	var orderBy *ast.OrderByClause
+	if len(table.PrimaryKeyColumns) > 0 {
+		orderBy = &ast.OrderByClause{
+			Items: make([]*ast.ByItem, len(table.PrimaryKeyColumns)),
+		}
+		for i, pkCol := range table.PrimaryKeyColumns {
+			orderBy.Items[i] = &ast.ByItem{
+				Expr: mysqlconv.MySQLASTColumnField(pkCol),
+			}
 		}
 	}
 	return &ast.SelectStmt{
@@ -306,36 +353,49 @@ func newMySQLBaseSelectClause(table Table) *ast.SelectStmt {
 }
 
 func (sq *scanQuery) generate(pkCursor tree.Datums) (string, []any, error) {
+	endPKVals := tree.Datums(sq.table.EndPKVals)
+	if sq.table.Splitter != nil {
+		endPKVals = sq.table.Splitter.End()
+	}
 	switch stmt := sq.base.(type) {
 	case *tree.Select:
 		andClause := &tree.AndExpr{
 			Left:  tree.DBoolTrue,
 			Right: tree.DBoolTrue,
 		}
-		// Use the cursor if available, otherwise not.
-		if len(pkCursor) > 0 {
+		// Use the cursor if available, otherwise not. Scans which are not
+		// paged are run in a single query.
+		if len(pkCursor) > 0 && sq.table.paged() {
 			andClause.Left = makePGCompareExpr(
 				treecmp.MakeComparisonOperator(treecmp.GT),
-				sq.table.ColumnNames,
+				sq.table.PrimaryKeyColumns,
 				pkCursor,
 			)
 		} else if len(sq.table.StartPKVals) > 0 {
 			andClause.Left = makePGCompareExpr(
 				treecmp.MakeComparisonOperator(treecmp.GE),
-				sq.table.ColumnNames,
+				sq.table.PrimaryKeyColumns,
 				sq.table.StartPKVals,
 			)
 		}
-		if len(sq.table.EndPKVals) > 0 {
+		if len(endPKVals) > 0 {
 			andClause.Right = makePGCompareExpr(
 				treecmp.MakeComparisonOperator(treecmp.LT),
-				sq.table.ColumnNames,
-				sq.table.EndPKVals,
+				sq.table.PrimaryKeyColumns,
+				endPKVals,
 			)
 		}
+		var where tree.Expr = andClause
+		if sq.table.Predicate != "" {
+			pred, err := parser.ParseExpr(sq.table.Predicate)
+			if err != nil {
+				return "", nil, errors.Wrapf(err, "invalid predicate %q", sq.table.Predicate)
+			}
+			where = &tree.AndExpr{Left: andClause, Right: &tree.ParenExpr{Expr: pred}}
+		}
 		stmt.Select.(*tree.SelectClause).Where = &tree.Where{
 			Type: tree.AstWhere,
-			Expr: andClause,
+			Expr: where,
 		}
 		f := tree.NewFmtCtx(tree.FmtParsableNumerics)
 		f.FormatNode(stmt)
@@ -344,36 +404,60 @@ func (sq *scanQuery) generate(pkCursor tree.Datums) (string, []any, error) {
 		// TODO: escaping names is not supported.
 		sb := strings.Builder{}
 		sb.WriteString("SELECT ")
-		for i := range sq.table.ColumnNames {
-			if i > 0 {
-				sb.WriteString(", ")
+		var selectCols []string
+		if sq.table.KeyType == dbtable.KeyTypeRowID {
+			for _, col := range sq.table.PrimaryKeyColumns {
+				selectCols = append(selectCols, fmt.Sprintf("ROWIDTOCHAR(%s)", col))
 			}
-			sb.WriteString(string(sq.table.ColumnNames[i]))
 		}
+		for _, col := range sq.table.ColumnNames {
+			selectCols = append(selectCols, string(col))
+		}
+		sb.WriteString(strings.Join(selectCols, ", "))
 		sb.WriteString(" FROM ")
 		sb.WriteString(string(sq.table.Name.Table))
+		if sq.table.AsOfSCN != "" {
+			sb.WriteString(" AS OF SCN ")
+			sb.WriteString(sq.table.AsOfSCN)
+		}
 
-		if len(pkCursor) > 0 {
-			sb.WriteString(" WHERE ")
-			// TODO: support multi-pk keys
-			if len(sq.table.PrimaryKeyColumns) > 1 {
-				panic("unsupported")
+		var args []any
+		var conds []string
+		// Use the cursor if available, otherwise not.
+		if len(pkCursor) > 0 && sq.table.paged() {
+			conds = append(conds, makeOracleCompareExpr(">", sq.table.PrimaryKeyColumns, pkCursor, &args))
+		} else if len(sq.table.StartPKVals) > 0 {
+			conds = append(conds, makeOracleCompareExpr(">=", sq.table.PrimaryKeyColumns, sq.table.StartPKVals, &args))
+		}
+		if len(endPKVals) > 0 {
+			conds = append(conds, makeOracleCompareExpr("<", sq.table.PrimaryKeyColumns, endPKVals, &args))
+		}
+		if sq.table.Predicate != "" {
+			if err := checkOraclePredicate(sq.table.Predicate); err != nil {
+				return "", nil, err
 			}
-			f := tree.NewFmtCtx(tree.FmtBareStrings)
-			f.FormatNode(pkCursor[0])
-			// TODO: support converting data types back into string representations in oracle.
-			sb.WriteString(fmt.Sprintf("%s > '%s'", sq.table.PrimaryKeyColumns[0], f.CloseAndGetString()))
+			conds = append(conds, "("+sq.table.Predicate+")")
+		}
+		if len(conds) > 0 {
+			sb.WriteString(" WHERE ")
+			sb.WriteString(strings.Join(conds, " AND "))
 		}
 
-		sb.WriteString(" ORDER BY ")
 		for i := range sq.table.PrimaryKeyColumns {
-			if i > 0 {
+			if i == 0 {
+				sb.WriteString(" ORDER BY ")
+			} else {
 				sb.WriteString(", ")
 			}
 			sb.WriteString(string(sq.table.PrimaryKeyColumns[i]))
+			if sq.table.KeyType == dbtable.KeyTypeNone {
+				sb.WriteString(" NULLS FIRST")
+			}
 		}
-		sb.WriteString(fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", stmt.rowBatchSize))
-		return sb.String(), nil, nil
+		if sq.table.paged() {
+			sb.WriteString(fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", stmt.rowBatchSize))
+		}
+		return sb.String(), args, nil
 	case *ast.SelectStmt:
 		andClause := &ast.BinaryOperationExpr{
 			Op: opcode.LogicAnd,
@@ -381,27 +465,38 @@ func (sq *scanQuery) generate(pkCursor tree.Datums) (string, []any, error) {
 			R:  ast.NewValueExpr(1, "", ""),
 		}
 		// Use the cursor if available, otherwise not.
-		if len(pkCursor) > 0 {
+		if len(pkCursor) > 0 && sq.table.paged() {
 			andClause.L = makeMySQLCompareExpr(
 				opcode.GT,
-				sq.table.ColumnNames,
+				sq.table.PrimaryKeyColumns,
 				pkCursor,
 			)
 		} else if len(sq.table.StartPKVals) > 0 {
 			andClause.L = makeMySQLCompareExpr(
 				opcode.GE,
-				sq.table.ColumnNames,
+				sq.table.PrimaryKeyColumns,
 				sq.table.StartPKVals,
 			)
 		}
-		if len(sq.table.EndPKVals) > 0 {
+		if len(endPKVals) > 0 {
 			andClause.R = makeMySQLCompareExpr(
 				opcode.LT,
-				sq.table.ColumnNames,
-				sq.table.EndPKVals,
+				sq.table.PrimaryKeyColumns,
+				endPKVals,
 			)
 		}
 		stmt.Where = andClause
+		if sq.table.Predicate != "" {
+			pred, err := parseMySQLPredicate(sq.table.Predicate)
+			if err != nil {
+				return "", nil, err
+			}
+			stmt.Where = &ast.BinaryOperationExpr{
+				Op: opcode.LogicAnd,
+				L:  andClause,
+				R:  &ast.ParenthesesExpr{Expr: pred},
+			}
+		}
 		var sb strings.Builder
 		if err := stmt.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
 			return "", nil, errors.Wrap(err, "error generating MySQL statement")
@@ -444,6 +539,45 @@ func datumToMySQLValue(val tree.Datum) ast.ValueExpr {
 	return ast.NewValueExpr(f.CloseAndGetString(), "", "")
 }
 
+// makeOracleCompareExpr generates a comparison of the given columns against
+// vals, appending the bind arguments to args. Oracle does not support
+// comparing tuples with inequalities, so multi-column comparisons are expanded
+// lexicographically, e.g. (a, b) > (x, y) becomes
+// (a > x OR (a = x AND b > y)).
+func makeOracleCompareExpr(op string, cols []tree.Name, vals tree.Datums, args *[]any) string {
+	bindVal := func(val tree.Datum) string {
+		// TODO: support converting data types back into string representations in oracle.
+		f := tree.NewFmtCtx(tree.FmtBareStrings)
+		f.FormatNode(val)
+		*args = append(*args, f.CloseAndGetString())
+		return fmt.Sprintf(":%d", len(*args))
+	}
+	if len(vals) == 1 {
+		return fmt.Sprintf("%s %s %s", cols[0], op, bindVal(vals[0]))
+	}
+	// The inequality without the equals portion is used for all but the last
+	// column, e.g. (a, b) >= (x, y) becomes (a > x OR (a = x AND b >= y)).
+	strictOp := strings.TrimSuffix(op, "=")
+	var sb strings.Builder
+	for i := range vals {
+		if i > 0 {
+			sb.WriteString(" OR (")
+			for j := 0; j < i; j++ {
+				sb.WriteString(fmt.Sprintf("%s = %s AND ", cols[j], bindVal(vals[j])))
+			}
+		}
+		currOp := strictOp
+		if i == len(vals)-1 {
+			currOp = op
+		}
+		sb.WriteString(fmt.Sprintf("%s %s %s", cols[i], currOp, bindVal(vals[i])))
+		if i > 0 {
+			sb.WriteString(")")
+		}
+	}
+	return "(" + sb.String() + ")"
+}
+
 func makePGCompareExpr(
 	op treecmp.ComparisonOperator, cols []tree.Name, vals tree.Datums,
 ) *tree.ComparisonExpr {
@@ -36,9 +36,41 @@ func TestScanQuery(t *testing.T) {
 			case "pg":
 				sq = newPGScanQuery(table, 10000)
 				return ""
+			case "oracle":
+				sq = newOracleScanQuery(table, 10000)
+				return ""
+			case "key_type":
+				var keyType string
+				d.ScanArgs(t, "type", &keyType)
+				switch keyType {
+				case "rowid":
+					var col string
+					d.ScanArgs(t, "col", &col)
+					table.KeyType = dbtable.KeyTypeRowID
+					table.PrimaryKeyColumns = []tree.Name{tree.Name(col)}
+				case "none":
+					table.KeyType = dbtable.KeyTypeNone
+					table.PrimaryKeyColumns = table.ColumnNames
+					if d.HasArg("unordered") {
+						table.PrimaryKeyColumns = nil
+					}
+				default:
+					t.Fatalf("unknown key type %s", keyType)
+				}
+				return ""
+			case "predicate":
+				table.Predicate = strings.TrimSpace(d.Input)
+				return ""
+			case "as_of_scn":
+				table.AsOfSCN = strings.TrimSpace(d.Input)
+				return ""
 			case "generate":
 				require.NotNil(t, sq.base)
 				s, args, err := sq.generate(parseDatums(t, d.Input, "\n"))
+				if d.HasArg("error") {
+					require.Error(t, err)
+					return err.Error()
+				}
 				require.NoError(t, err)
 				ret := s
 				if len(args) > 0 {
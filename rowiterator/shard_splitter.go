@@ -0,0 +1,93 @@
+package rowiterator
+
+import (
+	"sync"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/comparectx"
+)
+
+// ShardSplitter tracks the progress of the scans over a shard, allowing the
+// remaining rows of the shard to be split off and scanned elsewhere while
+// the scans are running.
+//
+// Scans claim each row before returning it, and stop at the first row which
+// is at or after the end of the shard. A split point must be after every
+// claimed row, so no row is returned by both shards.
+type ShardSplitter struct {
+	mu struct {
+		sync.Mutex
+		cursor tree.Datums
+		end    tree.Datums
+	}
+}
+
+// NewShardSplitter returns a ShardSplitter for a shard ending at end, where
+// an empty end represents the end of the table.
+func NewShardSplitter(end tree.Datums) *ShardSplitter {
+	s := &ShardSplitter{}
+	s.mu.end = end
+	return s
+}
+
+// End returns the current end of the shard.
+func (s *ShardSplitter) End() tree.Datums {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mu.end
+}
+
+// Progress returns the primary key of the last row claimed by a scan, and
+// the current end of the shard. The cursor is empty if no rows have been
+// claimed.
+func (s *ShardSplitter) Progress() (cursor tree.Datums, end tree.Datums) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mu.cursor, s.mu.end
+}
+
+// Split moves the end of the shard to at, which may be a prefix of the
+// primary key. It returns false if at is not after every claimed row and
+// before the current end.
+func (s *ShardSplitter) Split(at tree.Datums) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(at) == 0 {
+		return false
+	}
+	if len(s.mu.cursor) > 0 && comparePKBounds(s.mu.cursor, at) >= 0 {
+		return false
+	}
+	if len(s.mu.end) > 0 && comparePKBounds(at, s.mu.end) >= 0 {
+		return false
+	}
+	s.mu.end = at
+	return true
+}
+
+// claim records that the row with the given primary key is about to be
+// returned by a scan. It returns false if the row is at or after the end of
+// the shard.
+func (s *ShardSplitter) claim(pk tree.Datums) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.mu.end) > 0 && comparePKBounds(pk, s.mu.end) >= 0 {
+		return false
+	}
+	if len(s.mu.cursor) == 0 || comparePKBounds(pk, s.mu.cursor) > 0 {
+		s.mu.cursor = pk
+	}
+	return true
+}
+
+// comparePKBounds compares primary keys or prefixes of primary keys. A prefix
+// sorts before every primary key starting with it, matching the tuple
+// comparisons used to bound scans.
+func comparePKBounds(a, b tree.Datums) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := a[i].Compare(comparectx.CompareContext, b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
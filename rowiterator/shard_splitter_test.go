@@ -0,0 +1,52 @@
+package rowiterator
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardSplitter(t *testing.T) {
+	pk := func(vals ...int) tree.Datums {
+		ret := make(tree.Datums, len(vals))
+		for i, v := range vals {
+			ret[i] = tree.NewDInt(tree.DInt(v))
+		}
+		return ret
+	}
+
+	t.Run("unbounded", func(t *testing.T) {
+		s := NewShardSplitter(nil)
+		// Nothing claimed yet, so any split point is after the cursor.
+		require.True(t, s.claim(pk(1, 1)))
+		require.True(t, s.claim(pk(5, 2)))
+		// Claims from a slower iterator do not move the cursor back.
+		require.True(t, s.claim(pk(3, 1)))
+		cursor, end := s.Progress()
+		require.Equal(t, pk(5, 2), cursor)
+		require.Empty(t, end)
+
+		require.False(t, s.Split(nil))
+		require.False(t, s.Split(pk(5, 2)))
+		// A prefix sorts before every key starting with it.
+		require.False(t, s.Split(pk(5)))
+		require.True(t, s.Split(pk(10)))
+		require.Equal(t, pk(10), s.End())
+
+		require.True(t, s.claim(pk(9, 100)))
+		require.False(t, s.claim(pk(10, 0)))
+		require.False(t, s.claim(pk(11, 0)))
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		s := NewShardSplitter(pk(100))
+		require.True(t, s.claim(pk(10)))
+		require.False(t, s.Split(pk(100)))
+		require.False(t, s.Split(pk(200)))
+		require.True(t, s.Split(pk(50)))
+		require.False(t, s.Split(pk(60)))
+		require.True(t, s.Split(pk(20)))
+		require.False(t, s.claim(pk(20)))
+	})
+}
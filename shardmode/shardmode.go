@@ -0,0 +1,23 @@
+package shardmode
+
+import "github.com/thediveo/enumflag/v2"
+
+// Flag determines how the boundaries between the shards of a table are
+// chosen.
+//
+//go:generate go run github.com/alvaroloes/enumer -type=Flag -output shardmode_enumer.gen.go
+type Flag enumflag.Flag
+
+const (
+	// Linear divides the range between the minimum and maximum primary key
+	// values evenly.
+	Linear Flag = iota + 1
+	// Stats uses table statistics or a sample of the table to choose
+	// boundaries which give each shard a similar number of rows.
+	Stats
+)
+
+var ShardModeStringRepresentations = map[Flag][]string{
+	Linear: {"linear"},
+	Stats:  {"stats"},
+}
@@ -7,6 +7,10 @@ type FetchTestingKnobs struct {
 	FailedWriteToBucket FailedWriteToBucketKnob
 
 	FailedEstablishSrcConnForExport bool
+
+	// FailedExportAfterNumFiles fails the export of each shard once it has
+	// written the given number of files, if set.
+	FailedExportAfterNumFiles int
 }
 
 type FailedWriteToBucketKnob struct {
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/cockroachdb/molt/dbtable"
@@ -176,3 +178,107 @@ func TestFilterResult(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterTables(t *testing.T) {
+	tables := []dbtable.DBTable{
+		{Name: dbtable.Name{Schema: "aaa", Table: "aaa"}},
+		{Name: dbtable.Name{Schema: "aaa", Table: "bbb"}},
+		{Name: dbtable.Name{Schema: "ccc", Table: "bbb"}},
+	}
+	res, err := FilterTables(FilterConfig{SchemaFilter: "aaa", TableFilter: "b.*"}, tables)
+	require.NoError(t, err)
+	require.Equal(t, []dbtable.DBTable{{Name: dbtable.Name{Schema: "aaa", Table: "bbb"}}}, res)
+
+	_, err = FilterTables(FilterConfig{SchemaFilter: "(", TableFilter: DefaultFilterString}, tables)
+	require.Error(t, err)
+}
+
+func TestTableMatcher(t *testing.T) {
+	tables := []dbtable.Name{
+		{Schema: "public", Table: "orders"},
+		{Schema: "public", Table: "tmp_orders"},
+		{Schema: "public", Table: "Audit_Log"},
+		{Schema: "archive", Table: "orders"},
+		{Schema: "archive", Table: "tmp_users"},
+	}
+	for _, tc := range []struct {
+		desc        string
+		include     []string
+		exclude     []string
+		expected    []dbtable.Name
+		expectedErr string
+	}{
+		{
+			desc:     "include",
+			include:  []string{"orders", "public.audit_*"},
+			expected: []dbtable.Name{tables[0], tables[2], tables[3]},
+		},
+		{
+			desc:     "exclude",
+			exclude:  []string{"tmp_*", "archive.orders"},
+			expected: []dbtable.Name{tables[0], tables[2]},
+		},
+		{
+			desc:     "include and exclude",
+			include:  []string{"*.*orders"},
+			exclude:  []string{"*.tmp_*"},
+			expected: []dbtable.Name{tables[0], tables[3]},
+		},
+		{
+			desc:        "invalid pattern",
+			exclude:     []string{"public.[orders"},
+			expectedErr: `invalid table pattern "public.[orders": syntax error in pattern`,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			cfg := DefaultFilterConfig()
+			cfg.Include = tc.include
+			cfg.Exclude = tc.exclude
+			m, err := NewTableMatcher(cfg)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			var matched []dbtable.Name
+			for _, n := range tables {
+				if m.Matches(n) {
+					matched = append(matched, n)
+				}
+			}
+			require.Equal(t, tc.expected, matched)
+		})
+	}
+}
+
+func TestLoadTableList(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "tables.txt")
+	require.NoError(t, os.WriteFile(filePath, []byte(`
+# Tables of the first tenant.
+public.orders
+public.order_items_*
+invoices
+`), 0o644))
+	patterns, err := LoadTableList(filePath)
+	require.NoError(t, err)
+	require.Equal(t, []string{`public.orders`, `public.order_items_\*`, `invoices`}, patterns)
+
+	cfg := DefaultFilterConfig()
+	cfg.Include = patterns
+	res, err := FilterTables(cfg, []dbtable.DBTable{
+		{Name: dbtable.Name{Schema: "public", Table: "orders"}},
+		{Name: dbtable.Name{Schema: "public", Table: "order_items_2023"}},
+		{Name: dbtable.Name{Schema: "public", Table: "order_items_*"}},
+		{Name: dbtable.Name{Schema: "billing", Table: "invoices"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []dbtable.DBTable{
+		{Name: dbtable.Name{Schema: "public", Table: "orders"}},
+		{Name: dbtable.Name{Schema: "public", Table: "order_items_*"}},
+		{Name: dbtable.Name{Schema: "billing", Table: "invoices"}},
+	}, res)
+
+	require.NoError(t, os.WriteFile(filePath, []byte("db.public.orders\n"), 0o644))
+	_, err = LoadTableList(filePath)
+	require.ErrorContains(t, err, `line 1: expected a schema.table name, got "db.public.orders"`)
+}
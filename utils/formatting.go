@@ -13,7 +13,7 @@ func SchemaTableString(schema, table tree.Name) string {
 	return fmt.Sprintf("%s.%s", string(schema), string(table))
 }
 
-var FileConventionRegex = regexp.MustCompile(`part_[\d+]{8}(\.csv|\.tar\.gz)`)
+var FileConventionRegex = regexp.MustCompile(`part_[\d+]{8}(\.csv|\.tar\.gz|\.parquet)`)
 
 func MatchesFileConvention(fileName string) bool {
 	return FileConventionRegex.MatchString(fileName)
@@ -40,6 +40,14 @@ func MaybeFormatDurationForTest(testOnly bool, duration time.Duration) time.Dura
 	return time.Second
 }
 
+// MaybeFormatTimeForTest is to make a deterministic time for test.
+func MaybeFormatTimeForTest(testOnly bool, t time.Time) time.Time {
+	if !testOnly {
+		return t
+	}
+	return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
 // MaybeFormatCDCCursor is to make a deterministic CDC cursor for test.
 func MaybeFormatCDCCursor(testOnly bool, s string) string {
 	if !testOnly {
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+)
+
+// NamePair explicitly maps a source table to a target table. A source without
+// a schema matches the table in any schema, and a target without a schema
+// keeps the schema given by the rename rules.
+type NamePair struct {
+	Source dbtable.Name
+	Target dbtable.Name
+}
+
+// ParseNamePair parses a pair formatted as source=target, e.g.
+// legacy.Orders=sales.orders. Names are parsed as SQL identifiers, so they
+// are lowercased unless quoted.
+func ParseNamePair(s string) (NamePair, error) {
+	src, tgt, ok := strings.Cut(s, "=")
+	if !ok {
+		return NamePair{}, errors.Newf("invalid table mapping %q, expected source=target", s)
+	}
+	var ret NamePair
+	for _, p := range []struct {
+		name string
+		ret  *dbtable.Name
+	}{
+		{name: src, ret: &ret.Source},
+		{name: tgt, ret: &ret.Target},
+	} {
+		n, err := parser.ParseTableName(strings.TrimSpace(p.name))
+		if err != nil {
+			return NamePair{}, errors.Wrapf(err, "invalid table mapping %q", s)
+		}
+		if n.NumParts > 2 {
+			return NamePair{}, errors.Newf("invalid table mapping %q, expected schema.table names", s)
+		}
+		p.ret.Table = tree.Name(n.Parts[0])
+		if n.NumParts == 2 {
+			p.ret.Schema = tree.Name(n.Parts[1])
+		}
+	}
+	return ret, nil
+}
+
+// NameMapping maps the names of the source tables to the names of the tables
+// they are created in, loaded into and compared with on the target. Explicit
+// pairs take precedence over the rename rules, which are applied in the order
+// of the fields below. The zero value maps every table to the same name.
+type NameMapping struct {
+	Pairs []NamePair
+	// StripTablePrefix is removed from the start of the table names which
+	// begin with it.
+	StripTablePrefix string
+	// Lowercase lowercases the schema and table names.
+	Lowercase bool
+	// Schema, if set, is the schema on the target of every table.
+	Schema string
+	// DatabaseAsSchema places the tables of a MySQL source in the schema
+	// named after the source database, which is set with WithSourceDatabase.
+	// Schema takes precedence over it.
+	DatabaseAsSchema bool
+
+	sourceDatabase string
+}
+
+// WithSourceDatabase returns the mapping for a source whose tables are in the
+// given database, which is used as their schema if DatabaseAsSchema is set.
+func (m NameMapping) WithSourceDatabase(database string) NameMapping {
+	m.sourceDatabase = database
+	return m
+}
+
+// TargetName returns the name on the target of the source table.
+func (m NameMapping) TargetName(n dbtable.Name) dbtable.Name {
+	ret := n
+	if m.StripTablePrefix != "" {
+		ret.Table = tree.Name(strings.TrimPrefix(string(ret.Table), m.StripTablePrefix))
+	}
+	if m.Lowercase {
+		ret.Schema = tree.Name(strings.ToLower(string(ret.Schema)))
+		ret.Table = tree.Name(strings.ToLower(string(ret.Table)))
+	}
+	switch {
+	case m.Schema != "":
+		ret.Schema = tree.Name(m.Schema)
+	case m.DatabaseAsSchema && m.sourceDatabase != "":
+		ret.Schema = tree.Name(m.sourceDatabase)
+		if m.Lowercase {
+			ret.Schema = tree.Name(strings.ToLower(m.sourceDatabase))
+		}
+	}
+	for _, p := range m.Pairs {
+		if !strings.EqualFold(string(p.Source.Table), string(n.Table)) ||
+			(p.Source.Schema != "" && !strings.EqualFold(string(p.Source.Schema), string(n.Schema))) {
+			continue
+		}
+		ret.Table = p.Target.Table
+		if p.Target.Schema != "" {
+			ret.Schema = p.Target.Schema
+		}
+		break
+	}
+	return ret
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNamePair(t *testing.T) {
+	for _, tc := range []struct {
+		desc        string
+		s           string
+		expected    NamePair
+		expectedErr string
+	}{
+		{
+			desc: "tables",
+			s:    "Orders=orders_v2",
+			expected: NamePair{
+				Source: dbtable.Name{Table: "orders"},
+				Target: dbtable.Name{Table: "orders_v2"},
+			},
+		},
+		{
+			desc: "quoted schemas and tables",
+			s:    `legacy."Orders" = sales.orders`,
+			expected: NamePair{
+				Source: dbtable.Name{Schema: "legacy", Table: "Orders"},
+				Target: dbtable.Name{Schema: "sales", Table: "orders"},
+			},
+		},
+		{desc: "missing target", s: "orders", expectedErr: `invalid table mapping "orders", expected source=target`},
+		{desc: "database", s: "db.public.orders=orders", expectedErr: `invalid table mapping "db.public.orders=orders", expected schema.table names`},
+		{desc: "invalid name", s: "orders=", expectedErr: `invalid table mapping "orders="`},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			p, err := ParseNamePair(tc.s)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, p)
+		})
+	}
+}
+
+func TestNameMappingTargetName(t *testing.T) {
+	orders := dbtable.Name{Schema: "public", Table: "tbl_Orders"}
+	for _, tc := range []struct {
+		desc     string
+		mapping  NameMapping
+		table    dbtable.Name
+		expected dbtable.Name
+	}{
+		{
+			desc:     "zero value",
+			table:    orders,
+			expected: orders,
+		},
+		{
+			desc:     "strip prefix and lowercase",
+			mapping:  NameMapping{StripTablePrefix: "tbl_", Lowercase: true},
+			table:    orders,
+			expected: dbtable.Name{Schema: "public", Table: "orders"},
+		},
+		{
+			desc:     "prefix not matching",
+			mapping:  NameMapping{StripTablePrefix: "old_"},
+			table:    orders,
+			expected: orders,
+		},
+		{
+			desc:     "target schema",
+			mapping:  NameMapping{Schema: "sales", DatabaseAsSchema: true},
+			table:    orders,
+			expected: dbtable.Name{Schema: "sales", Table: "tbl_Orders"},
+		},
+		{
+			desc:     "database as schema",
+			mapping:  NameMapping{DatabaseAsSchema: true, Lowercase: true}.WithSourceDatabase("Shop"),
+			table:    orders,
+			expected: dbtable.Name{Schema: "shop", Table: "tbl_orders"},
+		},
+		{
+			desc:     "database as schema without a database",
+			mapping:  NameMapping{DatabaseAsSchema: true},
+			table:    orders,
+			expected: orders,
+		},
+		{
+			desc: "pair takes precedence",
+			mapping: NameMapping{
+				Schema: "sales",
+				Pairs: []NamePair{
+					{Source: dbtable.Name{Schema: "other", Table: "tbl_orders"}, Target: dbtable.Name{Table: "unused"}},
+					{Source: dbtable.Name{Table: "tbl_orders"}, Target: dbtable.Name{Table: "orders_v2"}},
+				},
+			},
+			table:    orders,
+			expected: dbtable.Name{Schema: "sales", Table: "orders_v2"},
+		},
+		{
+			desc: "pair with target schema",
+			mapping: NameMapping{
+				Pairs: []NamePair{
+					{Source: dbtable.Name{Schema: "public", Table: "tbl_orders"}, Target: dbtable.Name{Schema: "legacy", Table: "orders"}},
+				},
+			},
+			table:    orders,
+			expected: dbtable.Name{Schema: "legacy", Table: "orders"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.mapping.TargetName(tc.table))
+		})
+	}
+}
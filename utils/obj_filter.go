@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"os"
+	"path"
 	"regexp"
+	"strings"
 
+	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbtable"
 )
 
@@ -17,20 +21,179 @@ func DefaultFilterConfig() FilterConfig {
 	}
 }
 
+// FilterConfig selects the tables to action on. A table is selected if its
+// schema and table names match SchemaFilter and TableFilter, it matches one
+// of the Include patterns if there are any, and it matches none of the
+// Exclude patterns.
+//
+// Patterns are globs, as supported by path.Match, which are matched case
+// insensitively. A pattern formatted as schema.table matches the schema and
+// table names, while a pattern without a schema matches the table name in
+// any schema, e.g. public.tmp_* or audit_*.
 type FilterConfig struct {
 	SchemaFilter FilterString
 	TableFilter  FilterString
+	Include      []string
+	Exclude      []string
 }
 
-func FilterResult(cfg FilterConfig, r Result) (Result, error) {
-	if cfg.SchemaFilter == DefaultFilterString && cfg.TableFilter == DefaultFilterString {
-		return r, nil
-	}
+// IsDefault returns whether the filter selects every table.
+func (cfg FilterConfig) IsDefault() bool {
+	return cfg.SchemaFilter == DefaultFilterString && cfg.TableFilter == DefaultFilterString &&
+		len(cfg.Include) == 0 && len(cfg.Exclude) == 0
+}
+
+// TableMatcher matches the names of tables against a FilterConfig.
+type TableMatcher struct {
+	schemaRe, tableRe *regexp.Regexp
+	include, exclude  []tablePattern
+}
+
+// NewTableMatcher returns a matcher of the tables selected by the filter.
+func NewTableMatcher(cfg FilterConfig) (*TableMatcher, error) {
 	schemaRe, err := regexp.CompilePOSIX(cfg.SchemaFilter)
 	if err != nil {
-		return r, err
+		return nil, err
 	}
 	tableRe, err := regexp.CompilePOSIX(cfg.TableFilter)
+	if err != nil {
+		return nil, err
+	}
+	ret := &TableMatcher{schemaRe: schemaRe, tableRe: tableRe}
+	if ret.include, err = parseTablePatterns(cfg.Include); err != nil {
+		return nil, err
+	}
+	if ret.exclude, err = parseTablePatterns(cfg.Exclude); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Matches returns whether the table is selected by the filter.
+func (m *TableMatcher) Matches(n dbtable.Name) bool {
+	if !MatchesFilter(n, m.schemaRe, m.tableRe) {
+		return false
+	}
+	if len(m.include) > 0 && !matchesAnyPattern(m.include, n) {
+		return false
+	}
+	return !matchesAnyPattern(m.exclude, n)
+}
+
+type tablePattern struct {
+	schema string
+	table  string
+}
+
+func parseTablePatterns(patterns []string) ([]tablePattern, error) {
+	ret := make([]tablePattern, 0, len(patterns))
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		var tp tablePattern
+		if schema, table, ok := cutUnescaped(p, '.'); ok {
+			tp = tablePattern{schema: schema, table: table}
+		} else {
+			tp = tablePattern{table: p}
+		}
+		for _, part := range []string{tp.schema, tp.table} {
+			if _, err := path.Match(part, ""); err != nil {
+				return nil, errors.Wrapf(err, "invalid table pattern %q", p)
+			}
+		}
+		if tp.table == "" {
+			return nil, errors.Newf("invalid table pattern %q", p)
+		}
+		ret = append(ret, tp)
+	}
+	return ret, nil
+}
+
+// cutUnescaped cuts s around the first sep which is not escaped with a
+// backslash.
+func cutUnescaped(s string, sep byte) (before, after string, found bool) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+// MatchesTablePattern returns whether the table matches a glob, as used by
+// the Include and Exclude patterns of FilterConfig.
+func MatchesTablePattern(pattern string, n dbtable.Name) (bool, error) {
+	patterns, err := parseTablePatterns([]string{pattern})
+	if err != nil {
+		return false, err
+	}
+	return matchesAnyPattern(patterns, n), nil
+}
+
+func matchesAnyPattern(patterns []tablePattern, n dbtable.Name) bool {
+	schema := strings.ToLower(string(n.Schema))
+	table := strings.ToLower(string(n.Table))
+	for _, p := range patterns {
+		if p.schema != "" {
+			if ok, _ := path.Match(p.schema, schema); !ok {
+				continue
+			}
+		}
+		if ok, _ := path.Match(p.table, table); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTableList reads a file listing table names, one per line, and returns
+// the patterns matching exactly these tables. Names are formatted as
+// schema.table, or as table to match the table in any schema. Blank lines and
+// lines starting with # are ignored.
+func LoadTableList(filePath string) ([]string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read table list %s", filePath)
+	}
+	var ret []string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		schema, table, ok := strings.Cut(line, ".")
+		if !ok {
+			ret = append(ret, escapeGlob(line))
+			continue
+		}
+		if schema == "" || table == "" || strings.Contains(table, ".") {
+			return nil, errors.Newf("table list %s, line %d: expected a schema.table name, got %q", filePath, i+1, line)
+		}
+		ret = append(ret, escapeGlob(schema)+"."+escapeGlob(table))
+	}
+	return ret, nil
+}
+
+// escapeGlob escapes the characters of s which have a meaning in globs.
+func escapeGlob(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\', '.':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func FilterResult(cfg FilterConfig, r Result) (Result, error) {
+	if cfg.IsDefault() {
+		return r, nil
+	}
+	m, err := NewTableMatcher(cfg)
 	if err != nil {
 		return r, err
 	}
@@ -40,23 +203,38 @@ func FilterResult(cfg FilterConfig, r Result) (Result, error) {
 		ExtraneousTables: r.ExtraneousTables[:0],
 	}
 	for _, v := range r.Verified {
-		if MatchesFilter(v[0].Name, schemaRe, tableRe) {
+		if m.Matches(v[0].Name) {
 			newResult.Verified = append(newResult.Verified, v)
 		}
 	}
 	for _, t := range r.MissingTables {
-		if MatchesFilter(t.Name, schemaRe, tableRe) {
+		if m.Matches(t.Name) {
 			newResult.MissingTables = append(newResult.MissingTables, t)
 		}
 	}
 	for _, t := range r.ExtraneousTables {
-		if MatchesFilter(t.Name, schemaRe, tableRe) {
+		if m.Matches(t.Name) {
 			newResult.ExtraneousTables = append(newResult.ExtraneousTables, t)
 		}
 	}
 	return newResult, nil
 }
 
+// FilterTables returns the tables matching the filter.
+func FilterTables(cfg FilterConfig, tables []dbtable.DBTable) ([]dbtable.DBTable, error) {
+	m, err := NewTableMatcher(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var ret []dbtable.DBTable
+	for _, t := range tables {
+		if m.Matches(t.Name) {
+			ret = append(ret, t)
+		}
+	}
+	return ret, nil
+}
+
 func MatchesFilter(n dbtable.Name, schemaRe, tableRe *regexp.Regexp) bool {
 	return schemaRe.MatchString(string(n.Schema)) && tableRe.MatchString(string(n.Table))
 }
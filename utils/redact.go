@@ -6,6 +6,9 @@ const (
 	redactionMarker    = "redacted"
 	AWSSecretAccessKey = "AWS_SECRET_ACCESS_KEY"
 	GCPCredentials     = "CREDENTIALS"
+	AzureAccountKey    = "AZURE_ACCOUNT_KEY"
+	// AzureSASSignature is the signature of an Azure SAS token.
+	AzureSASSignature = "sig"
 )
 
 // redactedQueryParams is the set of query parameter names registered by the
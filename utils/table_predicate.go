@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+)
+
+// TablePredicate restricts the rows of a source table which are fetched and
+// verified to those matching a SQL boolean expression. A table without a
+// schema matches the table in any schema.
+type TablePredicate struct {
+	Table     dbtable.Name
+	Predicate string
+}
+
+// ParseTablePredicate parses a predicate formatted as table:predicate, e.g.
+// public.orders:tenant_id = 42. The table is parsed as a SQL identifier, so
+// it is lowercased unless quoted.
+func ParseTablePredicate(s string) (TablePredicate, error) {
+	tbl, pred, ok := strings.Cut(s, ":")
+	pred = strings.TrimSpace(pred)
+	if !ok || pred == "" {
+		return TablePredicate{}, errors.Newf("invalid table predicate %q, expected table:predicate", s)
+	}
+	n, err := parser.ParseTableName(strings.TrimSpace(tbl))
+	if err != nil {
+		return TablePredicate{}, errors.Wrapf(err, "invalid table predicate %q", s)
+	}
+	if n.NumParts > 2 {
+		return TablePredicate{}, errors.Newf("invalid table predicate %q, expected a schema.table name", s)
+	}
+	ret := TablePredicate{Predicate: pred}
+	ret.Table.Table = tree.Name(n.Parts[0])
+	if n.NumParts == 2 {
+		ret.Table.Schema = tree.Name(n.Parts[1])
+	}
+	return ret, nil
+}
+
+// TablePredicates are the predicates of the source tables.
+type TablePredicates []TablePredicate
+
+// For returns the predicate of the source table, or an empty string if its
+// rows are not restricted. Predicates are matched case insensitively, and
+// the predicates matching the same table are combined with AND.
+func (p TablePredicates) For(n dbtable.Name) string {
+	var preds []string
+	for _, tp := range p {
+		if !strings.EqualFold(string(tp.Table.Table), string(n.Table)) ||
+			(tp.Table.Schema != "" && !strings.EqualFold(string(tp.Table.Schema), string(n.Schema))) {
+			continue
+		}
+		preds = append(preds, tp.Predicate)
+	}
+	if len(preds) == 1 {
+		return preds[0]
+	}
+	for i := range preds {
+		preds[i] = "(" + preds[i] + ")"
+	}
+	return strings.Join(preds, " AND ")
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTablePredicate(t *testing.T) {
+	for _, tc := range []struct {
+		desc        string
+		s           string
+		expected    TablePredicate
+		expectedErr string
+	}{
+		{
+			desc: "table",
+			s:    "Orders:tenant_id = 42",
+			expected: TablePredicate{
+				Table:     dbtable.Name{Table: "orders"},
+				Predicate: "tenant_id = 42",
+			},
+		},
+		{
+			desc: "quoted schema and table with a colon in the predicate",
+			s:    `public."Orders": created_at >= '2023-01-01 00:00:00'`,
+			expected: TablePredicate{
+				Table:     dbtable.Name{Schema: "public", Table: "Orders"},
+				Predicate: "created_at >= '2023-01-01 00:00:00'",
+			},
+		},
+		{desc: "missing predicate", s: "orders:", expectedErr: `invalid table predicate "orders:", expected table:predicate`},
+		{desc: "database", s: "db.public.orders:id > 1", expectedErr: `invalid table predicate "db.public.orders:id > 1", expected a schema.table name`},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			p, err := ParseTablePredicate(tc.s)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, p)
+		})
+	}
+}
+
+func TestTablePredicatesFor(t *testing.T) {
+	preds := TablePredicates{
+		{Table: dbtable.Name{Table: "orders"}, Predicate: "tenant_id = 42"},
+		{Table: dbtable.Name{Schema: "public", Table: "orders"}, Predicate: "created_at >= '2023-01-01'"},
+		{Table: dbtable.Name{Schema: "public", Table: "users"}, Predicate: "tenant_id = 42"},
+	}
+	require.Equal(t, "(tenant_id = 42) AND (created_at >= '2023-01-01')", preds.For(dbtable.Name{Schema: "public", Table: "Orders"}))
+	require.Equal(t, "tenant_id = 42", preds.For(dbtable.Name{Schema: "archive", Table: "orders"}))
+	require.Equal(t, "", preds.For(dbtable.Name{Schema: "archive", Table: "users"}))
+	require.Equal(t, "", TablePredicates(nil).For(dbtable.Name{Schema: "public", Table: "orders"}))
+}
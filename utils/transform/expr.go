@@ -0,0 +1,277 @@
+package transform
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree/treebin"
+	"github.com/cockroachdb/errors"
+)
+
+// exprFunc is a function which can be used in the expressions of transforms.
+// Values are strings, with nil standing for NULL.
+type exprFunc struct {
+	minArgs, maxArgs int
+	// nullable functions are called with NULL arguments, while other
+	// functions return NULL if any argument is NULL.
+	nullable bool
+	fn       func(args []*string) (*string, error)
+}
+
+var exprFuncs = map[string]exprFunc{
+	"lower": {minArgs: 1, maxArgs: 1, fn: func(args []*string) (*string, error) {
+		return strPtr(strings.ToLower(*args[0])), nil
+	}},
+	"upper": {minArgs: 1, maxArgs: 1, fn: func(args []*string) (*string, error) {
+		return strPtr(strings.ToUpper(*args[0])), nil
+	}},
+	"trim": {minArgs: 1, maxArgs: 1, fn: func(args []*string) (*string, error) {
+		return strPtr(strings.TrimSpace(*args[0])), nil
+	}},
+	"length": {minArgs: 1, maxArgs: 1, fn: func(args []*string) (*string, error) {
+		return strPtr(strconv.Itoa(utf8.RuneCountInString(*args[0]))), nil
+	}},
+	"left": {minArgs: 2, maxArgs: 2, fn: func(args []*string) (*string, error) {
+		n, err := intArg(args[1])
+		if err != nil {
+			return nil, err
+		}
+		r := []rune(*args[0])
+		return strPtr(string(r[:clamp(n, len(r))])), nil
+	}},
+	"right": {minArgs: 2, maxArgs: 2, fn: func(args []*string) (*string, error) {
+		n, err := intArg(args[1])
+		if err != nil {
+			return nil, err
+		}
+		r := []rune(*args[0])
+		return strPtr(string(r[len(r)-clamp(n, len(r)):])), nil
+	}},
+	"substr":    {minArgs: 2, maxArgs: 3, fn: substr},
+	"substring": {minArgs: 2, maxArgs: 3, fn: substr},
+	"replace": {minArgs: 3, maxArgs: 3, fn: func(args []*string) (*string, error) {
+		return strPtr(strings.ReplaceAll(*args[0], *args[1], *args[2])), nil
+	}},
+	"concat": {minArgs: 1, maxArgs: -1, nullable: true, fn: func(args []*string) (*string, error) {
+		var sb strings.Builder
+		for _, a := range args {
+			if a != nil {
+				sb.WriteString(*a)
+			}
+		}
+		return strPtr(sb.String()), nil
+	}},
+	"coalesce": {minArgs: 1, maxArgs: -1, nullable: true, fn: func(args []*string) (*string, error) {
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	}},
+	"md5": {minArgs: 1, maxArgs: 1, fn: func(args []*string) (*string, error) {
+		sum := md5.Sum([]byte(*args[0]))
+		return strPtr(hex.EncodeToString(sum[:])), nil
+	}},
+	"sha256": {minArgs: 1, maxArgs: 1, fn: func(args []*string) (*string, error) {
+		sum := sha256.Sum256([]byte(*args[0]))
+		return strPtr(hex.EncodeToString(sum[:])), nil
+	}},
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func intArg(s *string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(*s))
+	if err != nil {
+		return 0, errors.Newf("expected an integer, got %q", *s)
+	}
+	return n, nil
+}
+
+// clamp returns n, bounded by 0 and max.
+func clamp(n, max int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// substr returns the substring starting at a 1-based position, with an
+// optional length, as the SQL function of the same name.
+func substr(args []*string) (*string, error) {
+	start, err := intArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+	r := []rune(*args[0])
+	end := len(r)
+	if len(args) == 3 {
+		n, err := intArg(args[2])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, errors.Newf("negative substring length %d", n)
+		}
+		end = clamp(start-1+n, len(r))
+	}
+	start = clamp(start-1, len(r))
+	if end < start {
+		end = start
+	}
+	return strPtr(string(r[start:end])), nil
+}
+
+// checkExpr checks an expression only uses supported constructs, which are
+// column references, string and numeric constants, NULL, the || operator and
+// the functions of exprFuncs.
+func checkExpr(expr tree.Expr) error {
+	_, err := bindExpr(expr, nil /* columns */)
+	return err
+}
+
+// boundExpr is an expression whose column references are resolved to the
+// index of the column in the row.
+type boundExpr struct {
+	isNull   bool
+	constant *string
+	isColumn bool
+	column   int
+	concat   *[2]*boundExpr
+	fn       *exprFunc
+	args     []*boundExpr
+}
+
+// bindExpr resolves the column references of an expression. If columns is
+// nil, the references are not resolved and the expression is only checked.
+func bindExpr(expr tree.Expr, columns []tree.Name) (*boundExpr, error) {
+	if expr == tree.DNull {
+		return &boundExpr{isNull: true}, nil
+	}
+	switch expr := expr.(type) {
+	case *tree.ParenExpr:
+		return bindExpr(expr.Expr, columns)
+	case *tree.StrVal:
+		return &boundExpr{constant: strPtr(expr.RawString())}, nil
+	case *tree.NumVal:
+		return &boundExpr{constant: strPtr(expr.OrigString())}, nil
+	case *tree.UnresolvedName:
+		if expr.Star || expr.NumParts != 1 {
+			return nil, errors.Newf("unsupported column reference %s", expr)
+		}
+		if columns == nil {
+			return &boundExpr{isColumn: true}, nil
+		}
+		idx := columnIndex(columns, expr.Parts[0])
+		if idx < 0 {
+			return nil, errors.Newf("unknown column %s", expr.Parts[0])
+		}
+		return &boundExpr{isColumn: true, column: idx}, nil
+	case *tree.BinaryExpr:
+		if expr.Operator.Symbol != treebin.Concat {
+			return nil, errors.Newf("unsupported operator %s", expr.Operator)
+		}
+		l, err := bindExpr(expr.Left, columns)
+		if err != nil {
+			return nil, err
+		}
+		r, err := bindExpr(expr.Right, columns)
+		if err != nil {
+			return nil, err
+		}
+		return &boundExpr{concat: &[2]*boundExpr{l, r}}, nil
+	case *tree.CoalesceExpr:
+		return bindExpr(&tree.FuncExpr{
+			Func:  tree.ResolvableFunctionReference{FunctionReference: tree.NewUnresolvedName(expr.Name)},
+			Exprs: expr.Exprs,
+		}, columns)
+	case *tree.FuncExpr:
+		name, ok := funcName(expr.Func.FunctionReference)
+		if !ok || expr.Type != 0 || expr.Filter != nil || expr.WindowDef != nil || len(expr.OrderBy) > 0 {
+			return nil, errors.Newf("unsupported function call %s", expr)
+		}
+		fn, ok := exprFuncs[strings.ToLower(name)]
+		if !ok {
+			return nil, errors.Newf("unsupported function %s", name)
+		}
+		if len(expr.Exprs) < fn.minArgs || (fn.maxArgs >= 0 && len(expr.Exprs) > fn.maxArgs) {
+			return nil, errors.Newf("wrong number of arguments for %s: %d", name, len(expr.Exprs))
+		}
+		ret := &boundExpr{fn: &fn}
+		for _, arg := range expr.Exprs {
+			a, err := bindExpr(arg, columns)
+			if err != nil {
+				return nil, err
+			}
+			ret.args = append(ret.args, a)
+		}
+		return ret, nil
+	default:
+		return nil, errors.Newf("unsupported expression %s", tree.AsString(expr))
+	}
+}
+
+// funcName returns the name of an unqualified function. Functions with a
+// special syntax, e.g. substring, are parsed as their definition.
+func funcName(ref tree.FunctionReference) (string, bool) {
+	switch ref := ref.(type) {
+	case *tree.UnresolvedName:
+		return ref.Parts[0], ref.NumParts == 1 && !ref.Star
+	case *tree.FunctionDefinition:
+		return ref.Name, true
+	default:
+		return "", false
+	}
+}
+
+// eval evaluates the expression on the values of a row, returning nil for
+// NULL.
+func (e *boundExpr) eval(row tree.Datums) (*string, error) {
+	switch {
+	case e.isNull:
+		return nil, nil
+	case e.constant != nil:
+		return e.constant, nil
+	case e.isColumn:
+		if row[e.column] == tree.DNull {
+			return nil, nil
+		}
+		return strPtr(formatValue(row[e.column])), nil
+	case e.concat != nil:
+		l, err := e.concat[0].eval(row)
+		if err != nil || l == nil {
+			return nil, err
+		}
+		r, err := e.concat[1].eval(row)
+		if err != nil || r == nil {
+			return nil, err
+		}
+		return strPtr(*l + *r), nil
+	case e.fn != nil:
+		args := make([]*string, len(e.args))
+		for i, a := range e.args {
+			v, err := a.eval(row)
+			if err != nil {
+				return nil, err
+			}
+			if v == nil && !e.fn.nullable {
+				return nil, nil
+			}
+			args[i] = v
+		}
+		return e.fn.fn(args)
+	default:
+		return nil, errors.AssertionFailedf("empty expression")
+	}
+}
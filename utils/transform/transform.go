@@ -0,0 +1,274 @@
+// Package transform transforms the values of source columns before they are
+// written to the target, e.g. to mask PII. Verify applies the same transforms
+// to the source values, so that transformed columns compare cleanly.
+package transform
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/parsectx"
+	"gopkg.in/yaml.v3"
+)
+
+// Actions of a transform rule.
+const (
+	// ActionHash replaces values with the hex encoded SHA-256 hash of the
+	// salt followed by the value.
+	ActionHash = "hash"
+	// ActionTokenize replaces values with a short token, made of the prefix
+	// followed by the start of the HMAC-SHA256 of the value keyed by the salt.
+	// Equal values are replaced with equal tokens.
+	ActionTokenize = "tokenize"
+	// ActionNull replaces values with NULL.
+	ActionNull = "null"
+	// ActionConstant replaces values with a constant.
+	ActionConstant = "constant"
+	// ActionExpr replaces values with the result of a SQL expression, which
+	// can reference the columns of the row.
+	ActionExpr = "expr"
+)
+
+const (
+	defaultTokenPrefix = "tok_"
+	tokenLength        = 16
+)
+
+// Rule transforms the values of a column.
+type Rule struct {
+	// Table is the name of the table the rule applies to, either as table or
+	// schema.table.
+	Table string `yaml:"table"`
+	// Column is the name of the column the rule applies to.
+	Column string `yaml:"column"`
+	// Action is the transform applied to the values of the column.
+	Action string `yaml:"action"`
+	// Salt is prepended to the values hashed by ActionHash, and is the key
+	// of the HMAC of ActionTokenize.
+	Salt string `yaml:"salt"`
+	// Prefix is the prefix of the tokens of ActionTokenize, tok_ by default.
+	Prefix *string `yaml:"prefix"`
+	// Value is the constant of ActionConstant, parsed as the type of the
+	// target column.
+	Value string `yaml:"value"`
+	// Expr is the SQL expression of ActionExpr, e.g.
+	// left(email, 2) || '***'.
+	Expr string `yaml:"expr"`
+
+	expr tree.Expr
+}
+
+// Transforms are the rules transforming columns, as read from a transforms
+// file. A nil *Transforms transforms nothing.
+type Transforms struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Load reads the transforms file at path.
+func Load(path string) (*Transforms, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read transforms file %s", path)
+	}
+	ret, err := Parse(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid transforms file %s", path)
+	}
+	return ret, nil
+}
+
+// Parse parses the YAML contents of a transforms file.
+func Parse(data []byte) (*Transforms, error) {
+	var ret Transforms
+	if err := yaml.Unmarshal(data, &ret); err != nil {
+		return nil, err
+	}
+	for i := range ret.Rules {
+		r := &ret.Rules[i]
+		if r.Table == "" || r.Column == "" {
+			return nil, errors.Newf("rule %d: table and column are required", i+1)
+		}
+		switch r.Action {
+		case ActionHash, ActionTokenize, ActionNull, ActionConstant:
+		case ActionExpr:
+			if r.Expr == "" {
+				return nil, errors.Newf("rule %d: expr is required", i+1)
+			}
+			expr, err := parser.ParseExpr(r.Expr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "rule %d: invalid expr %s", i+1, r.Expr)
+			}
+			if err := checkExpr(expr); err != nil {
+				return nil, errors.Wrapf(err, "rule %d: invalid expr %s", i+1, r.Expr)
+			}
+			r.expr = expr
+		default:
+			return nil, errors.Newf(
+				"rule %d: unknown action %q, expected one of %s",
+				i+1,
+				r.Action,
+				strings.Join([]string{ActionHash, ActionTokenize, ActionNull, ActionConstant, ActionExpr}, ", "),
+			)
+		}
+	}
+	return &ret, nil
+}
+
+// ForTable returns the transforms of the columns of a verified table, indexed
+// like its columns, or nil if none of its columns is transformed. Rules are
+// matched by the source name of the table and the names of its columns, case
+// insensitively, and the first matching rule of a column wins. The columns
+// rows are ordered by cannot be transformed, as that would change the order
+// of the rows on the target.
+func (t *Transforms) ForTable(table dbtable.VerifiedTable) ([]dbtable.ColumnTransform, error) {
+	if t == nil {
+		return nil, nil
+	}
+	var ret []dbtable.ColumnTransform
+	for _, r := range t.Rules {
+		if !table.Matches(r.Table) {
+			continue
+		}
+		idx := columnIndex(table.Columns, r.Column)
+		if idx < 0 {
+			return nil, errors.Newf("table %s has no column %s to transform", table.SafeString(), r.Column)
+		}
+		if ret != nil && ret[idx] != nil {
+			continue
+		}
+		if columnIndex(table.PrimaryKeyColumns, r.Column) >= 0 {
+			return nil, errors.Newf(
+				"column %s of table %s orders the rows of the table and cannot be transformed",
+				r.Column,
+				table.SafeString(),
+			)
+		}
+		typ, ok := types.OidToType[table.ColumnOIDs[1][idx]]
+		if !ok {
+			return nil, errors.AssertionFailedf("unknown type oid %d for column %s", table.ColumnOIDs[1][idx], table.Columns[idx])
+		}
+		ct := &columnTransform{rule: r, column: table.Columns[idx], typ: typ}
+		switch r.Action {
+		case ActionConstant:
+			d, _, err := tree.ParseAndRequireString(typ, r.Value, parsectx.ParseContext)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid constant for column %s of table %s", r.Column, table.SafeString())
+			}
+			ct.constant = d
+		case ActionExpr:
+			e, err := bindExpr(r.expr, table.Columns)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid expr for column %s of table %s", r.Column, table.SafeString())
+			}
+			ct.expr = e
+		}
+		if ret == nil {
+			ret = make([]dbtable.ColumnTransform, len(table.Columns))
+		}
+		ret[idx] = ct
+	}
+	return ret, nil
+}
+
+func columnIndex(columns []tree.Name, column string) int {
+	for i, c := range columns {
+		if strings.EqualFold(string(c), column) {
+			return i
+		}
+	}
+	return -1
+}
+
+type columnTransform struct {
+	rule     Rule
+	column   tree.Name
+	typ      *types.T
+	constant tree.Datum
+	expr     *boundExpr
+}
+
+var _ dbtable.ColumnTransform = (*columnTransform)(nil)
+
+// Transform implements the dbtable.ColumnTransform interface. NULL values are
+// kept as NULL by every action but ActionConstant and ActionExpr.
+func (t *columnTransform) Transform(row tree.Datums, idx int) (tree.Datum, error) {
+	d := row[idx]
+	var s string
+	switch t.rule.Action {
+	case ActionNull:
+		return tree.DNull, nil
+	case ActionConstant:
+		return t.constant, nil
+	case ActionHash:
+		if d == tree.DNull {
+			return d, nil
+		}
+		sum := sha256.Sum256([]byte(t.rule.Salt + formatValue(d)))
+		s = hex.EncodeToString(sum[:])
+	case ActionTokenize:
+		if d == tree.DNull {
+			return d, nil
+		}
+		mac := hmac.New(sha256.New, []byte(t.rule.Salt))
+		_, _ = mac.Write([]byte(formatValue(d)))
+		prefix := defaultTokenPrefix
+		if t.rule.Prefix != nil {
+			prefix = *t.rule.Prefix
+		}
+		s = prefix + hex.EncodeToString(mac.Sum(nil))[:tokenLength]
+	case ActionExpr:
+		v, err := t.expr.eval(row)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to evaluate expr of column %s", t.column)
+		}
+		if v == nil {
+			return tree.DNull, nil
+		}
+		s = *v
+	default:
+		return nil, errors.AssertionFailedf("unknown action %q", t.rule.Action)
+	}
+	ret, _, err := tree.ParseAndRequireString(t.typ, s, parsectx.ParseContext)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to transform value of column %s to %s", t.column, t.typ.SQLString())
+	}
+	return ret, nil
+}
+
+// formatValue returns the text representation of a value, as it is hashed
+// and used by expressions. Strings are formatted without quotes or escapes.
+func formatValue(d tree.Datum) string {
+	f := tree.NewFmtCtx(tree.FmtPgwireText)
+	f.FormatNode(d)
+	return f.CloseAndGetString()
+}
+
+// Apply applies the transforms of a verified table to the values of a row
+// read from the source, so they can be written to the target or compared
+// with its values. Transforms see the values of the row before any of them
+// is transformed. A copy of the values is returned if any is transformed.
+func Apply(table dbtable.VerifiedTable, vals tree.Datums) (tree.Datums, error) {
+	if len(table.Transforms) == 0 {
+		return vals, nil
+	}
+	ret := append(tree.Datums(nil), vals...)
+	for i, t := range table.Transforms {
+		if t == nil || i >= len(vals) {
+			continue
+		}
+		d, err := t.Transform(vals, i)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = d
+	}
+	return ret, nil
+}
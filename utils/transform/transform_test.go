@@ -0,0 +1,324 @@
+package transform
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/lib/pq/oid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		desc        string
+		data        string
+		expectedErr string
+	}{
+		{
+			desc: "valid",
+			data: `
+rules:
+  - table: public.users
+    column: email
+    action: hash
+    salt: s3cret
+  - table: users
+    column: name
+    action: expr
+    expr: upper(left(name, 1)) || '***'
+`,
+		},
+		{
+			desc: "missing column",
+			data: `
+rules:
+  - table: users
+    action: "null"
+`,
+			expectedErr: "rule 1: table and column are required",
+		},
+		{
+			desc: "unknown action",
+			data: `
+rules:
+  - table: users
+    column: email
+    action: encrypt
+`,
+			expectedErr: `rule 1: unknown action "encrypt", expected one of hash, tokenize, null, constant, expr`,
+		},
+		{
+			desc: "missing expr",
+			data: `
+rules:
+  - table: users
+    column: email
+    action: expr
+`,
+			expectedErr: "rule 1: expr is required",
+		},
+		{
+			desc: "unsupported function",
+			data: `
+rules:
+  - table: users
+    column: email
+    action: expr
+    expr: now()
+`,
+			expectedErr: "rule 1: invalid expr now(): unsupported function now",
+		},
+		{
+			desc: "unsupported operator",
+			data: `
+rules:
+  - table: users
+    column: age
+    action: expr
+    expr: age + 1
+`,
+			expectedErr: "rule 1: invalid expr age + 1: unsupported operator +",
+		},
+		{
+			desc: "wrong number of arguments",
+			data: `
+rules:
+  - table: users
+    column: email
+    action: expr
+    expr: left(email)
+`,
+			expectedErr: "rule 1: invalid expr left(email): wrong number of arguments for left: 1",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := Parse([]byte(tc.data))
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func usersTable() dbtable.VerifiedTable {
+	return dbtable.VerifiedTable{
+		Name:              dbtable.Name{Schema: "public", Table: "users"},
+		PrimaryKeyColumns: []tree.Name{"id"},
+		Columns:           []tree.Name{"id", "email", "name", "country", "phone", "ssn", "age"},
+		ColumnOIDs: [2][]oid.Oid{
+			{oid.T_int8, oid.T_varchar, oid.T_varchar, oid.T_varchar, oid.T_varchar, oid.T_varchar, oid.T_int4},
+			{oid.T_int8, oid.T_varchar, oid.T_varchar, oid.T_varchar, oid.T_varchar, oid.T_varchar, oid.T_int4},
+		},
+	}
+}
+
+func TestForTable(t *testing.T) {
+	for _, tc := range []struct {
+		desc        string
+		data        string
+		expected    []bool
+		expectedErr string
+	}{
+		{
+			desc: "other table",
+			data: `
+rules:
+  - table: other.users
+    column: email
+    action: hash
+`,
+		},
+		{
+			desc: "first rule wins",
+			data: `
+rules:
+  - table: users
+    column: EMAIL
+    action: hash
+  - table: public.users
+    column: email
+    action: "null"
+  - table: users
+    column: ssn
+    action: "null"
+`,
+			expected: []bool{false, true, false, false, false, true, false},
+		},
+		{
+			desc: "unknown column",
+			data: `
+rules:
+  - table: users
+    column: address
+    action: "null"
+`,
+			expectedErr: "table public.users has no column address to transform",
+		},
+		{
+			desc: "primary key",
+			data: `
+rules:
+  - table: users
+    column: id
+    action: hash
+`,
+			expectedErr: "column id of table public.users orders the rows of the table and cannot be transformed",
+		},
+		{
+			desc: "invalid constant",
+			data: `
+rules:
+  - table: users
+    column: age
+    action: constant
+    value: abc
+`,
+			expectedErr: `invalid constant for column age of table public.users: could not parse "abc" as type int: strconv.ParseInt: parsing "abc": invalid syntax`,
+		},
+		{
+			desc: "unknown column in expr",
+			data: `
+rules:
+  - table: users
+    column: name
+    action: expr
+    expr: lower(nickname)
+`,
+			expectedErr: "invalid expr for column name of table public.users: unknown column nickname",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			transforms, err := Parse([]byte(tc.data))
+			require.NoError(t, err)
+			ret, err := transforms.ForTable(usersTable())
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			var transformed []bool
+			for _, ct := range ret {
+				transformed = append(transformed, ct != nil)
+			}
+			require.Equal(t, tc.expected, transformed)
+		})
+	}
+
+	t.Run("nil transforms", func(t *testing.T) {
+		var transforms *Transforms
+		ret, err := transforms.ForTable(usersTable())
+		require.NoError(t, err)
+		require.Nil(t, ret)
+	})
+}
+
+func TestApply(t *testing.T) {
+	transforms, err := Parse([]byte(`
+rules:
+  - table: users
+    column: email
+    action: hash
+    salt: s3cret
+  - table: users
+    column: name
+    action: expr
+    expr: upper(left(name, 1)) || '***' || coalesce(country, '?')
+  - table: users
+    column: country
+    action: constant
+    value: XX
+  - table: users
+    column: phone
+    action: tokenize
+  - table: users
+    column: ssn
+    action: "null"
+`))
+	require.NoError(t, err)
+	table := usersTable()
+	table.Transforms, err = transforms.ForTable(table)
+	require.NoError(t, err)
+
+	vals := tree.Datums{
+		tree.NewDInt(1),
+		tree.NewDString("alice@example.com"),
+		tree.NewDString("alice"),
+		tree.NewDString("FR"),
+		tree.NewDString("+33 1 23 45 67 89"),
+		tree.NewDString("123-45-6789"),
+		tree.NewDInt(42),
+	}
+	transformed, err := Apply(table, vals)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"1",
+		"'2381fe3eade233e3a88e6bb90b5bcf873fc22d540a415e2b95baad268326571f'",
+		"'A***FR'",
+		"'XX'",
+		"'tok_df531bbf02b1a483'",
+		"NULL",
+		"42",
+	}, formatDatums(transformed))
+	// The values are not modified in place.
+	require.Equal(t, tree.NewDString("alice"), vals[2])
+
+	// Transforms are deterministic, so that verify computes the same values
+	// as fetch.
+	again, err := Apply(table, vals)
+	require.NoError(t, err)
+	require.Equal(t, transformed, again)
+
+	// NULLs are kept by hashes and tokens, and propagated by expressions.
+	vals = tree.Datums{tree.NewDInt(2), tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull}
+	transformed, err = Apply(table, vals)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2", "NULL", "NULL", "'XX'", "NULL", "NULL", "NULL"}, formatDatums(transformed))
+}
+
+func formatDatums(datums tree.Datums) []string {
+	ret := make([]string, len(datums))
+	for i, d := range datums {
+		ret[i] = tree.AsString(d)
+	}
+	return ret
+}
+
+func TestEvalExpr(t *testing.T) {
+	columns := []tree.Name{"email", "nickname"}
+	row := tree.Datums{tree.NewDString("Alice.Smith@example.com"), tree.DNull}
+	for _, tc := range []struct {
+		expr     string
+		expected string
+		isNull   bool
+	}{
+		{expr: "lower(email)", expected: "alice.smith@example.com"},
+		{expr: "left(email, 3) || '***'", expected: "Ali***"},
+		{expr: "right(email, 11)", expected: "example.com"},
+		{expr: "substr(email, 7, 5)", expected: "Smith"},
+		{expr: "substring(email, 20)", expected: ".com"},
+		{expr: "replace(email, 'example', 'masked')", expected: "Alice.Smith@masked.com"},
+		{expr: "concat('user-', nickname, length(email))", expected: "user-23"},
+		{expr: "COALESCE(nickname, 'anonymous')", expected: "anonymous"},
+		{expr: "md5(email)", expected: "cf95883f400bf02a4588a9a03ff8b9a8"},
+		{expr: "upper(nickname)", isNull: true},
+		{expr: "email || NULL", isNull: true},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			parsed, err := Parse([]byte("rules:\n  - {table: t, column: c, action: expr, expr: \"" + tc.expr + "\"}\n"))
+			require.NoError(t, err)
+			e, err := bindExpr(parsed.Rules[0].expr, columns)
+			require.NoError(t, err)
+			v, err := e.eval(row)
+			require.NoError(t, err)
+			if tc.isNull {
+				require.Nil(t, v)
+				return
+			}
+			require.NotNil(t, v)
+			require.Equal(t, tc.expected, *v)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package typeconv
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/molt/oracleconv"
+	"github.com/lib/pq/oid"
+)
+
+// maxOracleTimePrecision is the highest fractional seconds precision
+// supported by CockroachDB.
+const maxOracleTimePrecision = 6
+
+// ToDefaultCRDBType maps an Oracle data type, as reported by ALL_TAB_COLUMNS,
+// to the CockroachDB type used to create the column. The base mapping is the
+// one used by verify, so that the created column is comparable to the
+// source column.
+func (cm *OracleColumnMeta) ToDefaultCRDBType(
+	dataType, colName string,
+) (*types.T, []*TypeConvError) {
+	tfs := make([]*TypeConvError, 0)
+
+	typName := oracleconv.NormalizeDataType(dataType)
+	typOID, ok := oracleconv.DataTypeToOID(typName, cm.DataPrecision, cm.DataScale)
+	if !ok {
+		t := strings.ToLower(typName)
+		return types.Unknown, append(tfs, &TypeConvError{
+			ShortDescription: UnsupportedColumnType(t),
+			Message:          fmt.Sprintf("type %s from column %s is unsupported", t, colName),
+			Blocking:         true,
+		})
+	}
+
+	timePrecision := func() int32 {
+		// DATA_SCALE holds the fractional seconds precision of timestamps.
+		if !cm.DataScale.Valid {
+			return maxOracleTimePrecision
+		}
+		if cm.DataScale.Int64 > maxOracleTimePrecision {
+			tfs = append(tfs, &TypeConvError{
+				ShortDescription: UnsupportedTimePrecision,
+				Message: fmt.Sprintf(
+					"column %s has a fractional seconds precision of %d, which is unsupported in CockroachDB - this has been reduced to %d",
+					colName,
+					cm.DataScale.Int64,
+					maxOracleTimePrecision,
+				),
+			})
+			return maxOracleTimePrecision
+		}
+		return int32(cm.DataScale.Int64)
+	}
+
+	switch typOID {
+	case oid.T_varchar:
+		if cm.CharLength > 0 {
+			return types.MakeVarChar(int32(cm.CharLength)), tfs
+		}
+		return types.VarChar, tfs
+	case oid.T_bpchar:
+		if cm.CharLength > 0 {
+			return types.MakeChar(int32(cm.CharLength)), tfs
+		}
+		return types.MakeChar(1), tfs
+	case oid.T_int8:
+		return types.Int, tfs
+	case oid.T_numeric:
+		if !cm.DataPrecision.Valid {
+			return types.Decimal, tfs
+		}
+		var scale int64
+		if cm.DataScale.Valid {
+			scale = cm.DataScale.Int64
+		}
+		if scale < 0 || scale > cm.DataPrecision.Int64 {
+			return types.Decimal, append(tfs, &TypeConvError{
+				ShortDescription: InvalidDecimalArgs,
+				Message: fmt.Sprintf(
+					"column %s has precision %d and scale %d, which is unsupported in CockroachDB - this has been promoted to DECIMAL",
+					colName,
+					cm.DataPrecision.Int64,
+					scale,
+				),
+			})
+		}
+		return types.MakeDecimal(int32(cm.DataPrecision.Int64), int32(scale)), tfs
+	case oid.T_timestamp:
+		if typName == "DATE" {
+			// DATE has no fractional seconds.
+			return types.MakeTimestamp(0), tfs
+		}
+		return types.MakeTimestamp(timePrecision()), tfs
+	case oid.T_timestamptz:
+		return types.MakeTimestampTZ(timePrecision()), tfs
+	case oid.T_bytea:
+		if typName == "RAW" {
+			tfs = append(tfs, &TypeConvError{
+				ShortDescription: UnsupportedBytesMax,
+				Message:          fmt.Sprintf("column %s specifies a max length, which is unsupported in CockroachDB", colName),
+			})
+		}
+		return types.Bytes, tfs
+	}
+	return types.OidToType[typOID], tfs
+}
+
+// OracleColumnMeta collects the information about the column in an Oracle
+// table. This information is stored in ALL_TAB_COLUMNS.
+type OracleColumnMeta struct {
+	DataPrecision sql.NullInt64
+	DataScale     sql.NullInt64
+	CharLength    int64
+}
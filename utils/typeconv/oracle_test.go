@@ -0,0 +1,82 @@
+package typeconv
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOracleToDefaultCRDBType(t *testing.T) {
+	valid := func(i int64) sql.NullInt64 { return sql.NullInt64{Int64: i, Valid: true} }
+	for _, tc := range []struct {
+		dataType string
+		meta     OracleColumnMeta
+		expected *types.T
+		errs     []TypeConvError
+	}{
+		{dataType: "NUMBER", meta: OracleColumnMeta{DataPrecision: valid(10), DataScale: valid(0)}, expected: types.Int},
+		{dataType: "NUMBER", meta: OracleColumnMeta{DataPrecision: valid(10), DataScale: valid(2)}, expected: types.MakeDecimal(10, 2)},
+		{dataType: "NUMBER", meta: OracleColumnMeta{DataPrecision: valid(30), DataScale: valid(0)}, expected: types.MakeDecimal(30, 0)},
+		{dataType: "NUMBER", expected: types.Decimal},
+		{
+			dataType: "NUMBER",
+			meta:     OracleColumnMeta{DataPrecision: valid(5), DataScale: valid(-2)},
+			expected: types.Decimal,
+			errs: []TypeConvError{{
+				ShortDescription: InvalidDecimalArgs,
+				Message:          "column c has precision 5 and scale -2, which is unsupported in CockroachDB - this has been promoted to DECIMAL",
+			}},
+		},
+		{dataType: "VARCHAR2", meta: OracleColumnMeta{CharLength: 20}, expected: types.MakeVarChar(20)},
+		{dataType: "NVARCHAR2", meta: OracleColumnMeta{CharLength: 10}, expected: types.MakeVarChar(10)},
+		{dataType: "CHAR", meta: OracleColumnMeta{CharLength: 3}, expected: types.MakeChar(3)},
+		{dataType: "CLOB", expected: types.String},
+		{dataType: "NCLOB", expected: types.String},
+		{dataType: "BLOB", expected: types.Bytes},
+		{
+			dataType: "RAW",
+			expected: types.Bytes,
+			errs: []TypeConvError{{
+				ShortDescription: UnsupportedBytesMax,
+				Message:          "column c specifies a max length, which is unsupported in CockroachDB",
+			}},
+		},
+		{dataType: "DATE", expected: types.MakeTimestamp(0)},
+		{dataType: "FLOAT", meta: OracleColumnMeta{DataPrecision: valid(126)}, expected: types.Float},
+		{dataType: "BINARY_FLOAT", expected: types.Float4},
+		{dataType: "BINARY_DOUBLE", expected: types.Float},
+		{dataType: "TIMESTAMP(3)", meta: OracleColumnMeta{DataScale: valid(3)}, expected: types.MakeTimestamp(3)},
+		{dataType: "TIMESTAMP(6) WITH TIME ZONE", meta: OracleColumnMeta{DataScale: valid(6)}, expected: types.MakeTimestampTZ(6)},
+		{
+			dataType: "TIMESTAMP(9) WITH LOCAL TIME ZONE",
+			meta:     OracleColumnMeta{DataScale: valid(9)},
+			expected: types.MakeTimestampTZ(6),
+			errs: []TypeConvError{{
+				ShortDescription: UnsupportedTimePrecision,
+				Message:          "column c has a fractional seconds precision of 9, which is unsupported in CockroachDB - this has been reduced to 6",
+			}},
+		},
+		{dataType: "INTERVAL DAY(2) TO SECOND(6)", expected: types.Interval},
+		{dataType: "INTERVAL YEAR(2) TO MONTH", expected: types.Interval},
+		{
+			dataType: "XMLTYPE",
+			expected: types.Unknown,
+			errs: []TypeConvError{{
+				ShortDescription: "Unsupported column type xmltype",
+				Message:          "type xmltype from column c is unsupported",
+				Blocking:         true,
+			}},
+		},
+	} {
+		t.Run(tc.dataType, func(t *testing.T) {
+			typ, errs := tc.meta.ToDefaultCRDBType(tc.dataType, "c")
+			require.Equal(t, tc.expected.SQLString(), typ.SQLString())
+			require.Len(t, errs, len(tc.errs))
+			for i, err := range errs {
+				require.Equal(t, tc.errs[i], *err)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package typeconv
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/parser"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/parsectx"
+	"gopkg.in/yaml.v3"
+)
+
+// TypeOverrideRule maps source columns to a CockroachDB type, replacing the
+// default type mapping. A rule applies to the columns of a source type, to
+// the columns of a source type in a table, or to a single column.
+type TypeOverrideRule struct {
+	// Table is the name of the table the rule applies to, either as table or
+	// schema.table. If empty, the rule applies to every table.
+	Table string `yaml:"table"`
+	// Column is the name of the column the rule applies to. It requires
+	// Table to be set.
+	Column string `yaml:"column"`
+	// SourceType is the source type the rule applies to, e.g. tinyint(1).
+	// A type without modifiers, e.g. tinyint, matches the type whatever its
+	// modifiers.
+	SourceType string `yaml:"source_type"`
+	// CRDBType is the CockroachDB type of the matching columns, e.g. BOOL.
+	CRDBType string `yaml:"crdb_type"`
+
+	crdbType *types.T
+}
+
+// TypeOverrides are the rules overriding the default type mapping, as read
+// from a type mapping file. A nil *TypeOverrides overrides nothing.
+type TypeOverrides struct {
+	Rules []TypeOverrideRule `yaml:"rules"`
+}
+
+// LoadTypeOverrides reads the type mapping file at path.
+func LoadTypeOverrides(path string) (*TypeOverrides, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read type mapping file %s", path)
+	}
+	ret, err := ParseTypeOverrides(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid type mapping file %s", path)
+	}
+	return ret, nil
+}
+
+// ParseTypeOverrides parses the YAML contents of a type mapping file.
+func ParseTypeOverrides(data []byte) (*TypeOverrides, error) {
+	var ret TypeOverrides
+	if err := yaml.Unmarshal(data, &ret); err != nil {
+		return nil, err
+	}
+	for i := range ret.Rules {
+		r := &ret.Rules[i]
+		switch {
+		case r.CRDBType == "":
+			return nil, errors.Newf("rule %d: crdb_type is required", i+1)
+		case r.Column != "" && r.Table == "":
+			return nil, errors.Newf("rule %d: column %s requires a table", i+1, r.Column)
+		case r.Column == "" && r.SourceType == "":
+			return nil, errors.Newf("rule %d: either a column or a source_type is required", i+1)
+		}
+		ref, err := parser.GetTypeFromValidSQLSyntax(r.CRDBType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "rule %d: invalid crdb_type %s", i+1, r.CRDBType)
+		}
+		typ, ok := tree.GetStaticallyKnownType(ref)
+		if !ok {
+			return nil, errors.Newf("rule %d: crdb_type %s is not a built-in type", i+1, r.CRDBType)
+		}
+		r.crdbType = typ
+	}
+	return &ret, nil
+}
+
+// Lookup returns the CockroachDB type overriding the default mapping of a
+// source column, given the names of its source type, e.g. both tinyint and
+// tinyint(1) for a MySQL column. A rule for the column takes precedence over
+// a rule for the type in the table, which takes precedence over a rule for
+// the type. Otherwise, the first matching rule wins. Names are compared case
+// insensitively.
+//
+// The type of a key column, which orders the rows of the table, cannot be
+// overridden, as that would change the order of the rows on the target. Any
+// rule matching such a column is an error. Skipping rules for types instead
+// would leave the key with its default type while the columns referencing
+// it are overridden, which breaks their foreign keys.
+func (o *TypeOverrides) Lookup(
+	table dbtable.Name, column string, key bool, sourceTypes ...string,
+) (*types.T, bool, error) {
+	if o == nil {
+		return nil, false, nil
+	}
+	const (
+		noMatch = iota
+		typeMatch
+		tableTypeMatch
+		columnMatch
+	)
+	var ret *types.T
+	best := noMatch
+	for _, r := range o.Rules {
+		if r.Table != "" && !table.Matches(r.Table) {
+			continue
+		}
+		match := noMatch
+		switch {
+		case r.Column != "":
+			if strings.EqualFold(r.Column, column) {
+				if key {
+					return nil, false, errors.Newf(
+						"column %s of table %s orders the rows of the table and its type cannot be overridden",
+						column,
+						table.SafeString(),
+					)
+				}
+				match = columnMatch
+			}
+		case matchesSourceType(r.SourceType, sourceTypes):
+			if key {
+				return nil, false, errors.Newf(
+					"source type rule %s matches column %s of table %s, which orders the rows of the table and whose type cannot be overridden",
+					r.SourceType,
+					column,
+					table.SafeString(),
+				)
+			}
+			match = typeMatch
+			if r.Table != "" {
+				match = tableTypeMatch
+			}
+		}
+		if match > best {
+			ret, best = r.crdbType, match
+		}
+	}
+	return ret, best != noMatch, nil
+}
+
+var typeModifiersRegex = regexp.MustCompile(`\s*\([^)]*\)`)
+
+// baseTypeName strips the modifiers of a type name, e.g. both varchar(36)
+// and timestamp(6) with time zone are turned into their base type.
+func baseTypeName(typName string) string {
+	return strings.Join(strings.Fields(typeModifiersRegex.ReplaceAllString(typName, " ")), " ")
+}
+
+func matchesSourceType(ruleType string, sourceTypes []string) bool {
+	ruleType = strings.Join(strings.Fields(ruleType), " ")
+	matchBase := !strings.Contains(ruleType, "(")
+	for _, t := range sourceTypes {
+		if strings.EqualFold(ruleType, strings.Join(strings.Fields(t), " ")) {
+			return true
+		}
+		if matchBase && strings.EqualFold(ruleType, baseTypeName(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConvertOverriddenValues converts the values read from the source for the
+// columns of the table whose type is overridden to their type on the target,
+// so they can be written to the target or compared with its values. The
+// values are converted through their text representation. A copy of the
+// values is returned if any is converted.
+func ConvertOverriddenValues(table dbtable.VerifiedTable, vals tree.Datums) (tree.Datums, error) {
+	ret := vals
+	copied := false
+	for i, overridden := range table.TypeOverridden {
+		if !overridden || i >= len(vals) || vals[i] == tree.DNull {
+			continue
+		}
+		typ, ok := types.OidToType[table.ColumnOIDs[1][i]]
+		if !ok {
+			return nil, errors.AssertionFailedf("unknown type oid %d for column %s", table.ColumnOIDs[1][i], table.Columns[i])
+		}
+		f := tree.NewFmtCtx(tree.FmtBareStrings)
+		f.FormatNode(vals[i])
+		d, _, err := tree.ParseAndRequireString(typ, f.CloseAndGetString(), parsectx.ParseContext)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to convert value of column %s to %s", table.Columns[i], typ.SQLString())
+		}
+		if !copied {
+			ret = append(tree.Datums(nil), vals...)
+			copied = true
+		}
+		ret[i] = d
+	}
+	return ret, nil
+}
@@ -0,0 +1,184 @@
+package typeconv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/lib/pq/oid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTypeOverrides(t *testing.T) {
+	for _, tc := range []struct {
+		desc        string
+		data        string
+		expectedErr string
+	}{
+		{
+			desc: "valid",
+			data: `
+rules:
+  - source_type: tinyint(1)
+    crdb_type: BOOL
+  - table: public.users
+    column: id
+    crdb_type: UUID
+`,
+		},
+		{
+			desc: "missing crdb type",
+			data: `
+rules:
+  - source_type: tinyint(1)
+`,
+			expectedErr: "rule 1: crdb_type is required",
+		},
+		{
+			desc: "column without table",
+			data: `
+rules:
+  - column: id
+    crdb_type: UUID
+`,
+			expectedErr: "rule 1: column id requires a table",
+		},
+		{
+			desc: "table without column or source type",
+			data: `
+rules:
+  - table: users
+    crdb_type: UUID
+`,
+			expectedErr: "rule 1: either a column or a source_type is required",
+		},
+		{
+			desc: "invalid crdb type",
+			data: `
+rules:
+  - source_type: datetime
+    crdb_type: NOT A TYPE
+`,
+			expectedErr: "rule 1: invalid crdb_type NOT A TYPE",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ParseTypeOverrides([]byte(tc.data))
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTypeOverridesLookup(t *testing.T) {
+	overrides, err := ParseTypeOverrides([]byte(`
+rules:
+  - source_type: tinyint(1)
+    crdb_type: BOOL
+  - source_type: datetime
+    crdb_type: TIMESTAMPTZ
+  - source_type: varchar
+    crdb_type: STRING
+  - table: sessions
+    source_type: varchar(36)
+    crdb_type: UUID
+  - table: public.users
+    column: Name
+    crdb_type: VARCHAR(100)
+`))
+	require.NoError(t, err)
+
+	users := dbtable.Name{Schema: "public", Table: "users"}
+	sessions := dbtable.Name{Schema: "public", Table: "sessions"}
+	for _, tc := range []struct {
+		desc        string
+		table       dbtable.Name
+		column      string
+		key         bool
+		sourceTypes []string
+		expected    *types.T
+	}{
+		{desc: "full source type", table: users, column: "active", sourceTypes: []string{"tinyint", "tinyint(1)"}, expected: types.Bool},
+		{desc: "full source type mismatch", table: users, column: "age", sourceTypes: []string{"tinyint", "tinyint(4)"}},
+		{desc: "base source type", table: users, column: "created_at", sourceTypes: []string{"datetime", "datetime(6)"}, expected: types.TimestampTZ},
+		{desc: "base source type with modifiers", table: users, column: "email", sourceTypes: []string{"VARCHAR(36)"}, expected: types.String},
+		{desc: "table rule takes precedence", table: sessions, column: "token", sourceTypes: []string{"varchar", "varchar(36)"}, expected: types.Uuid},
+		{desc: "column rule takes precedence", table: users, column: "name", sourceTypes: []string{"varchar", "varchar(36)"}, expected: types.MakeVarChar(100)},
+		{desc: "column rule in another schema", table: dbtable.Name{Schema: "other", Table: "users"}, column: "name", sourceTypes: []string{"text"}},
+		{desc: "no match", table: users, column: "id", sourceTypes: []string{"int"}},
+		{desc: "key column without a rule", table: users, column: "id", key: true, sourceTypes: []string{"int"}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			typ, ok, err := overrides.Lookup(tc.table, tc.column, tc.key, tc.sourceTypes...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected != nil, ok)
+			if tc.expected != nil {
+				require.Equal(t, tc.expected.SQLString(), typ.SQLString())
+			}
+		})
+	}
+
+	t.Run("nil overrides", func(t *testing.T) {
+		var overrides *TypeOverrides
+		_, ok, err := overrides.Lookup(users, "active", false /* key */, "tinyint(1)")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("column rule for a key column", func(t *testing.T) {
+		_, _, err := overrides.Lookup(users, "name", true /* key */, "text")
+		require.EqualError(t, err, "column name of table public.users orders the rows of the table and its type cannot be overridden")
+	})
+
+	t.Run("type rule for a key column", func(t *testing.T) {
+		_, _, err := overrides.Lookup(sessions, "token", true /* key */, "varchar", "varchar(36)")
+		require.EqualError(t, err, "source type rule varchar matches column token of table public.sessions, which orders the rows of the table and whose type cannot be overridden")
+	})
+}
+
+func TestConvertOverriddenValues(t *testing.T) {
+	ts, err := tree.MakeDTimestamp(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), time.Microsecond)
+	require.NoError(t, err)
+	table := dbtable.VerifiedTable{
+		Columns: []tree.Name{"id", "active", "token", "created_at", "name"},
+		ColumnOIDs: [2][]oid.Oid{
+			{oid.T_int4, oid.T_int2, oid.T_varchar, oid.T_timestamp, oid.T_text},
+			{oid.T_int4, oid.T_bool, oid.T_uuid, oid.T_timestamptz, oid.T_text},
+		},
+		TypeOverridden: []bool{false, true, true, true, false},
+	}
+	vals := tree.Datums{
+		tree.NewDInt(1),
+		tree.NewDInt(1),
+		tree.NewDString("b5c5b8a2-5e9a-4b43-8b6a-2a8f1a3d9c01"),
+		ts,
+		tree.NewDString("a"),
+	}
+	converted, err := ConvertOverriddenValues(table, vals)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1", "true", "'b5c5b8a2-5e9a-4b43-8b6a-2a8f1a3d9c01'", "'2023-01-02 03:04:05+00'", "'a'"}, formatDatums(converted))
+	// The values are not modified in place.
+	require.Equal(t, tree.NewDInt(1), vals[1])
+
+	vals[1] = tree.DNull
+	converted, err = ConvertOverriddenValues(table, vals)
+	require.NoError(t, err)
+	require.Equal(t, tree.DNull, converted[1])
+
+	vals[1] = tree.NewDInt(2)
+	_, err = ConvertOverriddenValues(table, vals)
+	require.ErrorContains(t, err, "failed to convert value of column active to BOOL")
+}
+
+func formatDatums(datums tree.Datums) []string {
+	ret := make([]string, len(datums))
+	for i, d := range datums {
+		ret[i] = tree.AsString(d)
+	}
+	return ret
+}
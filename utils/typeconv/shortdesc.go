@@ -8,6 +8,7 @@ const (
 	InvalidDecimalArgs       ShortDesc = "Invalid decimal args"
 	UnsupportedBytesMax      ShortDesc = "Bytes limit not supported"
 	UnsupportedColumnTypeRaw ShortDesc = "Unsupported column type"
+	UnsupportedTimePrecision ShortDesc = "Time precision not supported"
 	UnsupportedTinyInt       ShortDesc = "TINYINT not supported"
 )
 
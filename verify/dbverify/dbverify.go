@@ -36,121 +36,76 @@ func (c *tableVerificationIterator) curr() dbtable.DBTable {
 
 // Verify verifies tables exist in all databases.
 func Verify(ctx context.Context, conns dbconn.OrderedConns) (utils.Result, error) {
+	return VerifyWithMapping(ctx, conns, utils.NameMapping{})
+}
+
+// VerifyWithMapping verifies tables exist in all databases, pairing each
+// source table with the target table it is mapped to.
+func VerifyWithMapping(
+	ctx context.Context, conns dbconn.OrderedConns, mapping utils.NameMapping,
+) (utils.Result, error) {
 	// Grab all tables and verify them.
 	var in []connWithTables
 	for _, conn := range conns {
-		var tms []dbtable.DBTable
-		switch conn := conn.(type) {
-		case *dbconn.MySQLConn:
-			rows, err := conn.QueryContext(
-				ctx,
-				`SELECT table_name FROM information_schema.tables
-WHERE table_schema = database() AND table_type = "BASE TABLE"
-ORDER BY table_name`,
-			)
-			if err != nil {
-				return utils.Result{}, err
-			}
-
-			for rows.Next() {
-				var tn string
-				if err := rows.Scan(&tn); err != nil {
-					return utils.Result{}, errors.Wrap(err, "error decoding tables metadata")
-				}
-				// Fake the public schema for now.
-				tm := dbtable.DBTable{
-					Name: dbtable.Name{
-						Schema: "public",
-						Table:  tree.Name(tn),
-					},
-				}
-				tms = append(tms, tm)
-			}
-			if rows.Err() != nil {
-				return utils.Result{}, errors.Wrap(err, "error collecting tables metadata")
-			}
-			if err := rows.Close(); err != nil {
-				return utils.Result{}, err
-			}
-		case *dbconn.OracleConn:
-			rows, err := conn.QueryContext(
-				ctx,
-				`select tablespace_name, table_name from all_tables where tablespace_name not in ( 'SYSTEM', 'SYSAUX', 'ADMINISTRATOR' )`,
-			)
-			if err != nil {
-				return utils.Result{}, err
-			}
-
-			for rows.Next() {
-				var sn string
-				var tn string
-				if err := rows.Scan(&sn, &tn); err != nil {
-					return utils.Result{}, errors.Wrap(err, "error decoding tables metadata")
-				}
-				tm := dbtable.DBTable{
-					Name: dbtable.Name{
-						Schema: tree.Name(sn),
-						Table:  tree.Name(tn),
-					},
-				}
-				tms = append(tms, tm)
-			}
-			if rows.Err() != nil {
-				return utils.Result{}, errors.Wrap(err, "error collecting tables metadata")
-			}
-			if err := rows.Close(); err != nil {
-				return utils.Result{}, err
-			}
-		case *dbconn.PGConn:
-			rows, err := conn.Query(
-				ctx,
-				`SELECT pg_class.oid, pg_class.relname, pg_namespace.nspname
-FROM pg_class
-JOIN pg_namespace on (pg_class.relnamespace = pg_namespace.oid)
-WHERE relkind = 'r' AND pg_namespace.nspname NOT IN ('pg_catalog', 'information_schema', 'crdb_internal', 'pg_extension')
-ORDER BY 3, 2`,
-			)
-			if err != nil {
-				return utils.Result{}, err
-			}
-
-			for rows.Next() {
-				var tm dbtable.DBTable
-				if err := rows.Scan(&tm.OID, &tm.Table, &tm.Schema); err != nil {
-					return utils.Result{}, errors.Wrap(err, "error decoding tables metadata")
-				}
-				tms = append(tms, tm)
-			}
-			if rows.Err() != nil {
-				return utils.Result{}, errors.Wrap(err, "error collecting tables metadata")
-			}
-			rows.Close()
-		default:
-			return utils.Result{}, errors.Newf("connection %T not supported", conn)
+		tms, err := ListTables(ctx, conn)
+		if err != nil {
+			return utils.Result{}, err
 		}
-
-		// Sort tables by schemas and names.
-		sort.Slice(tms, func(i, j int) bool {
-			return tms[i].Less(tms[j])
-		})
 		in = append(in, connWithTables{
 			Conn:          conn,
 			tableMetadata: tms,
 		})
 	}
 
+	// Source tables are compared by the name they are mapped to.
+	mapping = SourceNameMapping(conns[0], mapping)
+	targetNames := make(map[dbtable.Name]dbtable.Name, len(in[0].tableMetadata))
+	for _, t := range in[0].tableMetadata {
+		targetNames[t.Name] = mapping.TargetName(t.Name)
+	}
+	mapped := func(t dbtable.DBTable) dbtable.DBTable {
+		return dbtable.DBTable{Name: targetNames[t.Name]}
+	}
+	sort.SliceStable(in[0].tableMetadata, func(i, j int) bool {
+		return mapped(in[0].tableMetadata[i]).Less(mapped(in[0].tableMetadata[j]))
+	})
+	for i := 1; i < len(in[0].tableMetadata); i++ {
+		prev, curr := in[0].tableMetadata[i-1], in[0].tableMetadata[i]
+		if mapped(prev).Compare(mapped(curr)) == 0 {
+			return utils.Result{}, errors.Newf(
+				"source tables %s and %s are both mapped to %s on the target",
+				prev.SafeString(),
+				curr.SafeString(),
+				targetNames[curr.Name].SafeString(),
+			)
+		}
+	}
+
 	var iterators [2]tableVerificationIterator
 	for i := range in {
 		iterators[i] = tableVerificationIterator{
 			tables: in[i],
 		}
 	}
-	return compare(iterators), nil
+	return compare(iterators, mapped), nil
+}
+
+// SourceNameMapping returns the mapping of the names of the tables of the
+// source connection, which places MySQL tables in the schema named after
+// their database if requested.
+func SourceNameMapping(conn dbconn.Conn, mapping utils.NameMapping) utils.NameMapping {
+	if conn, ok := conn.(*dbconn.MySQLConn); ok {
+		return mapping.WithSourceDatabase(conn.Database())
+	}
+	return mapping
 }
 
 // compare compares two lists of tables.
-// It assumes tables are in sorted order in each iterator.
-func compare(iterators [2]tableVerificationIterator) utils.Result {
+// It assumes tables are in sorted order in each iterator, with the tables of
+// the source of truth sorted by the name they are mapped to.
+func compare(
+	iterators [2]tableVerificationIterator, mapped func(dbtable.DBTable) dbtable.DBTable,
+) utils.Result {
 	ret := utils.Result{}
 	// Iterate through all tables in source of truthIterator, moving iterators
 	// across
@@ -162,7 +117,7 @@ func compare(iterators [2]tableVerificationIterator) utils.Result {
 		// tables.
 		compareVal := 1
 		if !nonTruthIterator.done() {
-			compareVal = nonTruthIterator.curr().Compare(truthIterator.curr())
+			compareVal = nonTruthIterator.curr().Compare(mapped(truthIterator.curr()))
 		}
 		switch compareVal {
 		case -1:
@@ -199,3 +154,102 @@ func compare(iterators [2]tableVerificationIterator) utils.Result {
 	}
 	return ret
 }
+
+// ListTables returns the tables of the database, sorted by schema and name.
+func ListTables(ctx context.Context, conn dbconn.Conn) ([]dbtable.DBTable, error) {
+	var tms []dbtable.DBTable
+	switch conn := conn.(type) {
+	case *dbconn.MySQLConn:
+		rows, err := conn.QueryContext(
+			ctx,
+			`SELECT table_name FROM information_schema.tables
+WHERE table_schema = database() AND table_type = "BASE TABLE"
+ORDER BY table_name`,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var tn string
+			if err := rows.Scan(&tn); err != nil {
+				return nil, errors.Wrap(err, "error decoding tables metadata")
+			}
+			// Fake the public schema for now.
+			tm := dbtable.DBTable{
+				Name: dbtable.Name{
+					Schema: "public",
+					Table:  tree.Name(tn),
+				},
+			}
+			tms = append(tms, tm)
+		}
+		if rows.Err() != nil {
+			return nil, errors.Wrap(err, "error collecting tables metadata")
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	case *dbconn.OracleConn:
+		rows, err := conn.QueryContext(
+			ctx,
+			`select tablespace_name, table_name from all_tables where tablespace_name not in ( 'SYSTEM', 'SYSAUX', 'ADMINISTRATOR' )`,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var sn string
+			var tn string
+			if err := rows.Scan(&sn, &tn); err != nil {
+				return nil, errors.Wrap(err, "error decoding tables metadata")
+			}
+			tm := dbtable.DBTable{
+				Name: dbtable.Name{
+					Schema: tree.Name(sn),
+					Table:  tree.Name(tn),
+				},
+			}
+			tms = append(tms, tm)
+		}
+		if rows.Err() != nil {
+			return nil, errors.Wrap(err, "error collecting tables metadata")
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	case *dbconn.PGConn:
+		rows, err := conn.Query(
+			ctx,
+			`SELECT pg_class.oid, pg_class.relname, pg_namespace.nspname
+FROM pg_class
+JOIN pg_namespace on (pg_class.relnamespace = pg_namespace.oid)
+WHERE relkind = 'r' AND pg_namespace.nspname NOT IN ('pg_catalog', 'information_schema', 'crdb_internal', 'pg_extension')
+ORDER BY 3, 2`,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var tm dbtable.DBTable
+			if err := rows.Scan(&tm.OID, &tm.Table, &tm.Schema); err != nil {
+				return nil, errors.Wrap(err, "error decoding tables metadata")
+			}
+			tms = append(tms, tm)
+		}
+		if rows.Err() != nil {
+			return nil, errors.Wrap(err, "error collecting tables metadata")
+		}
+		rows.Close()
+	default:
+		return nil, errors.Newf("connection %T not supported", conn)
+	}
+
+	// Sort tables by schemas and names.
+	sort.Slice(tms, func(i, j int) bool {
+		return tms[i].Less(tms[j])
+	})
+	return tms, nil
+}
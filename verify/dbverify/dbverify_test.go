@@ -43,6 +43,7 @@ func TestTableCompare(t *testing.T) {
 	for _, tc := range []struct {
 		desc     string
 		its      [2]tableVerificationIterator
+		mapping  utils.NameMapping
 		expected utils.Result
 	}{
 		{
@@ -143,9 +144,38 @@ func TestTableCompare(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "mapped names",
+			its: [2]tableVerificationIterator{
+				{
+					tables: connWithTables{
+						Conn:          conn1,
+						tableMetadata: []dbtable.DBTable{table2, table1},
+					},
+				},
+				{
+					tables: connWithTables{
+						Conn:          conn2,
+						tableMetadata: []dbtable.DBTable{table2, table3},
+					},
+				},
+			},
+			mapping: utils.NameMapping{
+				Pairs: []utils.NamePair{{Source: table1.Name, Target: table3.Name}},
+			},
+			expected: utils.Result{
+				Verified: [][2]dbtable.DBTable{
+					{table2, table2},
+					{table1, table3},
+				},
+			},
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
-			require.Equal(t, tc.expected, compare(tc.its))
+			mapped := func(t dbtable.DBTable) dbtable.DBTable {
+				return dbtable.DBTable{Name: tc.mapping.TargetName(t.Name)}
+			}
+			require.Equal(t, tc.expected, compare(tc.its, mapped))
 		})
 	}
 }
@@ -145,7 +145,7 @@ func newLiveReverifier(
 					iterators[i] = rowiterator.NewPointLookupIterator(
 						conn,
 						rowiterator.Table{
-							Name:              table.Name,
+							Name:              table.Names()[i],
 							ColumnNames:       table.Columns,
 							ColumnOIDs:        table.ColumnOIDs[i],
 							PrimaryKeyColumns: table.PrimaryKeyColumns,
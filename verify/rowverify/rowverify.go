@@ -12,6 +12,8 @@ import (
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/cockroachdb/molt/utils/transform"
+	"github.com/cockroachdb/molt/utils/typeconv"
 	"github.com/cockroachdb/molt/verify/inconsistency"
 	"github.com/rs/zerolog"
 	"golang.org/x/time/rate"
@@ -22,9 +24,24 @@ type TableShard struct {
 
 	StartPKVals []tree.Datum
 	EndPKVals   []tree.Datum
+	// CursorPKVals, if set, is the primary key of the last row of the shard
+	// which has already been processed.
+	CursorPKVals []tree.Datum
 
 	ShardNum    int
 	TotalShards int
+
+	// Splitter, if set, allows the remaining rows of the shard to be split
+	// off into another shard while it is being scanned.
+	Splitter *rowiterator.ShardSplitter
+	// RowBatchSize, if set, overrides the number of rows of the shard read at
+	// a time.
+	RowBatchSize int
+	// SourceAOST and SourceAsOfSCN, if set, are the point in time the shard
+	// is read at on CockroachDB and Oracle sources respectively. The target
+	// is read as of now.
+	SourceAOST    *time.Time
+	SourceAsOfSCN string
 }
 
 func VerifyRowsOnShard(
@@ -39,20 +56,28 @@ func VerifyRowsOnShard(
 ) error {
 	var iterators [2]rowiterator.Iterator
 	for i, conn := range conns {
+		scanTable := rowiterator.ScanTable{
+			Table: rowiterator.Table{
+				Name:              table.Names()[i],
+				ColumnNames:       table.Columns,
+				ColumnOIDs:        table.ColumnOIDs[i],
+				PrimaryKeyColumns: table.PrimaryKeyColumns,
+				KeyType:           table.KeyType,
+			},
+			StartPKVals: table.StartPKVals,
+			EndPKVals:   table.EndPKVals,
+			Splitter:    table.Splitter,
+			Predicate:   table.Predicate,
+		}
+		if i == 0 {
+			scanTable.AOST = table.SourceAOST
+			scanTable.AsOfSCN = table.SourceAsOfSCN
+		}
 		var err error
 		iterators[i], err = rowiterator.NewScanIterator(
 			ctx,
 			conn,
-			rowiterator.ScanTable{
-				Table: rowiterator.Table{
-					Name:              table.Name,
-					ColumnNames:       table.Columns,
-					ColumnOIDs:        table.ColumnOIDs[i],
-					PrimaryKeyColumns: table.PrimaryKeyColumns,
-				},
-				StartPKVals: table.StartPKVals,
-				EndPKVals:   table.EndPKVals,
-			},
+			scanTable,
 			rowBatchSize,
 			rateLimiter,
 		)
@@ -68,6 +93,11 @@ func VerifyRowsOnShard(
 		}}
 	var rowEVL RowEventListener = defaultRowEVL
 	var liveReverifier *liveReverifier
+	if liveReverifySettings != nil && table.KeyType == dbtable.KeyTypeNone {
+		// Rows without a unique key cannot be looked up again.
+		logger.Warn().Msgf("table %s has no unique key, skipping live reverification", table.SafeString())
+		liveReverifySettings = nil
+	}
 	if liveReverifySettings != nil {
 		var err error
 		liveReverifier, err = newLiveReverifier(ctx, logger, conns, table, rowEVL, rate.NewLimiter(liveReverifySettings.rateLimit(), 1))
@@ -115,7 +145,16 @@ func verifyRows(
 	for truth.HasNext(ctx) {
 		evl.OnRowScan()
 
-		truthVals := truth.Next(ctx)
+		// Source values of columns whose type is overridden are compared as
+		// the type of the target column, and transformed columns are compared
+		// after applying the same transforms as fetch.
+		truthVals, err := typeconv.ConvertOverriddenValues(table.VerifiedTable, truth.Next(ctx))
+		if err != nil {
+			return err
+		}
+		if truthVals, err = transform.Apply(table.VerifiedTable, truthVals); err != nil {
+			return err
+		}
 		it := iterators[1]
 
 	itLoop:
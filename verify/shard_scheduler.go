@@ -0,0 +1,246 @@
+package verify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/rs/zerolog"
+)
+
+// stealRetryInterval is how long an idle worker waits before trying to split
+// a running shard again.
+const stealRetryInterval = time.Second
+
+// ShardScheduler hands out shards to a pool of workers.
+//
+// If work stealing is enabled, a worker which finds no queued shards splits
+// the remaining range of a running shard and takes the upper part, so that
+// workers are not left idle while a large shard is scanned.
+type ShardScheduler struct {
+	conn         dbconn.Conn
+	logger       zerolog.Logger
+	workStealing bool
+	onSplit      func(ctx context.Context, shard rowverify.TableShard, split rowverify.TableShard)
+
+	// stealMu serializes finding split points, as they are found using conn.
+	// It is held without holding mu, so that other workers are not blocked
+	// on the queries against the source.
+	stealMu sync.Mutex
+	mu      struct {
+		sync.Mutex
+		queue   []rowverify.TableShard
+		running []rowverify.TableShard
+		// numShards is the number of shards created for each table, used
+		// to number the shards created by splitting.
+		numShards map[dbtable.Name]int
+	}
+}
+
+// NewShardScheduler returns a ShardScheduler which hands out the given
+// shards. conn is used to find split points and must not be used
+// concurrently by anything else.
+func NewShardScheduler(
+	conn dbconn.Conn, logger zerolog.Logger, shards []rowverify.TableShard, workStealing bool,
+) *ShardScheduler {
+	s := &ShardScheduler{
+		conn:         conn,
+		logger:       logger,
+		workStealing: workStealing,
+	}
+	s.mu.queue = shards
+	s.mu.numShards = make(map[dbtable.Name]int)
+	for _, shard := range shards {
+		s.mu.numShards[shard.Name] = max(s.mu.numShards[shard.Name], shard.TotalShards)
+	}
+	return s
+}
+
+// OnSplit sets a function which is called when the remaining rows of a
+// running shard are split off into a new shard. It is called before the new
+// shard is returned by Next, and before Done returns for the shard which was
+// split.
+func (s *ShardScheduler) OnSplit(
+	fn func(ctx context.Context, shard rowverify.TableShard, split rowverify.TableShard),
+) {
+	s.onSplit = fn
+}
+
+// Next returns the next shard to process. It returns false if there are no
+// shards left to process.
+//
+// With work stealing, Next waits until a running shard can be split, and
+// only returns false once every shard has been processed.
+func (s *ShardScheduler) Next(ctx context.Context) (rowverify.TableShard, bool) {
+	for {
+		shard, ok, retry := s.next(ctx)
+		if ok || !retry {
+			return shard, ok
+		}
+		select {
+		case <-ctx.Done():
+			return rowverify.TableShard{}, false
+		case <-time.After(stealRetryInterval):
+		}
+	}
+}
+
+func (s *ShardScheduler) next(ctx context.Context) (_ rowverify.TableShard, ok bool, retry bool) {
+	s.mu.Lock()
+	if len(s.mu.queue) > 0 {
+		defer s.mu.Unlock()
+		shard := s.mu.queue[0]
+		s.mu.queue = s.mu.queue[1:]
+		if s.workStealing {
+			shard.Splitter = rowiterator.NewShardSplitter(shard.EndPKVals)
+			s.mu.running = append(s.mu.running, shard)
+		}
+		return shard, true, false
+	}
+	if !s.workStealing || len(s.mu.running) == 0 {
+		s.mu.Unlock()
+		return rowverify.TableShard{}, false, false
+	}
+	candidates := append([]rowverify.TableShard(nil), s.mu.running...)
+	s.mu.Unlock()
+	shard, ok := s.steal(ctx, candidates)
+	return shard, ok, !ok
+}
+
+// Done marks a shard returned by Next as processed.
+func (s *ShardScheduler) Done(shard rowverify.TableShard) {
+	if shard.Splitter == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, running := range s.mu.running {
+		if running.Splitter == shard.Splitter {
+			s.mu.running = append(s.mu.running[:i], s.mu.running[i+1:]...)
+			return
+		}
+	}
+}
+
+// steal splits the remaining range of the longest running shard which can be
+// split, returning the upper part as a new shard. Split points are found
+// without holding mu, and only installed if the shard is still running.
+func (s *ShardScheduler) steal(
+	ctx context.Context, candidates []rowverify.TableShard,
+) (rowverify.TableShard, bool) {
+	s.stealMu.Lock()
+	defer s.stealMu.Unlock()
+	for _, running := range candidates {
+		cursor, end := running.Splitter.Progress()
+		if len(cursor) == 0 {
+			// Nothing has been scanned yet, so we have no idea how far along
+			// the shard is.
+			continue
+		}
+		at, ok, err := splitRemainingRange(ctx, s.conn, tableverify.Result{VerifiedTable: running.VerifiedTable}, cursor, end)
+		if err != nil {
+			s.logger.Warn().Err(err).
+				Str("table", running.SafeString()).
+				Int("shard", running.ShardNum).
+				Msgf("unable to split shard")
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if shard, ok := s.installSplit(ctx, running, at, end); ok {
+			return shard, true
+		}
+	}
+	return rowverify.TableShard{}, false
+}
+
+// installSplit splits running at at, and adds the upper part as a new
+// running shard. It returns false if running has finished, or has scanned
+// past at, since the split point was found.
+func (s *ShardScheduler) installSplit(
+	ctx context.Context, running rowverify.TableShard, at tree.Datums, end tree.Datums,
+) (rowverify.TableShard, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stillRunning := false
+	for _, r := range s.mu.running {
+		if r.Splitter == running.Splitter {
+			stillRunning = true
+			break
+		}
+	}
+	if !stillRunning || !running.Splitter.Split(at) {
+		return rowverify.TableShard{}, false
+	}
+	s.mu.numShards[running.Name]++
+	shardNum := s.mu.numShards[running.Name]
+	shard := rowverify.TableShard{
+		VerifiedTable: running.VerifiedTable,
+		StartPKVals:   at,
+		EndPKVals:     end,
+		ShardNum:      shardNum,
+		TotalShards:   shardNum,
+		Splitter:      rowiterator.NewShardSplitter(end),
+		RowBatchSize:  running.RowBatchSize,
+	}
+	s.mu.running = append(s.mu.running, shard)
+	if s.onSplit != nil {
+		s.onSplit(ctx, running, shard)
+	}
+	s.logger.Info().
+		Str("table", running.SafeString()).
+		Int("shard", running.ShardNum).
+		Int("new_shard", shardNum).
+		Msgf("split remaining rows of slow shard at %s", at.String())
+	return shard, true
+}
+
+// splitRemainingRange returns a split point between the last scanned
+// primary key of a shard and the end of the shard, which is the end of the
+// table if empty.
+//
+// Tables without a unique key are not split. Tables with string primary keys
+// are not split either, as the split point must be compared against rows
+// which have already been read, and strings may not sort the same way on the
+// source database.
+func splitRemainingRange(
+	ctx context.Context, truthConn dbconn.Conn, tbl tableverify.Result, cursor tree.Datums, end tree.Datums,
+) (tree.Datums, bool, error) {
+	if !splittableKey(tbl.KeyType) {
+		return nil, false, nil
+	}
+	for _, typOID := range tbl.ColumnOIDs[0][:len(tbl.PrimaryKeyColumns)] {
+		if typ, ok := types.OidToType[typOID]; !ok ||
+			typ.Family() == types.StringFamily || typ.Family() == types.CollatedStringFamily {
+			return nil, false, nil
+		}
+	}
+	if len(end) == 0 {
+		var err error
+		end, err = getTableExtremes(ctx, truthConn, tbl, false, extremesFilter{})
+		if err != nil {
+			return nil, false, err
+		}
+		if len(end) == 0 {
+			return nil, false, nil
+		}
+	}
+	splits, err := splitPKRange(ctx, truthConn, tbl, cursor[:len(end)], end, 2)
+	if err != nil || len(splits) == 0 {
+		return nil, false, err
+	}
+	// There is no room left to split if the midpoint is rounded onto either
+	// end of the remaining range.
+	if compareDatums(splits[0], cursor) <= 0 || compareDatums(splits[0], end) >= 0 {
+		return nil, false, nil
+	}
+	return splits[0], true, nil
+}
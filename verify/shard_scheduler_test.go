@@ -0,0 +1,78 @@
+package verify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/rowverify"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/lib/pq/oid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardSchedulerNoWorkStealing(t *testing.T) {
+	ctx := context.Background()
+	var shards []rowverify.TableShard
+	for i := 1; i <= 3; i++ {
+		shards = append(shards, rowverify.TableShard{
+			VerifiedTable: dbtable.VerifiedTable{Name: dbtable.Name{Schema: "public", Table: "tbl"}},
+			ShardNum:      i,
+			TotalShards:   3,
+		})
+	}
+	s := NewShardScheduler(nil, zerolog.Nop(), shards, false)
+	for i := 1; i <= 3; i++ {
+		shard, ok := s.Next(ctx)
+		require.True(t, ok)
+		require.Equal(t, i, shard.ShardNum)
+		require.Nil(t, shard.Splitter)
+		s.Done(shard)
+	}
+	_, ok := s.Next(ctx)
+	require.False(t, ok)
+}
+
+func TestSplitRemainingRange(t *testing.T) {
+	ctx := context.Background()
+	tbl := func(oids ...oid.Oid) tableverify.Result {
+		var pkCols []tree.Name
+		for range oids {
+			pkCols = append(pkCols, "pk")
+		}
+		return tableverify.Result{
+			VerifiedTable: dbtable.VerifiedTable{
+				Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+				PrimaryKeyColumns: pkCols,
+				ColumnOIDs:        [2][]oid.Oid{oids, oids},
+			},
+		}
+	}
+
+	t.Run("int", func(t *testing.T) {
+		at, ok, err := splitRemainingRange(
+			ctx, nil, tbl(oid.T_int8), tree.Datums{tree.NewDInt(100)}, tree.Datums{tree.NewDInt(200)},
+		)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, tree.Datums{tree.NewDInt(150)}, at)
+	})
+
+	t.Run("no space left", func(t *testing.T) {
+		_, ok, err := splitRemainingRange(
+			ctx, nil, tbl(oid.T_int8), tree.Datums{tree.NewDInt(199)}, tree.Datums{tree.NewDInt(200)},
+		)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("string", func(t *testing.T) {
+		_, ok, err := splitRemainingRange(
+			ctx, nil, tbl(oid.T_text), tree.Datums{tree.NewDString("a")}, tree.Datums{tree.NewDString("z")},
+		)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
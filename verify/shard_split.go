@@ -0,0 +1,267 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/timeutil/pgdate"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uint128"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+)
+
+// splitStringAlphabet is the set of characters used to generate split points
+// for strings, which are in increasing byte order. Strings are only split
+// under collations which sort by bytes, as other collations may not sort the
+// split points in the same order.
+const splitStringAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// splitStringWidth is the number of characters after the common prefix that
+// are used to generate split points for strings.
+const splitStringWidth = 3
+
+// splitDatum returns numSplits-1 increasing split points dividing the range
+// between minVal and maxVal. It returns false if the type cannot be split, or
+// if the range cannot be divided.
+func splitDatum(minVal, maxVal tree.Datum, numSplits int) ([]tree.Datum, bool) {
+	if numSplits < 2 {
+		return nil, false
+	}
+	ret := make([]tree.Datum, 0, numSplits-1)
+	switch minVal.ResolvedType().Family() {
+	case types.IntFamily:
+		minInt := int64(*minVal.(*tree.DInt))
+		maxInt := int64(*maxVal.(*tree.DInt))
+		valRange := maxInt - minInt
+		if valRange <= 0 {
+			return nil, false
+		}
+		for splitNum := 1; splitNum < numSplits; splitNum++ {
+			splitVal := minInt + (max((valRange/int64(numSplits)), 1) * int64(splitNum))
+			ret = append(ret, tree.NewDInt(tree.DInt(splitVal)))
+		}
+	case types.FloatFamily:
+		minFloat := float64(*minVal.(*tree.DFloat))
+		maxFloat := float64(*maxVal.(*tree.DFloat))
+		valRange := maxFloat - minFloat
+		if valRange <= 0 || math.IsNaN(valRange) || math.IsInf(valRange, 0) {
+			return nil, false
+		}
+		for splitNum := 1; splitNum < numSplits; splitNum++ {
+			splitVal := minFloat + (max((valRange/float64(numSplits)), 1) * float64(splitNum))
+			ret = append(ret, tree.NewDFloat(tree.DFloat(splitVal)))
+		}
+	case types.UuidFamily:
+		// Use the high ranges to divide.
+		minHi := minVal.(*tree.DUuid).UUID.ToUint128().Hi
+		maxHi := maxVal.(*tree.DUuid).UUID.ToUint128().Hi
+		valRange := maxHi - minHi
+		if valRange <= 0 {
+			return nil, false
+		}
+		for splitNum := 1; splitNum < numSplits; splitNum++ {
+			splitVal := minHi + (max((valRange/uint64(numSplits)), 1) * uint64(splitNum))
+			ret = append(ret, &tree.DUuid{UUID: uuid.FromUint128(uint128.Uint128{Hi: splitVal})})
+		}
+	case types.DecimalFamily:
+		minDec := &minVal.(*tree.DDecimal).Decimal
+		maxDec := &maxVal.(*tree.DDecimal).Decimal
+		if minDec.Form != apd.Finite || maxDec.Form != apd.Finite {
+			return nil, false
+		}
+		var valRange, step apd.Decimal
+		if _, err := tree.DecimalCtx.Sub(&valRange, maxDec, minDec); err != nil || valRange.Sign() <= 0 {
+			return nil, false
+		}
+		if _, err := tree.DecimalCtx.Quo(&step, &valRange, apd.New(int64(numSplits), 0)); err != nil {
+			return nil, false
+		}
+		for splitNum := 1; splitNum < numSplits; splitNum++ {
+			d := &tree.DDecimal{}
+			if _, err := tree.DecimalCtx.Mul(&d.Decimal, &step, apd.New(int64(splitNum), 0)); err != nil {
+				return nil, false
+			}
+			if _, err := tree.DecimalCtx.Add(&d.Decimal, &d.Decimal, minDec); err != nil {
+				return nil, false
+			}
+			d.Decimal.Reduce(&d.Decimal)
+			ret = append(ret, d)
+		}
+	case types.DateFamily:
+		minDate, maxDate := minVal.(*tree.DDate).Date, maxVal.(*tree.DDate).Date
+		if !minDate.IsFinite() || !maxDate.IsFinite() {
+			return nil, false
+		}
+		minDays := int64(minDate.PGEpochDays())
+		valRange := int64(maxDate.PGEpochDays()) - minDays
+		if valRange <= 0 {
+			return nil, false
+		}
+		for splitNum := 1; splitNum < numSplits; splitNum++ {
+			d, err := pgdate.MakeDateFromPGEpoch(int32(minDays + (max((valRange/int64(numSplits)), 1) * int64(splitNum))))
+			if err != nil {
+				return nil, false
+			}
+			ret = append(ret, tree.NewDDate(d))
+		}
+	case types.TimestampFamily, types.TimestampTZFamily:
+		var minTime, maxTime time.Time
+		if minVal.ResolvedType().Family() == types.TimestampFamily {
+			minTime, maxTime = minVal.(*tree.DTimestamp).Time, maxVal.(*tree.DTimestamp).Time
+		} else {
+			minTime, maxTime = minVal.(*tree.DTimestampTZ).Time, maxVal.(*tree.DTimestampTZ).Time
+		}
+		// Work in microseconds as that is the precision of timestamps in
+		// the databases we support, and avoids overflowing time.Duration.
+		minMicros := minTime.UnixMicro()
+		valRange := maxTime.UnixMicro() - minMicros
+		if valRange <= 0 {
+			return nil, false
+		}
+		for splitNum := 1; splitNum < numSplits; splitNum++ {
+			t := time.UnixMicro(minMicros + (max((valRange/int64(numSplits)), 1) * int64(splitNum))).UTC()
+			var d tree.Datum
+			var err error
+			if minVal.ResolvedType().Family() == types.TimestampFamily {
+				d, err = tree.MakeDTimestamp(t, time.Microsecond)
+			} else {
+				d, err = tree.MakeDTimestampTZ(t, time.Microsecond)
+			}
+			if err != nil {
+				return nil, false
+			}
+			ret = append(ret, d)
+		}
+	case types.BytesFamily:
+		splits := splitBytes([]byte(*minVal.(*tree.DBytes)), []byte(*maxVal.(*tree.DBytes)), numSplits)
+		for _, s := range splits {
+			ret = append(ret, tree.NewDBytes(tree.DBytes(s)))
+		}
+	case types.StringFamily:
+		splits := splitString(string(*minVal.(*tree.DString)), string(*maxVal.(*tree.DString)), numSplits)
+		for _, s := range splits {
+			ret = append(ret, tree.NewDString(s))
+		}
+	default:
+		return nil, false
+	}
+	if len(ret) == 0 {
+		return nil, false
+	}
+	return ret, true
+}
+
+// splitBytes divides the range between minVal and maxVal by interpolating
+// the 8 bytes following their common prefix.
+func splitBytes(minVal, maxVal []byte, numSplits int) [][]byte {
+	prefixLen := commonPrefixLen(minVal, maxVal)
+	var minBuf, maxBuf [8]byte
+	copy(minBuf[:], minVal[prefixLen:])
+	copy(maxBuf[:], maxVal[prefixLen:])
+	minInt := binary.BigEndian.Uint64(minBuf[:])
+	maxInt := binary.BigEndian.Uint64(maxBuf[:])
+	if maxInt <= minInt {
+		return nil
+	}
+	step := (maxInt - minInt) / uint64(numSplits)
+	if step == 0 {
+		return nil
+	}
+	var ret [][]byte
+	for splitNum := 1; splitNum < numSplits; splitNum++ {
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], minInt+step*uint64(splitNum))
+		// Trailing zero bytes are removed as they do not change the order of
+		// the split points.
+		split := append(append([]byte{}, minVal[:prefixLen]...), bytes.TrimRight(buf[:], "\x00")...)
+		if len(ret) > 0 && bytes.Equal(ret[len(ret)-1], split) {
+			continue
+		}
+		ret = append(ret, split)
+	}
+	return ret
+}
+
+// splitString divides the range between minVal and maxVal by interpolating
+// the characters following their common prefix using splitStringAlphabet.
+func splitString(minVal, maxVal string, numSplits int) []string {
+	prefixLen := commonPrefixLen([]byte(minVal), []byte(maxVal))
+	// Do not split in the middle of a multi-byte character.
+	for prefixLen > 0 && prefixLen < len(minVal) && !isRuneStart(minVal[prefixLen]) {
+		prefixLen--
+	}
+	prefix := minVal[:prefixLen]
+	minPos := stringAlphabetPos(minVal[prefixLen:])
+	maxPos := stringAlphabetPos(maxVal[prefixLen:])
+	if maxPos <= minPos {
+		// The characters following the prefix are not in the alphabet, so
+		// fall back to dividing the entire alphabet.
+		minPos, maxPos = 0, int64(math.Pow(float64(len(splitStringAlphabet)), splitStringWidth))-1
+	}
+	step := (maxPos - minPos) / int64(numSplits)
+	if step == 0 {
+		return nil
+	}
+	var ret []string
+	for splitNum := 1; splitNum < numSplits; splitNum++ {
+		pos := minPos + step*int64(splitNum)
+		var sb strings.Builder
+		sb.WriteString(prefix)
+		chars := make([]byte, splitStringWidth)
+		for i := splitStringWidth - 1; i >= 0; i-- {
+			chars[i] = splitStringAlphabet[pos%int64(len(splitStringAlphabet))]
+			pos /= int64(len(splitStringAlphabet))
+		}
+		// Trailing "0" characters are kept, as removing them would change the
+		// order relative to strings ending in other characters.
+		sb.Write(chars)
+		ret = append(ret, sb.String())
+	}
+	return ret
+}
+
+// stringAlphabetPos returns the position of the first splitStringWidth
+// characters of s in the space of strings made from splitStringAlphabet.
+// Upper case letters are treated as lower case, and any other character is
+// treated as the closest character in the alphabet.
+func stringAlphabetPos(s string) int64 {
+	var pos int64
+	for i := 0; i < splitStringWidth; i++ {
+		idx := 0
+		if i < len(s) {
+			c := s[i]
+			if c >= 'A' && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			idx = strings.IndexByte(splitStringAlphabet, c)
+			if idx == -1 {
+				if c > 'z' {
+					idx = len(splitStringAlphabet) - 1
+				} else if c > '9' {
+					idx = strings.IndexByte(splitStringAlphabet, 'a')
+				} else {
+					idx = 0
+				}
+			}
+		}
+		pos = pos*int64(len(splitStringAlphabet)) + int64(idx)
+	}
+	return pos
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func commonPrefixLen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
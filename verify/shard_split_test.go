@@ -0,0 +1,158 @@
+package verify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/util/timeutil/pgdate"
+	"github.com/cockroachdb/molt/comparectx"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitDatum(t *testing.T) {
+	mustDecimal := func(s string) tree.Datum {
+		d, _, err := apd.NewFromString(s)
+		require.NoError(t, err)
+		return &tree.DDecimal{Decimal: *d}
+	}
+	mustDate := func(s string) tree.Datum {
+		d, _, err := pgdate.ParseDate(time.Time{}, pgdate.DefaultDateStyle(), s, nil)
+		require.NoError(t, err)
+		return tree.NewDDate(d)
+	}
+	mustTimestamp := func(s string) tree.Datum {
+		ts, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		d, err := tree.MakeDTimestampTZ(ts, time.Microsecond)
+		require.NoError(t, err)
+		return d
+	}
+
+	for _, tc := range []struct {
+		desc      string
+		min       tree.Datum
+		max       tree.Datum
+		numSplits int
+		expected  []string
+	}{
+		{
+			desc:      "int",
+			min:       tree.NewDInt(0),
+			max:       tree.NewDInt(100),
+			numSplits: 4,
+			expected:  []string{"25", "50", "75"},
+		},
+		{
+			desc:      "int with no range",
+			min:       tree.NewDInt(5),
+			max:       tree.NewDInt(5),
+			numSplits: 4,
+		},
+		{
+			desc:      "decimal",
+			min:       mustDecimal("1.5"),
+			max:       mustDecimal("3.5"),
+			numSplits: 4,
+			expected:  []string{"2", "2.5", "3"},
+		},
+		{
+			desc:      "date",
+			min:       mustDate("2024-01-01"),
+			max:       mustDate("2024-01-31"),
+			numSplits: 3,
+			expected:  []string{"'2024-01-11'", "'2024-01-21'"},
+		},
+		{
+			desc:      "timestamptz",
+			min:       mustTimestamp("2024-01-01T00:00:00Z"),
+			max:       mustTimestamp("2024-01-01T04:00:00Z"),
+			numSplits: 4,
+			expected: []string{
+				"'2024-01-01 01:00:00+00'",
+				"'2024-01-01 02:00:00+00'",
+				"'2024-01-01 03:00:00+00'",
+			},
+		},
+		{
+			desc:      "bytes",
+			min:       tree.NewDBytes("ab\x00"),
+			max:       tree.NewDBytes("ab\x80"),
+			numSplits: 4,
+			expected:  []string{`'\x616220'`, `'\x616240'`, `'\x616260'`},
+		},
+		{
+			desc:      "string",
+			min:       tree.NewDString("code-a00"),
+			max:       tree.NewDString("code-z00"),
+			numSplits: 4,
+			expected:  []string{"'code-g90'", "'code-mi0'", "'code-sr0'"},
+		},
+		{
+			desc:      "string with characters outside the alphabet",
+			min:       tree.NewDString("a!!"),
+			max:       tree.NewDString("a~~"),
+			numSplits: 2,
+			expected:  []string{"'ahzi'"},
+		},
+		{
+			desc:      "string falls back to the full alphabet",
+			min:       tree.NewDString("abc"),
+			max:       tree.NewDString("abc0"),
+			numSplits: 4,
+			expected:  []string{"'abc8zz'", "'abchzy'", "'abcqzx'"},
+		},
+		{
+			desc:      "unsupported type",
+			min:       tree.DBoolFalse,
+			max:       tree.DBoolTrue,
+			numSplits: 4,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			splits, ok := splitDatum(tc.min, tc.max, tc.numSplits)
+			if tc.expected == nil {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			var strs []string
+			for i, split := range splits {
+				strs = append(strs, split.String())
+				if i > 0 {
+					require.Equal(t, 1, split.Compare(comparectx.CompareContext, splits[i-1]))
+				}
+			}
+			require.Equal(t, tc.expected, strs)
+		})
+	}
+}
+
+func TestSplitPKRangeCollation(t *testing.T) {
+	ctx := context.Background()
+	minE, maxE := tree.Datums{tree.NewDString("apple")}, tree.Datums{tree.NewDString("zebra")}
+	tbl := tableverify.Result{
+		VerifiedTable: dbtable.VerifiedTable{
+			Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+			PrimaryKeyColumns: []tree.Name{"id"},
+		},
+	}
+
+	t.Run("binary collation", func(t *testing.T) {
+		tbl := tbl
+		tbl.PKBinaryCollation = []bool{true}
+		splits, err := splitPKRange(ctx, nil, tbl, minE, maxE, 2)
+		require.NoError(t, err)
+		require.Equal(t, []tree.Datums{{tree.NewDString("n20")}}, splits)
+	})
+
+	t.Run("other collation", func(t *testing.T) {
+		splits, err := splitPKRange(ctx, nil, tbl, minE, maxE, 2)
+		require.NoError(t, err)
+		require.Empty(t, splits)
+	})
+}
@@ -0,0 +1,376 @@
+package verify
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/comparectx"
+	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/mysqlconv"
+	"github.com/cockroachdb/molt/pgconv"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/jackc/pgx/v5"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/format"
+	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/opcode"
+)
+
+// samplesPerShard is the number of rows sampled for each shard when
+// choosing boundaries from a sample of the table.
+const samplesPerShard = 100
+
+// sampleLimitFactor bounds the number of rows sampled to this multiple of
+// the sample size. The estimated row count the sample ratio is computed
+// from may be stale, which would otherwise load every primary key of the
+// table into memory.
+const sampleLimitFactor = 4
+
+// getStatsSplits returns up to numSplits-1 increasing split points which
+// divide the table into shards with a similar number of rows.
+//
+// PostgreSQL and CockroachDB use the histogram of the first primary key
+// column collected by ANALYZE. MySQL and Oracle do not keep histograms on
+// primary keys, so a random sample of the primary key is used instead.
+// No split points are returned if statistics are not available.
+func getStatsSplits(
+	ctx context.Context, truthConn dbconn.Conn, tbl tableverify.Result, numSplits int,
+) ([]tree.Datums, error) {
+	var bounds []tree.Datums
+	var err error
+	switch truthConn := truthConn.(type) {
+	case *dbconn.PGConn:
+		bounds, err = getHistogramBoundsPG(ctx, truthConn, tbl)
+	case *dbconn.MySQLConn:
+		bounds, err = getSampleBoundsMySQL(ctx, truthConn, tbl, numSplits*samplesPerShard)
+	case *dbconn.OracleConn:
+		bounds, err = getSampleBoundsOracle(ctx, truthConn, tbl, numSplits*samplesPerShard)
+	default:
+		return nil, errors.AssertionFailedf("unknown type for statistics: %T", truthConn)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get statistics for %s.%s", tbl.Schema, tbl.Table)
+	}
+	return pickQuantiles(bounds, numSplits), nil
+}
+
+// pickQuantiles returns up to numSplits-1 split points from the sorted
+// bounds such that a similar number of bounds fall between each split
+// point. Duplicate split points are removed. The bounds are sorted by the
+// source database, so are not compared for order here as the collation may
+// differ.
+func pickQuantiles(bounds []tree.Datums, numSplits int) []tree.Datums {
+	var ret []tree.Datums
+	for splitNum := 1; splitNum < numSplits; splitNum++ {
+		idx := splitNum * len(bounds) / numSplits
+		if idx == 0 {
+			continue
+		}
+		if len(ret) > 0 && compareDatums(bounds[idx], ret[len(ret)-1]) == 0 {
+			continue
+		}
+		ret = append(ret, bounds[idx])
+	}
+	return ret
+}
+
+func compareDatums(a, b tree.Datums) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := a[i].Compare(comparectx.CompareContext, b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+// getHistogramBoundsPG returns the histogram bounds of the first primary
+// key column, in increasing order.
+func getHistogramBoundsPG(
+	ctx context.Context, truthConn *dbconn.PGConn, tbl tableverify.Result,
+) ([]tree.Datums, error) {
+	typOIDs := tbl.ColumnOIDs[0][:1]
+	// Histograms are stored as text, so we need the type name to cast them
+	// back to the column type.
+	var typName string
+	if err := truthConn.QueryRow(ctx, "SELECT format_type($1, NULL)", uint32(typOIDs[0])).Scan(&typName); err != nil {
+		return nil, errors.Wrap(err, "error getting type name")
+	}
+
+	var q string
+	var args []any
+	if truthConn.IsCockroach() {
+		tn := tree.MakeTableNameFromPrefix(
+			tree.ObjectNamePrefix{SchemaName: tbl.Schema, ExplicitSchema: true},
+			tbl.Table,
+		)
+		var histogramID int64
+		if err := truthConn.QueryRow(
+			ctx,
+			fmt.Sprintf(
+				`SELECT histogram_id FROM [SHOW STATISTICS FOR TABLE %s]
+WHERE column_names = ARRAY[$1::STRING] AND histogram_id IS NOT NULL
+ORDER BY created DESC LIMIT 1`,
+				tn.String(),
+			),
+			string(tbl.PrimaryKeyColumns[0]),
+		).Scan(&histogramID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, nil
+			}
+			return nil, errors.Wrap(err, "error getting histogram")
+		}
+		q = fmt.Sprintf("SELECT upper_bound::%s FROM [SHOW HISTOGRAM %d]", typName, histogramID)
+	} else {
+		// Partitioned tables only have statistics including their children.
+		q = fmt.Sprintf(
+			`SELECT unnest(histogram_bounds::text::%s[]) FROM (
+	SELECT histogram_bounds FROM pg_stats
+	WHERE schemaname = $1 AND tablename = $2 AND attname = $3
+	ORDER BY inherited DESC LIMIT 1
+) s`,
+			typName,
+		)
+		args = []any{string(tbl.Schema), string(tbl.Table), string(tbl.PrimaryKeyColumns[0])}
+	}
+
+	rows, err := truthConn.Query(ctx, q, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting histogram bounds")
+	}
+	defer rows.Close()
+	var ret []tree.Datums
+	for rows.Next() {
+		vals, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		if vals[0] == nil {
+			continue
+		}
+		rowVals, err := pgconv.ConvertRowValues(truthConn.TypeMap(), vals, typOIDs)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, rowVals)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if truthConn.IsCockroach() {
+		// SHOW HISTOGRAM does not guarantee the order of buckets. PostgreSQL
+		// bounds are already sorted using the column collation, which may not
+		// match the order of the datums.
+		sort.Slice(ret, func(i, j int) bool {
+			return compareDatums(ret[i], ret[j]) < 0
+		})
+	}
+	return ret, nil
+}
+
+// getSampleBoundsMySQL returns a random sample of roughly sampleSize
+// primary keys, in increasing order. No keys are returned if the estimated
+// row count of the table is too stale for the sample to be bounded.
+//
+// The sample is sorted here rather than by MySQL, as sorting on the source
+// holds the sample back until the whole table has been scanned. Primary keys
+// with strings which MySQL does not sort by their bytes are still sorted by
+// MySQL, as the order of the datums may not match their collation.
+func getSampleBoundsMySQL(
+	ctx context.Context, truthConn *dbconn.MySQLConn, tbl tableverify.Result, sampleSize int,
+) ([]tree.Datums, error) {
+	var estimatedRows int64
+	if err := truthConn.QueryRowContext(
+		ctx,
+		"SELECT COALESCE(TABLE_ROWS, 0) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?",
+		string(tbl.Table),
+	).Scan(&estimatedRows); err != nil {
+		return nil, errors.Wrap(err, "error getting estimated row count")
+	}
+	sortOnSource := !pkSortsByBytes(tbl)
+	limit := sampleSize * sampleLimitFactor
+	var sb strings.Builder
+	if err := buildSampleSelectMySQL(tbl, sampleRatio(estimatedRows, sampleSize), sortOnSource, limit).Restore(
+		format.NewRestoreCtx(format.DefaultRestoreFlags, &sb),
+	); err != nil {
+		return nil, errors.Wrap(err, "error generating MySQL statement")
+	}
+	rows, err := truthConn.QueryContext(ctx, sb.String())
+	if err != nil {
+		return nil, errors.Wrap(err, "error sampling primary keys")
+	}
+	defer rows.Close()
+	var ret []tree.Datums
+	for rows.Next() {
+		row, err := mysqlconv.ScanRowDynamicTypes(rows, truthConn.TypeMap(), tbl.ColumnOIDs[0][:len(tbl.PrimaryKeyColumns)])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ret) >= limit {
+		// The sample was cut short, so it only covers the start of the table.
+		return nil, nil
+	}
+	if !sortOnSource {
+		sort.Slice(ret, func(i, j int) bool {
+			return compareDatums(ret[i], ret[j]) < 0
+		})
+	}
+	return ret, nil
+}
+
+func buildSampleSelectMySQL(
+	table tableverify.Result, ratio float64, sortOnSource bool, limit int,
+) *ast.SelectStmt {
+	fields := &ast.FieldList{
+		Fields: make([]*ast.SelectField, len(table.PrimaryKeyColumns)),
+	}
+	var orderBy *ast.OrderByClause
+	if sortOnSource {
+		orderBy = &ast.OrderByClause{
+			Items: make([]*ast.ByItem, len(table.PrimaryKeyColumns)),
+		}
+	}
+	for i, col := range table.PrimaryKeyColumns {
+		fields.Fields[i] = &ast.SelectField{
+			Expr: mysqlconv.MySQLASTColumnField(col),
+		}
+		if orderBy != nil {
+			orderBy.Items[i] = &ast.ByItem{
+				Expr: mysqlconv.MySQLASTColumnField(col),
+			}
+		}
+	}
+	var where ast.ExprNode
+	if ratio < 1 {
+		where = &ast.BinaryOperationExpr{
+			Op: opcode.LT,
+			L:  &ast.FuncCallExpr{FnName: model.NewCIStr("RAND")},
+			R:  ast.NewValueExpr(ratio, "", ""),
+		}
+	}
+	return &ast.SelectStmt{
+		SelectStmtOpts: &ast.SelectStmtOpts{
+			SQLCache: true,
+		},
+		From: &ast.TableRefsClause{
+			TableRefs: &ast.Join{
+				Left: &ast.TableSource{
+					Source: &ast.TableName{Name: model.NewCIStr(string(table.Table))},
+				},
+			},
+		},
+		Fields:  fields,
+		Kind:    ast.SelectStmtKindSelect,
+		Where:   where,
+		OrderBy: orderBy,
+		Limit:   &ast.Limit{Count: ast.NewValueExpr(limit, "", "")},
+	}
+}
+
+// getSampleBoundsOracle returns a random sample of roughly sampleSize
+// primary keys, in increasing order. No keys are returned if the table has
+// not been analyzed, or if its statistics are too stale for the sample to be
+// bounded.
+func getSampleBoundsOracle(
+	ctx context.Context, truthConn *dbconn.OracleConn, tbl tableverify.Result, sampleSize int,
+) ([]tree.Datums, error) {
+	var estimatedRows sql.NullInt64
+	if err := truthConn.QueryRowContext(
+		ctx,
+		"SELECT NUM_ROWS FROM USER_TABLES WHERE TABLE_NAME = :1",
+		string(tbl.Table),
+	).Scan(&estimatedRows); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The table is not owned by the user, so its statistics are not
+			// in USER_TABLES.
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "error getting estimated row count")
+	}
+	if !estimatedRows.Valid {
+		return nil, nil
+	}
+	limit := sampleSize * sampleLimitFactor
+	rows, err := truthConn.QueryContext(ctx, buildSampleSelectOracle(tbl, sampleRatio(estimatedRows.Int64, sampleSize), limit))
+	if err != nil {
+		return nil, errors.Wrap(err, "error sampling primary keys")
+	}
+	defer rows.Close()
+	var ret []tree.Datums
+	for rows.Next() {
+		row, err := mysqlconv.ScanRowDynamicTypes(rows, truthConn.TypeMap(), tbl.ColumnOIDs[0][:len(tbl.PrimaryKeyColumns)])
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ret) >= limit {
+		// The sample was cut short, so it only covers the start of the table.
+		return nil, nil
+	}
+	return ret, nil
+}
+
+func buildSampleSelectOracle(table tableverify.Result, ratio float64, limit int) string {
+	// TODO: escaping names is not supported.
+	var cols []string
+	for _, col := range table.PrimaryKeyColumns {
+		cols = append(cols, string(col))
+	}
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	sb.WriteString(strings.Join(cols, ", "))
+	sb.WriteString(" FROM ")
+	sb.WriteString(string(table.Table))
+	if ratio < 1 {
+		// SAMPLE takes a percentage of rows, which must be at least 0.000001.
+		sb.WriteString(fmt.Sprintf(" SAMPLE (%f)", max(ratio*100, 0.000001)))
+	}
+	sb.WriteString(" ORDER BY ")
+	sb.WriteString(strings.Join(cols, ", "))
+	sb.WriteString(fmt.Sprintf(" FETCH FIRST %d ROWS ONLY", limit))
+	return sb.String()
+}
+
+// pkSortsByBytes returns whether the primary keys of the table sort the
+// same way as their datums on the source, which is not the case for strings
+// under a collation which does not sort by bytes.
+func pkSortsByBytes(tbl tableverify.Result) bool {
+	for i := range tbl.PrimaryKeyColumns {
+		if i >= len(tbl.ColumnOIDs[0]) {
+			return false
+		}
+		typ, ok := types.OidToType[tbl.ColumnOIDs[0][i]]
+		if !ok {
+			return false
+		}
+		if typ.Family() == types.StringFamily || typ.Family() == types.CollatedStringFamily {
+			if i >= len(tbl.PKBinaryCollation) || !tbl.PKBinaryCollation[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sampleRatio returns the fraction of rows to sample to get roughly
+// sampleSize rows out of estimatedRows.
+func sampleRatio(estimatedRows int64, sampleSize int) float64 {
+	if estimatedRows <= int64(sampleSize) {
+		return 1
+	}
+	return float64(sampleSize) / float64(estimatedRows)
+}
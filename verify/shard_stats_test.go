@@ -0,0 +1,127 @@
+package verify
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/verify/tableverify"
+	"github.com/lib/pq/oid"
+	"github.com/pingcap/tidb/parser/format"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickQuantiles(t *testing.T) {
+	intBounds := func(vals ...int) []tree.Datums {
+		var ret []tree.Datums
+		for _, v := range vals {
+			ret = append(ret, tree.Datums{tree.NewDInt(tree.DInt(v))})
+		}
+		return ret
+	}
+	for _, tc := range []struct {
+		desc      string
+		bounds    []tree.Datums
+		numSplits int
+		expected  []tree.Datums
+	}{
+		{
+			desc:      "no bounds",
+			numSplits: 4,
+		},
+		{
+			desc:      "evenly divided",
+			bounds:    intBounds(1, 2, 3, 4, 5, 6, 7, 8),
+			numSplits: 4,
+			expected:  intBounds(3, 5, 7),
+		},
+		{
+			desc:      "skewed",
+			bounds:    intBounds(1, 2, 3, 4, 5, 6, 7, 8, 9, 1000000),
+			numSplits: 4,
+			expected:  intBounds(3, 6, 8),
+		},
+		{
+			desc:      "duplicates are removed",
+			bounds:    intBounds(1, 5, 5, 5, 5, 5, 5, 9),
+			numSplits: 4,
+			expected:  intBounds(5),
+		},
+		{
+			desc:      "fewer bounds than splits",
+			bounds:    intBounds(1, 9),
+			numSplits: 4,
+			expected:  intBounds(9),
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			require.Equal(t, tc.expected, pickQuantiles(tc.bounds, tc.numSplits))
+		})
+	}
+}
+
+func TestBuildSampleSelect(t *testing.T) {
+	tbl := tableverify.Result{
+		VerifiedTable: dbtable.VerifiedTable{
+			Name:              dbtable.Name{Schema: "public", Table: "tbl"},
+			PrimaryKeyColumns: []tree.Name{"tenant_id", "id"},
+		},
+	}
+	for _, tc := range []struct {
+		desc           string
+		ratio          float64
+		sortOnSource   bool
+		expectedMySQL  string
+		expectedOracle string
+	}{
+		{
+			desc:           "sample all rows",
+			ratio:          1,
+			expectedMySQL:  "SELECT `tenant_id`,`id` FROM `tbl` LIMIT 400",
+			expectedOracle: "SELECT tenant_id, id FROM tbl ORDER BY tenant_id, id FETCH FIRST 400 ROWS ONLY",
+		},
+		{
+			desc:           "sample some rows",
+			ratio:          0.25,
+			expectedMySQL:  "SELECT `tenant_id`,`id` FROM `tbl` WHERE RAND()<2.5e-01 LIMIT 400",
+			expectedOracle: "SELECT tenant_id, id FROM tbl SAMPLE (25.000000) ORDER BY tenant_id, id FETCH FIRST 400 ROWS ONLY",
+		},
+		{
+			desc:           "sort on source",
+			ratio:          0.25,
+			sortOnSource:   true,
+			expectedMySQL:  "SELECT `tenant_id`,`id` FROM `tbl` WHERE RAND()<2.5e-01 ORDER BY `tenant_id`,`id` LIMIT 400",
+			expectedOracle: "SELECT tenant_id, id FROM tbl SAMPLE (25.000000) ORDER BY tenant_id, id FETCH FIRST 400 ROWS ONLY",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var sb strings.Builder
+			require.NoError(t, buildSampleSelectMySQL(tbl, tc.ratio, tc.sortOnSource, 400).Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)))
+			require.Equal(t, tc.expectedMySQL, sb.String())
+			require.Equal(t, tc.expectedOracle, buildSampleSelectOracle(tbl, tc.ratio, 400))
+		})
+	}
+}
+
+func TestSampleRatio(t *testing.T) {
+	require.Equal(t, float64(1), sampleRatio(0, 400))
+	require.Equal(t, float64(1), sampleRatio(400, 400))
+	require.Equal(t, 0.25, sampleRatio(1600, 400))
+}
+
+func TestPKSortsByBytes(t *testing.T) {
+	tbl := func(oids []oid.Oid, binaryCollation []bool) tableverify.Result {
+		pkCols := make([]tree.Name, len(oids))
+		return tableverify.Result{
+			VerifiedTable: dbtable.VerifiedTable{
+				PrimaryKeyColumns: pkCols,
+				ColumnOIDs:        [2][]oid.Oid{oids, oids},
+			},
+			PKBinaryCollation: binaryCollation,
+		}
+	}
+	require.True(t, pkSortsByBytes(tbl([]oid.Oid{oid.T_int8, oid.T_bytea}, nil)))
+	require.True(t, pkSortsByBytes(tbl([]oid.Oid{oid.T_int8, oid.T_varchar}, []bool{false, true})))
+	require.False(t, pkSortsByBytes(tbl([]oid.Oid{oid.T_int8, oid.T_varchar}, nil)))
+}
@@ -4,23 +4,24 @@ import (
 	"context"
 	"fmt"
 	"go/constant"
-	"math"
 	"strings"
 
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
-	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
-	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uint128"
-	"github.com/cockroachdb/cockroachdb-parser/pkg/util/uuid"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree/treecmp"
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/molt/comparectx"
 	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/mysqlconv"
 	"github.com/cockroachdb/molt/pgconv"
+	"github.com/cockroachdb/molt/shardmode"
 	"github.com/cockroachdb/molt/verify/inconsistency"
 	"github.com/cockroachdb/molt/verify/rowverify"
 	"github.com/cockroachdb/molt/verify/tableverify"
 	"github.com/pingcap/tidb/parser/ast"
 	"github.com/pingcap/tidb/parser/format"
 	"github.com/pingcap/tidb/parser/model"
+	"github.com/pingcap/tidb/parser/opcode"
 )
 
 func ShardTable(
@@ -29,79 +30,68 @@ func ShardTable(
 	tbl tableverify.Result,
 	reporter inconsistency.Reporter,
 	numSplits int,
+	mode shardmode.Flag,
 ) ([]rowverify.TableShard, error) {
 	if numSplits < 1 {
 		return nil, errors.AssertionFailedf("failed to split rows: %d", numSplits)
 	}
-	if numSplits > 1 {
-		ret := make([]rowverify.TableShard, 0, numSplits)
-		// For now, be dumb and split only the first column.
-		minE, err := getTableExtremes(ctx, truthConn, tbl, true)
+	if numSplits > 1 && !splittableKey(tbl.KeyType) {
+		// Tables without a unique key are read in a single scan.
+		numSplits = 1
+	}
+	var splits []tree.Datums
+	if numSplits > 1 && mode == shardmode.Stats {
+		var err error
+		splits, err = getStatsSplits(ctx, truthConn, tbl, numSplits)
+		if err != nil && ctx.Err() != nil {
+			return nil, err
+		}
+		if len(splits) == 0 && reporter != nil {
+			// Statistics only improve the shard boundaries, so the table is
+			// split by its primary key range if they cannot be read.
+			reason := fmt.Sprintf("no statistics found for %s.%s", tbl.Schema, tbl.Table)
+			if err != nil {
+				reason = err.Error()
+			}
+			reporter.Report(inconsistency.StatusReport{
+				Info: fmt.Sprintf("%s, defaulting to linear shard boundaries", reason),
+			})
+		}
+	}
+	if numSplits > 1 && len(splits) == 0 {
+		minE, err := getTableExtremes(ctx, truthConn, tbl, true, extremesFilter{})
 		if err != nil {
 			return nil, errors.Wrapf(err, "cannot get minimum of %s.%s", tbl.Schema, tbl.Table)
 		}
-		maxE, err := getTableExtremes(ctx, truthConn, tbl, false)
+		maxE, err := getTableExtremes(ctx, truthConn, tbl, false, extremesFilter{})
 		if err != nil {
 			return nil, errors.Wrapf(err, "cannot get maximum of %s.%s", tbl.Schema, tbl.Table)
 		}
-		var nextMin tree.Datums
 		if !(len(minE) == 0 || len(maxE) == 0 || len(minE) != len(maxE)) {
-			splittable := true
-		splitLoop:
-			for splitNum := 1; splitNum <= numSplits; splitNum++ {
-				var nextMax tree.Datums
-				if splitNum < numSplits {
-					// For now, split by only first column of PK.
-					switch minE[0].ResolvedType().Family() {
-					case types.IntFamily:
-						minVal := int64(*minE[0].(*tree.DInt))
-						maxVal := int64(*maxE[0].(*tree.DInt))
-						valRange := maxVal - minVal
-						if valRange <= 0 {
-							splittable = false
-							break splitLoop
-						}
-						splitVal := minVal + (max((valRange/int64(numSplits)), 1) * int64(splitNum))
-						nextMax = append(nextMax, tree.NewDInt(tree.DInt(splitVal)))
-					case types.FloatFamily:
-						minVal := float64(*minE[0].(*tree.DFloat))
-						maxVal := float64(*maxE[0].(*tree.DFloat))
-						valRange := maxVal - minVal
-						if valRange <= 0 || math.IsNaN(valRange) || math.IsInf(valRange, 0) {
-							splittable = false
-							break splitLoop
-						}
-						splitVal := minVal + (max((valRange/float64(numSplits)), 1) * float64(splitNum))
-						nextMax = append(nextMax, tree.NewDFloat(tree.DFloat(splitVal)))
-					case types.UuidFamily:
-						// Use the high ranges to divide.
-						minVal := minE[0].(*tree.DUuid).UUID.ToUint128().Hi
-						maxVal := maxE[0].(*tree.DUuid).UUID.ToUint128().Hi
-						valRange := maxVal - minVal
-						if valRange <= 0 {
-							splittable = false
-							break splitLoop
-						}
-						splitVal := minVal + (max((valRange/uint64(numSplits)), 1) * uint64(splitNum))
-						nextMax = append(nextMax, &tree.DUuid{UUID: uuid.FromUint128(uint128.Uint128{Hi: splitVal})})
-					default:
-						splittable = false
-						break splitLoop
-					}
-				}
-				ret = append(ret, rowverify.TableShard{
-					VerifiedTable: tbl.VerifiedTable,
-					StartPKVals:   nextMin,
-					EndPKVals:     nextMax,
-					ShardNum:      splitNum,
-					TotalShards:   numSplits,
-				})
-				nextMin = nextMax
+			splits, err = splitPKRange(ctx, truthConn, tbl, minE, maxE, numSplits)
+			if err != nil {
+				return nil, err
 			}
-			if splittable {
-				return ret, nil
+		}
+	}
+	if len(splits) > 0 {
+		ret := make([]rowverify.TableShard, 0, len(splits)+1)
+		var nextMin tree.Datums
+		for splitNum := 1; splitNum <= len(splits)+1; splitNum++ {
+			var nextMax tree.Datums
+			if splitNum <= len(splits) {
+				nextMax = splits[splitNum-1]
 			}
+			ret = append(ret, rowverify.TableShard{
+				VerifiedTable: tbl.VerifiedTable,
+				StartPKVals:   nextMin,
+				EndPKVals:     nextMax,
+				ShardNum:      splitNum,
+				TotalShards:   len(splits) + 1,
+			})
+			nextMin = nextMax
 		}
+		return ret, nil
 	}
 	ret := []rowverify.TableShard{
 		{
@@ -124,8 +114,147 @@ func ShardTable(
 	return ret, nil
 }
 
+// splitPKRange returns up to numSplits-1 increasing split points for the
+// primary key range between minE and maxE. Split points are prefixes of the
+// primary key.
+//
+// Columns which have the same value at minE and maxE are held constant, and
+// the first differing column is split. If that column has at most numSplits
+// distinct values and is followed by more primary key columns (e.g. a
+// tenant_id in a (tenant_id, id) primary key), each distinct value is given
+// its own shards which are split on the following columns.
+//
+// Split points are only interpolated between strings which the source sorts
+// by their bytes. Other collations may not sort the split points in the
+// same order as Go, so such ranges are not split.
+func splitPKRange(
+	ctx context.Context,
+	truthConn dbconn.Conn,
+	tbl tableverify.Result,
+	minE tree.Datums,
+	maxE tree.Datums,
+	numSplits int,
+) ([]tree.Datums, error) {
+	if numSplits < 2 {
+		return nil, nil
+	}
+	colIdx := 0
+	for colIdx < len(minE) && minE[colIdx].Compare(comparectx.CompareContext, maxE[colIdx]) == 0 {
+		colIdx++
+	}
+	if colIdx == len(minE) {
+		return nil, nil
+	}
+	prefix := minE[:colIdx]
+
+	if colIdx < len(minE)-1 {
+		distinctVals, err := getDistinctPKValues(ctx, truthConn, tbl, prefix, minE[colIdx], numSplits+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(distinctVals) <= numSplits {
+			return splitLowCardinalityPKRange(ctx, truthConn, tbl, prefix, distinctVals, numSplits)
+		}
+	}
+
+	if _, isString := minE[colIdx].(*tree.DString); isString &&
+		(colIdx >= len(tbl.PKBinaryCollation) || !tbl.PKBinaryCollation[colIdx]) {
+		return nil, nil
+	}
+	splitVals, ok := splitDatum(minE[colIdx], maxE[colIdx], numSplits)
+	if !ok {
+		return nil, nil
+	}
+	ret := make([]tree.Datums, len(splitVals))
+	for i, splitVal := range splitVals {
+		ret[i] = append(append(tree.Datums{}, prefix...), splitVal)
+	}
+	return ret, nil
+}
+
+// splitLowCardinalityPKRange splits a primary key range where the column
+// following prefix only has the given distinct values. The shards are
+// distributed evenly between the distinct values.
+func splitLowCardinalityPKRange(
+	ctx context.Context,
+	truthConn dbconn.Conn,
+	tbl tableverify.Result,
+	prefix tree.Datums,
+	distinctVals tree.Datums,
+	numSplits int,
+) ([]tree.Datums, error) {
+	var ret []tree.Datums
+	for i, val := range distinctVals {
+		groupPrefix := append(append(tree.Datums{}, prefix...), val)
+		if i > 0 {
+			ret = append(ret, groupPrefix)
+		}
+		groupSplits := numSplits / len(distinctVals)
+		if i < numSplits%len(distinctVals) {
+			groupSplits++
+		}
+		if groupSplits < 2 {
+			continue
+		}
+		groupMin, err := getTableExtremes(ctx, truthConn, tbl, true, extremesFilter{prefix: groupPrefix})
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot get minimum of %s.%s", tbl.Schema, tbl.Table)
+		}
+		groupMax, err := getTableExtremes(ctx, truthConn, tbl, false, extremesFilter{prefix: groupPrefix})
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot get maximum of %s.%s", tbl.Schema, tbl.Table)
+		}
+		if len(groupMin) == 0 || len(groupMax) == 0 {
+			continue
+		}
+		groupRet, err := splitPKRange(ctx, truthConn, tbl, groupMin, groupMax, groupSplits)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, groupRet...)
+	}
+	return ret, nil
+}
+
+// getDistinctPKValues returns up to limit distinct values of the primary key
+// column following prefix, starting from firstVal. Each value is found using
+// an index lookup, so this is cheap for low cardinality columns.
+func getDistinctPKValues(
+	ctx context.Context,
+	truthConn dbconn.Conn,
+	tbl tableverify.Result,
+	prefix tree.Datums,
+	firstVal tree.Datum,
+	limit int,
+) (tree.Datums, error) {
+	ret := tree.Datums{firstVal}
+	for len(ret) < limit {
+		row, err := getTableExtremes(ctx, truthConn, tbl, true, extremesFilter{prefix: prefix, after: ret[len(ret)-1]})
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot get distinct values of %s.%s", tbl.Schema, tbl.Table)
+		}
+		if len(row) == 0 {
+			break
+		}
+		ret = append(ret, row[len(prefix)])
+	}
+	return ret, nil
+}
+
+// extremesFilter restricts the rows considered when finding the extremes of
+// a table to those where the primary key starts with prefix, and the
+// primary key column following prefix is greater than after (if set).
+type extremesFilter struct {
+	prefix tree.Datums
+	after  tree.Datum
+}
+
 func getTableExtremes(
-	ctx context.Context, truthConn dbconn.Conn, tbl tableverify.Result, isMin bool,
+	ctx context.Context,
+	truthConn dbconn.Conn,
+	tbl tableverify.Result,
+	isMin bool,
+	filter extremesFilter,
 ) (tree.Datums, error) {
 	// Note here we use `.Query` instead of the `.QueryRow` counterpart.
 	// This is because the API for `.Query` actually has other metadata from
@@ -133,7 +262,7 @@ func getTableExtremes(
 	switch truthConn := truthConn.(type) {
 	case *dbconn.PGConn:
 		f := tree.NewFmtCtx(tree.FmtParsableNumerics)
-		s := buildSelectForSplitPG(tbl, isMin)
+		s := buildSelectForSplitPG(tbl, isMin, filter)
 		f.FormatNode(s)
 		q := f.CloseAndGetString()
 		rows, err := truthConn.Query(ctx, q)
@@ -155,7 +284,7 @@ func getTableExtremes(
 		return nil, rows.Err()
 	case *dbconn.MySQLConn:
 		var sb strings.Builder
-		if err := buildSelectForSplitMySQL(tbl, isMin).Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
+		if err := buildSelectForSplitMySQL(tbl, isMin, filter).Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, &sb)); err != nil {
 			return nil, errors.Wrap(err, "error generating MySQL statement")
 		}
 		q := sb.String()
@@ -168,12 +297,24 @@ func getTableExtremes(
 			return mysqlconv.ScanRowDynamicTypes(rows, truthConn.TypeMap(), tbl.ColumnOIDs[0][:len(tbl.PrimaryKeyColumns)])
 		}
 		return nil, rows.Err()
-
+	case *dbconn.OracleConn:
+		q, args := buildSelectForSplitOracle(tbl, isMin, filter)
+		rows, err := truthConn.QueryContext(ctx, q, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting minimum value for %s.%s", tbl.Schema, tbl.Table)
+		}
+		defer rows.Close()
+		if rows.Next() {
+			return mysqlconv.ScanRowDynamicTypes(rows, truthConn.TypeMap(), tbl.ColumnOIDs[0][:len(tbl.PrimaryKeyColumns)])
+		}
+		return nil, rows.Err()
 	}
 	return nil, errors.AssertionFailedf("unknown type for extremes: %T", truthConn)
 }
 
-func buildSelectForSplitPG(tbl tableverify.Result, isMin bool) *tree.Select {
+func buildSelectForSplitPG(
+	tbl tableverify.Result, isMin bool, filter extremesFilter,
+) *tree.Select {
 	tn := tree.MakeTableNameFromPrefix(
 		tree.ObjectNamePrefix{SchemaName: tbl.Schema, ExplicitSchema: true},
 		tbl.Table,
@@ -191,6 +332,28 @@ func buildSelectForSplitPG(tbl tableverify.Result, isMin bool) *tree.Select {
 			},
 		)
 	}
+	var conds []tree.Expr
+	for i, val := range filter.prefix {
+		conds = append(conds, &tree.ComparisonExpr{
+			Operator: treecmp.MakeComparisonOperator(treecmp.EQ),
+			Left:     tree.NewUnresolvedName(string(tbl.PrimaryKeyColumns[i])),
+			Right:    val,
+		})
+	}
+	if filter.after != nil {
+		conds = append(conds, &tree.ComparisonExpr{
+			Operator: treecmp.MakeComparisonOperator(treecmp.GT),
+			Left:     tree.NewUnresolvedName(string(tbl.PrimaryKeyColumns[len(filter.prefix)])),
+			Right:    filter.after,
+		})
+	}
+	for i, cond := range conds {
+		if i == 0 {
+			selectClause.Where = &tree.Where{Type: tree.AstWhere, Expr: cond}
+		} else {
+			selectClause.Where.Expr = &tree.AndExpr{Left: selectClause.Where.Expr, Right: cond}
+		}
+	}
 	baseSelectExpr := &tree.Select{
 		Select: selectClause,
 		Limit:  &tree.Limit{Count: tree.NewNumVal(constant.MakeUint64(uint64(1)), "", false)},
@@ -208,7 +371,9 @@ func buildSelectForSplitPG(tbl tableverify.Result, isMin bool) *tree.Select {
 	return baseSelectExpr
 }
 
-func buildSelectForSplitMySQL(table tableverify.Result, isMin bool) *ast.SelectStmt {
+func buildSelectForSplitMySQL(
+	table tableverify.Result, isMin bool, filter extremesFilter,
+) *ast.SelectStmt {
 	fields := &ast.FieldList{
 		Fields: make([]*ast.SelectField, len(table.PrimaryKeyColumns)),
 	}
@@ -226,6 +391,28 @@ func buildSelectForSplitMySQL(table tableverify.Result, isMin bool) *ast.SelectS
 			orderBy.Items[i].Desc = true
 		}
 	}
+	var where ast.ExprNode
+	addCond := func(cond ast.ExprNode) {
+		if where == nil {
+			where = cond
+		} else {
+			where = &ast.BinaryOperationExpr{Op: opcode.LogicAnd, L: where, R: cond}
+		}
+	}
+	for i, val := range filter.prefix {
+		addCond(&ast.BinaryOperationExpr{
+			Op: opcode.EQ,
+			L:  mysqlconv.MySQLASTColumnField(table.PrimaryKeyColumns[i]),
+			R:  datumToMySQLValue(val),
+		})
+	}
+	if filter.after != nil {
+		addCond(&ast.BinaryOperationExpr{
+			Op: opcode.GT,
+			L:  mysqlconv.MySQLASTColumnField(table.PrimaryKeyColumns[len(filter.prefix)]),
+			R:  datumToMySQLValue(filter.after),
+		})
+	}
 	return &ast.SelectStmt{
 		SelectStmtOpts: &ast.SelectStmtOpts{
 			SQLCache: true,
@@ -239,7 +426,71 @@ func buildSelectForSplitMySQL(table tableverify.Result, isMin bool) *ast.SelectS
 		},
 		Fields:  fields,
 		Kind:    ast.SelectStmtKindSelect,
+		Where:   where,
 		Limit:   &ast.Limit{Count: ast.NewValueExpr(1, "", "")},
 		OrderBy: orderBy,
 	}
 }
+
+func datumToMySQLValue(val tree.Datum) ast.ValueExpr {
+	f := tree.NewFmtCtx(tree.FmtParsableNumerics | tree.FmtBareStrings)
+	f.FormatNode(val)
+	return ast.NewValueExpr(f.CloseAndGetString(), "", "")
+}
+
+func buildSelectForSplitOracle(
+	table tableverify.Result, isMin bool, filter extremesFilter,
+) (string, []any) {
+	// TODO: escaping names is not supported.
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	for i, col := range table.PrimaryKeyColumns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(string(col))
+	}
+	sb.WriteString(" FROM ")
+	sb.WriteString(string(table.Table))
+	var args []any
+	bindVal := func(val tree.Datum) string {
+		f := tree.NewFmtCtx(tree.FmtBareStrings)
+		f.FormatNode(val)
+		args = append(args, f.CloseAndGetString())
+		return fmt.Sprintf(":%d", len(args))
+	}
+	for i, val := range filter.prefix {
+		if i == 0 {
+			sb.WriteString(" WHERE ")
+		} else {
+			sb.WriteString(" AND ")
+		}
+		sb.WriteString(fmt.Sprintf("%s = %s", table.PrimaryKeyColumns[i], bindVal(val)))
+	}
+	if filter.after != nil {
+		if len(filter.prefix) == 0 {
+			sb.WriteString(" WHERE ")
+		} else {
+			sb.WriteString(" AND ")
+		}
+		sb.WriteString(fmt.Sprintf("%s > %s", table.PrimaryKeyColumns[len(filter.prefix)], bindVal(filter.after)))
+	}
+	sb.WriteString(" ORDER BY ")
+	for i, col := range table.PrimaryKeyColumns {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(string(col))
+		if !isMin {
+			sb.WriteString(" DESC")
+		}
+	}
+	sb.WriteString(" FETCH FIRST 1 ROWS ONLY")
+	return sb.String(), args
+}
+
+// splittableKey returns whether rows can be split into shards by the given
+// key type, which requires the key to be unique.
+func splittableKey(keyType dbtable.KeyType) bool {
+	return keyType == dbtable.KeyTypePrimaryKey || keyType == dbtable.KeyTypeUniqueIndex
+}
@@ -20,6 +20,12 @@ type Column struct {
 	OID       oid.Oid
 	NotNull   bool
 	Collation sql.NullString
+	// BinaryCollation is set if the column is a string which its database
+	// sorts by its bytes, such as under a C or binary collation.
+	BinaryCollation bool
+	// TypeNames are the names of the type of the column, which type mapping
+	// overrides are matched against.
+	TypeNames []string
 }
 
 func GetColumns(
@@ -41,7 +47,7 @@ WHERE pg_database.datname = pg_catalog.current_database()`,
 		rows, err := conn.Query(
 			ctx,
 			`SELECT
-attname, atttypid, attnotnull, collname
+attname, atttypid, attnotnull, collname, format_type(atttypid, atttypmod)
 FROM pg_attribute
 LEFT OUTER JOIN pg_collation ON (pg_collation.oid = pg_attribute.attcollation)
 WHERE attrelid = $1 AND attnum > 0 AND attisdropped = false
@@ -54,13 +60,20 @@ ORDER BY attnum`,
 
 		for rows.Next() {
 			var cm Column
-			if err := rows.Scan(&cm.Name, &cm.OID, &cm.NotNull, &cm.Collation); err != nil {
+			var typeName string
+			if err := rows.Scan(&cm.Name, &cm.OID, &cm.NotNull, &cm.Collation, &typeName); err != nil {
 				return ret, errors.Wrap(err, "error decoding column metadata")
 			}
-			if !cm.Collation.Valid || cm.Collation.String == "default" {
+			cm.TypeNames = []string{typeName}
+			isDefaultCollation := !cm.Collation.Valid || cm.Collation.String == "default"
+			if isDefaultCollation {
 				cm.Collation.String = defaultCollation
 				cm.Collation.Valid = true
 			}
+			// CockroachDB sorts strings without a collation by their bytes,
+			// whatever the collation of the database is.
+			cm.BinaryCollation = isBinaryCollationPG(cm.Collation.String) ||
+				(conn.IsCockroach() && isDefaultCollation)
 			ret = append(ret, cm)
 		}
 		if rows.Err() != nil {
@@ -101,6 +114,7 @@ WHERE table_name = :1`,
 			}
 			cm.NotNull = isNullable == "N"
 			cm.Collation = collation
+			cm.TypeNames = []string{dt, oracleconv.NormalizeDataType(dt)}
 			ret = append(ret, cm)
 		}
 		if rows.Err() != nil {
@@ -141,6 +155,8 @@ ORDER BY ordinal_position`,
 			cm.OID = typeOid
 			cm.NotNull = isNullable == "NO"
 			cm.Collation = collation
+			cm.BinaryCollation = isBinaryCollationMySQL(collation.String)
+			cm.TypeNames = []string{dt, ct}
 			ret = append(ret, cm)
 		}
 		if rows.Err() != nil {
@@ -296,3 +312,179 @@ func mapColumns(cols []Column) map[tree.Name]Column {
 	}
 	return ret
 }
+
+// getUniqueKey returns the columns of the narrowest unique index of a table
+// without a primary key whose columns are all NOT NULL, which identify rows
+// in the same way as a primary key. Partial and expression indexes are not
+// used. No columns are returned if there is no such index.
+func getUniqueKey(
+	ctx context.Context, conn dbconn.Conn, table dbtable.DBTable, columns []Column,
+) ([]tree.Name, error) {
+	type uniqueIndex struct {
+		name string
+		cols []tree.Name
+		// valid is false if the index contains expressions.
+		valid bool
+	}
+	var indexes []*uniqueIndex
+	addCol := func(indexName string, col sql.NullString) {
+		if len(indexes) == 0 || indexes[len(indexes)-1].name != indexName {
+			indexes = append(indexes, &uniqueIndex{name: indexName, valid: true})
+		}
+		idx := indexes[len(indexes)-1]
+		if !col.Valid {
+			idx.valid = false
+			return
+		}
+		idx.cols = append(idx.cols, tree.Name(col.String))
+	}
+
+	switch conn := conn.(type) {
+	case *dbconn.PGConn:
+		rows, err := conn.Query(
+			ctx,
+			`SELECT
+    i.indexrelid::TEXT, a.attname
+FROM
+    pg_index i
+JOIN
+    pg_attribute a ON a.attrelid = i.indrelid
+AND
+    a.attnum = ANY(i.indkey)
+WHERE
+    i.indrelid = $1
+AND
+    i.indisunique
+AND
+    i.indpred IS NULL
+AND
+    i.indexprs IS NULL
+ORDER BY
+    i.indexrelid, array_position(i.indkey, a.attnum)`,
+			table.OID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var indexName string
+			var c sql.NullString
+			if err := rows.Scan(&indexName, &c); err != nil {
+				return nil, errors.Wrap(err, "error decoding unique index")
+			}
+			addCol(indexName, c)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, errors.Wrap(err, "error collecting unique indexes")
+		}
+		rows.Close()
+	case *dbconn.MySQLConn:
+		// Functional key parts have a NULL column name.
+		rows, err := conn.QueryContext(
+			ctx,
+			`SELECT index_name, column_name
+FROM information_schema.statistics
+WHERE table_schema = database()
+  AND table_name = ?
+  AND non_unique = 0
+  ORDER BY index_name, seq_in_index`,
+			string(table.Table),
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var indexName string
+			var c sql.NullString
+			if err := rows.Scan(&indexName, &c); err != nil {
+				return nil, errors.Wrap(err, "error decoding unique index")
+			}
+			c.String = strings.ToLower(c.String)
+			addCol(indexName, c)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, errors.Wrap(err, "error collecting unique indexes")
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	case *dbconn.OracleConn:
+		// Function-based indexes reference hidden columns, which are not in
+		// the column list and so are rejected below.
+		rows, err := conn.QueryContext(
+			ctx,
+			`SELECT ic.index_name, ic.column_name
+FROM user_indexes i
+JOIN user_ind_columns ic ON i.index_name = ic.index_name
+WHERE i.table_name = :1
+  AND i.uniqueness = 'UNIQUE'
+ORDER BY ic.index_name, ic.column_position`,
+			string(table.Table),
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var indexName string
+			var c sql.NullString
+			if err := rows.Scan(&indexName, &c); err != nil {
+				return nil, errors.Wrap(err, "error decoding unique index")
+			}
+			c.String = strings.ToLower(c.String)
+			addCol(indexName, c)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, errors.Wrap(err, "error collecting unique indexes")
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.AssertionFailedf("unhandled database connection: %T", conn)
+	}
+
+	notNull := make(map[tree.Name]bool, len(columns))
+	for _, col := range columns {
+		notNull[col.Name] = col.NotNull
+	}
+	var ret []tree.Name
+	for _, idx := range indexes {
+		if !idx.valid || len(idx.cols) == 0 {
+			continue
+		}
+		usable := true
+		for _, col := range idx.cols {
+			if !notNull[col] {
+				usable = false
+				break
+			}
+		}
+		if usable && (ret == nil || len(idx.cols) < len(ret)) {
+			ret = idx.cols
+		}
+	}
+	return ret, nil
+}
+
+// binaryCollationsPG are the PostgreSQL collations which sort strings by
+// their bytes, or by code point which is the same order for UTF-8.
+var binaryCollationsPG = map[string]struct{}{
+	"C":         {},
+	"POSIX":     {},
+	"C.UTF-8":   {},
+	"C.utf8":    {},
+	"ucs_basic": {},
+	"pg_c_utf8": {},
+}
+
+func isBinaryCollationPG(collation string) bool {
+	_, ok := binaryCollationsPG[collation]
+	return ok
+}
+
+// isBinaryCollationMySQL returns whether the MySQL collation sorts strings by
+// their bytes, which is the case for the binary collation and the _bin
+// collation of each character set.
+func isBinaryCollationMySQL(collation string) bool {
+	return collation == "binary" || strings.HasSuffix(collation, "_bin")
+}
@@ -8,8 +8,13 @@ import (
 
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
+	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/rowiterator"
+	"github.com/cockroachdb/molt/utils"
+	"github.com/cockroachdb/molt/utils/transform"
+	"github.com/cockroachdb/molt/utils/typeconv"
 	"github.com/cockroachdb/molt/verify/inconsistency"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/lib/pq/oid"
@@ -19,6 +24,10 @@ import (
 type Result struct {
 	RowVerifiable bool
 	dbtable.VerifiedTable
+	// PKBinaryCollation is set for each primary key column which is a string
+	// the source sorts by its bytes, indexed like PrimaryKeyColumns, or nil if
+	// there is no such column.
+	PKBinaryCollation           []bool
 	MismatchingTableDefinitions []inconsistency.MismatchingTableDefinition
 }
 
@@ -27,6 +36,9 @@ func VerifyCommonTables(
 	conns dbconn.OrderedConns,
 	logger zerolog.Logger,
 	allTables [][2]dbtable.DBTable,
+	overrides *typeconv.TypeOverrides,
+	transforms *transform.Transforms,
+	predicates utils.TablePredicates,
 ) ([]Result, error) {
 	var ret []Result
 
@@ -39,10 +51,39 @@ func VerifyCommonTables(
 		if err != nil {
 			return nil, err
 		}
-		res, err := verifyTable(ctx, conns, cmpTables, pkCols, columns)
+		keyType := dbtable.KeyTypePrimaryKey
+		if len(pkCols[0]) == 0 {
+			uniqueCols, err := getUniqueKey(ctx, conns[0], cmpTables[0], columns[0])
+			if err != nil {
+				return nil, errors.Wrapf(err, "error getting unique indexes for %s", cmpTables[0].String())
+			}
+			if len(uniqueCols) > 0 {
+				keyType = dbtable.KeyTypeUniqueIndex
+				pkCols[0] = uniqueCols
+				logger.Info().Msgf("table %s has no PRIMARY KEY, ordering rows by unique index on %s", cmpTables[0].String(), uniqueCols)
+			} else {
+				keyType = dbtable.KeyTypeNone
+				logger.Warn().Msgf("table %s has no PRIMARY KEY or NOT NULL unique index, rows will be read in a single scan ordered by every column", cmpTables[0].String())
+			}
+		}
+		res, err := verifyTable(ctx, conns, cmpTables, pkCols, keyType, columns, overrides)
 		if err != nil {
 			return nil, err
 		}
+		if keyType == dbtable.KeyTypeNone && len(res.PrimaryKeyColumns) < len(res.Columns) {
+			logger.Warn().Msgf(
+				"table %s has columns which cannot be ordered, so rows are ordered by %s; rows which only differ in other columns may be reported as mismatching",
+				cmpTables[0].String(),
+				res.PrimaryKeyColumns,
+			)
+		}
+		if res.Transforms, err = transforms.ForTable(res.VerifiedTable); err != nil {
+			return nil, err
+		}
+		res.Predicate = predicates.For(res.Name)
+		if err := rowiterator.ValidatePredicate(conns[0], res.VerifiedTable); err != nil {
+			return nil, err
+		}
 		ret = append(ret, res)
 	}
 	return ret, nil
@@ -53,7 +94,9 @@ func verifyTable(
 	conns dbconn.OrderedConns,
 	cmpTables [2]dbtable.DBTable,
 	pkCols [2][]tree.Name,
+	keyType dbtable.KeyType,
 	columns [2][]Column,
+	overrides *typeconv.TypeOverrides,
 ) (Result, error) {
 	truthTbl := cmpTables[0]
 	var columnMap [2]map[tree.Name]Column
@@ -68,6 +111,9 @@ func verifyTable(
 			},
 		},
 	}
+	if targetName := cmpTables[1].Name; targetName != truthTbl.Name {
+		res.TargetName = targetName
+	}
 	truthCols := columns[0]
 	for _, truthCol := range truthCols {
 		columnMap[0][truthCol.Name] = truthCol
@@ -75,17 +121,20 @@ func verifyTable(
 
 	pkSame := true
 	truthPKCols := pkCols[0]
-	if len(truthPKCols) == 0 {
-		res.MismatchingTableDefinitions = append(
-			res.MismatchingTableDefinitions,
-			inconsistency.MismatchingTableDefinition{
-				DBTable: truthTbl,
-				Info:    "missing a PRIMARY KEY - results cannot be compared",
-			},
-		)
+	targetPKCols := pkCols[1]
+	// Tables without a primary key on the source are created with a
+	// synthetic key (e.g. rowid) on the target, which is not compared.
+	syntheticTargetCols := make(map[tree.Name]struct{})
+	if keyType != dbtable.KeyTypePrimaryKey {
+		for _, col := range targetPKCols {
+			syntheticTargetCols[col] = struct{}{}
+		}
 	}
 
 	comparableColumns := mapColumns(truthCols)
+	// overriddenColumns are the columns whose source values are converted to
+	// the type of the target column, as given by the type overrides.
+	overriddenColumns := make(map[tree.Name]struct{})
 	truthMappedCols := mapColumns(truthCols)
 	targetTbl := cmpTables[1]
 	compareColumns := columns[1]
@@ -93,6 +142,9 @@ func verifyTable(
 		columnMap[1][targetCol.Name] = targetCol
 		sourceCol, ok := truthMappedCols[targetCol.Name]
 		if !ok {
+			if _, ok := syntheticTargetCols[targetCol.Name]; ok {
+				continue
+			}
 			res.MismatchingTableDefinitions = append(
 				res.MismatchingTableDefinitions,
 				inconsistency.MismatchingTableDefinition{
@@ -127,7 +179,20 @@ func verifyTable(
 		if err != nil {
 			return Result{}, err
 		}
-		if !comparableType(truthTyp, compareTyp) {
+		key := false
+		for _, col := range truthPKCols {
+			key = key || col == sourceCol.Name
+		}
+		typ, ok, err := overrides.Lookup(truthTbl.Name, string(sourceCol.Name), key, sourceCol.TypeNames...)
+		if err != nil {
+			return Result{}, err
+		}
+		if ok && sourceCol.OID != targetCol.OID {
+			if targetTyp, ok := types.OidToType[targetCol.OID]; ok && targetTyp.Equivalent(typ) {
+				overriddenColumns[sourceCol.Name] = struct{}{}
+			}
+		}
+		if _, ok := overriddenColumns[sourceCol.Name]; !ok && !comparableType(truthTyp, compareTyp) {
 			res.MismatchingTableDefinitions = append(
 				res.MismatchingTableDefinitions,
 				inconsistency.MismatchingTableDefinition{
@@ -157,17 +222,36 @@ func verifyTable(
 		delete(comparableColumns, colName)
 	}
 
-	targetPKCols := pkCols[1]
+	if keyType == dbtable.KeyTypeNone {
+		// Rows are ordered by every column which can be compared, and which
+		// both databases order the same way as their datums. Columns whose
+		// type is overridden are ordered differently once converted.
+		truthPKCols = nil
+		for _, col := range truthCols {
+			if _, overridden := overriddenColumns[col.Name]; overridden {
+				continue
+			}
+			if _, ok := comparableColumns[col.Name]; ok && orderableType(col.OID) && orderableType(columnMap[1][col.Name].OID) {
+				truthPKCols = append(truthPKCols, col.Name)
+			}
+		}
+	}
+	// Without a primary key on the source, the target only needs to have
+	// the columns rows are ordered by.
+	cmpPKCols := targetPKCols
+	if keyType != dbtable.KeyTypePrimaryKey {
+		cmpPKCols = truthPKCols
+	}
 
-	currPKSame := len(targetPKCols) == len(truthPKCols)
+	currPKSame := len(cmpPKCols) == len(truthPKCols)
 	var collationMismatch bool
 	if currPKSame {
-		for i, col := range targetPKCols {
-			if targetPKCols[i] != truthPKCols[i] {
+		for i, col := range cmpPKCols {
+			if cmpPKCols[i] != truthPKCols[i] {
 				currPKSame = false
 				break
 			}
-			if _, ok := comparableColumns[targetPKCols[i]]; !ok {
+			if _, ok := comparableColumns[cmpPKCols[i]]; !ok {
 				currPKSame = false
 				break
 			}
@@ -200,23 +284,41 @@ func verifyTable(
 	}
 	if !currPKSame && len(truthPKCols) > 0 {
 		pkSame = false
+		info := "PRIMARY KEY does not match source of truth (columns and types must match)"
+		if keyType == dbtable.KeyTypeUniqueIndex {
+			info = "unique index columns used to order rows do not match source of truth (columns and types must match)"
+		}
 		res.MismatchingTableDefinitions = append(
 			res.MismatchingTableDefinitions,
 			inconsistency.MismatchingTableDefinition{
 				DBTable: targetTbl,
-				Info:    "PRIMARY KEY does not match source of truth (columns and types must match)",
+				Info:    info,
 			},
 		)
 	}
 
 	res.PrimaryKeyColumns = truthPKCols
+	for i, col := range truthPKCols {
+		if columnMap[0][col].BinaryCollation {
+			if res.PKBinaryCollation == nil {
+				res.PKBinaryCollation = make([]bool, len(truthPKCols))
+			}
+			res.PKBinaryCollation[i] = true
+		}
+	}
+	res.KeyType = keyType
+	addColumn := func(col tree.Name) {
+		res.Columns = append(res.Columns, col)
+		for i := 0; i < 2; i++ {
+			res.ColumnOIDs[i] = append(res.ColumnOIDs[i], columnMap[i][col].OID)
+		}
+		_, overridden := overriddenColumns[col]
+		res.TypeOverridden = append(res.TypeOverridden, overridden)
+	}
 	// Place PK columns first.
 	for _, col := range truthPKCols {
 		if _, ok := comparableColumns[col]; ok {
-			res.Columns = append(res.Columns, col)
-			for i := 0; i < 2; i++ {
-				res.ColumnOIDs[i] = append(res.ColumnOIDs[i], columnMap[i][col].OID)
-			}
+			addColumn(col)
 			delete(comparableColumns, col)
 		}
 	}
@@ -224,16 +326,32 @@ func verifyTable(
 	// Then every other column.
 	for _, col := range truthCols {
 		if _, ok := comparableColumns[col.Name]; ok {
-			res.Columns = append(res.Columns, col.Name)
-			for i := 0; i < 2; i++ {
-				res.ColumnOIDs[i] = append(res.ColumnOIDs[i], columnMap[i][col.Name].OID)
-			}
+			addColumn(col.Name)
 		}
 	}
+	if len(overriddenColumns) == 0 {
+		res.TypeOverridden = nil
+	}
 	res.RowVerifiable = pkSame && len(truthPKCols) > 0 && !collationMismatch
 	return res, nil
 }
 
+// orderableType returns whether values of the type are ordered the same way
+// by every database, so that rows can be ordered by them. JSON and spatial
+// types are ordered differently, if at all.
+func orderableType(typOID oid.Oid) bool {
+	typ, ok := types.OidToType[typOID]
+	if !ok {
+		return false
+	}
+	switch typ.Family() {
+	case types.JsonFamily, types.GeometryFamily, types.GeographyFamily, types.Box2DFamily,
+		types.TSVectorFamily, types.TSQueryFamily:
+		return false
+	}
+	return true
+}
+
 // This logic isn't 100% there, but it's good enough.
 func comparableCollation(a, b sql.NullString) bool {
 	if a == b {
@@ -303,6 +421,14 @@ func allowableTypes(a oid.Oid, b oid.Oid) bool {
 	if a == oid.T_timestamptz && b == oid.T_timestamp {
 		return true
 	}
+	// Oracle DATE columns hold the time of day and are read as timestamps,
+	// but may have been created as DATE on the target.
+	if a == oid.T_timestamp && b == oid.T_date {
+		return true
+	}
+	if a == oid.T_date && b == oid.T_timestamp {
+		return true
+	}
 	if a == oid.T_varchar && b == oid.T_uuid {
 		return true
 	}
@@ -5,8 +5,10 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroachdb-parser/pkg/sql/types"
 	"github.com/cockroachdb/molt/dbconn"
 	"github.com/cockroachdb/molt/dbtable"
+	"github.com/cockroachdb/molt/utils/typeconv"
 	"github.com/cockroachdb/molt/verify/inconsistency"
 	"github.com/lib/pq/oid"
 	"github.com/stretchr/testify/require"
@@ -18,11 +20,14 @@ func TestVerifyTable(t *testing.T) {
 		dbconn.MakeFakeConn("bbb"),
 	}
 	for _, tc := range []struct {
-		desc      string
-		cmpTables [2]dbtable.DBTable
-		pkCols    [2][]tree.Name
-		columns   [2][]Column
-		expected  Result
+		desc        string
+		cmpTables   [2]dbtable.DBTable
+		pkCols      [2][]tree.Name
+		keyType     dbtable.KeyType
+		columns     [2][]Column
+		overrides   string
+		expected    Result
+		expectedErr string
 	}{
 		{
 			desc: "success",
@@ -55,46 +60,423 @@ func TestVerifyTable(t *testing.T) {
 			},
 		},
 		{
-			desc: "missing primary key on source",
+			// Oracle DATE columns are read as TIMESTAMP, and may have been
+			// created as DATE on the target by earlier versions.
+			desc: "timestamp against date",
 			cmpTables: [2]dbtable.DBTable{
 				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
 				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
 			},
 			pkCols: [2][]tree.Name{
-				{},
+				{"id"},
 				{"id"},
 			},
 			columns: [2][]Column{
 				{
 					{Name: "id", OID: oid.T_int4, NotNull: true},
-					{Name: "txt", OID: oid.T_text, NotNull: true},
+					{Name: "created", OID: oid.T_timestamp},
 				},
 				{
 					{Name: "id", OID: oid.T_int4, NotNull: true},
+					{Name: "created", OID: oid.T_date},
+				},
+			},
+			expected: Result{
+				RowVerifiable: true,
+				VerifiedTable: dbtable.VerifiedTable{
+					Name:              dbtable.Name{Schema: "public", Table: "tbl_name"},
+					PrimaryKeyColumns: []tree.Name{"id"},
+					Columns:           []tree.Name{"id", "created"},
+					ColumnOIDs:        [2][]oid.Oid{{oid.T_int4, oid.T_timestamp}, {oid.T_int4, oid.T_date}},
+				},
+			},
+		},
+		{
+			desc: "binary collated string primary key",
+			cmpTables: [2]dbtable.DBTable{
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+			},
+			pkCols: [2][]tree.Name{
+				{"tenant", "name"},
+				{"tenant", "name"},
+			},
+			columns: [2][]Column{
+				{
+					{Name: "tenant", OID: oid.T_text, NotNull: true},
+					{Name: "name", OID: oid.T_text, NotNull: true, BinaryCollation: true},
+				},
+				{
+					{Name: "tenant", OID: oid.T_text, NotNull: true},
+					{Name: "name", OID: oid.T_text, NotNull: true, BinaryCollation: true},
+				},
+			},
+			expected: Result{
+				RowVerifiable: true,
+				VerifiedTable: dbtable.VerifiedTable{
+					Name:              dbtable.Name{Schema: "public", Table: "tbl_name"},
+					PrimaryKeyColumns: []tree.Name{"tenant", "name"},
+					Columns:           []tree.Name{"tenant", "name"},
+					ColumnOIDs:        [2][]oid.Oid{{oid.T_text, oid.T_text}, {oid.T_text, oid.T_text}},
+				},
+				PKBinaryCollation: []bool{false, true},
+			},
+		},
+		{
+			desc: "unique index on source without primary key",
+			cmpTables: [2]dbtable.DBTable{
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+			},
+			pkCols: [2][]tree.Name{
+				{"txt"},
+				{"rowid"},
+			},
+			keyType: dbtable.KeyTypeUniqueIndex,
+			columns: [2][]Column{
+				{
+					{Name: "id", OID: oid.T_int4},
+					{Name: "txt", OID: oid.T_text, NotNull: true},
+				},
+				{
+					{Name: "id", OID: oid.T_int4},
+					{Name: "txt", OID: oid.T_text, NotNull: true},
+					{Name: "rowid", OID: oid.T_int8, NotNull: true},
+				},
+			},
+			expected: Result{
+				RowVerifiable: true,
+				VerifiedTable: dbtable.VerifiedTable{
+					Name:              dbtable.Name{Schema: "public", Table: "tbl_name"},
+					PrimaryKeyColumns: []tree.Name{"txt"},
+					KeyType:           dbtable.KeyTypeUniqueIndex,
+					Columns:           []tree.Name{"txt", "id"},
+					ColumnOIDs:        [2][]oid.Oid{{oid.T_text, oid.T_int4}, {oid.T_text, oid.T_int4}},
+				},
+			},
+		},
+		{
+			desc: "unique index missing on target",
+			cmpTables: [2]dbtable.DBTable{
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+			},
+			pkCols: [2][]tree.Name{
+				{"txt"},
+				{"rowid"},
+			},
+			keyType: dbtable.KeyTypeUniqueIndex,
+			columns: [2][]Column{
+				{
+					{Name: "id", OID: oid.T_int4},
 					{Name: "txt", OID: oid.T_text, NotNull: true},
 				},
+				{
+					{Name: "id", OID: oid.T_int4},
+					{Name: "rowid", OID: oid.T_int8, NotNull: true},
+				},
+			},
+			expected: Result{
+				VerifiedTable: dbtable.VerifiedTable{
+					Name:              dbtable.Name{Schema: "public", Table: "tbl_name"},
+					PrimaryKeyColumns: []tree.Name{"txt"},
+					KeyType:           dbtable.KeyTypeUniqueIndex,
+					Columns:           []tree.Name{"id"},
+					ColumnOIDs:        [2][]oid.Oid{{oid.T_int4}, {oid.T_int4}},
+				},
+				MismatchingTableDefinitions: []inconsistency.MismatchingTableDefinition{
+					{
+						DBTable: dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}, OID: 0x0},
+						Info:    "missing column txt",
+					},
+					{
+						DBTable: dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}, OID: 0x0},
+						Info:    "unique index columns used to order rows do not match source of truth (columns and types must match)",
+					},
+				},
+			},
+		},
+		{
+			desc: "no unique key on source",
+			cmpTables: [2]dbtable.DBTable{
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+			},
+			pkCols: [2][]tree.Name{
+				{},
+				{"rowid"},
+			},
+			keyType: dbtable.KeyTypeNone,
+			columns: [2][]Column{
+				{
+					{Name: "id", OID: oid.T_int4},
+					{Name: "txt", OID: oid.T_text},
+				},
+				{
+					{Name: "id", OID: oid.T_int4},
+					{Name: "txt", OID: oid.T_text},
+					{Name: "rowid", OID: oid.T_int8, NotNull: true},
+				},
 			},
 			expected: Result{
+				RowVerifiable: true,
 				VerifiedTable: dbtable.VerifiedTable{
 					Name:              dbtable.Name{Schema: "public", Table: "tbl_name"},
-					PrimaryKeyColumns: []tree.Name{},
+					PrimaryKeyColumns: []tree.Name{"id", "txt"},
+					KeyType:           dbtable.KeyTypeNone,
 					Columns:           []tree.Name{"id", "txt"},
 					ColumnOIDs:        [2][]oid.Oid{{oid.T_int4, oid.T_text}, {oid.T_int4, oid.T_text}},
 				},
-				// TODO: add more tests after we make mismatches more specialised.
+			},
+		},
+		{
+			desc: "no unique key on source with unorderable columns",
+			cmpTables: [2]dbtable.DBTable{
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+			},
+			pkCols: [2][]tree.Name{
+				{},
+				{"rowid"},
+			},
+			keyType: dbtable.KeyTypeNone,
+			columns: [2][]Column{
+				{
+					{Name: "id", OID: oid.T_int4},
+					{Name: "j", OID: oid.T_jsonb},
+					{Name: "js", OID: oid.T_json},
+				},
+				{
+					{Name: "id", OID: oid.T_int4},
+					{Name: "j", OID: oid.T_jsonb},
+					{Name: "js", OID: oid.T_json},
+					{Name: "rowid", OID: oid.T_int8, NotNull: true},
+				},
+			},
+			expected: Result{
+				RowVerifiable: true,
+				VerifiedTable: dbtable.VerifiedTable{
+					Name:              dbtable.Name{Schema: "public", Table: "tbl_name"},
+					PrimaryKeyColumns: []tree.Name{"id"},
+					KeyType:           dbtable.KeyTypeNone,
+					Columns:           []tree.Name{"id", "j", "js"},
+					ColumnOIDs: [2][]oid.Oid{
+						{oid.T_int4, oid.T_jsonb, oid.T_json},
+						{oid.T_int4, oid.T_jsonb, oid.T_json},
+					},
+				},
+			},
+		},
+		{
+			desc: "no unique key on source with overridden columns",
+			cmpTables: [2]dbtable.DBTable{
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+			},
+			pkCols: [2][]tree.Name{
+				{},
+				{"rowid"},
+			},
+			keyType: dbtable.KeyTypeNone,
+			columns: [2][]Column{
+				{
+					{Name: "id", OID: oid.T_int4, TypeNames: []string{"integer"}},
+					{Name: "flag", OID: oid.T_int8, TypeNames: []string{"bigint"}},
+				},
+				{
+					{Name: "id", OID: oid.T_int4},
+					{Name: "flag", OID: oid.T_bool},
+					{Name: "rowid", OID: oid.T_int8, NotNull: true},
+				},
+			},
+			overrides: `
+rules:
+  - source_type: bigint
+    crdb_type: BOOL
+`,
+			expected: Result{
+				RowVerifiable: true,
+				VerifiedTable: dbtable.VerifiedTable{
+					Name:              dbtable.Name{Schema: "public", Table: "tbl_name"},
+					PrimaryKeyColumns: []tree.Name{"id"},
+					KeyType:           dbtable.KeyTypeNone,
+					Columns:           []tree.Name{"id", "flag"},
+					ColumnOIDs:        [2][]oid.Oid{{oid.T_int4, oid.T_int8}, {oid.T_int4, oid.T_bool}},
+					TypeOverridden:    []bool{false, true},
+				},
+			},
+		},
+		{
+			desc: "overridden types",
+			cmpTables: [2]dbtable.DBTable{
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+			},
+			pkCols: [2][]tree.Name{
+				{"id"},
+				{"id"},
+			},
+			columns: [2][]Column{
+				{
+					{Name: "id", OID: oid.T_int4, NotNull: true, TypeNames: []string{"integer"}},
+					{Name: "flag", OID: oid.T_int8, TypeNames: []string{"bigint"}},
+					{Name: "amount", OID: oid.T_int8, TypeNames: []string{"bigint"}},
+				},
+				{
+					{Name: "id", OID: oid.T_int4, NotNull: true},
+					{Name: "flag", OID: oid.T_bool},
+					{Name: "amount", OID: oid.T_numeric},
+				},
+			},
+			overrides: `
+rules:
+  - table: tbl_name
+    column: flag
+    crdb_type: BOOL
+  - source_type: bigint
+    crdb_type: DECIMAL(10, 2)
+`,
+			expected: Result{
+				RowVerifiable: true,
+				VerifiedTable: dbtable.VerifiedTable{
+					Name:              dbtable.Name{Schema: "public", Table: "tbl_name"},
+					PrimaryKeyColumns: []tree.Name{"id"},
+					Columns:           []tree.Name{"id", "flag", "amount"},
+					ColumnOIDs:        [2][]oid.Oid{{oid.T_int4, oid.T_int8, oid.T_int8}, {oid.T_int4, oid.T_bool, oid.T_numeric}},
+					TypeOverridden:    []bool{false, true, true},
+				},
+			},
+		},
+		{
+			desc: "source type rule matching the primary key",
+			cmpTables: [2]dbtable.DBTable{
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+			},
+			pkCols: [2][]tree.Name{
+				{"id"},
+				{"id"},
+			},
+			columns: [2][]Column{
+				{
+					{Name: "id", OID: oid.T_varchar, NotNull: true, TypeNames: []string{"varchar", "varchar(36)"}},
+					{Name: "parent_id", OID: oid.T_varchar, TypeNames: []string{"varchar", "varchar(36)"}},
+				},
+				{
+					{Name: "id", OID: oid.T_uuid, NotNull: true},
+					{Name: "parent_id", OID: oid.T_uuid},
+				},
+			},
+			overrides: `
+rules:
+  - source_type: varchar(36)
+    crdb_type: UUID
+`,
+			expectedErr: "source type rule varchar(36) matches column id of table public.tbl_name, which orders the rows of the table and whose type cannot be overridden",
+		},
+		{
+			desc: "overridden primary key",
+			cmpTables: [2]dbtable.DBTable{
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+			},
+			pkCols: [2][]tree.Name{
+				{"id"},
+				{"id"},
+			},
+			columns: [2][]Column{
+				{{Name: "id", OID: oid.T_text, NotNull: true, TypeNames: []string{"text"}}},
+				{{Name: "id", OID: oid.T_uuid, NotNull: true}},
+			},
+			overrides: `
+rules:
+  - table: public.tbl_name
+    column: id
+    crdb_type: UUID
+`,
+			expectedErr: "column id of table public.tbl_name orders the rows of the table and its type cannot be overridden",
+		},
+		{
+			desc: "target does not match overridden type",
+			cmpTables: [2]dbtable.DBTable{
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+				{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}},
+			},
+			pkCols: [2][]tree.Name{
+				{"id"},
+				{"id"},
+			},
+			columns: [2][]Column{
+				{
+					{Name: "id", OID: oid.T_int4, NotNull: true, TypeNames: []string{"integer"}},
+					{Name: "flag", OID: oid.T_int8, TypeNames: []string{"bigint"}},
+				},
+				{
+					{Name: "id", OID: oid.T_int4, NotNull: true},
+					{Name: "flag", OID: oid.T_text},
+				},
+			},
+			overrides: `
+rules:
+  - source_type: bigint
+    crdb_type: BOOL
+`,
+			expected: Result{
+				RowVerifiable: true,
+				VerifiedTable: dbtable.VerifiedTable{
+					Name:              dbtable.Name{Schema: "public", Table: "tbl_name"},
+					PrimaryKeyColumns: []tree.Name{"id"},
+					Columns:           []tree.Name{"id"},
+					ColumnOIDs:        [2][]oid.Oid{{oid.T_int4}, {oid.T_int4}},
+				},
 				MismatchingTableDefinitions: []inconsistency.MismatchingTableDefinition{
 					{
 						DBTable: dbtable.DBTable{Name: dbtable.Name{Schema: "public", Table: "tbl_name"}, OID: 0x0},
-						Info:    "missing a PRIMARY KEY - results cannot be compared"},
+						Info:    "column type mismatch on flag: int8 vs text",
+					},
 				},
 			},
 		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			ctx := context.Background()
-			res, err := verifyTable(ctx, conns, tc.cmpTables, tc.pkCols, tc.columns)
+			var overrides *typeconv.TypeOverrides
+			if tc.overrides != "" {
+				var err error
+				overrides, err = typeconv.ParseTypeOverrides([]byte(tc.overrides))
+				require.NoError(t, err)
+			}
+			res, err := verifyTable(ctx, conns, tc.cmpTables, tc.pkCols, tc.keyType, tc.columns, overrides)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
 			require.NoError(t, err)
 			require.Equal(t, tc.expected, res)
 		})
 	}
 }
+
+func TestIsBinaryCollation(t *testing.T) {
+	for _, c := range []string{"C", "POSIX", "C.UTF-8", "ucs_basic"} {
+		require.True(t, isBinaryCollationPG(c), c)
+	}
+	for _, c := range []string{"en_US.utf8", "und-x-icu", "default"} {
+		require.False(t, isBinaryCollationPG(c), c)
+	}
+	for _, c := range []string{"binary", "utf8mb4_bin", "latin1_bin", "utf8mb4_0900_bin"} {
+		require.True(t, isBinaryCollationMySQL(c), c)
+	}
+	for _, c := range []string{"utf8mb4_0900_ai_ci", "utf8mb4_general_ci", "latin1_swedish_ci", ""} {
+		require.False(t, isBinaryCollationMySQL(c), c)
+	}
+}
+
+func TestOrderableType(t *testing.T) {
+	for _, typOID := range []oid.Oid{oid.T_int4, oid.T_text, oid.T_timestamptz, oid.T_numeric} {
+		require.True(t, orderableType(typOID), typOID)
+	}
+	for _, typOID := range []oid.Oid{oid.T_jsonb, oid.T_json, types.Geometry.Oid(), types.Geography.Oid()} {
+		require.False(t, orderableType(typOID), typOID)
+	}
+}
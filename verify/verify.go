@@ -8,8 +8,12 @@ import (
 
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/dbtable"
 	"github.com/cockroachdb/molt/molttelemetry"
+	"github.com/cockroachdb/molt/shardmode"
 	"github.com/cockroachdb/molt/utils"
+	"github.com/cockroachdb/molt/utils/transform"
+	"github.com/cockroachdb/molt/utils/typeconv"
 	"github.com/cockroachdb/molt/verify/dbverify"
 	"github.com/cockroachdb/molt/verify/inconsistency"
 	"github.com/cockroachdb/molt/verify/rowverify"
@@ -30,21 +34,29 @@ type verifyOpts struct {
 	concurrency              int
 	rowBatchSize             int
 	tableSplits              int
+	shardMode                shardmode.Flag
+	workStealing             bool
 	rowsPerSecond            int
 	continuous               bool
 	continuousPause          time.Duration
 	rows                     bool
 	dbFilter                 utils.FilterConfig
 	liveVerificationSettings *rowverify.LiveReverificationSettings
+	typeOverrides            *typeconv.TypeOverrides
+	nameMapping              utils.NameMapping
+	transforms               *transform.Transforms
+	predicates               utils.TablePredicates
+	tableOverrides           []TableOverride
 
 	testOnly bool
 }
 
-func (o verifyOpts) rateLimit() rate.Limit {
+// rateLimit returns the rate at which batches of rowBatchSize rows are read.
+func (o verifyOpts) rateLimit(rowBatchSize int) rate.Limit {
 	if o.rowsPerSecond == 0 {
 		return rate.Inf
 	}
-	perSecond := float64(o.rowBatchSize) / float64(o.rowsPerSecond)
+	perSecond := float64(rowBatchSize) / float64(o.rowsPerSecond)
 	return rate.Every(time.Duration(float64(time.Second) * perSecond))
 }
 
@@ -72,6 +84,18 @@ func WithTableSplits(c int) VerifyOpt {
 	}
 }
 
+func WithShardMode(m shardmode.Flag) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.shardMode = m
+	}
+}
+
+func WithWorkStealing(b bool) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.workStealing = b
+	}
+}
+
 func WithContinuous(c bool, pauseLength time.Duration) VerifyOpt {
 	return func(o *verifyOpts) {
 		o.continuous = c
@@ -93,6 +117,74 @@ func WithDBFilter(filter utils.FilterConfig) VerifyOpt {
 	}
 }
 
+// WithTypeOverrides compares the source columns whose type is overridden
+// with target columns of the overriding type.
+func WithTypeOverrides(overrides *typeconv.TypeOverrides) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.typeOverrides = overrides
+	}
+}
+
+// WithNameMapping compares the source tables with the target tables their
+// names are mapped to.
+func WithNameMapping(mapping utils.NameMapping) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.nameMapping = mapping
+	}
+}
+
+// WithTransforms compares transformed columns after applying their
+// transforms to the source values.
+func WithTransforms(transforms *transform.Transforms) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.transforms = transforms
+	}
+}
+
+// WithPredicates only compares the rows of the tables matching their
+// predicates.
+func WithPredicates(predicates utils.TablePredicates) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.predicates = predicates
+	}
+}
+
+// TableOverride overrides the number of shards and the row batch size of
+// the tables matching Table, which is a glob as used by utils.FilterConfig.
+// Settings which are nil are not overridden.
+type TableOverride struct {
+	Table        string
+	TableSplits  *int
+	RowBatchSize *int
+}
+
+// WithTableOverrides overrides settings for some tables. Each setting of a
+// table takes the value of the first matching override which sets it.
+func WithTableOverrides(overrides []TableOverride) VerifyOpt {
+	return func(o *verifyOpts) {
+		o.tableOverrides = overrides
+	}
+}
+
+// forTable returns the number of shards and the row batch size of a table.
+func (o verifyOpts) forTable(n dbtable.Name) (tableSplits int, rowBatchSize int) {
+	tableSplits, rowBatchSize = o.tableSplits, o.rowBatchSize
+	for i := len(o.tableOverrides) - 1; i >= 0; i-- {
+		t := o.tableOverrides[i]
+		// Patterns are validated when the overrides are loaded.
+		if ok, _ := utils.MatchesTablePattern(t.Table, n); !ok {
+			continue
+		}
+		if t.TableSplits != nil {
+			tableSplits = *t.TableSplits
+		}
+		if t.RowBatchSize != nil {
+			rowBatchSize = *t.RowBatchSize
+		}
+	}
+	return tableSplits, rowBatchSize
+}
+
 func WithRows(b bool) VerifyOpt {
 	return func(o *verifyOpts) {
 		o.rows = b
@@ -129,7 +221,7 @@ func Verify(
 	}
 	reportTelemetry(logger, opts, conns)
 
-	dbTables, err := dbverify.Verify(ctx, conns)
+	dbTables, err := dbverify.VerifyWithMapping(ctx, conns, opts.nameMapping)
 	if err != nil {
 		return errors.Wrap(err, "error comparing database tables")
 	}
@@ -145,7 +237,7 @@ func Verify(
 	}
 
 	// Grab columns for each table on both sides.
-	tbls, err := tableverify.VerifyCommonTables(ctx, conns, logger, dbTables.Verified)
+	tbls, err := tableverify.VerifyCommonTables(ctx, conns, logger, dbTables.Verified, opts.typeOverrides, opts.transforms, opts.predicates)
 	if err != nil {
 		return err
 	}
@@ -171,10 +263,14 @@ func Verify(
 			continue
 		}
 		// Get and first and last of each PK.
-		tableShards, err := ShardTable(ctx, conns[0], tbl, reporter, opts.tableSplits)
+		tableSplits, rowBatchSize := opts.forTable(tbl.Name)
+		tableShards, err := ShardTable(ctx, conns[0], tbl, reporter, tableSplits, opts.shardMode)
 		if err != nil {
 			return errors.Wrapf(err, "error splitting tables")
 		}
+		for i := range tableShards {
+			tableShards[i].RowBatchSize = rowBatchSize
+		}
 		shards = append(shards, tableShards...)
 	}
 
@@ -198,16 +294,19 @@ func Verify(
 		)
 	}
 
+	// Work stealing is not used in continuous mode, as every shard is
+	// already running on its own goroutine.
+	scheduler := NewShardScheduler(conns[0], logger, shards, opts.workStealing && !opts.continuous)
+
 	// Compare rows up to the numGoroutines specified.
 	g, _ := errgroup.WithContext(ctx)
-	workQueue := make(chan rowverify.TableShard)
 	for goroutineIdx := 0; goroutineIdx < numGoroutines; goroutineIdx++ {
 		g.Go(func() error {
 			verifymetrics.NumShards.Inc()
 			defer verifymetrics.NumShards.Dec()
 
 			for {
-				shard, ok := <-workQueue
+				shard, ok := scheduler.Next(ctx)
 				if !ok {
 					return nil
 				}
@@ -255,10 +354,10 @@ func Verify(
 						conns,
 						reporter,
 						logger,
-						opts.rowBatchSize,
+						shard.RowBatchSize,
 						shard,
 						opts.liveVerificationSettings,
-						rate.NewLimiter(opts.rateLimit(), 1),
+						rate.NewLimiter(opts.rateLimit(shard.RowBatchSize), 1),
 					); err != nil {
 						logger.Err(err).
 							Str("schema", string(shard.Schema)).
@@ -270,13 +369,10 @@ func Verify(
 					}
 					time.Sleep(opts.continuousPause)
 				}
+				scheduler.Done(shard)
 			}
 		})
 	}
-	for _, shard := range shards {
-		workQueue <- shard
-	}
-	close(workQueue)
 	return g.Wait()
 }
 
@@ -10,6 +10,7 @@ import (
 
 	"github.com/cockroachdb/datadriven"
 	"github.com/cockroachdb/molt/dbconn"
+	"github.com/cockroachdb/molt/shardmode"
 	"github.com/cockroachdb/molt/testutils"
 	"github.com/cockroachdb/molt/verify/inconsistency"
 	"github.com/rs/zerolog"
@@ -53,7 +54,7 @@ func TestVerifyOpts_rateLimit(t *testing.T) {
 		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
-			require.Equal(t, tc.expected, tc.opts.rateLimit())
+			require.Equal(t, tc.expected, tc.opts.rateLimit(tc.opts.rowBatchSize))
 		})
 	}
 }
@@ -122,12 +123,17 @@ func testDataDriven(t *testing.T, path string, connArgs []connArg) {
 			return testutils.QueryConnCommand(t, d, conns)
 		case "verify":
 			numSplits := 1
+			mode := shardmode.Linear
 			for _, arg := range d.CmdArgs {
 				switch arg.Key {
 				case "splits":
 					var err error
 					numSplits, err = strconv.Atoi(arg.Vals[0])
 					require.NoError(t, err)
+				case "shard_mode":
+					var err error
+					mode, err = shardmode.FlagString(arg.Vals[0])
+					require.NoError(t, err)
 				}
 			}
 			reporter := &inconsistency.LogReporter{
@@ -142,6 +148,7 @@ func testDataDriven(t *testing.T, path string, connArgs []connArg) {
 				WithConcurrency(1),
 				WithRowBatchSize(2),
 				WithTableSplits(numSplits),
+				WithShardMode(mode),
 			)
 			if err != nil {
 				sb.WriteString(fmt.Sprintf("error: %s\n", err.Error()))